@@ -0,0 +1,85 @@
+// Package main create the first admin user of a new install, or another admin user if all of them are locked out.
+// The bootstrap password is read from the environment variable LOL_ADMIN_PASSWORD, so it is not kept in the
+// shell history. The admin user must set up two-factor authentication to log in and change the password
+// before any other route is allowed.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/go-dev-frame/sponge/pkg/logger"
+
+	"lol/configs"
+	"lol/internal/config"
+	"lol/internal/dao"
+	"lol/internal/database"
+	"lol/internal/model"
+	"lol/internal/rbac"
+)
+
+// passwordEnv the environment variable of the bootstrap password
+const passwordEnv = "LOL_ADMIN_PASSWORD"
+
+func main() {
+	var configFile string
+	var username string
+	flag.StringVar(&configFile, "c", "", "configuration file")
+	flag.StringVar(&username, "username", "admin", "username of the admin user")
+	flag.Parse()
+
+	if configFile == "" {
+		configFile = configs.Path("lol.yml")
+	}
+	if err := config.Init(configFile); err != nil {
+		panic("init config error: " + err.Error())
+	}
+	cfg := config.Get()
+	if _, err := logger.Init(logger.WithLevel(cfg.Logger.Level), logger.WithFormat(cfg.Logger.Format)); err != nil {
+		panic(err)
+	}
+
+	database.InitDB()
+	err := create(context.Background(), username, os.Getenv(passwordEnv))
+	_ = database.CloseDB()
+	if err != nil {
+		logger.Error("create admin user error", logger.String("username", username), logger.Err(err))
+		os.Exit(1)
+	}
+	logger.Info("admin user created, set up two-factor authentication and change the password at the first login",
+		logger.String("username", username))
+}
+
+func create(ctx context.Context, username string, password string) error {
+	if len(password) < 8 || len(password) > 72 {
+		return errors.New(passwordEnv + " must be 8 to 72 characters")
+	}
+	iDao := dao.NewAdminUserDao(database.GetDB(), nil)
+	_, err := iDao.GetByUsername(ctx, username)
+	if err == nil {
+		return errors.New("the username already exists")
+	}
+	if !errors.Is(err, database.ErrRecordNotFound) {
+		return err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	return iDao.Create(ctx, &model.AdminUser{
+		Username:           username,
+		Password:           string(hash),
+		Role:               rbac.RoleAdmin,
+		Status:             1,
+		MustChangePassword: true,
+		CreateAt:           &now,
+		UpdateAt:           &now,
+	})
+}
@@ -0,0 +1,88 @@
+// Package main encrypt the personal information of the existing rows with the current key of fieldCrypt
+// and fill their blind indexes. It is run once after deployments/sql/009_encrypt_pii.sql is applied,
+// and again after the key version is changed, rows that are already current are skipped.
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+
+	"github.com/go-dev-frame/sponge/pkg/logger"
+
+	"lol/configs"
+	"lol/internal/config"
+	"lol/internal/database"
+	"lol/internal/fieldcrypt"
+)
+
+// tables the encrypted columns and their blind indexes, they must match the models
+var tables = []fieldcrypt.Table{
+	{
+		Name: "loan",
+		Columns: []fieldcrypt.Column{
+			{Name: "name", Indexes: []fieldcrypt.Index{{Column: "name_index", Kind: fieldcrypt.IndexName}}},
+			{Name: "user_id", Indexes: []fieldcrypt.Index{
+				{Column: "user_id_index", Kind: fieldcrypt.IndexUserID},
+				{Column: "user_code_index", Kind: fieldcrypt.IndexUserCode, Value: fieldcrypt.UserCode},
+			}},
+			{Name: "mobile", Indexes: []fieldcrypt.Index{{Column: "mobile_index", Kind: fieldcrypt.IndexMobile}}},
+		},
+	},
+	{
+		Name: "payment_history",
+		Columns: []fieldcrypt.Column{
+			{Name: "user_phone", Indexes: []fieldcrypt.Index{{Column: "user_phone_index", Kind: fieldcrypt.IndexMobile}}},
+		},
+	},
+	{
+		Name: "sms_history",
+		Columns: []fieldcrypt.Column{
+			{Name: "mobile", Indexes: []fieldcrypt.Index{{Column: "mobile_index", Kind: fieldcrypt.IndexMobile}}},
+		},
+	},
+}
+
+func main() {
+	var configFile string
+	var batchSize int
+	flag.StringVar(&configFile, "c", "", "configuration file")
+	flag.IntVar(&batchSize, "batch", 500, "number of rows read at a time")
+	flag.Parse()
+
+	if configFile == "" {
+		configFile = configs.Path("lol.yml")
+	}
+	if err := config.Init(configFile); err != nil {
+		panic("init config error: " + err.Error())
+	}
+	cfg := config.Get()
+	if _, err := logger.Init(logger.WithLevel(cfg.Logger.Level), logger.WithFormat(cfg.Logger.Format)); err != nil {
+		panic(err)
+	}
+	if err := fieldcrypt.Init(cfg.FieldCrypt); err != nil {
+		panic("init fieldCrypt error: " + err.Error())
+	}
+	if cfg.FieldCrypt.Version == 0 {
+		logger.Warn("fieldCrypt.version is 0, encrypted values are decrypted back to plaintext")
+	}
+
+	database.InitDB()
+	err := migrate(context.Background(), batchSize)
+	_ = database.CloseDB()
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+func migrate(ctx context.Context, batchSize int) error {
+	for _, table := range tables {
+		updated, err := fieldcrypt.MigrateTable(ctx, database.GetDB(), table, batchSize)
+		if err != nil {
+			logger.Error("encrypt table error", logger.String("table", table.Name), logger.Int("updated", updated), logger.Err(err))
+			return err
+		}
+		logger.Info("encrypt table done", logger.String("table", table.Name), logger.Int("updated", updated))
+	}
+	return nil
+}
@@ -27,7 +27,7 @@ func Close(servers []app.IServer) []app.Close {
 	})
 
 	// close redis
-	if config.Get().App.CacheType == "redis" {
+	if config.Get().App.CacheType == "redis" || (config.Get().App.CacheBroadcast && config.Get().App.CacheType != "") {
 		closes = append(closes, func() error {
 			return database.CloseRedis()
 		})
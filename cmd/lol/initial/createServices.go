@@ -4,6 +4,7 @@ import (
 	"strconv"
 
 	"lol/internal/config"
+	"lol/internal/reminder"
 	"lol/internal/server"
 
 	"github.com/go-dev-frame/sponge/pkg/app"
@@ -21,5 +22,10 @@ func CreateServices() []app.IServer {
 	)
 	servers = append(servers, httpServer)
 
+	// create the repayment reminder scheduler
+	if cfg.Reminder.Enable {
+		servers = append(servers, reminder.NewScheduler())
+	}
+
 	return servers
 }
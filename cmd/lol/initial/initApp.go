@@ -4,6 +4,7 @@
 package initial
 
 import (
+	"context"
 	"flag"
 	"strconv"
 
@@ -12,8 +13,10 @@ import (
 	"github.com/go-dev-frame/sponge/pkg/tracer"
 
 	"lol/configs"
+	"lol/internal/cache"
 	"lol/internal/config"
 	"lol/internal/database"
+	"lol/internal/fieldcrypt"
 )
 
 var (
@@ -68,6 +71,11 @@ func InitApp() {
 		logger.Info("[resource statistics] was initialized")
 	}
 
+	// initializing the encryption of personal information, it must be done before the database is used
+	if err = fieldcrypt.Init(cfg.FieldCrypt); err != nil {
+		panic("init fieldCrypt error: " + err.Error())
+	}
+
 	// initializing database
 	database.InitDB()
 	logger.Infof("[%s] was initialized", cfg.Database.Driver)
@@ -75,6 +83,15 @@ func InitApp() {
 	if cfg.App.CacheType != "" {
 		logger.Infof("[%s] was initialized", cfg.App.CacheType)
 	}
+
+	// initializing the cache invalidation, the keys are broadcast to the other replicas by redis
+	if cfg.App.CacheBroadcast && cfg.App.CacheType != "" {
+		cache.InitInvalidator(database.GetCacheType(), database.GetRedisCli())
+		go cache.GetInvalidator().Subscribe(context.Background())
+		logger.Info("[cache broadcast] was initialized")
+	} else {
+		cache.InitInvalidator(database.GetCacheType(), nil)
+	}
 }
 
 func initConfig() {
@@ -0,0 +1,8 @@
+-- payment orders record the loan they pay for and how the money is applied to its installments,
+-- existing orders keep loan_id = 0 and amount = 0 and are counted as one monthly payment each.
+ALTER TABLE `payment_history`
+    ADD COLUMN `loan_id` int(11) NOT NULL DEFAULT 0 COMMENT '借款序号' AFTER `method`,
+    ADD COLUMN `installments` int(11) NOT NULL DEFAULT 0 COMMENT '支付期数，0表示自定义金额' AFTER `loan_id`,
+    ADD COLUMN `amount` double NOT NULL DEFAULT 0 COMMENT '分配到分期的金额' AFTER `installments`,
+    ADD COLUMN `total_amount` double NOT NULL DEFAULT 0 COMMENT '订单金额，含逾期费用及手续费' AFTER `amount`,
+    ADD INDEX `idx_loan_id_status` (`loan_id`, `status`);
@@ -0,0 +1,19 @@
+-- loan products define the term options, annual rate and repayment method of a loan,
+-- every loan keeps a copy of the product terms it was created with.
+CREATE TABLE `loan_product` (
+    `id` int(11) NOT NULL AUTO_INCREMENT COMMENT '序号',
+    `name` varchar(50) NOT NULL DEFAULT '' COMMENT '产品名称',
+    `terms` varchar(100) NOT NULL DEFAULT '' COMMENT '可选期数，多个用逗号分隔',
+    `annual_rate` double NOT NULL DEFAULT 0 COMMENT '年利率',
+    `repayment_method` varchar(20) NOT NULL DEFAULT '' COMMENT '还款方式 flat/annuity/equal_principal/balloon',
+    `balloon_ratio` double NOT NULL DEFAULT 0 COMMENT '尾款占本金比例',
+    `create_at` datetime DEFAULT NULL COMMENT '创建时间',
+    PRIMARY KEY (`id`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COMMENT = '借款产品';
+
+-- existing loans keep repayment_method = '' and are repaid by their monthly_payment.
+ALTER TABLE `loan`
+    ADD COLUMN `product_id` int(11) NOT NULL DEFAULT 0 COMMENT '产品序号' AFTER `status`,
+    ADD COLUMN `annual_rate` double NOT NULL DEFAULT 0 COMMENT '年利率，创建时取自产品' AFTER `product_id`,
+    ADD COLUMN `repayment_method` varchar(20) NOT NULL DEFAULT '' COMMENT '还款方式，创建时取自产品' AFTER `annual_rate`,
+    ADD COLUMN `balloon_ratio` double NOT NULL DEFAULT 0 COMMENT '尾款占本金比例' AFTER `repayment_method`;
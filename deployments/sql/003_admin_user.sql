@@ -0,0 +1,15 @@
+-- admin users log in to the management api, every admin route requires a role permission,
+-- see internal/rbac for the permissions of each role.
+CREATE TABLE `admin_user` (
+    `id` int(11) NOT NULL AUTO_INCREMENT COMMENT '序号',
+    `username` varchar(50) NOT NULL DEFAULT '' COMMENT '用户名',
+    `password` varchar(100) NOT NULL DEFAULT '' COMMENT 'bcrypt密码哈希',
+    `role` varchar(20) NOT NULL DEFAULT '' COMMENT '角色 viewer/operator/finance/admin',
+    `status` tinyint(4) NOT NULL DEFAULT 1 COMMENT '状态 1:正常 2:禁用',
+    `create_at` datetime DEFAULT NULL COMMENT '创建时间',
+    `update_at` datetime DEFAULT NULL COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_username` (`username`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COMMENT = '管理员';
+
+-- the first admin user is created by cmd/create-admin, see 014_admin_user_password_change.sql.
@@ -0,0 +1,6 @@
+-- TOTP two-factor authentication of admin users, the secret is only enabled after a code is verified,
+-- recovery codes are stored as sha256 hashes joined by commas.
+ALTER TABLE `admin_user`
+    ADD COLUMN `totp_secret` varchar(64) NOT NULL DEFAULT '' COMMENT '两步验证密钥' AFTER `status`,
+    ADD COLUMN `totp_enabled` tinyint(1) NOT NULL DEFAULT 0 COMMENT '是否已开启两步验证' AFTER `totp_secret`,
+    ADD COLUMN `recovery_codes` varchar(1000) NOT NULL DEFAULT '' COMMENT '恢复码的sha256哈希，多个用逗号分隔' AFTER `totp_enabled`;
@@ -0,0 +1,7 @@
+-- sms_history records every message sent by the sms service, rows created by hand keep status 0.
+ALTER TABLE `sms_history`
+    ADD COLUMN `content` varchar(500) NOT NULL DEFAULT '' COMMENT '短信内容' AFTER `mobile`,
+    ADD COLUMN `template` varchar(50) NOT NULL DEFAULT '' COMMENT '模板名称' AFTER `content`,
+    ADD COLUMN `provider` varchar(20) NOT NULL DEFAULT '' COMMENT '短信服务商 aliyun/tencent/log' AFTER `template`,
+    ADD COLUMN `message_id` varchar(64) NOT NULL DEFAULT '' COMMENT '服务商消息ID' AFTER `provider`,
+    ADD COLUMN `status` tinyint(4) NOT NULL DEFAULT 0 COMMENT '发送状态 0:手工录入 1:发送中 2:已发送 3:发送失败' AFTER `message_id`;
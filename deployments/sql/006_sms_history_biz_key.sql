@@ -0,0 +1,5 @@
+-- biz_key deduplicates the messages sent by scheduled jobs, e.g. reminder:{rule}:{loanID}:{seq} of repayment reminders.
+ALTER TABLE `sms_history`
+    ADD COLUMN `biz_key` varchar(64) NOT NULL DEFAULT '' COMMENT '业务去重键' AFTER `status`,
+    ADD INDEX `idx_biz_key` (`biz_key`),
+    ADD INDEX `idx_mobile_create_at` (`mobile`, `create_at`);
@@ -0,0 +1,11 @@
+-- sms templates are looked up by code when sending, codes not in the table fall back to the templates of the config.
+CREATE TABLE `sms_template` (
+    `id` int(11) NOT NULL AUTO_INCREMENT COMMENT '序号',
+    `code` varchar(50) NOT NULL DEFAULT '' COMMENT '模板编码，如 loginCode',
+    `template_id` varchar(64) NOT NULL DEFAULT '' COMMENT '服务商审核通过的模板ID',
+    `content` varchar(500) NOT NULL DEFAULT '' COMMENT '模板内容，变量写作 {name}',
+    `enabled` tinyint(1) NOT NULL DEFAULT 1 COMMENT '是否启用',
+    `create_at` datetime DEFAULT NULL COMMENT '创建时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_code` (`code`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COMMENT = '短信模板';
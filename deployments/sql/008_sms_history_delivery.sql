@@ -0,0 +1,8 @@
+-- delivery_status is updated by the delivery report callbacks of the provider, error_code keeps the code of a failed send or report.
+ALTER TABLE `sms_history`
+    ADD COLUMN `loan_id` int(11) NOT NULL DEFAULT 0 COMMENT '关联借款序号' AFTER `template`,
+    ADD COLUMN `delivery_status` tinyint(4) NOT NULL DEFAULT 0 COMMENT '送达状态 0:未回执 1:已送达 2:送达失败' AFTER `status`,
+    ADD COLUMN `error_code` varchar(64) NOT NULL DEFAULT '' COMMENT '发送失败或送达回执的错误码' AFTER `delivery_status`,
+    ADD COLUMN `report_at` datetime DEFAULT NULL COMMENT '送达回执时间' AFTER `error_code`,
+    ADD INDEX `idx_provider_message_id` (`provider`, `message_id`),
+    ADD INDEX `idx_loan_id` (`loan_id`);
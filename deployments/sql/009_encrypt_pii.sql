@@ -0,0 +1,25 @@
+-- name, user_id and mobile of loans, user_phone of payment orders and mobile of sms are encrypted with AES-GCM,
+-- the *_index columns keep the HMAC blind indexes that exact value lookups use instead of the encrypted columns.
+-- Run `go run ./cmd/encrypt-pii -c configs/lol.yml` after this migration to encrypt the existing rows and fill their indexes.
+ALTER TABLE `loan`
+    MODIFY COLUMN `name` varchar(255) NOT NULL DEFAULT '' COMMENT '姓名，加密存储',
+    MODIFY COLUMN `user_id` varchar(255) NOT NULL DEFAULT '' COMMENT '身份证号码，加密存储',
+    MODIFY COLUMN `mobile` varchar(255) NOT NULL DEFAULT '' COMMENT '手机号码，加密存储',
+    ADD COLUMN `name_index` varchar(64) NOT NULL DEFAULT '' COMMENT '姓名的盲索引' AFTER `mobile`,
+    ADD COLUMN `user_id_index` varchar(64) NOT NULL DEFAULT '' COMMENT '身份证号码的盲索引' AFTER `name_index`,
+    ADD COLUMN `user_code_index` varchar(64) NOT NULL DEFAULT '' COMMENT '身份证号码后6位的盲索引' AFTER `user_id_index`,
+    ADD COLUMN `mobile_index` varchar(64) NOT NULL DEFAULT '' COMMENT '手机号码的盲索引' AFTER `user_code_index`,
+    ADD INDEX `idx_name_index` (`name_index`),
+    ADD INDEX `idx_user_id_index` (`user_id_index`),
+    ADD INDEX `idx_mobile_index_user_code_index` (`mobile_index`, `user_code_index`);
+
+ALTER TABLE `payment_history`
+    MODIFY COLUMN `user_phone` varchar(255) NOT NULL DEFAULT '' COMMENT '用户手机号码，加密存储',
+    ADD COLUMN `user_phone_index` varchar(64) NOT NULL DEFAULT '' COMMENT '用户手机号码的盲索引' AFTER `user_phone`,
+    ADD INDEX `idx_user_phone_index` (`user_phone_index`);
+
+ALTER TABLE `sms_history`
+    MODIFY COLUMN `mobile` varchar(255) NOT NULL DEFAULT '' COMMENT '手机号，加密存储',
+    ADD COLUMN `mobile_index` varchar(64) NOT NULL DEFAULT '' COMMENT '手机号的盲索引' AFTER `mobile`,
+    DROP INDEX `idx_mobile_create_at`,
+    ADD INDEX `idx_mobile_index_create_at` (`mobile_index`, `create_at`);
@@ -0,0 +1,18 @@
+-- every response with unmasked personal information writes a row per record to pii_access_log,
+-- compliance looks up who viewed the data of a loan by loan_id.
+CREATE TABLE `pii_access_log` (
+    `id` int(11) NOT NULL AUTO_INCREMENT COMMENT '序号',
+    `admin_user_id` int(11) NOT NULL DEFAULT 0 COMMENT '操作员序号',
+    `username` varchar(50) NOT NULL DEFAULT '' COMMENT '操作员用户名',
+    `resource` varchar(30) NOT NULL DEFAULT '' COMMENT '资源 loan/paymentHistory/smsHistory',
+    `resource_id` int(11) NOT NULL DEFAULT 0 COMMENT '记录序号',
+    `loan_id` int(11) NOT NULL DEFAULT 0 COMMENT '关联借款序号，0为不关联借款',
+    `fields` varchar(100) NOT NULL DEFAULT '' COMMENT '显示的字段，逗号分隔',
+    `route` varchar(100) NOT NULL DEFAULT '' COMMENT '请求的接口',
+    `request_id` varchar(64) NOT NULL DEFAULT '' COMMENT '请求ID',
+    `ip` varchar(64) NOT NULL DEFAULT '' COMMENT '客户端IP',
+    `create_at` datetime DEFAULT NULL COMMENT '访问时间',
+    PRIMARY KEY (`id`),
+    KEY `idx_loan_id_create_at` (`loan_id`, `create_at`),
+    KEY `idx_admin_user_id_create_at` (`admin_user_id`, `create_at`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COMMENT = '个人信息访问记录';
@@ -0,0 +1,17 @@
+-- every create, update and delete by an admin user writes a row per record to audit_log with the changed columns,
+-- in the transaction of the change. Encrypted columns are logged as they are stored.
+CREATE TABLE `audit_log` (
+    `id` int(11) NOT NULL AUTO_INCREMENT COMMENT '序号',
+    `admin_user_id` int(11) NOT NULL DEFAULT 0 COMMENT '操作员序号',
+    `username` varchar(50) NOT NULL DEFAULT '' COMMENT '操作员用户名',
+    `action` varchar(10) NOT NULL DEFAULT '' COMMENT '操作 create/update/delete',
+    `table_name` varchar(50) NOT NULL DEFAULT '' COMMENT '表名',
+    `record_id` int(11) NOT NULL DEFAULT 0 COMMENT '记录序号',
+    `changes` text COMMENT '变更的字段，json {"字段":{"before":旧值,"after":新值}}',
+    `request_id` varchar(64) NOT NULL DEFAULT '' COMMENT '请求ID',
+    `create_at` datetime DEFAULT NULL COMMENT '操作时间',
+    PRIMARY KEY (`id`),
+    KEY `idx_table_name_record_id` (`table_name`, `record_id`),
+    KEY `idx_admin_user_id_create_at` (`admin_user_id`, `create_at`),
+    KEY `idx_request_id` (`request_id`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COMMENT = '管理操作审计记录';
@@ -0,0 +1,17 @@
+-- loans, payment orders, payment results and sms are soft deleted: deleting sets deleted_at and the row moves to
+-- the trash, where it can be restored, or purged for good by an admin. Queries of the app skip the deleted rows.
+ALTER TABLE `loan`
+    ADD COLUMN `deleted_at` datetime DEFAULT NULL COMMENT '删除时间，为空表示未删除',
+    ADD KEY `idx_deleted_at` (`deleted_at`);
+
+ALTER TABLE `payment_history`
+    ADD COLUMN `deleted_at` datetime DEFAULT NULL COMMENT '删除时间，为空表示未删除',
+    ADD KEY `idx_deleted_at` (`deleted_at`);
+
+ALTER TABLE `result`
+    ADD COLUMN `deleted_at` datetime DEFAULT NULL COMMENT '删除时间，为空表示未删除',
+    ADD KEY `idx_deleted_at` (`deleted_at`);
+
+ALTER TABLE `sms_history`
+    ADD COLUMN `deleted_at` datetime DEFAULT NULL COMMENT '删除时间，为空表示未删除',
+    ADD KEY `idx_deleted_at` (`deleted_at`);
@@ -0,0 +1,4 @@
+-- optimistic locking of the loans: every update increments version, and an update made with a version other than
+-- the current one is refused, so concurrent edits by operators and payment callbacks don't overwrite each other.
+ALTER TABLE `loan`
+    ADD COLUMN `version` int(11) NOT NULL DEFAULT 0 COMMENT '版本号，每次更新加1';
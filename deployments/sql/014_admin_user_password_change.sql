@@ -0,0 +1,8 @@
+-- the admin users created by cmd/create-admin log in with a bootstrap password, they must set up two-factor
+-- authentication to log in and change the password before any other route is allowed.
+ALTER TABLE `admin_user`
+    ADD COLUMN `must_change_password` tinyint(1) NOT NULL DEFAULT 0 COMMENT '是否需要先修改密码' AFTER `status`;
+
+-- the admin user seeded by 003 before had a published password, it must be changed as well.
+UPDATE `admin_user` SET `must_change_password` = 1
+WHERE `password` = '$2a$10$WQfVsjE41vjmfLs.gARnbe2BQb0/61Dx/Qfy7lyxhdaC2KCYNk7MW';
@@ -0,0 +1,9 @@
+-- the variables of sensitive templates, such as the borrower login codes, are redacted in sms_history,
+-- so the users who can read the sent messages can't read the codes.
+ALTER TABLE `sms_template`
+    ADD COLUMN `sensitive` tinyint(1) NOT NULL DEFAULT 0 COMMENT '是否敏感，发送记录中不保存变量的值' AFTER `enabled`;
+
+UPDATE `sms_template` SET `sensitive` = 1 WHERE `code` = 'loginCode';
+
+-- the login codes sent before are removed from the history
+UPDATE `sms_history` SET `content` = '******' WHERE `template` = 'loginCode';
@@ -0,0 +1,17 @@
+-- biz_key is unique so a message is sent only once even if several replicas send it at the same time,
+-- the message is recorded before sending and only the replica whose insert succeeds sends it.
+-- biz_key is NULL for the messages that are not deduplicated, e.g. login codes and the rows created by hand.
+ALTER TABLE `sms_history`
+    MODIFY COLUMN `biz_key` varchar(64) NULL DEFAULT NULL COMMENT '业务去重键，唯一，为空表示不去重';
+
+UPDATE `sms_history` SET `biz_key` = NULL WHERE `biz_key` = '';
+
+-- keep the first message of the keys sent more than once before
+UPDATE `sms_history` h
+    JOIN (SELECT `biz_key`, MIN(`id`) AS `id` FROM `sms_history` WHERE `biz_key` IS NOT NULL GROUP BY `biz_key`) f
+    ON h.`biz_key` = f.`biz_key` AND h.`id` <> f.`id`
+SET h.`biz_key` = NULL;
+
+ALTER TABLE `sms_history`
+    DROP INDEX `idx_biz_key`,
+    ADD UNIQUE KEY `uk_biz_key` (`biz_key`);
@@ -0,0 +1,4 @@
+-- the time step of the last accepted two-factor code, a code is valid for three periods with the clock skew,
+-- the codes of the same or earlier time steps are rejected so that a code can only be used once.
+ALTER TABLE `admin_user`
+    ADD COLUMN `totp_counter` bigint(20) NOT NULL DEFAULT 0 COMMENT '最后一次通过验证的动态验证码的时间步' AFTER `recovery_codes`;
@@ -0,0 +1,124 @@
+// Package attempt count failed attempts of a key, such as a mobile or a client ip, and lock the key
+// for a progressively longer time after repeated failures, to protect secrets against brute force.
+package attempt
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"lol/internal/cache"
+	"lol/internal/config"
+	"lol/internal/database"
+)
+
+const (
+	defaultMaxFailures = 5
+	defaultLockout     = time.Minute
+	defaultMaxLockout  = time.Hour
+	defaultWindow      = 24 * time.Hour
+)
+
+type store interface {
+	Set(ctx context.Context, key string, data *cache.Attempt, duration time.Duration) error
+	Get(ctx context.Context, key string) (*cache.Attempt, error)
+	Del(ctx context.Context, key string) error
+}
+
+// Limiter the failed attempts of the keys of a kind, every maxFailures failures lock the key,
+// the lockout doubles each time up to maxLockout. The record of a key expires after window without failure.
+type Limiter struct {
+	store       store
+	kind        string // prefix of the keys, e.g. borrowerMobile
+	maxFailures int
+	lockout     time.Duration
+	maxLockout  time.Duration
+	window      time.Duration
+	now         func() time.Time
+}
+
+// NewLimiter create a limiter of the keys of the kind, maxFailures overrides the config if it is greater than 0
+func NewLimiter(kind string, conf config.BruteForce, maxFailures int, store store) *Limiter {
+	if maxFailures <= 0 {
+		maxFailures = conf.MaxFailures
+	}
+	if maxFailures <= 0 {
+		maxFailures = defaultMaxFailures
+	}
+	l := &Limiter{
+		store:       store,
+		kind:        kind,
+		maxFailures: maxFailures,
+		lockout:     time.Duration(conf.Lockout) * time.Second,
+		maxLockout:  time.Duration(conf.MaxLockout) * time.Second,
+		window:      time.Duration(conf.Window) * time.Second,
+		now:         time.Now,
+	}
+	if l.lockout <= 0 {
+		l.lockout = defaultLockout
+	}
+	if l.maxLockout < l.lockout {
+		l.maxLockout = defaultMaxLockout
+		if l.maxLockout < l.lockout {
+			l.maxLockout = l.lockout
+		}
+	}
+	if l.window <= 0 {
+		l.window = defaultWindow
+	}
+	return l
+}
+
+// Get the failed attempts of the key, an empty record is returned if there is no failure
+func (l *Limiter) Get(ctx context.Context, key string) (*cache.Attempt, error) {
+	record, err := l.store.Get(ctx, l.cacheKey(key))
+	if err != nil {
+		if errors.Is(err, database.ErrCacheNotFound) {
+			return &cache.Attempt{}, nil
+		}
+		return nil, err
+	}
+	return record, nil
+}
+
+// RetryAfter the time until the key is unlocked, 0 if it is not locked
+func (l *Limiter) RetryAfter(record *cache.Attempt) time.Duration {
+	if d := record.LockedUntil.Sub(l.now()); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// Fail record a failed attempt of the key, the key is locked if the failures reach a multiple of maxFailures
+func (l *Limiter) Fail(ctx context.Context, key string) (*cache.Attempt, error) {
+	record, err := l.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	record.Failures++
+	if record.Failures%l.maxFailures == 0 {
+		lockout := l.lockout
+		for i := 0; i < record.Lockouts && lockout < l.maxLockout; i++ {
+			lockout *= 2
+		}
+		if lockout > l.maxLockout {
+			lockout = l.maxLockout
+		}
+		record.Lockouts++
+		record.LockedUntil = l.now().Add(lockout)
+	}
+	err = l.store.Set(ctx, l.cacheKey(key), record, l.window+l.RetryAfter(record))
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// Reset forget the failed attempts of the key, called after a successful attempt
+func (l *Limiter) Reset(ctx context.Context, key string) error {
+	return l.store.Del(ctx, l.cacheKey(key))
+}
+
+func (l *Limiter) cacheKey(key string) string {
+	return l.kind + ":" + key
+}
@@ -0,0 +1,108 @@
+package attempt
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"lol/internal/cache"
+	"lol/internal/config"
+	"lol/internal/database"
+)
+
+type fakeStore struct {
+	records map[string]*cache.Attempt
+	expires map[string]time.Duration
+	err     error
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{records: map[string]*cache.Attempt{}, expires: map[string]time.Duration{}}
+}
+
+func (f *fakeStore) Set(ctx context.Context, key string, data *cache.Attempt, duration time.Duration) error {
+	v := *data
+	f.records[key] = &v
+	f.expires[key] = duration
+	return nil
+}
+
+func (f *fakeStore) Get(ctx context.Context, key string) (*cache.Attempt, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	v, ok := f.records[key]
+	if !ok {
+		return nil, database.ErrCacheNotFound
+	}
+	record := *v
+	return &record, nil
+}
+
+func (f *fakeStore) Del(ctx context.Context, key string) error {
+	delete(f.records, key)
+	return nil
+}
+
+func TestLimiter(t *testing.T) {
+	store := newFakeStore()
+	l := NewLimiter("borrowerMobile", config.BruteForce{MaxFailures: 3, Lockout: 60, MaxLockout: 200, Window: 3600}, 0, store)
+	now := time.Date(2024, 2, 15, 10, 0, 0, 0, time.Local)
+	l.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	record, err := l.Get(ctx, "13800000000")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, record.Failures)
+	assert.Equal(t, time.Duration(0), l.RetryAfter(record))
+
+	for i := 0; i < 2; i++ {
+		record, err = l.Fail(ctx, "13800000000")
+		assert.NoError(t, err)
+		assert.Equal(t, time.Duration(0), l.RetryAfter(record))
+	}
+	assert.Equal(t, time.Hour, store.expires["borrowerMobile:13800000000"])
+
+	// the lockouts double up to maxLockout
+	for _, lockout := range []time.Duration{time.Minute, 2 * time.Minute, 200 * time.Second, 200 * time.Second} {
+		record, err = l.Fail(ctx, "13800000000")
+		assert.NoError(t, err)
+		assert.Equal(t, lockout, l.RetryAfter(record))
+		assert.Equal(t, time.Hour+lockout, store.expires["borrowerMobile:13800000000"])
+		_, _ = l.Fail(ctx, "13800000000")
+		_, _ = l.Fail(ctx, "13800000000")
+	}
+	record, err = l.Get(ctx, "13800000000")
+	assert.NoError(t, err)
+	assert.Equal(t, 14, record.Failures)
+	assert.Equal(t, 4, record.Lockouts)
+
+	now = now.Add(201 * time.Second)
+	assert.Equal(t, time.Duration(0), l.RetryAfter(record))
+
+	assert.NoError(t, l.Reset(ctx, "13800000000"))
+	record, err = l.Get(ctx, "13800000000")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, record.Failures)
+
+	store.err = errors.New("redis error")
+	_, err = l.Get(ctx, "13800000000")
+	assert.Error(t, err)
+	_, err = l.Fail(ctx, "13800000000")
+	assert.Error(t, err)
+}
+
+func TestNewLimiter(t *testing.T) {
+	l := NewLimiter("borrowerIP", config.BruteForce{MaxFailures: 5}, 20, newFakeStore())
+	assert.Equal(t, 20, l.maxFailures)
+	assert.Equal(t, defaultLockout, l.lockout)
+	assert.Equal(t, defaultMaxLockout, l.maxLockout)
+	assert.Equal(t, defaultWindow, l.window)
+
+	l = NewLimiter("borrowerIP", config.BruteForce{Lockout: 7200}, 0, newFakeStore())
+	assert.Equal(t, defaultMaxFailures, l.maxFailures)
+	assert.Equal(t, 2*time.Hour, l.maxLockout)
+}
@@ -0,0 +1,174 @@
+// Package audit record who created, updated or deleted which records and the values of the changed columns.
+//
+// The admin auth middleware puts the logged in admin user into the context of the request with WithActor,
+// the gorm Plugin writes an audit log for every record changed by a statement whose context has an actor,
+// so mutations of the dao are audited without the dao knowing about it.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"lol/internal/fieldcrypt"
+)
+
+// actions of the audit logs
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// redactedValue replaces the values of the redacted columns, such as password hashes
+const redactedValue = "***"
+
+// Actor the admin user making the changes
+type Actor struct {
+	ID       uint64
+	Username string
+}
+
+type actorKey struct{}
+
+// WithActor returns a context whose changes are audited as made by the actor
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFrom the actor of the context, false if the changes of the context are not audited
+func ActorFrom(ctx context.Context) (Actor, bool) {
+	if ctx == nil {
+		return Actor{}, false
+	}
+	actor, ok := ctx.Value(actorKey{}).(Actor)
+	return actor, ok
+}
+
+// Change the values of a column before and after the change, nil before a create and after a delete
+type Change struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// Diff the changed columns of a row, before is nil for a created row and after is nil for a deleted row,
+// the empty columns of created and deleted rows are left out. Encrypted columns are compared by their plaintext,
+// the values of the redact columns and the blind index columns (*_index) are replaced by ***,
+// so only the fact that they changed is logged.
+func Diff(before map[string]interface{}, after map[string]interface{}, redact map[string]bool) map[string]Change {
+	changes := map[string]Change{}
+	columns := map[string]struct{}{}
+	for column := range before {
+		columns[column] = struct{}{}
+	}
+	for column := range after {
+		columns[column] = struct{}{}
+	}
+
+	for column := range columns {
+		b, a := normalize(before[column]), normalize(after[column])
+		switch {
+		case before == nil && isEmpty(a), after == nil && isEmpty(b):
+			continue
+		case before != nil && after != nil && equal(b, a):
+			continue
+		}
+		if isRedacted(column, redact) {
+			b, a = redactValue(b), redactValue(a)
+		}
+		changes[column] = Change{Before: b, After: a}
+	}
+	return changes
+}
+
+// Redact replace the values of the redact columns and the blind index columns of the changes of an audit log
+// by ***, e.g. to scrub the logs written before the columns were redacted. false is returned if nothing changed.
+func Redact(changes string, redact map[string]bool) (string, bool, error) {
+	diff := map[string]Change{}
+	if err := json.Unmarshal([]byte(changes), &diff); err != nil {
+		return "", false, err
+	}
+	redacted := false
+	for column, c := range diff {
+		if !isRedacted(column, redact) {
+			continue
+		}
+		if isRedactedValue(c.Before) && isRedactedValue(c.After) {
+			continue
+		}
+		diff[column] = Change{Before: redactValue(c.Before), After: redactValue(c.After)}
+		redacted = true
+	}
+	if !redacted {
+		return changes, false, nil
+	}
+	content, err := json.Marshal(diff)
+	if err != nil {
+		return "", false, err
+	}
+	return string(content), true, nil
+}
+
+func isRedacted(column string, redact map[string]bool) bool {
+	return redact[column] || strings.HasSuffix(column, "_index")
+}
+
+func isRedactedValue(v interface{}) bool {
+	return isEmpty(v) || v == redactedValue
+}
+
+// normalize the value scanned by the database driver
+func normalize(v interface{}) interface{} {
+	switch value := v.(type) {
+	case []byte:
+		return string(value)
+	case *time.Time:
+		if value == nil {
+			return nil
+		}
+		return *value
+	}
+	return v
+}
+
+func isEmpty(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	if t, ok := v.(time.Time); ok {
+		return t.IsZero()
+	}
+	return reflect.ValueOf(v).IsZero()
+}
+
+func equal(a interface{}, b interface{}) bool {
+	if ta, ok := a.(time.Time); ok {
+		if tb, ok := b.(time.Time); ok {
+			return ta.Equal(tb)
+		}
+	}
+	sa, sb := fmt.Sprint(a), fmt.Sprint(b)
+	if a == nil {
+		sa = ""
+	}
+	if b == nil {
+		sb = ""
+	}
+	if sa == sb {
+		return true
+	}
+	// an encrypted value differs every time it is written, even if its plaintext is the same
+	pa, errA := fieldcrypt.Decrypt(sa)
+	pb, errB := fieldcrypt.Decrypt(sb)
+	return errA == nil && errB == nil && pa == pb
+}
+
+func redactValue(v interface{}) interface{} {
+	if isEmpty(v) {
+		return v
+	}
+	return redactedValue
+}
@@ -0,0 +1,83 @@
+package audit
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"lol/internal/config"
+	"lol/internal/fieldcrypt"
+)
+
+func TestActor(t *testing.T) {
+	_, ok := ActorFrom(context.Background())
+	assert.False(t, ok)
+
+	ctx := WithActor(context.Background(), Actor{ID: 3, Username: "alice"})
+	actor, ok := ActorFrom(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, Actor{ID: 3, Username: "alice"}, actor)
+}
+
+func TestDiff(t *testing.T) {
+	now := time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)
+	redact := map[string]bool{"password": true}
+
+	// update, only the changed columns
+	changes := Diff(
+		map[string]interface{}{"id": int64(1), "status": int64(0), "name": []byte("a"), "password": "x", "create_at": now, "name_index": "i1"},
+		map[string]interface{}{"id": int64(1), "status": int64(1), "name": []byte("a"), "password": "y", "create_at": now, "name_index": "i2"},
+		redact,
+	)
+	assert.Equal(t, map[string]Change{
+		"status":     {Before: int64(0), After: int64(1)},
+		"password":   {Before: "***", After: "***"},
+		"name_index": {Before: "***", After: "***"},
+	}, changes)
+
+	// create, the empty columns are left out
+	changes = Diff(nil, map[string]interface{}{"id": int64(1), "status": int64(0), "remark": "", "create_at": nil, "password": ""}, redact)
+	assert.Equal(t, map[string]Change{"id": {After: int64(1)}}, changes)
+
+	// delete
+	changes = Diff(map[string]interface{}{"id": int64(1), "amount": int64(500)}, nil, redact)
+	assert.Equal(t, map[string]Change{"id": {Before: int64(1)}, "amount": {Before: int64(500)}}, changes)
+}
+
+func TestRedact(t *testing.T) {
+	changes, redacted, err := Redact(`{"name":{"before":"enc1","after":"enc2"},"name_index":{"before":"i1","after":"i2"},`+
+		`"mobile":{"before":null,"after":"enc3"},"status":{"before":0,"after":1}}`, map[string]bool{"name": true, "mobile": true})
+	assert.NoError(t, err)
+	assert.True(t, redacted)
+	assert.JSONEq(t, `{"name":{"before":"***","after":"***"},"name_index":{"before":"***","after":"***"},`+
+		`"mobile":{"before":null,"after":"***"},"status":{"before":0,"after":1}}`, changes)
+
+	// redacted already
+	_, redacted, err = Redact(changes, map[string]bool{"name": true, "mobile": true})
+	assert.NoError(t, err)
+	assert.False(t, redacted)
+
+	_, _, err = Redact("not json", nil)
+	assert.Error(t, err)
+}
+
+func TestDiff_encrypted(t *testing.T) {
+	err := fieldcrypt.Init(config.FieldCrypt{
+		Version:  1,
+		Keys:     []config.FieldCryptKey{{Version: 1, Key: base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))}},
+		IndexKey: base64.StdEncoding.EncodeToString([]byte("index-key-index-key-index-key-32")),
+	})
+	assert.NoError(t, err)
+	defer func() { _ = fieldcrypt.Init(config.FieldCrypt{}) }()
+
+	a, _ := fieldcrypt.Encrypt("13812345678")
+	b, _ := fieldcrypt.Encrypt("13812345678")
+	c, _ := fieldcrypt.Encrypt("13900000000")
+	assert.NotEqual(t, a, b)
+	assert.Empty(t, Diff(map[string]interface{}{"mobile": a}, map[string]interface{}{"mobile": b}, nil))
+	assert.Equal(t, map[string]Change{"mobile": {Before: a, After: c}},
+		Diff(map[string]interface{}{"mobile": a}, map[string]interface{}{"mobile": c}, nil))
+}
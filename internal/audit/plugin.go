@@ -0,0 +1,361 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+
+	"github.com/go-dev-frame/sponge/pkg/gin/middleware"
+
+	"lol/internal/model"
+	"lol/internal/pii"
+)
+
+// beforeKey the gorm instance key of the rows read before an update or a delete
+const beforeKey = "audit:before"
+
+var _ gorm.Plugin = (*Plugin)(nil)
+
+// Plugin the gorm plugin writing the audit logs of the creates, updates and deletes of the statements whose
+// context has an actor. The rows are read before and after the change by the conditions of the statement,
+// the audit logs are written by the connection of the statement, so they are in the same transaction as the change,
+// and a change whose rows or logs fail to be read or written fails.
+type Plugin struct {
+	ignoreTables  map[string]bool
+	redactColumns map[string]bool
+}
+
+// NewPlugin creating the plugin, the changes of the ignoreTables are not audited, the values of the
+// redactColumns, the personal information marked with the pii tag and the blind indexes are logged as ***.
+// The audit log table itself is never audited.
+func NewPlugin(ignoreTables []string, redactColumns []string) *Plugin {
+	p := &Plugin{
+		ignoreTables:  map[string]bool{(&model.AuditLog{}).TableName(): true},
+		redactColumns: map[string]bool{},
+	}
+	for _, table := range ignoreTables {
+		p.ignoreTables[table] = true
+	}
+	for _, column := range redactColumns {
+		p.redactColumns[column] = true
+	}
+	return p
+}
+
+// Name the name of the plugin
+func (p *Plugin) Name() string {
+	return "audit"
+}
+
+// Initialize register the callbacks, inside the transaction gorm begins for every change
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	const begin, commit = "gorm:begin_transaction", "gorm:commit_or_rollback_transaction"
+	callback := db.Callback()
+	err := callback.Create().After("gorm:create").Before(commit).Register("audit:after_create", p.afterCreate)
+	if err != nil {
+		return err
+	}
+	err = callback.Update().After(begin).Before("gorm:update").Register("audit:before_update", p.before)
+	if err != nil {
+		return err
+	}
+	err = callback.Update().After("gorm:update").Before(commit).Register("audit:after_update", p.afterUpdate)
+	if err != nil {
+		return err
+	}
+	err = callback.Delete().After(begin).Before("gorm:delete").Register("audit:before_delete", p.before)
+	if err != nil {
+		return err
+	}
+	return callback.Delete().After("gorm:delete").Before(commit).Register("audit:after_delete", p.afterDelete)
+}
+
+// actor the actor of the statement, false if the statement is not audited
+func (p *Plugin) actor(db *gorm.DB) (Actor, bool) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Table == "" || p.ignoreTables[stmt.Table] {
+		return Actor{}, false
+	}
+	return ActorFrom(stmt.Context)
+}
+
+// before read the rows to be updated or deleted
+func (p *Plugin) before(db *gorm.DB) {
+	if _, ok := p.actor(db); !ok {
+		return
+	}
+	conditions := whereConditions(db.Statement)
+	// gorm refuses to update or delete without conditions
+	if len(conditions) == 0 {
+		return
+	}
+	rows, err := p.find(db, conditions)
+	if err != nil {
+		_ = db.AddError(fmt.Errorf("audit: read the rows before the change error: %v", err))
+		return
+	}
+	db.InstanceSet(beforeKey, rows)
+}
+
+func (p *Plugin) afterCreate(db *gorm.DB) {
+	actor, ok := p.actor(db)
+	if !ok {
+		return
+	}
+	ids := createdIDs(db.Statement)
+	if len(ids) == 0 {
+		return
+	}
+	rows, err := p.find(db, []clause.Expression{clause.IN{Column: clause.Column{Name: primaryKey(db.Statement)}, Values: ids}})
+	if err != nil {
+		_ = db.AddError(fmt.Errorf("audit: read the created rows error: %v", err))
+		return
+	}
+	p.write(db, actor, ActionCreate, nil, rows)
+}
+
+func (p *Plugin) afterUpdate(db *gorm.DB) {
+	actor, ok := p.actor(db)
+	if !ok || db.RowsAffected == 0 {
+		return
+	}
+	before := p.beforeRows(db)
+	if len(before) == 0 {
+		return
+	}
+	pk := primaryKey(db.Statement)
+	ids := make([]interface{}, 0, len(before))
+	for _, row := range before {
+		ids = append(ids, row[pk])
+	}
+	after, err := p.find(db, []clause.Expression{clause.IN{Column: clause.Column{Name: pk}, Values: ids}})
+	if err != nil {
+		_ = db.AddError(fmt.Errorf("audit: read the rows after the change error: %v", err))
+		return
+	}
+	p.write(db, actor, ActionUpdate, before, after)
+}
+
+func (p *Plugin) afterDelete(db *gorm.DB) {
+	actor, ok := p.actor(db)
+	if !ok || db.RowsAffected == 0 {
+		return
+	}
+	before := p.beforeRows(db)
+	if len(before) == 0 {
+		return
+	}
+	p.write(db, actor, ActionDelete, before, nil)
+}
+
+func (p *Plugin) beforeRows(db *gorm.DB) []map[string]interface{} {
+	v, ok := db.InstanceGet(beforeKey)
+	if !ok {
+		return nil
+	}
+	rows, _ := v.([]map[string]interface{})
+	return rows
+}
+
+// find the rows of the table of the statement by the conditions, with the connection of the statement
+func (p *Plugin) find(db *gorm.DB, conditions []clause.Expression) ([]map[string]interface{}, error) {
+	rows := []map[string]interface{}{}
+	err := db.Session(&gorm.Session{NewDB: true}).Table(db.Statement.Table).
+		Clauses(clause.Where{Exprs: conditions}).Order(primaryKey(db.Statement)).Find(&rows).Error
+	return rows, err
+}
+
+// write an audit log for every changed row, the rows before and after are matched by the primary key,
+// before is nil for creates and after is nil for deletes
+func (p *Plugin) write(db *gorm.DB, actor Actor, action string, before []map[string]interface{}, after []map[string]interface{}) {
+	pk := primaryKey(db.Statement)
+	afterRows := make(map[string]map[string]interface{}, len(after))
+	for _, row := range after {
+		afterRows[fmt.Sprint(normalize(row[pk]))] = row
+	}
+
+	type change struct {
+		id            interface{}
+		before, after map[string]interface{}
+	}
+	var changes []change
+	if action == ActionCreate {
+		for _, row := range after {
+			changes = append(changes, change{id: row[pk], after: row})
+		}
+	} else {
+		for _, row := range before {
+			c := change{id: row[pk], before: row}
+			if action == ActionUpdate {
+				// a row missing after the update is logged as having every column removed
+				c.after = afterRows[fmt.Sprint(normalize(row[pk]))]
+				if c.after == nil {
+					c.after = map[string]interface{}{}
+				}
+			}
+			changes = append(changes, c)
+		}
+	}
+
+	now := time.Now()
+	requestID := middleware.CtxRequestID(db.Statement.Context)
+	redact := p.redact(db.Statement.Schema)
+	logs := make([]*model.AuditLog, 0, len(changes))
+	for _, c := range changes {
+		diff := Diff(c.before, c.after, redact)
+		if len(diff) == 0 {
+			continue
+		}
+		content, err := json.Marshal(diff)
+		if err != nil {
+			_ = db.AddError(fmt.Errorf("audit: marshal the changes error: %v", err))
+			return
+		}
+		logs = append(logs, &model.AuditLog{
+			AdminUserID: actor.ID,
+			Username:    actor.Username,
+			Action:      action,
+			Table:       db.Statement.Table,
+			RecordID:    toUint64(c.id),
+			Changes:     string(content),
+			RequestID:   requestID,
+			CreateAt:    &now,
+		})
+	}
+	if len(logs) == 0 {
+		return
+	}
+
+	err := db.Session(&gorm.Session{NewDB: true}).Create(logs).Error
+	if err != nil {
+		_ = db.AddError(fmt.Errorf("audit: write the audit logs error: %v", err))
+	}
+}
+
+// redact the redact columns of the plugin and the personal information columns of the model
+func (p *Plugin) redact(s *schema.Schema) map[string]bool {
+	columns := PiiColumns(s)
+	if len(columns) == 0 {
+		return p.redactColumns
+	}
+	for column := range p.redactColumns {
+		columns[column] = true
+	}
+	return columns
+}
+
+// whereConditions the conditions of the update or delete statement, gorm adds the primary key of the model
+// to the conditions when it builds the statement, after the before callbacks
+func whereConditions(stmt *gorm.Statement) []clause.Expression {
+	var conditions []clause.Expression
+	if c, ok := stmt.Clauses["WHERE"]; ok {
+		if where, ok := c.Expression.(clause.Where); ok {
+			conditions = append(conditions, where.Exprs...)
+		}
+	}
+	if stmt.Schema != nil && stmt.ReflectValue.Kind() == reflect.Struct {
+		for _, field := range stmt.Schema.PrimaryFields {
+			if value, isZero := field.ValueOf(stmt.Context, stmt.ReflectValue); !isZero {
+				conditions = append(conditions, clause.Eq{Column: clause.Column{Name: field.DBName}, Value: value})
+			}
+		}
+	}
+	return conditions
+}
+
+// createdIDs the primary keys of the created records, written back by gorm
+func createdIDs(stmt *gorm.Statement) []interface{} {
+	if stmt.Schema == nil || stmt.Schema.PrioritizedPrimaryField == nil {
+		return nil
+	}
+	field := stmt.Schema.PrioritizedPrimaryField
+	var ids []interface{}
+	add := func(v reflect.Value) {
+		v = reflect.Indirect(v)
+		if v.Kind() != reflect.Struct {
+			return
+		}
+		if id, isZero := field.ValueOf(stmt.Context, v); !isZero {
+			ids = append(ids, id)
+		}
+	}
+	switch stmt.ReflectValue.Kind() {
+	case reflect.Struct:
+		add(stmt.ReflectValue)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < stmt.ReflectValue.Len(); i++ {
+			add(stmt.ReflectValue.Index(i))
+		}
+	}
+	return ids
+}
+
+// primaryKey the primary key column of the table of the statement, id if the statement has no model
+func primaryKey(stmt *gorm.Statement) string {
+	if stmt.Schema != nil && stmt.Schema.PrioritizedPrimaryField != nil {
+		return stmt.Schema.PrioritizedPrimaryField.DBName
+	}
+	return "id"
+}
+
+// Scrub redact the past audit logs of the records of the model with db, e.g. when the data subject of the records
+// is anonymized. The personal information columns of the model, the blind indexes and the columns are redacted.
+func Scrub(db *gorm.DB, value interface{}, ids []uint64, columns ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	s, err := schema.Parse(value, &sync.Map{}, db.NamingStrategy)
+	if err != nil {
+		return err
+	}
+	redact := PiiColumns(s)
+	for _, column := range columns {
+		redact[column] = true
+	}
+
+	logs := []*model.AuditLog{}
+	err = db.Select("id", "changes").Where("table_name = ? AND record_id IN ?", s.Table, ids).Order("id").Find(&logs).Error
+	if err != nil {
+		return err
+	}
+	for _, log := range logs {
+		changes, redacted, err := Redact(log.Changes, redact)
+		if err != nil {
+			return fmt.Errorf("audit: redact the audit log %d error: %v", log.ID, err)
+		}
+		if !redacted {
+			continue
+		}
+		err = db.Model(&model.AuditLog{}).Where("id = ?", log.ID).Update("changes", changes).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PiiColumns the columns of the fields of the model marked with the pii tag, nil if the schema is nil
+func PiiColumns(s *schema.Schema) map[string]bool {
+	if s == nil {
+		return nil
+	}
+	columns := map[string]bool{}
+	for _, field := range s.Fields {
+		if field.DBName != "" && field.Tag.Get(pii.TagName) != "" {
+			columns[field.DBName] = true
+		}
+	}
+	return columns
+}
+
+func toUint64(v interface{}) uint64 {
+	id, _ := strconv.ParseUint(fmt.Sprint(normalize(v)), 10, 64)
+	return id
+}
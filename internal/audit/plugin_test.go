@@ -0,0 +1,244 @@
+package audit
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+
+	"lol/internal/model"
+)
+
+// testDialector a minimal mysql-like dialector over sqlmock
+type testDialector struct {
+	conn gorm.ConnPool
+}
+
+func (d testDialector) Name() string { return "test" }
+
+func (d testDialector) Initialize(db *gorm.DB) error {
+	callbacks.RegisterDefaultCallbacks(db, &callbacks.Config{})
+	db.ConnPool = d.conn
+	return nil
+}
+
+func (d testDialector) Migrator(db *gorm.DB) gorm.Migrator { return nil }
+
+func (d testDialector) DataTypeOf(*schema.Field) string { return "" }
+
+func (d testDialector) DefaultValueOf(*schema.Field) clause.Expression {
+	return clause.Expr{SQL: "DEFAULT"}
+}
+
+func (d testDialector) BindVarTo(writer clause.Writer, stmt *gorm.Statement, v interface{}) {
+	_ = writer.WriteByte('?')
+}
+
+func (d testDialector) QuoteTo(writer clause.Writer, str string) {
+	_, _ = writer.WriteString("`" + str + "`")
+}
+
+func (d testDialector) Explain(sql string, vars ...interface{}) string { return sql }
+
+func newTestDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	conn, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	db, err := gorm.Open(testDialector{conn: conn}, &gorm.Config{Logger: logger.Discard})
+	assert.NoError(t, err)
+	assert.NoError(t, db.Use(NewPlugin([]string{"pii_access_log"}, []string{"password"})))
+	return db, mock
+}
+
+type testAuditLog struct {
+	action, table string
+	recordID      uint64
+	changes       map[string]Change
+}
+
+// auditLogArgs match the values of an inserted audit log
+func auditLogArgs(t *testing.T, want testAuditLog) []driver.Value {
+	return []driver.Value{
+		uint64(3), "alice", want.action, want.table, want.recordID,
+		jsonArg{t: t, want: want.changes}, sqlmock.AnyArg(), sqlmock.AnyArg(),
+	}
+}
+
+type jsonArg struct {
+	t    *testing.T
+	want map[string]Change
+}
+
+func (a jsonArg) Match(v driver.Value) bool {
+	s, ok := v.(string)
+	if !ok {
+		return false
+	}
+	got := map[string]Change{}
+	if err := json.Unmarshal([]byte(s), &got); err != nil {
+		return false
+	}
+	want, _ := json.Marshal(a.want)
+	wantChanges := map[string]Change{}
+	_ = json.Unmarshal(want, &wantChanges)
+	return assert.Equal(a.t, wantChanges, got)
+}
+
+func TestPlugin_update(t *testing.T) {
+	db, mock := newTestDB(t)
+	ctx := WithActor(context.Background(), Actor{ID: 3, Username: "alice"})
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM `payment_history` WHERE `id` = \\? ORDER BY id").WithArgs(uint64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status", "amount"}).AddRow(1, "NOTPAY", 100.0))
+	mock.ExpectExec("UPDATE `payment_history` SET").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT \\* FROM `payment_history` WHERE `id` = \\? ORDER BY id").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status", "amount"}).AddRow(1, "SUCCESS", 100.0))
+	mock.ExpectExec("INSERT INTO `audit_log`").
+		WithArgs(auditLogArgs(t, testAuditLog{ActionUpdate, "payment_history", 1, map[string]Change{
+			"status": {Before: "NOTPAY", After: "SUCCESS"},
+		}})...).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := db.WithContext(ctx).Model(&model.PaymentHistory{ID: 1}).Updates(map[string]interface{}{"status": "SUCCESS"}).Error
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPlugin_update_pii(t *testing.T) {
+	db, mock := newTestDB(t)
+	ctx := WithActor(context.Background(), Actor{ID: 3, Username: "alice"})
+
+	// the personal information and its blind index are logged only as changed
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM `payment_history` WHERE `id` = \\? ORDER BY id").WithArgs(uint64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_phone", "user_phone_index"}).AddRow(1, "enc1", "i1"))
+	mock.ExpectExec("UPDATE `payment_history` SET").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT \\* FROM `payment_history` WHERE `id` = \\? ORDER BY id").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_phone", "user_phone_index"}).AddRow(1, "enc2", "i2"))
+	mock.ExpectExec("INSERT INTO `audit_log`").
+		WithArgs(auditLogArgs(t, testAuditLog{ActionUpdate, "payment_history", 1, map[string]Change{
+			"user_phone":       {Before: redactedValue, After: redactedValue},
+			"user_phone_index": {Before: redactedValue, After: redactedValue},
+		}})...).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := db.WithContext(ctx).Model(&model.PaymentHistory{ID: 1}).
+		Updates(map[string]interface{}{"user_phone": "enc2", "user_phone_index": "i2"}).Error
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestScrub(t *testing.T) {
+	db, mock := newTestDB(t)
+
+	mock.ExpectQuery("SELECT `id`,`changes` FROM `audit_log` WHERE table_name = \\? AND record_id IN \\(\\?\\)").
+		WithArgs("loan", uint64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "changes"}).
+			AddRow(7, `{"name":{"before":null,"after":"enc1"},"car_plate":{"before":null,"after":"粤A12345"}}`).
+			AddRow(8, `{"status":{"before":0,"after":1}}`))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `audit_log` SET `changes`=\\? WHERE id = \\?").
+		WithArgs(jsonArg{t: t, want: map[string]Change{
+			"name":      {After: redactedValue},
+			"car_plate": {After: redactedValue},
+		}}, 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	assert.NoError(t, Scrub(db, &model.Loan{}, []uint64{1}, "car_plate"))
+	assert.NoError(t, Scrub(db, &model.Loan{}, nil))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPlugin_delete(t *testing.T) {
+	db, mock := newTestDB(t)
+	ctx := WithActor(context.Background(), Actor{ID: 3, Username: "alice"})
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM `admin_user` WHERE id = \\? ORDER BY id").WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "password"}).AddRow(2, "bob", "hash"))
+	mock.ExpectExec("DELETE FROM `admin_user`").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO `audit_log`").
+		WithArgs(auditLogArgs(t, testAuditLog{ActionDelete, "admin_user", 2, map[string]Change{
+			"id":       {Before: 2},
+			"username": {Before: "bob"},
+			"password": {Before: redactedValue},
+		}})...).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := db.WithContext(ctx).Where("id = ?", 2).Delete(&model.AdminUser{}).Error
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPlugin_create(t *testing.T) {
+	db, mock := newTestDB(t)
+	ctx := WithActor(context.Background(), Actor{ID: 3, Username: "alice"})
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `sms_template`").WillReturnResult(sqlmock.NewResult(5, 1))
+	mock.ExpectQuery("SELECT \\* FROM `sms_template` WHERE `id` = \\? ORDER BY id").WithArgs(uint64(5)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "code"}).AddRow(5, "reminder"))
+	mock.ExpectExec("INSERT INTO `audit_log`").
+		WithArgs(auditLogArgs(t, testAuditLog{ActionCreate, "sms_template", 5, map[string]Change{
+			"id":   {After: 5},
+			"code": {After: "reminder"},
+		}})...).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := db.WithContext(ctx).Create(&model.SmsTemplate{Code: "reminder"}).Error
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPlugin_notAudited(t *testing.T) {
+	db, mock := newTestDB(t)
+
+	// no actor
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `payment_history` SET").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	err := db.Model(&model.PaymentHistory{ID: 1}).Updates(map[string]interface{}{"status": "SUCCESS"}).Error
+	assert.NoError(t, err)
+
+	// ignored table
+	ctx := WithActor(context.Background(), Actor{ID: 3, Username: "alice"})
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `pii_access_log`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	err = db.WithContext(ctx).Create(&model.PiiAccessLog{AdminUserID: 3}).Error
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPlugin_writeError(t *testing.T) {
+	db, mock := newTestDB(t)
+	ctx := WithActor(context.Background(), Actor{ID: 3, Username: "alice"})
+
+	// the change is rolled back if the audit log can't be written
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM `payment_history`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(1, "NOTPAY"))
+	mock.ExpectExec("UPDATE `payment_history` SET").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT \\* FROM `payment_history`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(1, "SUCCESS"))
+	mock.ExpectExec("INSERT INTO `audit_log`").WillReturnError(gorm.ErrInvalidDB)
+	mock.ExpectRollback()
+
+	err := db.WithContext(ctx).Model(&model.PaymentHistory{ID: 1}).Updates(map[string]interface{}{"status": "SUCCESS"}).Error
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/go-dev-frame/sponge/pkg/cache"
+	"github.com/go-dev-frame/sponge/pkg/encoding"
+
+	"lol/internal/database"
+)
+
+const (
+	// AdminChallengeExpireTime expire time
+	AdminChallengeExpireTime = 5 * time.Minute
+)
+
+// AdminChallenge 管理员密码验证通过后等待两步验证的登录，验证次数单独计数，见 IncrAttempts
+type AdminChallenge struct {
+	UserID    uint64    `json:"userID"`    // 管理员序号
+	CreatedAt time.Time `json:"createdAt"` // 创建时间
+}
+
+var _ AdminChallengeCache = (*adminChallengeCache)(nil)
+
+// AdminChallengeCache cache interface
+type AdminChallengeCache interface {
+	Set(ctx context.Context, token string, data *AdminChallenge, duration time.Duration) error
+	Get(ctx context.Context, token string) (*AdminChallenge, error)
+	Del(ctx context.Context, token string) error
+	IncrAttempts(ctx context.Context, token string, duration time.Duration) (int, error)
+}
+
+// adminChallengeCache define a cache struct
+type adminChallengeCache struct {
+	cache    cache.Cache
+	attempts attemptCounter
+}
+
+// NewAdminChallengeCache new a cache, the challenges must be stored somewhere, so memory is used if the cache type is empty
+func NewAdminChallengeCache(cacheType *database.CacheType) AdminChallengeCache {
+	jsonEncoding := encoding.JSONEncoding{}
+	cachePrefix := ""
+
+	cType := strings.ToLower(cacheType.CType)
+	if cType == "redis" {
+		c := cache.NewRedisCache(cacheType.Rdb, cachePrefix, jsonEncoding, func() interface{} {
+			return &AdminChallenge{}
+		})
+		return &adminChallengeCache{cache: c, attempts: newAttemptCounter(cacheType)}
+	}
+
+	c := cache.NewMemoryCache(cachePrefix, jsonEncoding, func() interface{} {
+		return &AdminChallenge{}
+	})
+	return &adminChallengeCache{cache: c, attempts: newAttemptCounter(cacheType)}
+}
+
+// GetAdminChallengeCacheKey cache key
+func (c *adminChallengeCache) GetAdminChallengeCacheKey(token string) string {
+	return adminChallengeCachePrefixKey + token
+}
+
+// GetAdminChallengeAttemptsCacheKey cache key
+func (c *adminChallengeCache) GetAdminChallengeAttemptsCacheKey(token string) string {
+	return adminChallengeAttemptsCachePrefixKey + token
+}
+
+// Set write to cache
+func (c *adminChallengeCache) Set(ctx context.Context, token string, data *AdminChallenge, duration time.Duration) error {
+	if data == nil || token == "" {
+		return nil
+	}
+	cacheKey := c.GetAdminChallengeCacheKey(token)
+	return c.cache.Set(ctx, cacheKey, data, duration)
+}
+
+// Get cache value
+func (c *adminChallengeCache) Get(ctx context.Context, token string) (*AdminChallenge, error) {
+	var data *AdminChallenge
+	cacheKey := c.GetAdminChallengeCacheKey(token)
+	err := c.cache.Get(ctx, cacheKey, &data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Del delete cache, the attempts of the challenge as well
+func (c *adminChallengeCache) Del(ctx context.Context, token string) error {
+	cacheKey := c.GetAdminChallengeCacheKey(token)
+	err := c.cache.Del(ctx, cacheKey)
+	if err != nil {
+		return err
+	}
+	return c.attempts.Del(ctx, c.GetAdminChallengeAttemptsCacheKey(token))
+}
+
+// IncrAttempts count an attempt to verify the challenge and return the attempts so far,
+// the count expires after duration, which should be the remaining time of the challenge
+func (c *adminChallengeCache) IncrAttempts(ctx context.Context, token string, duration time.Duration) (int, error) {
+	return c.attempts.Incr(ctx, c.GetAdminChallengeAttemptsCacheKey(token), duration)
+}
@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-dev-frame/sponge/pkg/gotest"
+
+	"lol/internal/database"
+)
+
+func newAdminChallengeCache() *gotest.Cache {
+	record1 := &AdminChallenge{UserID: 1}
+	testData := map[string]interface{}{
+		"token1": record1,
+	}
+
+	c := gotest.NewCache(testData)
+	c.ICache = NewAdminChallengeCache(&database.CacheType{
+		CType: "redis",
+		Rdb:   c.RedisClient,
+	})
+	return c
+}
+
+func Test_adminChallengeCache_Get(t *testing.T) {
+	c := newAdminChallengeCache()
+	defer c.Close()
+
+	record := c.TestDataSlice[0].(*AdminChallenge)
+	err := c.ICache.(AdminChallengeCache).Set(c.Ctx, "token1", record, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.ICache.(AdminChallengeCache).Get(c.Ctx, "token1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, record, got)
+
+	err = c.ICache.(AdminChallengeCache).Del(c.Ctx, "token1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.ICache.(AdminChallengeCache).Get(c.Ctx, "token1")
+	assert.Error(t, err)
+}
+
+func TestNewAdminChallengeCache(t *testing.T) {
+	c := NewAdminChallengeCache(&database.CacheType{
+		CType: "",
+	})
+	assert.NotNil(t, c)
+	c = NewAdminChallengeCache(&database.CacheType{
+		CType: "redis",
+	})
+	assert.NotNil(t, c)
+}
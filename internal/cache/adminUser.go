@@ -0,0 +1,150 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-dev-frame/sponge/pkg/cache"
+	"github.com/go-dev-frame/sponge/pkg/encoding"
+
+	"lol/internal/database"
+	"lol/internal/model"
+)
+
+const (
+	// AdminUserExpireTime expire time
+	AdminUserExpireTime = 5 * time.Minute
+)
+
+var _ AdminUserCache = (*adminUserCache)(nil)
+
+// AdminUserCache cache interface
+type AdminUserCache interface {
+	Set(ctx context.Context, id uint64, data *model.AdminUser, duration time.Duration) error
+	Get(ctx context.Context, id uint64) (*model.AdminUser, error)
+	MultiGet(ctx context.Context, ids []uint64) (map[uint64]*model.AdminUser, error)
+	MultiSet(ctx context.Context, data []*model.AdminUser, duration time.Duration) error
+	Del(ctx context.Context, id uint64) error
+	SetPlaceholder(ctx context.Context, id uint64) error
+	IsPlaceholderErr(err error) bool
+}
+
+// adminUserCache define a cache struct
+type adminUserCache struct {
+	cache cache.Cache
+}
+
+// NewAdminUserCache new a cache
+func NewAdminUserCache(cacheType *database.CacheType) AdminUserCache {
+	jsonEncoding := encoding.JSONEncoding{}
+	cachePrefix := ""
+
+	cType := strings.ToLower(cacheType.CType)
+	switch cType {
+	case "redis":
+		c := cache.NewRedisCache(cacheType.Rdb, cachePrefix, jsonEncoding, func() interface{} {
+			return &model.AdminUser{}
+		})
+		return &adminUserCache{cache: c}
+	case "memory":
+		c := cache.NewMemoryCache(cachePrefix, jsonEncoding, func() interface{} {
+			return &model.AdminUser{}
+		})
+		return &adminUserCache{cache: c}
+	}
+
+	return nil // no cache
+}
+
+// GetAdminUserCacheKey cache key
+func (c *adminUserCache) GetAdminUserCacheKey(id uint64) string {
+	return AdminUserKey(id)
+}
+
+// Set write to cache
+func (c *adminUserCache) Set(ctx context.Context, id uint64, data *model.AdminUser, duration time.Duration) error {
+	if data == nil || id == 0 {
+		return nil
+	}
+	cacheKey := c.GetAdminUserCacheKey(id)
+	err := c.cache.Set(ctx, cacheKey, data, duration)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Get cache value
+func (c *adminUserCache) Get(ctx context.Context, id uint64) (*model.AdminUser, error) {
+	var data *model.AdminUser
+	cacheKey := c.GetAdminUserCacheKey(id)
+	err := c.cache.Get(ctx, cacheKey, &data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// MultiSet multiple set cache
+func (c *adminUserCache) MultiSet(ctx context.Context, data []*model.AdminUser, duration time.Duration) error {
+	valMap := make(map[string]interface{})
+	for _, v := range data {
+		cacheKey := c.GetAdminUserCacheKey(v.ID)
+		valMap[cacheKey] = v
+	}
+
+	err := c.cache.MultiSet(ctx, valMap, duration)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// MultiGet multiple get cache, return key in map is id value
+func (c *adminUserCache) MultiGet(ctx context.Context, ids []uint64) (map[uint64]*model.AdminUser, error) {
+	var keys []string
+	for _, v := range ids {
+		cacheKey := c.GetAdminUserCacheKey(v)
+		keys = append(keys, cacheKey)
+	}
+
+	itemMap := make(map[string]*model.AdminUser)
+	err := c.cache.MultiGet(ctx, keys, itemMap)
+	if err != nil {
+		return nil, err
+	}
+
+	retMap := make(map[uint64]*model.AdminUser)
+	for _, id := range ids {
+		val, ok := itemMap[c.GetAdminUserCacheKey(id)]
+		if ok {
+			retMap[id] = val
+		}
+	}
+
+	return retMap, nil
+}
+
+// Del delete cache
+func (c *adminUserCache) Del(ctx context.Context, id uint64) error {
+	cacheKey := c.GetAdminUserCacheKey(id)
+	err := c.cache.Del(ctx, cacheKey)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// SetPlaceholder set placeholder value to cache
+func (c *adminUserCache) SetPlaceholder(ctx context.Context, id uint64) error {
+	cacheKey := c.GetAdminUserCacheKey(id)
+	return c.cache.SetCacheWithNotFound(ctx, cacheKey)
+}
+
+// IsPlaceholderErr check if cache is placeholder error
+func (c *adminUserCache) IsPlaceholderErr(err error) bool {
+	return errors.Is(err, cache.ErrPlaceholder)
+}
@@ -0,0 +1,147 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-dev-frame/sponge/pkg/gotest"
+	"github.com/go-dev-frame/sponge/pkg/utils"
+
+	"lol/internal/database"
+	"lol/internal/model"
+)
+
+func newAdminUserCache() *gotest.Cache {
+	record1 := &model.AdminUser{}
+	record1.ID = 1
+	record2 := &model.AdminUser{}
+	record2.ID = 2
+	testData := map[string]interface{}{
+		utils.Uint64ToStr(record1.ID): record1,
+		utils.Uint64ToStr(record2.ID): record2,
+	}
+
+	c := gotest.NewCache(testData)
+	c.ICache = NewAdminUserCache(&database.CacheType{
+		CType: "redis",
+		Rdb:   c.RedisClient,
+	})
+	return c
+}
+
+func Test_adminUserCache_Set(t *testing.T) {
+	c := newAdminUserCache()
+	defer c.Close()
+
+	record := c.TestDataSlice[0].(*model.AdminUser)
+	err := c.ICache.(AdminUserCache).Set(c.Ctx, record.ID, record, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// nil data
+	err = c.ICache.(AdminUserCache).Set(c.Ctx, 0, nil, time.Hour)
+	assert.NoError(t, err)
+}
+
+func Test_adminUserCache_Get(t *testing.T) {
+	c := newAdminUserCache()
+	defer c.Close()
+
+	record := c.TestDataSlice[0].(*model.AdminUser)
+	err := c.ICache.(AdminUserCache).Set(c.Ctx, record.ID, record, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.ICache.(AdminUserCache).Get(c.Ctx, record.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, record, got)
+
+	// zero key error
+	_, err = c.ICache.(AdminUserCache).Get(c.Ctx, 0)
+	assert.Error(t, err)
+}
+
+func Test_adminUserCache_MultiGet(t *testing.T) {
+	c := newAdminUserCache()
+	defer c.Close()
+
+	var testData []*model.AdminUser
+	for _, data := range c.TestDataSlice {
+		testData = append(testData, data.(*model.AdminUser))
+	}
+
+	err := c.ICache.(AdminUserCache).MultiSet(c.Ctx, testData, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.ICache.(AdminUserCache).MultiGet(c.Ctx, c.GetIDs())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := c.GetTestData()
+	for k, v := range expected {
+		assert.Equal(t, got[utils.StrToUint64(k)], v.(*model.AdminUser))
+	}
+}
+
+func Test_adminUserCache_MultiSet(t *testing.T) {
+	c := newAdminUserCache()
+	defer c.Close()
+
+	var testData []*model.AdminUser
+	for _, data := range c.TestDataSlice {
+		testData = append(testData, data.(*model.AdminUser))
+	}
+
+	err := c.ICache.(AdminUserCache).MultiSet(c.Ctx, testData, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_adminUserCache_Del(t *testing.T) {
+	c := newAdminUserCache()
+	defer c.Close()
+
+	record := c.TestDataSlice[0].(*model.AdminUser)
+	err := c.ICache.(AdminUserCache).Del(c.Ctx, record.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_adminUserCache_SetCacheWithNotFound(t *testing.T) {
+	c := newAdminUserCache()
+	defer c.Close()
+
+	record := c.TestDataSlice[0].(*model.AdminUser)
+	err := c.ICache.(AdminUserCache).SetPlaceholder(c.Ctx, record.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := c.ICache.(AdminUserCache).IsPlaceholderErr(err)
+	t.Log(b)
+}
+
+func TestNewAdminUserCache(t *testing.T) {
+	c := NewAdminUserCache(&database.CacheType{
+		CType: "",
+	})
+	assert.Nil(t, c)
+	c = NewAdminUserCache(&database.CacheType{
+		CType: "memory",
+	})
+	assert.NotNil(t, c)
+	c = NewAdminUserCache(&database.CacheType{
+		CType: "redis",
+	})
+	assert.NotNil(t, c)
+}
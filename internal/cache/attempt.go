@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/go-dev-frame/sponge/pkg/cache"
+	"github.com/go-dev-frame/sponge/pkg/encoding"
+
+	"lol/internal/database"
+)
+
+// Attempt 失败尝试记录，用于防止暴力破解
+type Attempt struct {
+	Failures    int       `json:"failures"`    // 失败次数
+	Lockouts    int       `json:"lockouts"`    // 锁定次数
+	LockedUntil time.Time `json:"lockedUntil"` // 锁定截止时间
+}
+
+var _ AttemptCache = (*attemptCache)(nil)
+
+// AttemptCache cache interface
+type AttemptCache interface {
+	Set(ctx context.Context, key string, data *Attempt, duration time.Duration) error
+	Get(ctx context.Context, key string) (*Attempt, error)
+	Del(ctx context.Context, key string) error
+}
+
+// attemptCache define a cache struct
+type attemptCache struct {
+	cache cache.Cache
+}
+
+// NewAttemptCache new a cache, the attempts must be stored somewhere, so memory is used if the cache type is empty
+func NewAttemptCache(cacheType *database.CacheType) AttemptCache {
+	jsonEncoding := encoding.JSONEncoding{}
+	cachePrefix := ""
+
+	cType := strings.ToLower(cacheType.CType)
+	if cType == "redis" {
+		return &attemptCache{
+			cache: cache.NewRedisCache(cacheType.Rdb, cachePrefix, jsonEncoding, func() interface{} {
+				return &Attempt{}
+			}),
+		}
+	}
+
+	return &attemptCache{
+		cache: cache.NewMemoryCache(cachePrefix, jsonEncoding, func() interface{} {
+			return &Attempt{}
+		}),
+	}
+}
+
+// GetAttemptCacheKey cache key, the key contains the kind of the counter, e.g. borrowerMobile:13800000000
+func (c *attemptCache) GetAttemptCacheKey(key string) string {
+	return attemptCachePrefixKey + key
+}
+
+// Set write to cache
+func (c *attemptCache) Set(ctx context.Context, key string, data *Attempt, duration time.Duration) error {
+	if data == nil || key == "" {
+		return nil
+	}
+	cacheKey := c.GetAttemptCacheKey(key)
+	return c.cache.Set(ctx, cacheKey, data, duration)
+}
+
+// Get cache value
+func (c *attemptCache) Get(ctx context.Context, key string) (*Attempt, error) {
+	var data *Attempt
+	cacheKey := c.GetAttemptCacheKey(key)
+	err := c.cache.Get(ctx, cacheKey, &data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Del delete cache
+func (c *attemptCache) Del(ctx context.Context, key string) error {
+	cacheKey := c.GetAttemptCacheKey(key)
+	return c.cache.Del(ctx, cacheKey)
+}
@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-dev-frame/sponge/pkg/gotest"
+
+	"lol/internal/database"
+)
+
+func newAttemptCache() *gotest.Cache {
+	record1 := &Attempt{Failures: 5, Lockouts: 1, LockedUntil: time.Now().Add(time.Minute)}
+	testData := map[string]interface{}{
+		"borrowerMobile:13800000000": record1,
+	}
+
+	c := gotest.NewCache(testData)
+	c.ICache = NewAttemptCache(&database.CacheType{
+		CType: "redis",
+		Rdb:   c.RedisClient,
+	})
+	return c
+}
+
+func Test_attemptCache_Set(t *testing.T) {
+	c := newAttemptCache()
+	defer c.Close()
+
+	record := c.TestDataSlice[0].(*Attempt)
+	err := c.ICache.(AttemptCache).Set(c.Ctx, "borrowerMobile:13800000000", record, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// nil data
+	err = c.ICache.(AttemptCache).Set(c.Ctx, "", nil, time.Minute)
+	assert.NoError(t, err)
+}
+
+func Test_attemptCache_Get(t *testing.T) {
+	c := newAttemptCache()
+	defer c.Close()
+
+	record := c.TestDataSlice[0].(*Attempt)
+	err := c.ICache.(AttemptCache).Set(c.Ctx, "borrowerMobile:13800000000", record, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.ICache.(AttemptCache).Get(c.Ctx, "borrowerMobile:13800000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, record.Failures, got.Failures)
+	assert.Equal(t, record.Lockouts, got.Lockouts)
+
+	// not found error
+	_, err = c.ICache.(AttemptCache).Get(c.Ctx, "borrowerMobile:13900000000")
+	assert.Error(t, err)
+}
+
+func Test_attemptCache_Del(t *testing.T) {
+	c := newAttemptCache()
+	defer c.Close()
+
+	err := c.ICache.(AttemptCache).Del(c.Ctx, "borrowerMobile:13800000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewAttemptCache(t *testing.T) {
+	c := NewAttemptCache(&database.CacheType{
+		CType: "",
+	})
+	assert.NotNil(t, c)
+	c = NewAttemptCache(&database.CacheType{
+		CType: "redis",
+	})
+	assert.NotNil(t, c)
+}
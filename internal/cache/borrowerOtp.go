@@ -0,0 +1,152 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/go-dev-frame/sponge/pkg/cache"
+	"github.com/go-dev-frame/sponge/pkg/encoding"
+
+	"lol/internal/database"
+)
+
+const (
+	// BorrowerOtpCountExpireTime expire time of the daily send count
+	BorrowerOtpCountExpireTime = 24 * time.Hour
+)
+
+// BorrowerOtp 借款人登录验证码，验证次数单独计数，见 IncrAttempts
+type BorrowerOtp struct {
+	Code   string    `json:"code"`   // 验证码
+	SentAt time.Time `json:"sentAt"` // 发送时间
+}
+
+// BorrowerOtpCount 借款人当天发送验证码的次数
+type BorrowerOtpCount struct {
+	Date  string `json:"date"`  // 日期
+	Count int    `json:"count"` // 次数
+}
+
+var _ BorrowerOtpCache = (*borrowerOtpCache)(nil)
+
+// BorrowerOtpCache cache interface
+type BorrowerOtpCache interface {
+	Set(ctx context.Context, mobile string, data *BorrowerOtp, duration time.Duration) error
+	Get(ctx context.Context, mobile string) (*BorrowerOtp, error)
+	Del(ctx context.Context, mobile string) error
+	IncrAttempts(ctx context.Context, mobile string, duration time.Duration) (int, error)
+	SetCount(ctx context.Context, mobile string, data *BorrowerOtpCount, duration time.Duration) error
+	GetCount(ctx context.Context, mobile string) (*BorrowerOtpCount, error)
+}
+
+// borrowerOtpCache define a cache struct
+type borrowerOtpCache struct {
+	cache      cache.Cache
+	countCache cache.Cache
+	attempts   attemptCounter
+}
+
+// NewBorrowerOtpCache new a cache, the codes must be stored somewhere, so memory is used if the cache type is empty
+func NewBorrowerOtpCache(cacheType *database.CacheType) BorrowerOtpCache {
+	jsonEncoding := encoding.JSONEncoding{}
+	cachePrefix := ""
+
+	cType := strings.ToLower(cacheType.CType)
+	if cType == "redis" {
+		return &borrowerOtpCache{
+			cache: cache.NewRedisCache(cacheType.Rdb, cachePrefix, jsonEncoding, func() interface{} {
+				return &BorrowerOtp{}
+			}),
+			countCache: cache.NewRedisCache(cacheType.Rdb, cachePrefix, jsonEncoding, func() interface{} {
+				return &BorrowerOtpCount{}
+			}),
+			attempts: newAttemptCounter(cacheType),
+		}
+	}
+
+	return &borrowerOtpCache{
+		cache: cache.NewMemoryCache(cachePrefix, jsonEncoding, func() interface{} {
+			return &BorrowerOtp{}
+		}),
+		countCache: cache.NewMemoryCache(cachePrefix, jsonEncoding, func() interface{} {
+			return &BorrowerOtpCount{}
+		}),
+		attempts: newAttemptCounter(cacheType),
+	}
+}
+
+// GetBorrowerOtpCacheKey cache key
+func (c *borrowerOtpCache) GetBorrowerOtpCacheKey(mobile string) string {
+	return borrowerOtpCachePrefixKey + mobile
+}
+
+// GetBorrowerOtpAttemptsCacheKey cache key
+func (c *borrowerOtpCache) GetBorrowerOtpAttemptsCacheKey(mobile string) string {
+	return borrowerOtpAttemptsCachePrefixKey + mobile
+}
+
+// GetBorrowerOtpCountCacheKey cache key
+func (c *borrowerOtpCache) GetBorrowerOtpCountCacheKey(mobile string) string {
+	return borrowerOtpCountCachePrefixKey + mobile
+}
+
+// Set write a new code to cache, the attempts of the previous code are cleared
+func (c *borrowerOtpCache) Set(ctx context.Context, mobile string, data *BorrowerOtp, duration time.Duration) error {
+	if data == nil || mobile == "" {
+		return nil
+	}
+	err := c.attempts.Del(ctx, c.GetBorrowerOtpAttemptsCacheKey(mobile))
+	if err != nil {
+		return err
+	}
+	cacheKey := c.GetBorrowerOtpCacheKey(mobile)
+	return c.cache.Set(ctx, cacheKey, data, duration)
+}
+
+// Get cache value
+func (c *borrowerOtpCache) Get(ctx context.Context, mobile string) (*BorrowerOtp, error) {
+	var data *BorrowerOtp
+	cacheKey := c.GetBorrowerOtpCacheKey(mobile)
+	err := c.cache.Get(ctx, cacheKey, &data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Del delete cache, the attempts of the code as well
+func (c *borrowerOtpCache) Del(ctx context.Context, mobile string) error {
+	cacheKey := c.GetBorrowerOtpCacheKey(mobile)
+	err := c.cache.Del(ctx, cacheKey)
+	if err != nil {
+		return err
+	}
+	return c.attempts.Del(ctx, c.GetBorrowerOtpAttemptsCacheKey(mobile))
+}
+
+// IncrAttempts count an attempt to verify the code of the mobile and return the attempts so far,
+// the count expires after duration, which should be the remaining time of the code
+func (c *borrowerOtpCache) IncrAttempts(ctx context.Context, mobile string, duration time.Duration) (int, error) {
+	return c.attempts.Incr(ctx, c.GetBorrowerOtpAttemptsCacheKey(mobile), duration)
+}
+
+// SetCount write the daily send count to cache
+func (c *borrowerOtpCache) SetCount(ctx context.Context, mobile string, data *BorrowerOtpCount, duration time.Duration) error {
+	if data == nil || mobile == "" {
+		return nil
+	}
+	cacheKey := c.GetBorrowerOtpCountCacheKey(mobile)
+	return c.countCache.Set(ctx, cacheKey, data, duration)
+}
+
+// GetCount get the daily send count from cache
+func (c *borrowerOtpCache) GetCount(ctx context.Context, mobile string) (*BorrowerOtpCount, error) {
+	var data *BorrowerOtpCount
+	cacheKey := c.GetBorrowerOtpCountCacheKey(mobile)
+	err := c.countCache.Get(ctx, cacheKey, &data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
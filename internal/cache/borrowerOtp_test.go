@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-dev-frame/sponge/pkg/gotest"
+
+	"lol/internal/database"
+)
+
+func newBorrowerOtpCache() *gotest.Cache {
+	record1 := &BorrowerOtp{Code: "123456", SentAt: time.Now()}
+	testData := map[string]interface{}{
+		"13800000000": record1,
+	}
+
+	c := gotest.NewCache(testData)
+	c.ICache = NewBorrowerOtpCache(&database.CacheType{
+		CType: "redis",
+		Rdb:   c.RedisClient,
+	})
+	return c
+}
+
+func Test_borrowerOtpCache_Set(t *testing.T) {
+	c := newBorrowerOtpCache()
+	defer c.Close()
+
+	record := c.TestDataSlice[0].(*BorrowerOtp)
+	err := c.ICache.(BorrowerOtpCache).Set(c.Ctx, "13800000000", record, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// nil data
+	err = c.ICache.(BorrowerOtpCache).Set(c.Ctx, "", nil, time.Minute)
+	assert.NoError(t, err)
+}
+
+func Test_borrowerOtpCache_Get(t *testing.T) {
+	c := newBorrowerOtpCache()
+	defer c.Close()
+
+	record := c.TestDataSlice[0].(*BorrowerOtp)
+	err := c.ICache.(BorrowerOtpCache).Set(c.Ctx, "13800000000", record, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.ICache.(BorrowerOtpCache).Get(c.Ctx, "13800000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, record.Code, got.Code)
+
+	// not found error
+	_, err = c.ICache.(BorrowerOtpCache).Get(c.Ctx, "13900000000")
+	assert.Error(t, err)
+}
+
+func Test_borrowerOtpCache_Del(t *testing.T) {
+	c := newBorrowerOtpCache()
+	defer c.Close()
+
+	err := c.ICache.(BorrowerOtpCache).Del(c.Ctx, "13800000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_borrowerOtpCache_IncrAttempts(t *testing.T) {
+	c := newBorrowerOtpCache()
+	defer c.Close()
+
+	for i := 1; i <= 3; i++ {
+		attempts, err := c.ICache.(BorrowerOtpCache).IncrAttempts(c.Ctx, "13800000000", time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, i, attempts)
+	}
+
+	// a new code clears the attempts
+	record := c.TestDataSlice[0].(*BorrowerOtp)
+	err := c.ICache.(BorrowerOtpCache).Set(c.Ctx, "13800000000", record, time.Minute)
+	assert.NoError(t, err)
+	attempts, err := c.ICache.(BorrowerOtpCache).IncrAttempts(c.Ctx, "13800000000", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, attempts)
+}
+
+func Test_borrowerOtpCache_Count(t *testing.T) {
+	c := newBorrowerOtpCache()
+	defer c.Close()
+
+	record := &BorrowerOtpCount{Date: time.Now().Format(time.DateOnly), Count: 1}
+	err := c.ICache.(BorrowerOtpCache).SetCount(c.Ctx, "13800000000", record, BorrowerOtpCountExpireTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.ICache.(BorrowerOtpCache).GetCount(c.Ctx, "13800000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, record, got)
+}
+
+func TestNewBorrowerOtpCache(t *testing.T) {
+	c := NewBorrowerOtpCache(&database.CacheType{
+		CType: "",
+	})
+	assert.NotNil(t, c)
+	c = NewBorrowerOtpCache(&database.CacheType{
+		CType: "redis",
+	})
+	assert.NotNil(t, c)
+}
@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/go-dev-frame/sponge/pkg/cache"
+	"github.com/go-dev-frame/sponge/pkg/encoding"
+
+	"lol/internal/database"
+)
+
+const (
+	// CaptchaExpireTime expire time of the captcha
+	CaptchaExpireTime = 5 * time.Minute
+)
+
+// Captcha 图形验证码的答案
+type Captcha struct {
+	Answer string `json:"answer"`
+}
+
+var _ CaptchaCache = (*captchaCache)(nil)
+
+// CaptchaCache cache interface
+type CaptchaCache interface {
+	Set(ctx context.Context, id string, data *Captcha, duration time.Duration) error
+	Get(ctx context.Context, id string) (*Captcha, error)
+	Del(ctx context.Context, id string) error
+}
+
+// captchaCache define a cache struct
+type captchaCache struct {
+	cache cache.Cache
+}
+
+// NewCaptchaCache new a cache, the answers must be stored somewhere, so memory is used if the cache type is empty
+func NewCaptchaCache(cacheType *database.CacheType) CaptchaCache {
+	jsonEncoding := encoding.JSONEncoding{}
+	cachePrefix := ""
+
+	cType := strings.ToLower(cacheType.CType)
+	if cType == "redis" {
+		return &captchaCache{
+			cache: cache.NewRedisCache(cacheType.Rdb, cachePrefix, jsonEncoding, func() interface{} {
+				return &Captcha{}
+			}),
+		}
+	}
+
+	return &captchaCache{
+		cache: cache.NewMemoryCache(cachePrefix, jsonEncoding, func() interface{} {
+			return &Captcha{}
+		}),
+	}
+}
+
+// GetCaptchaCacheKey cache key
+func (c *captchaCache) GetCaptchaCacheKey(id string) string {
+	return captchaCachePrefixKey + id
+}
+
+// Set write to cache
+func (c *captchaCache) Set(ctx context.Context, id string, data *Captcha, duration time.Duration) error {
+	if data == nil || id == "" {
+		return nil
+	}
+	cacheKey := c.GetCaptchaCacheKey(id)
+	return c.cache.Set(ctx, cacheKey, data, duration)
+}
+
+// Get cache value
+func (c *captchaCache) Get(ctx context.Context, id string) (*Captcha, error) {
+	var data *Captcha
+	cacheKey := c.GetCaptchaCacheKey(id)
+	err := c.cache.Get(ctx, cacheKey, &data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Del delete cache
+func (c *captchaCache) Del(ctx context.Context, id string) error {
+	cacheKey := c.GetCaptchaCacheKey(id)
+	return c.cache.Del(ctx, cacheKey)
+}
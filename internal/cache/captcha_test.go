@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-dev-frame/sponge/pkg/gotest"
+
+	"lol/internal/database"
+)
+
+func newCaptchaCache() *gotest.Cache {
+	record1 := &Captcha{Answer: "1234"}
+	testData := map[string]interface{}{
+		"0123456789abcdef": record1,
+	}
+
+	c := gotest.NewCache(testData)
+	c.ICache = NewCaptchaCache(&database.CacheType{
+		CType: "redis",
+		Rdb:   c.RedisClient,
+	})
+	return c
+}
+
+func Test_captchaCache_Set(t *testing.T) {
+	c := newCaptchaCache()
+	defer c.Close()
+
+	record := c.TestDataSlice[0].(*Captcha)
+	err := c.ICache.(CaptchaCache).Set(c.Ctx, "0123456789abcdef", record, CaptchaExpireTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// nil data
+	err = c.ICache.(CaptchaCache).Set(c.Ctx, "", nil, CaptchaExpireTime)
+	assert.NoError(t, err)
+}
+
+func Test_captchaCache_Get(t *testing.T) {
+	c := newCaptchaCache()
+	defer c.Close()
+
+	record := c.TestDataSlice[0].(*Captcha)
+	err := c.ICache.(CaptchaCache).Set(c.Ctx, "0123456789abcdef", record, CaptchaExpireTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.ICache.(CaptchaCache).Get(c.Ctx, "0123456789abcdef")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, record, got)
+
+	// not found error
+	_, err = c.ICache.(CaptchaCache).Get(c.Ctx, "fedcba9876543210")
+	assert.Error(t, err)
+}
+
+func Test_captchaCache_Del(t *testing.T) {
+	c := newCaptchaCache()
+	defer c.Close()
+
+	err := c.ICache.(CaptchaCache).Del(c.Ctx, "0123456789abcdef")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewCaptchaCache(t *testing.T) {
+	c := NewCaptchaCache(&database.CacheType{
+		CType: "",
+	})
+	assert.NotNil(t, c)
+	c = NewCaptchaCache(&database.CacheType{
+		CType: "redis",
+	})
+	assert.NotNil(t, c)
+}
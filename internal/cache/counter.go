@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	"lol/internal/database"
+)
+
+// attemptCounter the verification attempts of the codes, an attempt is counted with an atomic increment
+// instead of writing the count back, so the concurrent attempts of a code on different replicas are all counted
+type attemptCounter interface {
+	Incr(ctx context.Context, key string, duration time.Duration) (int, error)
+	Del(ctx context.Context, key string) error
+}
+
+// newAttemptCounter a redis counter, or the counter in memory if the cache type is not redis
+func newAttemptCounter(cacheType *database.CacheType) attemptCounter {
+	if strings.ToLower(cacheType.CType) == "redis" {
+		return &redisAttemptCounter{rdb: cacheType.Rdb}
+	}
+	return memoryAttemptCounters
+}
+
+// redisAttemptCounter the counts are redis integers
+type redisAttemptCounter struct {
+	rdb *goredis.Client
+}
+
+// Incr add an attempt and return the count, the count expires with the code
+func (i *redisAttemptCounter) Incr(ctx context.Context, key string, duration time.Duration) (int, error) {
+	pipe := i.rdb.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, duration)
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return int(incr.Val()), nil
+}
+
+// Del delete the count
+func (i *redisAttemptCounter) Del(ctx context.Context, key string) error {
+	return i.rdb.Del(ctx, key).Err()
+}
+
+// memoryAttemptCounters the counts of the memory cache, shared by the caches of the process
+var memoryAttemptCounters = &memoryAttemptCounter{counts: map[string]*memoryAttemptCount{}}
+
+type memoryAttemptCount struct {
+	count    int
+	expireAt time.Time
+}
+
+// memoryAttemptCounter the counts are in memory, the expired counts are removed when an attempt is added
+type memoryAttemptCounter struct {
+	mu     sync.Mutex
+	counts map[string]*memoryAttemptCount
+}
+
+// Incr add an attempt and return the count
+func (i *memoryAttemptCounter) Incr(ctx context.Context, key string, duration time.Duration) (int, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	now := time.Now()
+	for k, v := range i.counts {
+		if !now.Before(v.expireAt) {
+			delete(i.counts, k)
+		}
+	}
+	v, ok := i.counts[key]
+	if !ok {
+		v = &memoryAttemptCount{}
+		i.counts[key] = v
+	}
+	v.count++
+	v.expireAt = now.Add(duration)
+	return v.count, nil
+}
+
+// Del delete the count
+func (i *memoryAttemptCounter) Del(ctx context.Context, key string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	delete(i.counts, key)
+	return nil
+}
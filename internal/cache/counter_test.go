@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"lol/internal/database"
+)
+
+func Test_memoryAttemptCounter(t *testing.T) {
+	c := newAttemptCounter(&database.CacheType{CType: ""})
+	ctx := context.Background()
+
+	// concurrent attempts are all counted
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = c.Incr(ctx, "counter:1", time.Minute)
+		}()
+	}
+	wg.Wait()
+	n, err := c.Incr(ctx, "counter:1", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 11, n)
+
+	err = c.Del(ctx, "counter:1")
+	assert.NoError(t, err)
+	n, _ = c.Incr(ctx, "counter:1", time.Minute)
+	assert.Equal(t, 1, n)
+
+	// expired counts start again
+	_, _ = c.Incr(ctx, "counter:2", -time.Second)
+	n, _ = c.Incr(ctx, "counter:2", time.Minute)
+	assert.Equal(t, 1, n)
+}
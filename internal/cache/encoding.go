@@ -0,0 +1,34 @@
+package cache
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"lol/internal/fieldcrypt"
+)
+
+// encryptedJSONEncoding the json encoding of the cached models with personal information, the fields encrypted
+// in the database are encrypted in the cache too and decrypted after reading, so redis never holds them in plaintext
+type encryptedJSONEncoding struct{}
+
+// Marshal encrypt the fields of a copy of v, v itself is not modified
+func (encryptedJSONEncoding) Marshal(v interface{}) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() && rv.Elem().Kind() == reflect.Struct {
+		cp := reflect.New(rv.Elem().Type())
+		cp.Elem().Set(rv.Elem())
+		if err := fieldcrypt.EncryptFields(cp.Interface()); err != nil {
+			return nil, err
+		}
+		v = cp.Interface()
+	}
+	return json.Marshal(v)
+}
+
+// Unmarshal decrypt the fields after reading
+func (encryptedJSONEncoding) Unmarshal(data []byte, v interface{}) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+	return fieldcrypt.DecryptFields(v)
+}
@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"lol/internal/config"
+	"lol/internal/fieldcrypt"
+	"lol/internal/model"
+)
+
+func TestEncryptedJSONEncoding(t *testing.T) {
+	err := fieldcrypt.Init(config.FieldCrypt{
+		Version:  1,
+		Keys:     []config.FieldCryptKey{{Version: 1, Key: base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))}},
+		IndexKey: base64.StdEncoding.EncodeToString([]byte("index-key-index-key-index-key-32")),
+	})
+	assert.NoError(t, err)
+	defer func() { _ = fieldcrypt.Init(config.FieldCrypt{}) }()
+
+	e := encryptedJSONEncoding{}
+	loan := &model.Loan{ID: 1, Name: "张三", UserID: "110101199003071234", Mobile: "13800000000", CarPlate: "粤A12345"}
+	data, err := e.Marshal(loan)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "张三")
+	assert.NotContains(t, string(data), "110101199003071234")
+	assert.NotContains(t, string(data), "13800000000")
+	assert.Equal(t, "张三", loan.Name) // not modified
+
+	var got *model.Loan
+	assert.NoError(t, e.Unmarshal(data, &got))
+	assert.Equal(t, loan.Name, got.Name)
+	assert.Equal(t, loan.UserID, got.UserID)
+	assert.Equal(t, loan.Mobile, got.Mobile)
+	assert.Equal(t, loan.CarPlate, got.CarPlate)
+
+	// values without personal information
+	data, err = e.Marshal(&model.LoanPaymentSummary{LoanID: 1, PaidCount: 2})
+	assert.NoError(t, err)
+	summary := &model.LoanPaymentSummary{}
+	assert.NoError(t, e.Unmarshal(data, summary))
+	assert.Equal(t, &model.LoanPaymentSummary{LoanID: 1, PaidCount: 2}, summary)
+}
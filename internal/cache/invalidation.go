@@ -0,0 +1,143 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-dev-frame/sponge/pkg/cache"
+	"github.com/go-dev-frame/sponge/pkg/encoding"
+	"github.com/go-dev-frame/sponge/pkg/goredis"
+	"github.com/go-dev-frame/sponge/pkg/logger"
+
+	"lol/internal/database"
+)
+
+// InvalidationChannel the redis channel the invalidated keys are broadcast on
+const InvalidationChannel = "cache:invalidation"
+
+// invalidator the invalidator of the process, nil if the cache is not used
+var invalidator *Invalidator
+
+// Event a change of the data, the cache entries of its keys are deleted
+type Event interface {
+	Keys() []string
+}
+
+// PaymentChanged the payments are created, updated or deleted, or their status changed, so the cached
+// payments, the cached loans and their payment summaries are out of date
+type PaymentChanged struct {
+	PaymentIDs []uint64
+	LoanIDs    []uint64
+}
+
+// Keys the cache keys of the payments and the loans
+func (e PaymentChanged) Keys() []string {
+	keys := make([]string, 0, len(e.PaymentIDs)+2*len(e.LoanIDs))
+	for _, id := range e.PaymentIDs {
+		keys = append(keys, PaymentHistoryKey(id))
+	}
+	for _, id := range e.LoanIDs {
+		keys = append(keys, LoanKey(id), LoanSummaryKey(id))
+	}
+	return keys
+}
+
+// invalidation the message broadcast to the other replicas
+type invalidation struct {
+	Origin string   `json:"origin"` // the process publishing the message, which has deleted the keys already
+	Keys   []string `json:"keys"`
+}
+
+// Invalidator delete the cache entries of the events, and broadcast the keys by redis to the other replicas,
+// so their memory caches are evicted too
+type Invalidator struct {
+	cache  cache.Cache
+	rdb    *goredis.Client // if nil, the keys are not broadcast
+	origin string
+}
+
+// InitInvalidator initial the invalidator of the cache type, the keys are broadcast if rdb is not nil
+func InitInvalidator(cacheType *database.CacheType, rdb *goredis.Client) {
+	jsonEncoding := encoding.JSONEncoding{}
+	cachePrefix := ""
+	hostname, _ := os.Hostname()
+	i := &Invalidator{rdb: rdb, origin: fmt.Sprintf("%s-%d", hostname, os.Getpid())}
+
+	switch strings.ToLower(cacheType.CType) {
+	case "redis":
+		i.cache = cache.NewRedisCache(cacheType.Rdb, cachePrefix, jsonEncoding, nil)
+	case "memory":
+		i.cache = cache.NewMemoryCache(cachePrefix, jsonEncoding, nil)
+	default:
+		return // no cache
+	}
+	invalidator = i
+}
+
+// GetInvalidator get the invalidator, nil if it is not initialized or the cache is not used
+func GetInvalidator() *Invalidator {
+	return invalidator
+}
+
+// Publish delete the cache entries of the events and broadcast their keys
+func (i *Invalidator) Publish(ctx context.Context, events ...Event) error {
+	var keys []string
+	for _, event := range events {
+		keys = append(keys, event.Keys()...)
+	}
+	if i == nil || len(keys) == 0 {
+		return nil
+	}
+
+	if err := i.cache.Del(ctx, keys...); err != nil {
+		return err
+	}
+	if i.rdb == nil {
+		return nil
+	}
+	msg, err := json.Marshal(&invalidation{Origin: i.origin, Keys: keys})
+	if err != nil {
+		return err
+	}
+	return i.rdb.Publish(ctx, InvalidationChannel, msg).Err()
+}
+
+// Subscribe delete the keys broadcast by the other replicas until ctx is done,
+// the subscription is reconnected by the redis client
+func (i *Invalidator) Subscribe(ctx context.Context) {
+	if i == nil || i.rdb == nil {
+		return
+	}
+	pubSub := i.rdb.Subscribe(ctx, InvalidationChannel)
+	defer pubSub.Close() //nolint
+
+	ch := pubSub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case m, ok := <-ch:
+			if !ok {
+				return
+			}
+			i.receive(ctx, m.Payload)
+		}
+	}
+}
+
+func (i *Invalidator) receive(ctx context.Context, payload string) {
+	msg := &invalidation{}
+	if err := json.Unmarshal([]byte(payload), msg); err != nil {
+		logger.Warn("unmarshal the cache invalidation error", logger.Err(err), logger.String("payload", payload))
+		return
+	}
+	if msg.Origin == i.origin || len(msg.Keys) == 0 {
+		return
+	}
+	if err := i.cache.Del(ctx, msg.Keys...); err != nil {
+		logger.Warn("cache.Del error", logger.Err(err), logger.Any("keys", msg.Keys))
+	}
+}
@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"lol/internal/database"
+	"lol/internal/model"
+)
+
+func TestPaymentChanged_Keys(t *testing.T) {
+	event := PaymentChanged{PaymentIDs: []uint64{7}, LoanIDs: []uint64{1, 2}}
+	assert.Equal(t, []string{"paymentHistory:7", "loan:1", "loan:summary:1", "loan:2", "loan:summary:2"}, event.Keys())
+}
+
+func TestInvalidator_Publish(t *testing.T) {
+	c := newLoanCache()
+	defer c.Close()
+	defer func() { invalidator = nil }()
+
+	// not initialized
+	assert.NoError(t, GetInvalidator().Publish(c.Ctx, PaymentChanged{LoanIDs: []uint64{1}}))
+
+	record := c.TestDataSlice[0].(*model.Loan)
+	loanCache := c.ICache.(LoanCache)
+	assert.NoError(t, loanCache.Set(c.Ctx, record.ID, record, time.Hour))
+	assert.NoError(t, loanCache.SetSummary(c.Ctx, record.ID, &model.LoanPaymentSummary{LoanID: record.ID}, time.Hour))
+
+	InitInvalidator(&database.CacheType{CType: "redis", Rdb: c.RedisClient}, c.RedisClient)
+	err := GetInvalidator().Publish(c.Ctx, PaymentChanged{PaymentIDs: []uint64{7}, LoanIDs: []uint64{record.ID}})
+	assert.NoError(t, err)
+
+	_, err = loanCache.Get(c.Ctx, record.ID)
+	assert.Error(t, err)
+	_, err = loanCache.GetSummary(c.Ctx, record.ID)
+	assert.Error(t, err)
+}
+
+func TestInvalidator_receive(t *testing.T) {
+	c := newLoanCache()
+	defer c.Close()
+	defer func() { invalidator = nil }()
+
+	InitInvalidator(&database.CacheType{CType: "redis", Rdb: c.RedisClient}, c.RedisClient)
+	i := GetInvalidator()
+	record := c.TestDataSlice[0].(*model.Loan)
+	loanCache := c.ICache.(LoanCache)
+	assert.NoError(t, loanCache.Set(c.Ctx, record.ID, record, time.Hour))
+
+	// the keys published by the replica itself are deleted already
+	i.receive(c.Ctx, `{"origin":"`+i.origin+`","keys":["loan:1"]}`)
+	_, err := loanCache.Get(c.Ctx, record.ID)
+	assert.NoError(t, err)
+
+	i.receive(c.Ctx, `{"origin":"another-replica","keys":["loan:1"]}`)
+	_, err = loanCache.Get(c.Ctx, record.ID)
+	assert.Error(t, err)
+
+	i.receive(c.Ctx, `not json`)
+}
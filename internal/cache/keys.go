@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"github.com/go-dev-frame/sponge/pkg/utils"
+)
+
+// cache prefix keys, must end with a colon, the caches share one key space, so every prefix is defined here
+const (
+	adminChallengeCachePrefixKey         = "adminChallenge:"
+	adminChallengeAttemptsCachePrefixKey = "adminChallengeAttempts:"
+	adminUserCachePrefixKey              = "adminUser:"
+	attemptCachePrefixKey                = "attempt:"
+	borrowerOtpCachePrefixKey            = "borrowerOtp:"
+	borrowerOtpAttemptsCachePrefixKey    = "borrowerOtpAttempts:"
+	borrowerOtpCountCachePrefixKey       = "borrowerOtpCount:"
+	captchaCachePrefixKey                = "captcha:"
+	loanCachePrefixKey                   = "loan:"
+	loanSummaryCachePrefixKey            = "loan:summary:" // the payment summaries of the loans
+	loanProductCachePrefixKey            = "loanProduct:"
+	paymentHistoryCachePrefixKey         = "paymentHistory:"
+	resultCachePrefixKey                 = "result:"
+	sessionCachePrefixKey                = "session:"
+	sessionIndexCachePrefixKey           = "sessionSet:" // redis sets, the json indexes of sessionIndex: expire by themselves
+	smsHistoryCachePrefixKey             = "smsHistory:"
+	smsTemplateCachePrefixKey            = "smsTemplate:"
+)
+
+// sessionAllIndexCacheKey the index of the sessions of all users, the user indexes are sessionSet:{userType}:{uid}
+const sessionAllIndexCacheKey = sessionIndexCachePrefixKey + "all"
+
+// AdminUserKey cache key of an admin user
+func AdminUserKey(id uint64) string {
+	return adminUserCachePrefixKey + utils.Uint64ToStr(id)
+}
+
+// LoanKey cache key of a loan
+func LoanKey(id uint64) string {
+	return loanCachePrefixKey + utils.Uint64ToStr(id)
+}
+
+// LoanSummaryKey cache key of the payment summary of a loan
+func LoanSummaryKey(loanID uint64) string {
+	return loanSummaryCachePrefixKey + utils.Uint64ToStr(loanID)
+}
+
+// LoanProductKey cache key of a loan product
+func LoanProductKey(id uint64) string {
+	return loanProductCachePrefixKey + utils.Uint64ToStr(id)
+}
+
+// PaymentHistoryKey cache key of a payment
+func PaymentHistoryKey(id uint64) string {
+	return paymentHistoryCachePrefixKey + utils.Uint64ToStr(id)
+}
+
+// ResultKey cache key of a result
+func ResultKey(id uint64) string {
+	return resultCachePrefixKey + utils.Uint64ToStr(id)
+}
+
+// SmsHistoryKey cache key of a sent sms
+func SmsHistoryKey(id uint64) string {
+	return smsHistoryCachePrefixKey + utils.Uint64ToStr(id)
+}
+
+// SmsTemplateKey cache key of an sms template
+func SmsTemplateKey(id uint64) string {
+	return smsTemplateCachePrefixKey + utils.Uint64ToStr(id)
+}
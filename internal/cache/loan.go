@@ -7,18 +7,17 @@ import (
 	"time"
 
 	"github.com/go-dev-frame/sponge/pkg/cache"
-	"github.com/go-dev-frame/sponge/pkg/encoding"
-	"github.com/go-dev-frame/sponge/pkg/utils"
 
 	"lol/internal/database"
 	"lol/internal/model"
 )
 
 const (
-	// cache prefix key, must end with a colon
-	loanCachePrefixKey = "loan:"
 	// LoanExpireTime expire time
 	LoanExpireTime = 5 * time.Minute
+
+	// LoanSummaryExpireTime expire time of the payment summaries, they are deleted when the payments change
+	LoanSummaryExpireTime = 30 * time.Minute
 )
 
 var _ LoanCache = (*loanCache)(nil)
@@ -32,6 +31,10 @@ type LoanCache interface {
 	Del(ctx context.Context, id uint64) error
 	SetPlaceholder(ctx context.Context, id uint64) error
 	IsPlaceholderErr(err error) bool
+
+	SetSummary(ctx context.Context, loanID uint64, data *model.LoanPaymentSummary, duration time.Duration) error
+	GetSummary(ctx context.Context, loanID uint64) (*model.LoanPaymentSummary, error)
+	DelSummary(ctx context.Context, loanIDs ...uint64) error
 }
 
 // loanCache define a cache struct
@@ -41,7 +44,7 @@ type loanCache struct {
 
 // NewLoanCache new a cache
 func NewLoanCache(cacheType *database.CacheType) LoanCache {
-	jsonEncoding := encoding.JSONEncoding{}
+	jsonEncoding := encryptedJSONEncoding{} // the personal information is cached encrypted
 	cachePrefix := ""
 
 	cType := strings.ToLower(cacheType.CType)
@@ -63,7 +66,7 @@ func NewLoanCache(cacheType *database.CacheType) LoanCache {
 
 // GetLoanCacheKey cache key
 func (c *loanCache) GetLoanCacheKey(id uint64) string {
-	return loanCachePrefixKey + utils.Uint64ToStr(id)
+	return LoanKey(id)
 }
 
 // Set write to cache
@@ -151,3 +154,38 @@ func (c *loanCache) SetPlaceholder(ctx context.Context, id uint64) error {
 func (c *loanCache) IsPlaceholderErr(err error) bool {
 	return errors.Is(err, cache.ErrPlaceholder)
 }
+
+// GetLoanSummaryCacheKey cache key of the payment summary of a loan
+func (c *loanCache) GetLoanSummaryCacheKey(loanID uint64) string {
+	return LoanSummaryKey(loanID)
+}
+
+// SetSummary write the payment summary of a loan to cache
+func (c *loanCache) SetSummary(ctx context.Context, loanID uint64, data *model.LoanPaymentSummary, duration time.Duration) error {
+	if data == nil || loanID == 0 {
+		return nil
+	}
+	return c.cache.Set(ctx, c.GetLoanSummaryCacheKey(loanID), data, duration)
+}
+
+// GetSummary get the payment summary of a loan from cache
+func (c *loanCache) GetSummary(ctx context.Context, loanID uint64) (*model.LoanPaymentSummary, error) {
+	var data *model.LoanPaymentSummary
+	err := c.cache.Get(ctx, c.GetLoanSummaryCacheKey(loanID), &data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// DelSummary delete the payment summaries of the loans from cache
+func (c *loanCache) DelSummary(ctx context.Context, loanIDs ...uint64) error {
+	if len(loanIDs) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(loanIDs))
+	for _, loanID := range loanIDs {
+		keys = append(keys, c.GetLoanSummaryCacheKey(loanID))
+	}
+	return c.cache.Del(ctx, keys...)
+}
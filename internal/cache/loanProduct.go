@@ -0,0 +1,150 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-dev-frame/sponge/pkg/cache"
+	"github.com/go-dev-frame/sponge/pkg/encoding"
+
+	"lol/internal/database"
+	"lol/internal/model"
+)
+
+const (
+	// LoanProductExpireTime expire time
+	LoanProductExpireTime = 5 * time.Minute
+)
+
+var _ LoanProductCache = (*loanProductCache)(nil)
+
+// LoanProductCache cache interface
+type LoanProductCache interface {
+	Set(ctx context.Context, id uint64, data *model.LoanProduct, duration time.Duration) error
+	Get(ctx context.Context, id uint64) (*model.LoanProduct, error)
+	MultiGet(ctx context.Context, ids []uint64) (map[uint64]*model.LoanProduct, error)
+	MultiSet(ctx context.Context, data []*model.LoanProduct, duration time.Duration) error
+	Del(ctx context.Context, id uint64) error
+	SetPlaceholder(ctx context.Context, id uint64) error
+	IsPlaceholderErr(err error) bool
+}
+
+// loanProductCache define a cache struct
+type loanProductCache struct {
+	cache cache.Cache
+}
+
+// NewLoanProductCache new a cache
+func NewLoanProductCache(cacheType *database.CacheType) LoanProductCache {
+	jsonEncoding := encoding.JSONEncoding{}
+	cachePrefix := ""
+
+	cType := strings.ToLower(cacheType.CType)
+	switch cType {
+	case "redis":
+		c := cache.NewRedisCache(cacheType.Rdb, cachePrefix, jsonEncoding, func() interface{} {
+			return &model.LoanProduct{}
+		})
+		return &loanProductCache{cache: c}
+	case "memory":
+		c := cache.NewMemoryCache(cachePrefix, jsonEncoding, func() interface{} {
+			return &model.LoanProduct{}
+		})
+		return &loanProductCache{cache: c}
+	}
+
+	return nil // no cache
+}
+
+// GetLoanProductCacheKey cache key
+func (c *loanProductCache) GetLoanProductCacheKey(id uint64) string {
+	return LoanProductKey(id)
+}
+
+// Set write to cache
+func (c *loanProductCache) Set(ctx context.Context, id uint64, data *model.LoanProduct, duration time.Duration) error {
+	if data == nil || id == 0 {
+		return nil
+	}
+	cacheKey := c.GetLoanProductCacheKey(id)
+	err := c.cache.Set(ctx, cacheKey, data, duration)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Get cache value
+func (c *loanProductCache) Get(ctx context.Context, id uint64) (*model.LoanProduct, error) {
+	var data *model.LoanProduct
+	cacheKey := c.GetLoanProductCacheKey(id)
+	err := c.cache.Get(ctx, cacheKey, &data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// MultiSet multiple set cache
+func (c *loanProductCache) MultiSet(ctx context.Context, data []*model.LoanProduct, duration time.Duration) error {
+	valMap := make(map[string]interface{})
+	for _, v := range data {
+		cacheKey := c.GetLoanProductCacheKey(v.ID)
+		valMap[cacheKey] = v
+	}
+
+	err := c.cache.MultiSet(ctx, valMap, duration)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// MultiGet multiple get cache, return key in map is id value
+func (c *loanProductCache) MultiGet(ctx context.Context, ids []uint64) (map[uint64]*model.LoanProduct, error) {
+	var keys []string
+	for _, v := range ids {
+		cacheKey := c.GetLoanProductCacheKey(v)
+		keys = append(keys, cacheKey)
+	}
+
+	itemMap := make(map[string]*model.LoanProduct)
+	err := c.cache.MultiGet(ctx, keys, itemMap)
+	if err != nil {
+		return nil, err
+	}
+
+	retMap := make(map[uint64]*model.LoanProduct)
+	for _, id := range ids {
+		val, ok := itemMap[c.GetLoanProductCacheKey(id)]
+		if ok {
+			retMap[id] = val
+		}
+	}
+
+	return retMap, nil
+}
+
+// Del delete cache
+func (c *loanProductCache) Del(ctx context.Context, id uint64) error {
+	cacheKey := c.GetLoanProductCacheKey(id)
+	err := c.cache.Del(ctx, cacheKey)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// SetPlaceholder set placeholder value to cache
+func (c *loanProductCache) SetPlaceholder(ctx context.Context, id uint64) error {
+	cacheKey := c.GetLoanProductCacheKey(id)
+	return c.cache.SetCacheWithNotFound(ctx, cacheKey)
+}
+
+// IsPlaceholderErr check if cache is placeholder error
+func (c *loanProductCache) IsPlaceholderErr(err error) bool {
+	return errors.Is(err, cache.ErrPlaceholder)
+}
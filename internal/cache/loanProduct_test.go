@@ -0,0 +1,147 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-dev-frame/sponge/pkg/gotest"
+	"github.com/go-dev-frame/sponge/pkg/utils"
+
+	"lol/internal/database"
+	"lol/internal/model"
+)
+
+func newLoanProductCache() *gotest.Cache {
+	record1 := &model.LoanProduct{}
+	record1.ID = 1
+	record2 := &model.LoanProduct{}
+	record2.ID = 2
+	testData := map[string]interface{}{
+		utils.Uint64ToStr(record1.ID): record1,
+		utils.Uint64ToStr(record2.ID): record2,
+	}
+
+	c := gotest.NewCache(testData)
+	c.ICache = NewLoanProductCache(&database.CacheType{
+		CType: "redis",
+		Rdb:   c.RedisClient,
+	})
+	return c
+}
+
+func Test_loanProductCache_Set(t *testing.T) {
+	c := newLoanProductCache()
+	defer c.Close()
+
+	record := c.TestDataSlice[0].(*model.LoanProduct)
+	err := c.ICache.(LoanProductCache).Set(c.Ctx, record.ID, record, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// nil data
+	err = c.ICache.(LoanProductCache).Set(c.Ctx, 0, nil, time.Hour)
+	assert.NoError(t, err)
+}
+
+func Test_loanProductCache_Get(t *testing.T) {
+	c := newLoanProductCache()
+	defer c.Close()
+
+	record := c.TestDataSlice[0].(*model.LoanProduct)
+	err := c.ICache.(LoanProductCache).Set(c.Ctx, record.ID, record, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.ICache.(LoanProductCache).Get(c.Ctx, record.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, record, got)
+
+	// zero key error
+	_, err = c.ICache.(LoanProductCache).Get(c.Ctx, 0)
+	assert.Error(t, err)
+}
+
+func Test_loanProductCache_MultiGet(t *testing.T) {
+	c := newLoanProductCache()
+	defer c.Close()
+
+	var testData []*model.LoanProduct
+	for _, data := range c.TestDataSlice {
+		testData = append(testData, data.(*model.LoanProduct))
+	}
+
+	err := c.ICache.(LoanProductCache).MultiSet(c.Ctx, testData, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.ICache.(LoanProductCache).MultiGet(c.Ctx, c.GetIDs())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := c.GetTestData()
+	for k, v := range expected {
+		assert.Equal(t, got[utils.StrToUint64(k)], v.(*model.LoanProduct))
+	}
+}
+
+func Test_loanProductCache_MultiSet(t *testing.T) {
+	c := newLoanProductCache()
+	defer c.Close()
+
+	var testData []*model.LoanProduct
+	for _, data := range c.TestDataSlice {
+		testData = append(testData, data.(*model.LoanProduct))
+	}
+
+	err := c.ICache.(LoanProductCache).MultiSet(c.Ctx, testData, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_loanProductCache_Del(t *testing.T) {
+	c := newLoanProductCache()
+	defer c.Close()
+
+	record := c.TestDataSlice[0].(*model.LoanProduct)
+	err := c.ICache.(LoanProductCache).Del(c.Ctx, record.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_loanProductCache_SetCacheWithNotFound(t *testing.T) {
+	c := newLoanProductCache()
+	defer c.Close()
+
+	record := c.TestDataSlice[0].(*model.LoanProduct)
+	err := c.ICache.(LoanProductCache).SetPlaceholder(c.Ctx, record.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := c.ICache.(LoanProductCache).IsPlaceholderErr(err)
+	t.Log(b)
+}
+
+func TestNewLoanProductCache(t *testing.T) {
+	c := NewLoanProductCache(&database.CacheType{
+		CType: "",
+	})
+	assert.Nil(t, c)
+	c = NewLoanProductCache(&database.CacheType{
+		CType: "memory",
+	})
+	assert.NotNil(t, c)
+	c = NewLoanProductCache(&database.CacheType{
+		CType: "redis",
+	})
+	assert.NotNil(t, c)
+}
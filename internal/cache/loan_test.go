@@ -131,6 +131,35 @@ func Test_loanCache_SetCacheWithNotFound(t *testing.T) {
 	t.Log(b)
 }
 
+func Test_loanCache_Summary(t *testing.T) {
+	c := newLoanCache()
+	defer c.Close()
+
+	now := time.Now().Truncate(time.Second)
+	summary := &model.LoanPaymentSummary{LoanID: 1, PaidCount: 2, PaidAmount: 2000, LastPaidAt: &now}
+	err := c.ICache.(LoanCache).SetSummary(c.Ctx, summary.LoanID, summary, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.ICache.(LoanCache).GetSummary(c.Ctx, summary.LoanID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, summary.PaidAmount, got.PaidAmount)
+
+	// the summary is separate from the loan
+	_, err = c.ICache.(LoanCache).Get(c.Ctx, summary.LoanID)
+	assert.Error(t, err)
+
+	err = c.ICache.(LoanCache).DelSummary(c.Ctx, summary.LoanID, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.ICache.(LoanCache).GetSummary(c.Ctx, summary.LoanID)
+	assert.Error(t, err)
+}
+
 func TestNewLoanCache(t *testing.T) {
 	c := NewLoanCache(&database.CacheType{
 		CType: "",
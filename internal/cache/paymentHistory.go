@@ -7,16 +7,12 @@ import (
 	"time"
 
 	"github.com/go-dev-frame/sponge/pkg/cache"
-	"github.com/go-dev-frame/sponge/pkg/encoding"
-	"github.com/go-dev-frame/sponge/pkg/utils"
 
 	"lol/internal/database"
 	"lol/internal/model"
 )
 
 const (
-	// cache prefix key, must end with a colon
-	paymentHistoryCachePrefixKey = "paymentHistory:"
 	// PaymentHistoryExpireTime expire time
 	PaymentHistoryExpireTime = 5 * time.Minute
 )
@@ -41,7 +37,7 @@ type paymentHistoryCache struct {
 
 // NewPaymentHistoryCache new a cache
 func NewPaymentHistoryCache(cacheType *database.CacheType) PaymentHistoryCache {
-	jsonEncoding := encoding.JSONEncoding{}
+	jsonEncoding := encryptedJSONEncoding{} // the personal information is cached encrypted
 	cachePrefix := ""
 
 	cType := strings.ToLower(cacheType.CType)
@@ -63,7 +59,7 @@ func NewPaymentHistoryCache(cacheType *database.CacheType) PaymentHistoryCache {
 
 // GetPaymentHistoryCacheKey cache key
 func (c *paymentHistoryCache) GetPaymentHistoryCacheKey(id uint64) string {
-	return paymentHistoryCachePrefixKey + utils.Uint64ToStr(id)
+	return PaymentHistoryKey(id)
 }
 
 // Set write to cache
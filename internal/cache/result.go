@@ -8,15 +8,12 @@ import (
 
 	"github.com/go-dev-frame/sponge/pkg/cache"
 	"github.com/go-dev-frame/sponge/pkg/encoding"
-	"github.com/go-dev-frame/sponge/pkg/utils"
 
 	"lol/internal/database"
 	"lol/internal/model"
 )
 
 const (
-	// cache prefix key, must end with a colon
-	resultCachePrefixKey = "result:"
 	// ResultExpireTime expire time
 	ResultExpireTime = 5 * time.Minute
 )
@@ -63,7 +60,7 @@ func NewResultCache(cacheType *database.CacheType) ResultCache {
 
 // GetResultCacheKey cache key
 func (c *resultCache) GetResultCacheKey(id uint64) string {
-	return resultCachePrefixKey + utils.Uint64ToStr(id)
+	return ResultKey(id)
 }
 
 // Set write to cache
@@ -0,0 +1,298 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-dev-frame/sponge/pkg/cache"
+	"github.com/go-dev-frame/sponge/pkg/encoding"
+	"github.com/go-dev-frame/sponge/pkg/goredis"
+
+	"lol/internal/database"
+)
+
+// Session 登录会话，令牌只有在会话存在时才有效，删除会话即吊销令牌
+type Session struct {
+	ID        string    `json:"id"`        // 令牌ID
+	UserType  string    `json:"userType"`  // 用户类型 admin/borrower
+	UID       string    `json:"uid"`       // 管理员序号或借款人手机号
+	IP        string    `json:"ip"`        // 登录IP
+	UserAgent string    `json:"userAgent"` // 登录设备
+	CreatedAt time.Time `json:"createdAt"` // 登录时间
+	ExpireAt  time.Time `json:"expireAt"`  // 过期时间
+}
+
+var _ SessionCache = (*sessionCache)(nil)
+
+// SessionCache cache interface
+type SessionCache interface {
+	Set(ctx context.Context, data *Session) error
+	Get(ctx context.Context, id string) (*Session, error)
+	Del(ctx context.Context, id string) error
+	List(ctx context.Context, userType string, uid string) ([]*Session, error)
+	ListAll(ctx context.Context, userType string) ([]*Session, error)
+	DelAll(ctx context.Context, userType string, uid string) (int, error)
+}
+
+// sessionCache define a cache struct
+type sessionCache struct {
+	cache cache.Cache
+	index sessionIndex
+}
+
+// NewSessionCache new a cache, the sessions must be stored somewhere, so memory is used if the cache type is empty
+func NewSessionCache(cacheType *database.CacheType) SessionCache {
+	jsonEncoding := encoding.JSONEncoding{}
+	cachePrefix := ""
+
+	cType := strings.ToLower(cacheType.CType)
+	if cType == "redis" {
+		return &sessionCache{
+			cache: cache.NewRedisCache(cacheType.Rdb, cachePrefix, jsonEncoding, func() interface{} {
+				return &Session{}
+			}),
+			index: &redisSessionIndex{rdb: cacheType.Rdb},
+		}
+	}
+
+	return &sessionCache{
+		cache: cache.NewMemoryCache(cachePrefix, jsonEncoding, func() interface{} {
+			return &Session{}
+		}),
+		index: memorySessionIndexes,
+	}
+}
+
+// GetSessionCacheKey cache key
+func (c *sessionCache) GetSessionCacheKey(id string) string {
+	return sessionCachePrefixKey + id
+}
+
+// GetSessionIndexCacheKey cache key
+func (c *sessionCache) GetSessionIndexCacheKey(userType string, uid string) string {
+	return sessionIndexCachePrefixKey + userType + ":" + uid
+}
+
+// Set write the session to cache and add it to the indexes of the user and of all users, it expires at ExpireAt
+func (c *sessionCache) Set(ctx context.Context, data *Session) error {
+	if data == nil || data.ID == "" {
+		return nil
+	}
+	duration := time.Until(data.ExpireAt)
+	if duration <= 0 {
+		return nil
+	}
+	err := c.cache.Set(ctx, c.GetSessionCacheKey(data.ID), data, duration)
+	if err != nil {
+		return err
+	}
+
+	// 新会话最晚过期，索引跟随最新会话的过期时间
+	err = c.index.Add(ctx, c.GetSessionIndexCacheKey(data.UserType, data.UID), data.ID, duration)
+	if err != nil {
+		return err
+	}
+	return c.index.Add(ctx, sessionAllIndexCacheKey, data.ID, duration)
+}
+
+// Get cache value
+func (c *sessionCache) Get(ctx context.Context, id string) (*Session, error) {
+	var data *Session
+	cacheKey := c.GetSessionCacheKey(id)
+	err := c.cache.Get(ctx, cacheKey, &data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Del delete the session and remove it from the indexes
+func (c *sessionCache) Del(ctx context.Context, id string) error {
+	session, err := c.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, database.ErrCacheNotFound) {
+			return nil
+		}
+		return err
+	}
+	err = c.cache.Del(ctx, c.GetSessionCacheKey(id))
+	if err != nil {
+		return err
+	}
+	err = c.index.Remove(ctx, c.GetSessionIndexCacheKey(session.UserType, session.UID), id)
+	if err != nil {
+		return err
+	}
+	return c.index.Remove(ctx, sessionAllIndexCacheKey, id)
+}
+
+// List the active sessions of the user, the expired ones are removed from the index
+func (c *sessionCache) List(ctx context.Context, userType string, uid string) ([]*Session, error) {
+	return c.list(ctx, c.GetSessionIndexCacheKey(userType, uid))
+}
+
+// ListAll the active sessions of all users of the user type, or of all users if userType is empty,
+// the latest logins first
+func (c *sessionCache) ListAll(ctx context.Context, userType string) ([]*Session, error) {
+	sessions, err := c.list(ctx, sessionAllIndexCacheKey)
+	if err != nil {
+		return nil, err
+	}
+	if userType != "" {
+		filtered := []*Session{}
+		for _, session := range sessions {
+			if session.UserType == userType {
+				filtered = append(filtered, session)
+			}
+		}
+		sessions = filtered
+	}
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt.After(sessions[j].CreatedAt)
+	})
+	return sessions, nil
+}
+
+// DelAll delete all sessions of the user, return the number of active sessions deleted
+func (c *sessionCache) DelAll(ctx context.Context, userType string, uid string) (int, error) {
+	sessions, err := c.List(ctx, userType, uid)
+	if err != nil {
+		return 0, err
+	}
+	ids := make([]string, 0, len(sessions))
+	for _, session := range sessions {
+		err = c.cache.Del(ctx, c.GetSessionCacheKey(session.ID))
+		if err != nil {
+			return 0, err
+		}
+		ids = append(ids, session.ID)
+	}
+	// only the deleted sessions are removed, a session added meanwhile stays in the index
+	err = c.index.Remove(ctx, c.GetSessionIndexCacheKey(userType, uid), ids...)
+	if err != nil {
+		return 0, err
+	}
+	err = c.index.Remove(ctx, sessionAllIndexCacheKey, ids...)
+	if err != nil {
+		return 0, err
+	}
+	return len(sessions), nil
+}
+
+// list the active sessions of the index, the expired ones are removed from it
+func (c *sessionCache) list(ctx context.Context, key string) ([]*Session, error) {
+	ids, err := c.index.Members(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := []*Session{}
+	expired := []string{}
+	for _, id := range ids {
+		session, err := c.Get(ctx, id)
+		if err != nil {
+			if errors.Is(err, database.ErrCacheNotFound) {
+				expired = append(expired, id)
+				continue
+			}
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	err = c.index.Remove(ctx, key, expired...)
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// sessionIndex the session ids of the users, ids are added and removed one by one rather than by writing
+// the whole index back, so the concurrent logins and logouts of a user on different replicas are not lost
+type sessionIndex interface {
+	Add(ctx context.Context, key string, id string, duration time.Duration) error
+	Remove(ctx context.Context, key string, ids ...string) error
+	Members(ctx context.Context, key string) ([]string, error)
+}
+
+// redisSessionIndex the indexes are redis sets
+type redisSessionIndex struct {
+	rdb *goredis.Client
+}
+
+// Add the id to the set, the set expires with the session
+func (i *redisSessionIndex) Add(ctx context.Context, key string, id string, duration time.Duration) error {
+	pipe := i.rdb.TxPipeline()
+	pipe.SAdd(ctx, key, id)
+	pipe.Expire(ctx, key, duration)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Remove the ids from the set
+func (i *redisSessionIndex) Remove(ctx context.Context, key string, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	members := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		members = append(members, id)
+	}
+	return i.rdb.SRem(ctx, key, members...).Err()
+}
+
+// Members the ids of the set
+func (i *redisSessionIndex) Members(ctx context.Context, key string) ([]string, error) {
+	return i.rdb.SMembers(ctx, key).Result()
+}
+
+// memorySessionIndexes the indexes of the memory cache, shared by the session caches of the process
+var memorySessionIndexes = &memorySessionIndex{sets: map[string]map[string]struct{}{}}
+
+// memorySessionIndex the indexes are sets in memory, the expired ids are removed when they are listed
+type memorySessionIndex struct {
+	mu   sync.Mutex
+	sets map[string]map[string]struct{}
+}
+
+// Add the id to the set
+func (i *memorySessionIndex) Add(ctx context.Context, key string, id string, duration time.Duration) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	set, ok := i.sets[key]
+	if !ok {
+		set = map[string]struct{}{}
+		i.sets[key] = set
+	}
+	set[id] = struct{}{}
+	return nil
+}
+
+// Remove the ids from the set
+func (i *memorySessionIndex) Remove(ctx context.Context, key string, ids ...string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	set := i.sets[key]
+	for _, id := range ids {
+		delete(set, id)
+	}
+	if len(set) == 0 {
+		delete(i.sets, key)
+	}
+	return nil
+}
+
+// Members the ids of the set
+func (i *memorySessionIndex) Members(ctx context.Context, key string) ([]string, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	ids := make([]string, 0, len(i.sets[key]))
+	for id := range i.sets[key] {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
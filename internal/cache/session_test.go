@@ -0,0 +1,176 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-dev-frame/sponge/pkg/gotest"
+
+	"lol/internal/database"
+)
+
+func newSessionCache() *gotest.Cache {
+	record1 := &Session{ID: "id1", UserType: "admin", UID: "1", IP: "127.0.0.1", CreatedAt: time.Now(), ExpireAt: time.Now().Add(time.Hour)}
+	record2 := &Session{ID: "id2", UserType: "admin", UID: "1", IP: "127.0.0.2", CreatedAt: time.Now(), ExpireAt: time.Now().Add(time.Hour)}
+	testData := map[string]interface{}{
+		"id1": record1,
+		"id2": record2,
+	}
+
+	c := gotest.NewCache(testData)
+	c.ICache = NewSessionCache(&database.CacheType{
+		CType: "redis",
+		Rdb:   c.RedisClient,
+	})
+	return c
+}
+
+func Test_sessionCache_Get(t *testing.T) {
+	c := newSessionCache()
+	defer c.Close()
+
+	record := c.TestDataSlice[0].(*Session)
+	err := c.ICache.(SessionCache).Set(c.Ctx, record)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.ICache.(SessionCache).Get(c.Ctx, record.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, record.UID, got.UID)
+
+	err = c.ICache.(SessionCache).Del(c.Ctx, record.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.ICache.(SessionCache).Get(c.Ctx, record.ID)
+	assert.Error(t, err)
+
+	// expired session is not saved
+	err = c.ICache.(SessionCache).Set(c.Ctx, &Session{ID: "id3", ExpireAt: time.Now().Add(-time.Second)})
+	assert.NoError(t, err)
+	_, err = c.ICache.(SessionCache).Get(c.Ctx, "id3")
+	assert.Error(t, err)
+}
+
+func Test_sessionCache_List(t *testing.T) {
+	c := newSessionCache()
+	defer c.Close()
+
+	for _, v := range c.TestDataSlice {
+		err := c.ICache.(SessionCache).Set(c.Ctx, v.(*Session))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sessions, err := c.ICache.(SessionCache).List(c.Ctx, "admin", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, sessions, 2)
+
+	// deleted session is removed from the list
+	err = c.ICache.(SessionCache).Del(c.Ctx, "id1")
+	assert.NoError(t, err)
+	sessions, err = c.ICache.(SessionCache).List(c.Ctx, "admin", "1")
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 1)
+
+	sessions, err = c.ICache.(SessionCache).List(c.Ctx, "borrower", "1")
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 0)
+}
+
+func Test_sessionCache_DelAll(t *testing.T) {
+	c := newSessionCache()
+	defer c.Close()
+
+	for _, v := range c.TestDataSlice {
+		err := c.ICache.(SessionCache).Set(c.Ctx, v.(*Session))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n, err := c.ICache.(SessionCache).DelAll(c.Ctx, "admin", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, n)
+	_, err = c.ICache.(SessionCache).Get(c.Ctx, "id2")
+	assert.Error(t, err)
+}
+
+func Test_sessionCache_ListAll(t *testing.T) {
+	c := newSessionCache()
+	defer c.Close()
+
+	for _, v := range c.TestDataSlice {
+		err := c.ICache.(SessionCache).Set(c.Ctx, v.(*Session))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	borrower := &Session{ID: "id3", UserType: "borrower", UID: "13800000000", CreatedAt: time.Now().Add(time.Minute),
+		ExpireAt: time.Now().Add(time.Hour)}
+	assert.NoError(t, c.ICache.(SessionCache).Set(c.Ctx, borrower))
+
+	sessions, err := c.ICache.(SessionCache).ListAll(c.Ctx, "")
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 3)
+	assert.Equal(t, "id3", sessions[0].ID) // the latest login first
+
+	sessions, err = c.ICache.(SessionCache).ListAll(c.Ctx, "admin")
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 2)
+
+	// revoked sessions are removed from the index of all users
+	_, err = c.ICache.(SessionCache).DelAll(c.Ctx, "admin", "1")
+	assert.NoError(t, err)
+	sessions, err = c.ICache.(SessionCache).ListAll(c.Ctx, "")
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 1)
+}
+
+func Test_memorySessionIndex(t *testing.T) {
+	i := &memorySessionIndex{sets: map[string]map[string]struct{}{}}
+	ctx := context.Background()
+
+	// concurrent logins of a user are all indexed
+	var wg sync.WaitGroup
+	for n := 0; n < 10; n++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			_ = i.Add(ctx, "sessionSet:admin:1", fmt.Sprintf("id%d", n), time.Hour)
+		}(n)
+	}
+	wg.Wait()
+	ids, err := i.Members(ctx, "sessionSet:admin:1")
+	assert.NoError(t, err)
+	assert.Len(t, ids, 10)
+
+	assert.NoError(t, i.Remove(ctx, "sessionSet:admin:1", ids...))
+	ids, err = i.Members(ctx, "sessionSet:admin:1")
+	assert.NoError(t, err)
+	assert.Empty(t, ids)
+}
+
+func TestNewSessionCache(t *testing.T) {
+	c := NewSessionCache(&database.CacheType{
+		CType: "",
+	})
+	assert.NotNil(t, c)
+	c = NewSessionCache(&database.CacheType{
+		CType: "redis",
+	})
+	assert.NotNil(t, c)
+}
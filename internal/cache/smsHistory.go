@@ -7,16 +7,12 @@ import (
 	"time"
 
 	"github.com/go-dev-frame/sponge/pkg/cache"
-	"github.com/go-dev-frame/sponge/pkg/encoding"
-	"github.com/go-dev-frame/sponge/pkg/utils"
 
 	"lol/internal/database"
 	"lol/internal/model"
 )
 
 const (
-	// cache prefix key, must end with a colon
-	smsHistoryCachePrefixKey = "smsHistory:"
 	// SmsHistoryExpireTime expire time
 	SmsHistoryExpireTime = 5 * time.Minute
 )
@@ -41,7 +37,7 @@ type smsHistoryCache struct {
 
 // NewSmsHistoryCache new a cache
 func NewSmsHistoryCache(cacheType *database.CacheType) SmsHistoryCache {
-	jsonEncoding := encoding.JSONEncoding{}
+	jsonEncoding := encryptedJSONEncoding{} // the personal information is cached encrypted
 	cachePrefix := ""
 
 	cType := strings.ToLower(cacheType.CType)
@@ -63,7 +59,7 @@ func NewSmsHistoryCache(cacheType *database.CacheType) SmsHistoryCache {
 
 // GetSmsHistoryCacheKey cache key
 func (c *smsHistoryCache) GetSmsHistoryCacheKey(id uint64) string {
-	return smsHistoryCachePrefixKey + utils.Uint64ToStr(id)
+	return SmsHistoryKey(id)
 }
 
 // Set write to cache
@@ -0,0 +1,150 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-dev-frame/sponge/pkg/cache"
+	"github.com/go-dev-frame/sponge/pkg/encoding"
+
+	"lol/internal/database"
+	"lol/internal/model"
+)
+
+const (
+	// SmsTemplateExpireTime expire time
+	SmsTemplateExpireTime = 5 * time.Minute
+)
+
+var _ SmsTemplateCache = (*smsTemplateCache)(nil)
+
+// SmsTemplateCache cache interface
+type SmsTemplateCache interface {
+	Set(ctx context.Context, id uint64, data *model.SmsTemplate, duration time.Duration) error
+	Get(ctx context.Context, id uint64) (*model.SmsTemplate, error)
+	MultiGet(ctx context.Context, ids []uint64) (map[uint64]*model.SmsTemplate, error)
+	MultiSet(ctx context.Context, data []*model.SmsTemplate, duration time.Duration) error
+	Del(ctx context.Context, id uint64) error
+	SetPlaceholder(ctx context.Context, id uint64) error
+	IsPlaceholderErr(err error) bool
+}
+
+// smsTemplateCache define a cache struct
+type smsTemplateCache struct {
+	cache cache.Cache
+}
+
+// NewSmsTemplateCache new a cache
+func NewSmsTemplateCache(cacheType *database.CacheType) SmsTemplateCache {
+	jsonEncoding := encoding.JSONEncoding{}
+	cachePrefix := ""
+
+	cType := strings.ToLower(cacheType.CType)
+	switch cType {
+	case "redis":
+		c := cache.NewRedisCache(cacheType.Rdb, cachePrefix, jsonEncoding, func() interface{} {
+			return &model.SmsTemplate{}
+		})
+		return &smsTemplateCache{cache: c}
+	case "memory":
+		c := cache.NewMemoryCache(cachePrefix, jsonEncoding, func() interface{} {
+			return &model.SmsTemplate{}
+		})
+		return &smsTemplateCache{cache: c}
+	}
+
+	return nil // no cache
+}
+
+// GetSmsTemplateCacheKey cache key
+func (c *smsTemplateCache) GetSmsTemplateCacheKey(id uint64) string {
+	return SmsTemplateKey(id)
+}
+
+// Set write to cache
+func (c *smsTemplateCache) Set(ctx context.Context, id uint64, data *model.SmsTemplate, duration time.Duration) error {
+	if data == nil || id == 0 {
+		return nil
+	}
+	cacheKey := c.GetSmsTemplateCacheKey(id)
+	err := c.cache.Set(ctx, cacheKey, data, duration)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Get cache value
+func (c *smsTemplateCache) Get(ctx context.Context, id uint64) (*model.SmsTemplate, error) {
+	var data *model.SmsTemplate
+	cacheKey := c.GetSmsTemplateCacheKey(id)
+	err := c.cache.Get(ctx, cacheKey, &data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// MultiSet multiple set cache
+func (c *smsTemplateCache) MultiSet(ctx context.Context, data []*model.SmsTemplate, duration time.Duration) error {
+	valMap := make(map[string]interface{})
+	for _, v := range data {
+		cacheKey := c.GetSmsTemplateCacheKey(v.ID)
+		valMap[cacheKey] = v
+	}
+
+	err := c.cache.MultiSet(ctx, valMap, duration)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// MultiGet multiple get cache, return key in map is id value
+func (c *smsTemplateCache) MultiGet(ctx context.Context, ids []uint64) (map[uint64]*model.SmsTemplate, error) {
+	var keys []string
+	for _, v := range ids {
+		cacheKey := c.GetSmsTemplateCacheKey(v)
+		keys = append(keys, cacheKey)
+	}
+
+	itemMap := make(map[string]*model.SmsTemplate)
+	err := c.cache.MultiGet(ctx, keys, itemMap)
+	if err != nil {
+		return nil, err
+	}
+
+	retMap := make(map[uint64]*model.SmsTemplate)
+	for _, id := range ids {
+		val, ok := itemMap[c.GetSmsTemplateCacheKey(id)]
+		if ok {
+			retMap[id] = val
+		}
+	}
+
+	return retMap, nil
+}
+
+// Del delete cache
+func (c *smsTemplateCache) Del(ctx context.Context, id uint64) error {
+	cacheKey := c.GetSmsTemplateCacheKey(id)
+	err := c.cache.Del(ctx, cacheKey)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// SetPlaceholder set placeholder value to cache
+func (c *smsTemplateCache) SetPlaceholder(ctx context.Context, id uint64) error {
+	cacheKey := c.GetSmsTemplateCacheKey(id)
+	return c.cache.SetCacheWithNotFound(ctx, cacheKey)
+}
+
+// IsPlaceholderErr check if cache is placeholder error
+func (c *smsTemplateCache) IsPlaceholderErr(err error) bool {
+	return errors.Is(err, cache.ErrPlaceholder)
+}
@@ -0,0 +1,147 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-dev-frame/sponge/pkg/gotest"
+	"github.com/go-dev-frame/sponge/pkg/utils"
+
+	"lol/internal/database"
+	"lol/internal/model"
+)
+
+func newSmsTemplateCache() *gotest.Cache {
+	record1 := &model.SmsTemplate{}
+	record1.ID = 1
+	record2 := &model.SmsTemplate{}
+	record2.ID = 2
+	testData := map[string]interface{}{
+		utils.Uint64ToStr(record1.ID): record1,
+		utils.Uint64ToStr(record2.ID): record2,
+	}
+
+	c := gotest.NewCache(testData)
+	c.ICache = NewSmsTemplateCache(&database.CacheType{
+		CType: "redis",
+		Rdb:   c.RedisClient,
+	})
+	return c
+}
+
+func Test_smsTemplateCache_Set(t *testing.T) {
+	c := newSmsTemplateCache()
+	defer c.Close()
+
+	record := c.TestDataSlice[0].(*model.SmsTemplate)
+	err := c.ICache.(SmsTemplateCache).Set(c.Ctx, record.ID, record, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// nil data
+	err = c.ICache.(SmsTemplateCache).Set(c.Ctx, 0, nil, time.Hour)
+	assert.NoError(t, err)
+}
+
+func Test_smsTemplateCache_Get(t *testing.T) {
+	c := newSmsTemplateCache()
+	defer c.Close()
+
+	record := c.TestDataSlice[0].(*model.SmsTemplate)
+	err := c.ICache.(SmsTemplateCache).Set(c.Ctx, record.ID, record, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.ICache.(SmsTemplateCache).Get(c.Ctx, record.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, record, got)
+
+	// zero key error
+	_, err = c.ICache.(SmsTemplateCache).Get(c.Ctx, 0)
+	assert.Error(t, err)
+}
+
+func Test_smsTemplateCache_MultiGet(t *testing.T) {
+	c := newSmsTemplateCache()
+	defer c.Close()
+
+	var testData []*model.SmsTemplate
+	for _, data := range c.TestDataSlice {
+		testData = append(testData, data.(*model.SmsTemplate))
+	}
+
+	err := c.ICache.(SmsTemplateCache).MultiSet(c.Ctx, testData, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.ICache.(SmsTemplateCache).MultiGet(c.Ctx, c.GetIDs())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := c.GetTestData()
+	for k, v := range expected {
+		assert.Equal(t, got[utils.StrToUint64(k)], v.(*model.SmsTemplate))
+	}
+}
+
+func Test_smsTemplateCache_MultiSet(t *testing.T) {
+	c := newSmsTemplateCache()
+	defer c.Close()
+
+	var testData []*model.SmsTemplate
+	for _, data := range c.TestDataSlice {
+		testData = append(testData, data.(*model.SmsTemplate))
+	}
+
+	err := c.ICache.(SmsTemplateCache).MultiSet(c.Ctx, testData, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_smsTemplateCache_Del(t *testing.T) {
+	c := newSmsTemplateCache()
+	defer c.Close()
+
+	record := c.TestDataSlice[0].(*model.SmsTemplate)
+	err := c.ICache.(SmsTemplateCache).Del(c.Ctx, record.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_smsTemplateCache_SetCacheWithNotFound(t *testing.T) {
+	c := newSmsTemplateCache()
+	defer c.Close()
+
+	record := c.TestDataSlice[0].(*model.SmsTemplate)
+	err := c.ICache.(SmsTemplateCache).SetPlaceholder(c.Ctx, record.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := c.ICache.(SmsTemplateCache).IsPlaceholderErr(err)
+	t.Log(b)
+}
+
+func TestNewSmsTemplateCache(t *testing.T) {
+	c := NewSmsTemplateCache(&database.CacheType{
+		CType: "",
+	})
+	assert.Nil(t, c)
+	c = NewSmsTemplateCache(&database.CacheType{
+		CType: "memory",
+	})
+	assert.NotNil(t, c)
+	c = NewSmsTemplateCache(&database.CacheType{
+		CType: "redis",
+	})
+	assert.NotNil(t, c)
+}
@@ -0,0 +1,135 @@
+// Package captcha generate numeric image captchas, drawn with a bitmap font and noise so no font file is needed.
+package captcha
+
+import (
+	"bytes"
+	"crypto/rand"
+	"image"
+	"image/color"
+	"image/png"
+	"math/big"
+	mrand "math/rand"
+)
+
+const (
+	// DefaultLength the number of digits of a captcha
+	DefaultLength = 4
+
+	scale      = 4 // pixels of a dot of the font
+	digitWidth = 5 * scale
+	digitSpace = 8
+	height     = 44
+	padding    = 8
+	noiseDots  = 160
+	noiseLines = 3
+)
+
+// font the 5x7 bitmaps of the digits, a row is the lower 5 bits from left to right
+var font = [10][7]byte{
+	{0x0e, 0x11, 0x13, 0x15, 0x19, 0x11, 0x0e}, // 0
+	{0x04, 0x0c, 0x04, 0x04, 0x04, 0x04, 0x0e}, // 1
+	{0x0e, 0x11, 0x01, 0x02, 0x04, 0x08, 0x1f}, // 2
+	{0x1f, 0x02, 0x04, 0x02, 0x01, 0x11, 0x0e}, // 3
+	{0x02, 0x06, 0x0a, 0x12, 0x1f, 0x02, 0x02}, // 4
+	{0x1f, 0x10, 0x1e, 0x01, 0x01, 0x11, 0x0e}, // 5
+	{0x06, 0x08, 0x10, 0x1e, 0x11, 0x11, 0x0e}, // 6
+	{0x1f, 0x01, 0x02, 0x04, 0x08, 0x08, 0x08}, // 7
+	{0x0e, 0x11, 0x11, 0x0e, 0x11, 0x11, 0x0e}, // 8
+	{0x0e, 0x11, 0x11, 0x0f, 0x01, 0x02, 0x0c}, // 9
+}
+
+// Generate a captcha of random digits, the answer and the png image are returned
+func Generate(length int) (string, []byte, error) {
+	if length <= 0 {
+		length = DefaultLength
+	}
+	answer := make([]byte, length)
+	for i := range answer {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", nil, err
+		}
+		answer[i] = byte('0' + n.Int64())
+	}
+
+	img, err := Draw(string(answer))
+	if err != nil {
+		return "", nil, err
+	}
+	return string(answer), img, nil
+}
+
+// Draw the digits as a png image, the position and the color of every digit are jittered
+func Draw(digits string) ([]byte, error) {
+	width := padding*2 + len(digits)*(digitWidth+digitSpace) - digitSpace
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.RGBA{R: 245, G: 245, B: 240, A: 255})
+		}
+	}
+
+	for i, d := range digits {
+		if d < '0' || d > '9' {
+			continue
+		}
+		x0 := padding + i*(digitWidth+digitSpace) + mrand.Intn(5) - 2 //nolint
+		y0 := padding/2 + mrand.Intn(height-7*scale-padding)          //nolint
+		c := randomColor()
+		for row, bits := range font[d-'0'] {
+			for col := 0; col < 5; col++ {
+				if bits&(1<<(4-col)) == 0 {
+					continue
+				}
+				fillRect(img, x0+col*scale, y0+row*scale, scale, scale, c)
+			}
+		}
+	}
+
+	for i := 0; i < noiseLines; i++ {
+		drawLine(img, mrand.Intn(width), mrand.Intn(height), mrand.Intn(width), mrand.Intn(height), randomColor()) //nolint
+	}
+	for i := 0; i < noiseDots; i++ {
+		img.Set(mrand.Intn(width), mrand.Intn(height), randomColor()) //nolint
+	}
+
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func randomColor() color.RGBA {
+	return color.RGBA{R: uint8(mrand.Intn(150)), G: uint8(mrand.Intn(150)), B: uint8(mrand.Intn(150)), A: 255} //nolint
+}
+
+func fillRect(img *image.RGBA, x, y, w, h int, c color.Color) {
+	for i := x; i < x+w; i++ {
+		for j := y; j < y+h; j++ {
+			img.Set(i, j, c)
+		}
+	}
+}
+
+// drawLine draw a line by sampling the points between the two ends
+func drawLine(img *image.RGBA, x1, y1, x2, y2 int, c color.Color) {
+	steps := abs(x2 - x1)
+	if dy := abs(y2 - y1); dy > steps {
+		steps = dy
+	}
+	if steps == 0 {
+		img.Set(x1, y1, c)
+		return
+	}
+	for i := 0; i <= steps; i++ {
+		img.Set(x1+(x2-x1)*i/steps, y1+(y2-y1)*i/steps, c)
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,34 @@
+package captcha
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	answer, img, err := Generate(0)
+	assert.NoError(t, err)
+	assert.Len(t, answer, DefaultLength)
+	for _, v := range answer {
+		assert.True(t, v >= '0' && v <= '9')
+	}
+
+	decoded, err := png.Decode(bytes.NewReader(img))
+	assert.NoError(t, err)
+	assert.Equal(t, padding*2+DefaultLength*(digitWidth+digitSpace)-digitSpace, decoded.Bounds().Dx())
+	assert.Equal(t, height, decoded.Bounds().Dy())
+
+	answer, _, err = Generate(6)
+	assert.NoError(t, err)
+	assert.Len(t, answer, 6)
+}
+
+func TestDraw(t *testing.T) {
+	img, err := Draw("0123456789")
+	assert.NoError(t, err)
+	_, err = png.Decode(bytes.NewReader(img))
+	assert.NoError(t, err)
+}
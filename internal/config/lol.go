@@ -29,19 +29,27 @@ func Set(conf *Config) {
 }
 
 type Config struct {
-	App        App          `yaml:"app" json:"app"`
-	Consul     Consul       `yaml:"consul" json:"consul"`
-	Database   Database     `yaml:"database" json:"database"`
-	Etcd       Etcd         `yaml:"etcd" json:"etcd"`
-	Grpc       Grpc         `yaml:"grpc" json:"grpc"`
-	GrpcClient []GrpcClient `yaml:"grpcClient" json:"grpcClient"`
-	HTTP       HTTP         `yaml:"http" json:"http"`
-	Jaeger     Jaeger       `yaml:"jaeger" json:"jaeger"`
-	Logger     Logger       `yaml:"logger" json:"logger"`
-	NacosRd    NacosRd      `yaml:"nacosRd" json:"nacosRd"`
-	Redis      Redis        `yaml:"redis" json:"redis"`
-	Alipay     Alipay       `yaml:"alipay" json:"alipay"`
-	WechatPay  WechatPay    `yaml:"wechatPay" json:"wechatPay"`
+	App           App           `yaml:"app" json:"app"`
+	Consul        Consul        `yaml:"consul" json:"consul"`
+	Database      Database      `yaml:"database" json:"database"`
+	Etcd          Etcd          `yaml:"etcd" json:"etcd"`
+	Grpc          Grpc          `yaml:"grpc" json:"grpc"`
+	GrpcClient    []GrpcClient  `yaml:"grpcClient" json:"grpcClient"`
+	HTTP          HTTP          `yaml:"http" json:"http"`
+	Jaeger        Jaeger        `yaml:"jaeger" json:"jaeger"`
+	Logger        Logger        `yaml:"logger" json:"logger"`
+	NacosRd       NacosRd       `yaml:"nacosRd" json:"nacosRd"`
+	Redis         Redis         `yaml:"redis" json:"redis"`
+	Alipay        Alipay        `yaml:"alipay" json:"alipay"`
+	WechatPay     WechatPay     `yaml:"wechatPay" json:"wechatPay"`
+	Jwt           Jwt           `yaml:"jwt" json:"jwt"`
+	Otp           Otp           `yaml:"otp" json:"otp"`
+	BruteForce    BruteForce    `yaml:"bruteForce" json:"bruteForce"`
+	TwoFactor     TwoFactor     `yaml:"twoFactor" json:"twoFactor"`
+	Sms           Sms           `yaml:"sms" json:"sms"`
+	Reminder      Reminder      `yaml:"reminder" json:"reminder"`
+	PaymentNotice PaymentNotice `yaml:"paymentNotice" json:"paymentNotice"`
+	FieldCrypt    FieldCrypt    `yaml:"fieldCrypt" json:"fieldCrypt"`
 }
 
 type Consul struct {
@@ -79,6 +87,7 @@ type ServerSecure struct {
 }
 
 type App struct {
+	CacheBroadcast        bool    `yaml:"cacheBroadcast" json:"cacheBroadcast"`
 	CacheType             string  `yaml:"cacheType" json:"cacheType"`
 	EnableCircuitBreaker  bool    `yaml:"enableCircuitBreaker" json:"enableCircuitBreaker"`
 	EnableHTTPProfile     bool    `yaml:"enableHTTPProfile" json:"enableHTTPProfile"`
@@ -190,3 +199,107 @@ type WechatPay struct {
 	MchAPIv3Key                string `yaml:"mchAPIv3Key" json:"mchAPIv3Key"`
 	NotifyURL                  string `yaml:"notifyUrl" json:"notifyUrl"`
 }
+
+type Jwt struct {
+	SigningKey string `yaml:"signingKey" json:"signingKey"`
+	Expire     int    `yaml:"expire" json:"expire"`
+}
+
+type Otp struct {
+	Length      int `yaml:"length" json:"length"`
+	Expire      int `yaml:"expire" json:"expire"`
+	Interval    int `yaml:"interval" json:"interval"`
+	DailyLimit  int `yaml:"dailyLimit" json:"dailyLimit"`
+	MaxAttempts int `yaml:"maxAttempts" json:"maxAttempts"`
+}
+
+type BruteForce struct {
+	MaxFailures   int `yaml:"maxFailures" json:"maxFailures"`
+	IPMaxFailures int `yaml:"ipMaxFailures" json:"ipMaxFailures"`
+	Lockout       int `yaml:"lockout" json:"lockout"`
+	MaxLockout    int `yaml:"maxLockout" json:"maxLockout"`
+	Window        int `yaml:"window" json:"window"`
+	CaptchaAfter  int `yaml:"captchaAfter" json:"captchaAfter"`
+	MinDuration   int `yaml:"minDuration" json:"minDuration"`
+}
+
+type TwoFactor struct {
+	Issuer        string   `yaml:"issuer" json:"issuer"`
+	RequiredRoles []string `yaml:"requiredRoles" json:"requiredRoles"`
+}
+
+type Sms struct {
+	Provider      string                 `yaml:"provider" json:"provider"`
+	SignName      string                 `yaml:"signName" json:"signName"`
+	MaxRetries    int                    `yaml:"maxRetries" json:"maxRetries"`
+	RetryInterval int                    `yaml:"retryInterval" json:"retryInterval"`
+	LogFile       string                 `yaml:"logFile" json:"logFile"`
+	ReportToken   string                 `yaml:"reportToken" json:"reportToken"`
+	Aliyun        SmsAliyun              `yaml:"aliyun" json:"aliyun"`
+	Tencent       SmsTencent             `yaml:"tencent" json:"tencent"`
+	Templates     map[string]SmsTemplate `yaml:"templates" json:"templates"`
+}
+
+type SmsAliyun struct {
+	AccessKeyID     string `yaml:"accessKeyID" json:"accessKeyID"`
+	AccessKeySecret string `yaml:"accessKeySecret" json:"accessKeySecret"`
+	Endpoint        string `yaml:"endpoint" json:"endpoint"`
+}
+
+type SmsTencent struct {
+	SecretID  string `yaml:"secretID" json:"secretID"`
+	SecretKey string `yaml:"secretKey" json:"secretKey"`
+	SdkAppID  string `yaml:"sdkAppID" json:"sdkAppID"`
+	Region    string `yaml:"region" json:"region"`
+	Endpoint  string `yaml:"endpoint" json:"endpoint"`
+}
+
+type SmsTemplate struct {
+	Content    string `yaml:"content" json:"content"`
+	Sensitive  bool   `yaml:"sensitive" json:"sensitive"`
+	TemplateID string `yaml:"templateID" json:"templateID"`
+}
+
+type Reminder struct {
+	Enable   bool           `yaml:"enable" json:"enable"`
+	Interval int            `yaml:"interval" json:"interval"`
+	Rules    []ReminderRule `yaml:"rules" json:"rules"`
+}
+
+type ReminderRule struct {
+	Name       string `yaml:"name" json:"name"`
+	OffsetDays int    `yaml:"offsetDays" json:"offsetDays"`
+	Template   string `yaml:"template" json:"template"`
+	QuietStart string `yaml:"quietStart" json:"quietStart"`
+	QuietEnd   string `yaml:"quietEnd" json:"quietEnd"`
+	DailyCap   int    `yaml:"dailyCap" json:"dailyCap"`
+}
+
+type PaymentNotice struct {
+	Enable        bool         `yaml:"enable" json:"enable"`
+	SmsTemplate   string       `yaml:"smsTemplate" json:"smsTemplate"`
+	MaxRetries    int          `yaml:"maxRetries" json:"maxRetries"`
+	RetryInterval int          `yaml:"retryInterval" json:"retryInterval"`
+	Wechat        NoticeWechat `yaml:"wechat" json:"wechat"`
+}
+
+type NoticeWechat struct {
+	Enable     bool              `yaml:"enable" json:"enable"`
+	AppID      string            `yaml:"appID" json:"appID"`
+	AppSecret  string            `yaml:"appSecret" json:"appSecret"`
+	TemplateID string            `yaml:"templateID" json:"templateID"`
+	Page       string            `yaml:"page" json:"page"`
+	Data       map[string]string `yaml:"data" json:"data"`
+	Endpoint   string            `yaml:"endpoint" json:"endpoint"`
+}
+
+type FieldCrypt struct {
+	Version  int             `yaml:"version" json:"version"`
+	Keys     []FieldCryptKey `yaml:"keys" json:"keys"`
+	IndexKey string          `yaml:"indexKey" json:"indexKey"`
+}
+
+type FieldCryptKey struct {
+	Version int    `yaml:"version" json:"version"`
+	Key     string `yaml:"key" json:"key"`
+}
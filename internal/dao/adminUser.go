@@ -0,0 +1,336 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
+
+	"github.com/go-dev-frame/sponge/pkg/logger"
+	"github.com/go-dev-frame/sponge/pkg/sgorm/query"
+	"github.com/go-dev-frame/sponge/pkg/utils"
+
+	"lol/internal/cache"
+	"lol/internal/database"
+	"lol/internal/model"
+)
+
+var _ AdminUserDao = (*adminUserDao)(nil)
+
+// AdminUserDao defining the dao interface
+type AdminUserDao interface {
+	Create(ctx context.Context, table *model.AdminUser) error
+	DeleteByID(ctx context.Context, id uint64) error
+	UpdateByID(ctx context.Context, table *model.AdminUser) error
+	GetByID(ctx context.Context, id uint64) (*model.AdminUser, error)
+	GetByColumns(ctx context.Context, params *query.Params) ([]*model.AdminUser, int64, error)
+
+	CreateByTx(ctx context.Context, tx *gorm.DB, table *model.AdminUser) (uint64, error)
+	DeleteByTx(ctx context.Context, tx *gorm.DB, id uint64) error
+	UpdateByTx(ctx context.Context, tx *gorm.DB, table *model.AdminUser) error
+	GetByUsername(ctx context.Context, username string) (*model.AdminUser, error)
+	GetCredentialByID(ctx context.Context, id uint64) (*model.AdminUser, error)
+	UpdatePassword(ctx context.Context, id uint64, password string) error
+	UpdateTwoFactor(ctx context.Context, table *model.AdminUser) error
+	UseTotpCounter(ctx context.Context, id uint64, counter int64) (bool, error)
+}
+
+type adminUserDao struct {
+	db    *gorm.DB
+	cache cache.AdminUserCache // if nil, the cache is not used.
+	sfg   *singleflight.Group  // if cache is nil, the sfg is not used.
+}
+
+// NewAdminUserDao creating the dao interface
+func NewAdminUserDao(db *gorm.DB, xCache cache.AdminUserCache) AdminUserDao {
+	if xCache == nil {
+		return &adminUserDao{db: db}
+	}
+	return &adminUserDao{
+		db:    db,
+		cache: xCache,
+		sfg:   new(singleflight.Group),
+	}
+}
+
+func (d *adminUserDao) deleteCache(ctx context.Context, id uint64) error {
+	if d.cache != nil {
+		return d.cache.Del(ctx, id)
+	}
+	return nil
+}
+
+// Create a record, insert the record and the id value is written back to the table
+func (d *adminUserDao) Create(ctx context.Context, table *model.AdminUser) error {
+	return d.db.WithContext(ctx).Create(table).Error
+}
+
+// DeleteByID delete a record by id
+func (d *adminUserDao) DeleteByID(ctx context.Context, id uint64) error {
+	err := d.db.WithContext(ctx).Where("id = ?", id).Delete(&model.AdminUser{}).Error
+	if err != nil {
+		return err
+	}
+
+	// delete cache
+	_ = d.deleteCache(ctx, id)
+
+	return nil
+}
+
+// UpdateByID update a record by id
+func (d *adminUserDao) UpdateByID(ctx context.Context, table *model.AdminUser) error {
+	err := d.updateDataByID(ctx, d.db, table)
+
+	// delete cache
+	_ = d.deleteCache(ctx, table.ID)
+
+	return err
+}
+
+func (d *adminUserDao) updateDataByID(ctx context.Context, db *gorm.DB, table *model.AdminUser) error {
+	if table.ID < 1 {
+		return errors.New("id cannot be 0")
+	}
+
+	update := map[string]interface{}{}
+
+	if table.Username != "" {
+		update["username"] = table.Username
+	}
+	if table.Password != "" {
+		update["password"] = table.Password
+	}
+	if table.Role != "" {
+		update["role"] = table.Role
+	}
+	if table.Status != 0 {
+		update["status"] = table.Status
+	}
+	if table.CreateAt.IsZero() == false {
+		update["create_at"] = table.CreateAt
+	}
+	if table.UpdateAt.IsZero() == false {
+		update["update_at"] = table.UpdateAt
+	}
+
+	return db.WithContext(ctx).Model(table).Updates(update).Error
+}
+
+// GetByID get a record by id
+func (d *adminUserDao) GetByID(ctx context.Context, id uint64) (*model.AdminUser, error) {
+	// no cache
+	if d.cache == nil {
+		record := &model.AdminUser{}
+		err := d.db.WithContext(ctx).Where("id = ?", id).First(record).Error
+		return record, err
+	}
+
+	// get from cache
+	record, err := d.cache.Get(ctx, id)
+	if err == nil {
+		return record, nil
+	}
+
+	// get from database
+	if errors.Is(err, database.ErrCacheNotFound) {
+		// for the same id, prevent high concurrent simultaneous access to database
+		val, err, _ := d.sfg.Do(utils.Uint64ToStr(id), func() (interface{}, error) { //nolint
+			table := &model.AdminUser{}
+			err = d.db.WithContext(ctx).Where("id = ?", id).First(table).Error
+			if err != nil {
+				if errors.Is(err, database.ErrRecordNotFound) {
+					// set placeholder cache to prevent cache penetration, default expiration time 10 minutes
+					if err = d.cache.SetPlaceholder(ctx, id); err != nil {
+						logger.Warn("cache.SetPlaceholder error", logger.Err(err), logger.Any("id", id))
+					}
+					return nil, database.ErrRecordNotFound
+				}
+				return nil, err
+			}
+			// set cache
+			if err = d.cache.Set(ctx, id, table, cache.AdminUserExpireTime); err != nil {
+				logger.Warn("cache.Set error", logger.Err(err), logger.Any("id", id))
+			}
+			return table, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		table, ok := val.(*model.AdminUser)
+		if !ok {
+			return nil, database.ErrRecordNotFound
+		}
+		return table, nil
+	}
+
+	if d.cache.IsPlaceholderErr(err) {
+		return nil, database.ErrRecordNotFound
+	}
+
+	return nil, err
+}
+
+// GetByColumns get paging records by column information,
+// Note: query performance degrades when table rows are very large because of the use of offset.
+//
+// params includes paging parameters and query parameters
+// paging parameters (required):
+//
+//	page: page number, starting from 0
+//	limit: lines per page
+//	sort: sort fields, default is id backwards, you can add - sign before the field to indicate reverse order, no - sign to indicate ascending order, multiple fields separated by comma
+//
+// query parameters (not required):
+//
+//	name: column name
+//	exp: expressions, which default is "=",  support =, !=, >, >=, <, <=, like, in, notin, isnull, isnotnull
+//	value: column value, if exp=in, multiple values are separated by commas
+//	logic: logical type, default value is "and", support &, and, ||, or
+//
+// example: search for a male over 20 years of age
+//
+//	params = &query.Params{
+//	    Page: 0,
+//	    Limit: 20,
+//	    Columns: []query.Column{
+//		{
+//			Name:    "age",
+//			Exp: ">",
+//			Value:   20,
+//		},
+//		{
+//			Name:  "gender",
+//			Value: "male",
+//		},
+//	}
+func (d *adminUserDao) GetByColumns(ctx context.Context, params *query.Params) ([]*model.AdminUser, int64, error) {
+	queryStr, args, err := params.ConvertToGormConditions()
+	if err != nil {
+		return nil, 0, errors.New("query params error: " + err.Error())
+	}
+
+	var total int64
+	if params.Sort != "ignore count" { // determine if count is required
+		err = d.db.WithContext(ctx).Model(&model.AdminUser{}).Where(queryStr, args...).Count(&total).Error
+		if err != nil {
+			return nil, 0, err
+		}
+		if total == 0 {
+			return nil, total, nil
+		}
+	}
+
+	records := []*model.AdminUser{}
+	order, limit, offset := params.ConvertToPage()
+	err = d.db.WithContext(ctx).Order(order).Limit(limit).Offset(offset).Where(queryStr, args...).Find(&records).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return records, total, err
+}
+
+// CreateByTx create a record in the database using the provided transaction
+func (d *adminUserDao) CreateByTx(ctx context.Context, tx *gorm.DB, table *model.AdminUser) (uint64, error) {
+	err := tx.WithContext(ctx).Create(table).Error
+	return table.ID, err
+}
+
+// DeleteByTx delete a record by id in the database using the provided transaction
+func (d *adminUserDao) DeleteByTx(ctx context.Context, tx *gorm.DB, id uint64) error {
+	err := tx.WithContext(ctx).Where("id = ?", id).Delete(&model.AdminUser{}).Error
+	if err != nil {
+		return err
+	}
+
+	// delete cache
+	_ = d.deleteCache(ctx, id)
+
+	return nil
+}
+
+// UpdateByTx update a record by id in the database using the provided transaction
+func (d *adminUserDao) UpdateByTx(ctx context.Context, tx *gorm.DB, table *model.AdminUser) error {
+	err := d.updateDataByID(ctx, tx, table)
+
+	// delete cache
+	_ = d.deleteCache(ctx, table.ID)
+
+	return err
+}
+
+// GetByUsername get a record by username, the password is only loaded from database
+func (d *adminUserDao) GetByUsername(ctx context.Context, username string) (*model.AdminUser, error) {
+	record := &model.AdminUser{}
+	err := d.db.WithContext(ctx).Where("username = ?", username).First(record).Error
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// GetCredentialByID get a record with the password and two-factor secrets by id,
+// they are not cached, so the record is always loaded from database
+func (d *adminUserDao) GetCredentialByID(ctx context.Context, id uint64) (*model.AdminUser, error) {
+	record := &model.AdminUser{}
+	err := d.db.WithContext(ctx).Where("id = ?", id).First(record).Error
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// UpdatePassword update the password by id, the admin user no longer has to change it
+func (d *adminUserDao) UpdatePassword(ctx context.Context, id uint64, password string) error {
+	if id < 1 {
+		return errors.New("id cannot be 0")
+	}
+
+	err := d.db.WithContext(ctx).Model(&model.AdminUser{ID: id}).Updates(map[string]interface{}{
+		"password":             password,
+		"must_change_password": false,
+		"update_at":            time.Now(),
+	}).Error
+
+	// delete cache
+	_ = d.deleteCache(ctx, id)
+
+	return err
+}
+
+// UpdateTwoFactor update the two-factor columns by id, empty values are written as well
+func (d *adminUserDao) UpdateTwoFactor(ctx context.Context, table *model.AdminUser) error {
+	if table.ID < 1 {
+		return errors.New("id cannot be 0")
+	}
+
+	err := d.db.WithContext(ctx).Model(table).Updates(map[string]interface{}{
+		"totp_secret":    table.TotpSecret,
+		"totp_enabled":   table.TotpEnabled,
+		"recovery_codes": table.RecoveryCodes,
+	}).Error
+
+	// delete cache
+	_ = d.deleteCache(ctx, table.ID)
+
+	return err
+}
+
+// UseTotpCounter save the time step of an accepted two-factor code by id, false is returned if the same or
+// a later time step was used before, so concurrent requests with the same code are accepted only once
+func (d *adminUserDao) UseTotpCounter(ctx context.Context, id uint64, counter int64) (bool, error) {
+	if id < 1 {
+		return false, errors.New("id cannot be 0")
+	}
+
+	result := d.db.WithContext(ctx).Model(&model.AdminUser{}).
+		Where("id = ? AND totp_counter < ?", id, counter).
+		Update("totp_counter", counter)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
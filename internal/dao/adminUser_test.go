@@ -0,0 +1,263 @@
+package dao
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-dev-frame/sponge/pkg/gotest"
+	"github.com/go-dev-frame/sponge/pkg/sgorm/query"
+	"github.com/go-dev-frame/sponge/pkg/utils"
+	"github.com/stretchr/testify/assert"
+
+	"lol/internal/cache"
+	"lol/internal/database"
+	"lol/internal/model"
+)
+
+func newAdminUserDao() *gotest.Dao {
+	testData := &model.AdminUser{}
+	testData.ID = 1
+	// you can set the other fields of testData here, such as:
+	//testData.CreatedAt = time.Now()
+	//testData.UpdatedAt = testData.CreatedAt
+
+	// init mock cache
+	//c := gotest.NewCache(map[string]interface{}{"no cache": testData}) // to test mysql, disable caching
+	c := gotest.NewCache(map[string]interface{}{utils.Uint64ToStr(testData.ID): testData})
+	c.ICache = cache.NewAdminUserCache(&database.CacheType{
+		CType: "redis",
+		Rdb:   c.RedisClient,
+	})
+
+	// init mock dao
+	d := gotest.NewDao(c, testData)
+	d.IDao = NewAdminUserDao(d.DB, c.ICache.(cache.AdminUserCache))
+
+	return d
+}
+
+func Test_adminUserDao_Create(t *testing.T) {
+	d := newAdminUserDao()
+	defer d.Close()
+	testData := d.TestData.(*model.AdminUser)
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("INSERT INTO .*").
+		WithArgs(d.GetAnyArgs(testData)...).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(AdminUserDao).Create(d.Ctx, testData)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_adminUserDao_DeleteByID(t *testing.T) {
+	d := newAdminUserDao()
+	defer d.Close()
+	testData := d.TestData.(*model.AdminUser)
+	expectedSQLForDeletion := "DELETE .*"
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec(expectedSQLForDeletion).
+		WithArgs(testData.ID).
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(AdminUserDao).DeleteByID(d.Ctx, testData.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// zero id error
+	err = d.IDao.(AdminUserDao).DeleteByID(d.Ctx, 0)
+	assert.Error(t, err)
+}
+
+func Test_adminUserDao_UpdateByID(t *testing.T) {
+	d := newAdminUserDao()
+	defer d.Close()
+	testData := d.TestData.(*model.AdminUser)
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WithArgs(d.AnyTime, testData.ID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(AdminUserDao).UpdateByID(d.Ctx, testData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// zero id error
+	err = d.IDao.(AdminUserDao).UpdateByID(d.Ctx, &model.AdminUser{})
+	assert.Error(t, err)
+
+}
+
+func Test_adminUserDao_GetByID(t *testing.T) {
+	d := newAdminUserDao()
+	defer d.Close()
+	testData := d.TestData.(*model.AdminUser)
+
+	// column names and corresponding data
+	rows := sqlmock.NewRows([]string{"id"}).
+		AddRow(testData.ID)
+
+	d.SQLMock.ExpectQuery("SELECT .*").
+		WithArgs(testData.ID).
+		WillReturnRows(rows)
+
+	_, err := d.IDao.(AdminUserDao).GetByID(d.Ctx, testData.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = d.SQLMock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// notfound error
+	d.SQLMock.ExpectQuery("SELECT .*").
+		WithArgs(2).
+		WillReturnRows(rows)
+	_, err = d.IDao.(AdminUserDao).GetByID(d.Ctx, 2)
+	assert.Error(t, err)
+
+	d.SQLMock.ExpectQuery("SELECT .*").
+		WithArgs(3, 4).
+		WillReturnRows(rows)
+	_, err = d.IDao.(AdminUserDao).GetByID(d.Ctx, 4)
+	assert.Error(t, err)
+}
+
+func Test_adminUserDao_GetByColumns(t *testing.T) {
+	d := newAdminUserDao()
+	defer d.Close()
+	testData := d.TestData.(*model.AdminUser)
+
+	// column names and corresponding data
+	rows := sqlmock.NewRows([]string{"id"}).
+		AddRow(testData.ID)
+
+	d.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(rows)
+
+	_, _, err := d.IDao.(AdminUserDao).GetByColumns(d.Ctx, &query.Params{
+		Page:  0,
+		Limit: 10,
+		Sort:  "ignore count", // ignore test count(*)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = d.SQLMock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// err test
+	_, _, err = d.IDao.(AdminUserDao).GetByColumns(d.Ctx, &query.Params{
+		Page:  0,
+		Limit: 10,
+		Columns: []query.Column{
+			{
+				Name:  "id",
+				Exp:   "<",
+				Value: 0,
+			},
+		},
+	})
+	assert.Error(t, err)
+
+	// error test
+	dao := &adminUserDao{}
+	_, _, err = dao.GetByColumns(context.Background(), &query.Params{Columns: []query.Column{{}}})
+	t.Log(err)
+}
+
+func Test_adminUserDao_CreateByTx(t *testing.T) {
+	d := newAdminUserDao()
+	defer d.Close()
+	testData := d.TestData.(*model.AdminUser)
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("INSERT INTO .*").
+		WithArgs(d.GetAnyArgs(testData)...).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	d.SQLMock.ExpectCommit()
+
+	_, err := d.IDao.(AdminUserDao).CreateByTx(d.Ctx, d.DB, testData)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_adminUserDao_DeleteByTx(t *testing.T) {
+	d := newAdminUserDao()
+	defer d.Close()
+	testData := d.TestData.(*model.AdminUser)
+	expectedSQLForDeletion := "DELETE .*"
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec(expectedSQLForDeletion).
+		WithArgs(testData.ID).
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(AdminUserDao).DeleteByTx(d.Ctx, d.DB, testData.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_adminUserDao_UpdateByTx(t *testing.T) {
+	d := newAdminUserDao()
+	defer d.Close()
+	testData := d.TestData.(*model.AdminUser)
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WithArgs(d.AnyTime, testData.ID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(AdminUserDao).UpdateByTx(d.Ctx, d.DB, testData)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_adminUserDao_UseTotpCounter(t *testing.T) {
+	d := newAdminUserDao()
+	defer d.Close()
+	testData := d.TestData.(*model.AdminUser)
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	d.SQLMock.ExpectCommit()
+
+	ok, err := d.IDao.(AdminUserDao).UseTotpCounter(d.Ctx, testData.ID, 56666666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, ok)
+
+	// the code was used before
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(1, 0))
+	d.SQLMock.ExpectCommit()
+
+	ok, err = d.IDao.(AdminUserDao).UseTotpCounter(d.Ctx, testData.ID, 56666666)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	_, err = d.IDao.(AdminUserDao).UseTotpCounter(d.Ctx, 0, 56666666)
+	assert.Error(t, err)
+}
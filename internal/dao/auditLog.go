@@ -0,0 +1,56 @@
+package dao
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/go-dev-frame/sponge/pkg/sgorm/query"
+
+	"lol/internal/model"
+)
+
+var _ AuditLogDao = (*auditLogDao)(nil)
+
+// AuditLogDao defining the dao interface, the audit logs are written by the audit plugin of gorm and only read here
+type AuditLogDao interface {
+	GetByColumns(ctx context.Context, params *query.Params) ([]*model.AuditLog, int64, error)
+}
+
+type auditLogDao struct {
+	db *gorm.DB
+}
+
+// NewAuditLogDao creating the dao interface
+func NewAuditLogDao(db *gorm.DB) AuditLogDao {
+	return &auditLogDao{db: db}
+}
+
+// GetByColumns get paging records by column information, see smsTemplateDao.GetByColumns for the params
+func (d *auditLogDao) GetByColumns(ctx context.Context, params *query.Params) ([]*model.AuditLog, int64, error) {
+	queryStr, args, err := params.ConvertToGormConditions()
+	if err != nil {
+		return nil, 0, errors.New("query params error: " + err.Error())
+	}
+
+	var total int64
+	if params.Sort != "ignore count" { // determine if count is required
+		err = d.db.WithContext(ctx).Model(&model.AuditLog{}).Where(queryStr, args...).Count(&total).Error
+		if err != nil {
+			return nil, 0, err
+		}
+		if total == 0 {
+			return nil, total, nil
+		}
+	}
+
+	records := []*model.AuditLog{}
+	order, limit, offset := params.ConvertToPage()
+	err = d.db.WithContext(ctx).Order(order).Limit(limit).Offset(offset).Where(queryStr, args...).Find(&records).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return records, total, err
+}
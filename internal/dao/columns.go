@@ -0,0 +1,11 @@
+package dao
+
+// columnSet the set of the columns an update writes even if their values are zero,
+// the other columns are written only if their values are not zero
+func columnSet(columns []string) map[string]bool {
+	set := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		set[column] = true
+	}
+	return set
+}
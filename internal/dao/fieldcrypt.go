@@ -0,0 +1,79 @@
+package dao
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-dev-frame/sponge/pkg/sgorm/query"
+
+	"lol/internal/fieldcrypt"
+	"lol/internal/model"
+)
+
+// the encrypted columns that can be filtered by exact value, and the kinds of their blind indexes,
+// the blind index of a column is in the column named with the suffix _index.
+var (
+	loanIndexColumns = map[string]string{
+		"name":    fieldcrypt.IndexName,
+		"user_id": fieldcrypt.IndexUserID,
+		"mobile":  fieldcrypt.IndexMobile,
+	}
+	paymentHistoryIndexColumns = map[string]string{
+		"user_phone": fieldcrypt.IndexMobile,
+	}
+	smsHistoryIndexColumns = map[string]string{
+		"mobile": fieldcrypt.IndexMobile,
+	}
+)
+
+// setLoanIndexes fill the blind indexes of the encrypted columns before the loan is created
+func setLoanIndexes(table *model.Loan) {
+	table.NameIndex = fieldcrypt.BlindIndex(fieldcrypt.IndexName, table.Name)
+	table.UserIDIndex = fieldcrypt.BlindIndex(fieldcrypt.IndexUserID, table.UserID)
+	table.UserCodeIndex = fieldcrypt.BlindIndex(fieldcrypt.IndexUserCode, fieldcrypt.UserCode(table.UserID))
+	table.MobileIndex = fieldcrypt.BlindIndex(fieldcrypt.IndexMobile, table.Mobile)
+}
+
+// setPaymentHistoryIndexes fill the blind indexes of the encrypted columns before the payment history is created
+func setPaymentHistoryIndexes(table *model.PaymentHistory) {
+	table.UserPhoneIndex = fieldcrypt.BlindIndex(fieldcrypt.IndexMobile, table.UserPhone)
+}
+
+// setSmsHistoryIndexes fill the blind indexes of the encrypted columns before the sms history is created
+func setSmsHistoryIndexes(table *model.SmsHistory) {
+	table.MobileIndex = fieldcrypt.BlindIndex(fieldcrypt.IndexMobile, table.Mobile)
+}
+
+// setEncrypted add the encrypted value of the column and its blind index to the update map,
+// gorm does not apply the serializer of the field to map updates.
+func setEncrypted(update map[string]interface{}, column string, kind string, value string) error {
+	encrypted, err := fieldcrypt.Encrypt(value)
+	if err != nil {
+		return err
+	}
+	update[column] = encrypted
+	update[column+"_index"] = fieldcrypt.BlindIndex(kind, value)
+	return nil
+}
+
+// convertEncryptedColumns replace the conditions on encrypted columns with conditions on their blind indexes,
+// only = is supported since the values are encrypted. params is not modified.
+func convertEncryptedColumns(params *query.Params, kinds map[string]string) (*query.Params, error) {
+	converted := *params
+	converted.Columns = make([]query.Column, len(params.Columns))
+	for i, column := range params.Columns {
+		converted.Columns[i] = column
+		kind, ok := kinds[column.Name]
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(column.Exp) {
+		case "", "eq", "=":
+		default:
+			return nil, fmt.Errorf("column %s is encrypted, only = is supported", column.Name)
+		}
+		converted.Columns[i].Name = column.Name + "_index"
+		converted.Columns[i].Value = fieldcrypt.BlindIndex(kind, fmt.Sprint(column.Value))
+	}
+	return &converted, nil
+}
@@ -0,0 +1,31 @@
+package dao
+
+import (
+	"testing"
+
+	"github.com/go-dev-frame/sponge/pkg/sgorm/query"
+	"github.com/stretchr/testify/assert"
+
+	"lol/internal/fieldcrypt"
+)
+
+func Test_convertEncryptedColumns(t *testing.T) {
+	params := &query.Params{
+		Page:  0,
+		Limit: 10,
+		Columns: []query.Column{
+			{Name: "mobile", Value: "13800000000"},
+			{Name: "status", Value: 1},
+		},
+	}
+	converted, err := convertEncryptedColumns(params, loanIndexColumns)
+	assert.NoError(t, err)
+	assert.Equal(t, "mobile_index", converted.Columns[0].Name)
+	assert.Equal(t, fieldcrypt.BlindIndex(fieldcrypt.IndexMobile, "13800000000"), converted.Columns[0].Value)
+	assert.Equal(t, params.Columns[1], converted.Columns[1])
+	assert.Equal(t, "mobile", params.Columns[0].Name)
+
+	params.Columns[0].Exp = "like"
+	_, err = convertEncryptedColumns(params, loanIndexColumns)
+	assert.Error(t, err)
+}
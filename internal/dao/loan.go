@@ -16,26 +16,38 @@ import (
 
 	"lol/internal/cache"
 	"lol/internal/database"
+	"lol/internal/fieldcrypt"
 	"lol/internal/model"
+	"lol/internal/repayment"
 )
 
 var _ LoanDao = (*loanDao)(nil)
 
+// ErrLoanVersionConflict the loan has been updated by others since its version was read
+var ErrLoanVersionConflict = errors.New("loan version conflict")
+
 // LoanDao defining the dao interface
 type LoanDao interface {
 	Create(ctx context.Context, table *model.Loan) error
 	DeleteByID(ctx context.Context, id uint64) error
-	UpdateByID(ctx context.Context, table *model.Loan) error
+	UpdateByID(ctx context.Context, table *model.Loan, columns ...string) error
 	GetByID(ctx context.Context, id uint64) (*model.Loan, error)
 	GetByColumns(ctx context.Context, params *query.Params) ([]*model.Loan, int64, error)
+	GetDeletedByColumns(ctx context.Context, params *query.Params) ([]*model.Loan, int64, error)
+	RestoreByID(ctx context.Context, id uint64) error
+	PurgeByID(ctx context.Context, id uint64) error
 
 	CreateByTx(ctx context.Context, tx *gorm.DB, table *model.Loan) (uint64, error)
 	DeleteByTx(ctx context.Context, tx *gorm.DB, id uint64) error
-	UpdateByTx(ctx context.Context, tx *gorm.DB, table *model.Loan) error
-	GetByMobileAndCode(ctx context.Context, mobile string, code string) (*model.Loan, error)
+	UpdateByTx(ctx context.Context, tx *gorm.DB, table *model.Loan, columns ...string) error
+	GetByMobileAndCode(ctx context.Context, mobile string, code string) ([]*model.Loan, error)
+	GetByMobile(ctx context.Context, mobile string) ([]*model.Loan, error)
+	GetUnsettled(ctx context.Context) ([]*model.Loan, error)
+	GetWithRepayment(ctx context.Context, id uint64) (*model.Loan, error)
+	GetPaymentByTradeNo(ctx context.Context, tradeNo string) (*model.PaymentHistory, error)
 	CreatePaymentHistory(ctx context.Context, table *model.PaymentHistory) error
 	UpdatePaymentStatusByTradeNo(ctx context.Context, tradeNo string, status string) error
-	getSuccessPaymentHistory(ctx context.Context, mobile string) ([]*model.PaymentHistory, error)
+	SettlePaymentByTradeNo(ctx context.Context, tradeNo string) error
 }
 
 type loanDao struct {
@@ -65,6 +77,7 @@ func (d *loanDao) deleteCache(ctx context.Context, id uint64) error {
 
 // Create a record, insert the record and the id value is written back to the table
 func (d *loanDao) Create(ctx context.Context, table *model.Loan) error {
+	setLoanIndexes(table)
 	return d.db.WithContext(ctx).Create(table).Error
 }
 
@@ -81,9 +94,11 @@ func (d *loanDao) DeleteByID(ctx context.Context, id uint64) error {
 	return nil
 }
 
-// UpdateByID update a record by id
-func (d *loanDao) UpdateByID(ctx context.Context, table *model.Loan) error {
-	err := d.updateDataByID(ctx, d.db, table)
+// UpdateByID update a record by id, the non-zero fields and the columns are written. table.Version is the version
+// the record was read with, ErrLoanVersionConflict is returned if the record has been updated since,
+// the version is incremented on success
+func (d *loanDao) UpdateByID(ctx context.Context, table *model.Loan, columns ...string) error {
+	err := d.updateDataByID(ctx, d.db, table, columns)
 
 	// delete cache
 	_ = d.deleteCache(ctx, table.ID)
@@ -91,48 +106,83 @@ func (d *loanDao) UpdateByID(ctx context.Context, table *model.Loan) error {
 	return err
 }
 
-func (d *loanDao) updateDataByID(ctx context.Context, db *gorm.DB, table *model.Loan) error {
+func (d *loanDao) updateDataByID(ctx context.Context, db *gorm.DB, table *model.Loan, columns []string) error {
 	if table.ID < 1 {
 		return errors.New("id cannot be 0")
 	}
 
 	update := map[string]interface{}{}
+	written := columnSet(columns)
 
-	if table.Name != "" {
-		update["name"] = table.Name
+	if table.Name != "" || written["name"] {
+		if err := setEncrypted(update, "name", fieldcrypt.IndexName, table.Name); err != nil {
+			return err
+		}
 	}
-	if table.UserID != "" {
-		update["user_id"] = table.UserID
+	if table.UserID != "" || written["user_id"] {
+		if err := setEncrypted(update, "user_id", fieldcrypt.IndexUserID, table.UserID); err != nil {
+			return err
+		}
+		update["user_code_index"] = fieldcrypt.BlindIndex(fieldcrypt.IndexUserCode, fieldcrypt.UserCode(table.UserID))
 	}
-	if table.Mobile != "" {
-		update["mobile"] = table.Mobile
+	if table.Mobile != "" || written["mobile"] {
+		if err := setEncrypted(update, "mobile", fieldcrypt.IndexMobile, table.Mobile); err != nil {
+			return err
+		}
 	}
-	if table.CarModel != "" {
+	if table.CarModel != "" || written["car_model"] {
 		update["car_model"] = table.CarModel
 	}
-	if table.CarPlate != "" {
+	if table.CarPlate != "" || written["car_plate"] {
 		update["car_plate"] = table.CarPlate
 	}
-	if table.LoanMoney != 0 {
+	if table.LoanMoney != 0 || written["loan_money"] {
 		update["loan_money"] = table.LoanMoney
 	}
-	if table.LoanPeriod != 0 {
+	if table.LoanPeriod != 0 || written["loan_period"] {
 		update["loan_period"] = table.LoanPeriod
 	}
-	if table.LoanReturnDate != "" {
+	if table.LoanReturnDate != "" || written["loan_return_date"] {
 		update["loan_return_date"] = table.LoanReturnDate
 	}
-	if table.MonthlyPayment != 0 {
+	if table.MonthlyPayment != 0 || written["monthly_payment"] {
 		update["monthly_payment"] = table.MonthlyPayment
 	}
-	if table.CreateAt.IsZero() == false {
+	if table.CreateAt != nil && !table.CreateAt.IsZero() {
 		update["create_at"] = table.CreateAt
 	}
-	if table.Status != 0 {
+	if table.Status != 0 || written["status"] {
 		update["status"] = table.Status
 	}
+	if table.AnnualRate != 0 || written["annual_rate"] {
+		update["annual_rate"] = table.AnnualRate
+	}
+	if table.RepaymentMethod != "" || written["repayment_method"] {
+		update["repayment_method"] = table.RepaymentMethod
+	}
+	if table.BalloonRatio != 0 || written["balloon_ratio"] {
+		update["balloon_ratio"] = table.BalloonRatio
+	}
+	update["version"] = gorm.Expr("version + 1")
 
-	return db.WithContext(ctx).Model(table).Updates(update).Error
+	result := db.WithContext(ctx).Model(table).Where("version = ?", table.Version).Updates(update)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		var count int64
+		err := db.WithContext(ctx).Model(&model.Loan{}).Where("id = ?", table.ID).Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return database.ErrRecordNotFound
+		}
+		return ErrLoanVersionConflict
+	}
+	table.Version++
+
+	return nil
 }
 
 // GetByID get a record by id
@@ -223,6 +273,10 @@ func (d *loanDao) GetByID(ctx context.Context, id uint64) (*model.Loan, error) {
 //		},
 //	}
 func (d *loanDao) GetByColumns(ctx context.Context, params *query.Params) ([]*model.Loan, int64, error) {
+	params, err := convertEncryptedColumns(params, loanIndexColumns)
+	if err != nil {
+		return nil, 0, errors.New("query params error: " + err.Error())
+	}
 	queryStr, args, err := params.ConvertToGormConditions()
 	if err != nil {
 		return nil, 0, errors.New("query params error: " + err.Error())
@@ -249,8 +303,44 @@ func (d *loanDao) GetByColumns(ctx context.Context, params *query.Params) ([]*mo
 	return records, total, err
 }
 
+// GetDeletedByColumns get paging soft deleted records by column information, the params are as GetByColumns
+func (d *loanDao) GetDeletedByColumns(ctx context.Context, params *query.Params) ([]*model.Loan, int64, error) {
+	params, err := convertEncryptedColumns(params, loanIndexColumns)
+	if err != nil {
+		return nil, 0, errors.New("query params error: " + err.Error())
+	}
+	return getDeletedByColumns[model.Loan](ctx, d.db, params)
+}
+
+// RestoreByID restore a soft deleted record by id
+func (d *loanDao) RestoreByID(ctx context.Context, id uint64) error {
+	err := restoreByID[model.Loan](ctx, d.db, id)
+	if err != nil {
+		return err
+	}
+
+	// delete cache
+	_ = d.deleteCache(ctx, id)
+
+	return nil
+}
+
+// PurgeByID delete a soft deleted record permanently by id
+func (d *loanDao) PurgeByID(ctx context.Context, id uint64) error {
+	err := purgeByID[model.Loan](ctx, d.db, id)
+	if err != nil {
+		return err
+	}
+
+	// delete cache
+	_ = d.deleteCache(ctx, id)
+
+	return nil
+}
+
 // CreateByTx create a record in the database using the provided transaction
 func (d *loanDao) CreateByTx(ctx context.Context, tx *gorm.DB, table *model.Loan) (uint64, error) {
+	setLoanIndexes(table)
 	err := tx.WithContext(ctx).Create(table).Error
 	return table.ID, err
 }
@@ -268,9 +358,9 @@ func (d *loanDao) DeleteByTx(ctx context.Context, tx *gorm.DB, id uint64) error
 	return nil
 }
 
-// UpdateByTx update a record by id in the database using the provided transaction
-func (d *loanDao) UpdateByTx(ctx context.Context, tx *gorm.DB, table *model.Loan) error {
-	err := d.updateDataByID(ctx, tx, table)
+// UpdateByTx update a record by id in the database using the provided transaction, the version is checked as UpdateByID
+func (d *loanDao) UpdateByTx(ctx context.Context, tx *gorm.DB, table *model.Loan, columns ...string) error {
+	err := d.updateDataByID(ctx, tx, table, columns)
 
 	// delete cache
 	_ = d.deleteCache(ctx, table.ID)
@@ -278,39 +368,111 @@ func (d *loanDao) UpdateByTx(ctx context.Context, tx *gorm.DB, table *model.Loan
 	return err
 }
 
-// GetByMobileAndCode 根据手机号和代码获取贷款记录并计算逾期信息
-func (d *loanDao) GetByMobileAndCode(ctx context.Context, mobile string, code string) (*model.Loan, error) {
+// GetByMobileAndCode 根据手机号和身份证后六位获取借款人的借款记录，用于登录时核对借款人身份
+func (d *loanDao) GetByMobileAndCode(ctx context.Context, mobile string, code string) ([]*model.Loan, error) {
+	var loanRecords []*model.Loan
+	if err := d.db.WithContext(ctx).Where("mobile_index = ? AND user_code_index = ?",
+		fieldcrypt.BlindIndex(fieldcrypt.IndexMobile, mobile), fieldcrypt.BlindIndex(fieldcrypt.IndexUserCode, code)).
+		Order("id").Find(&loanRecords).Error; err != nil {
+		return nil, err
+	}
+	if len(loanRecords) == 0 {
+		return nil, database.ErrRecordNotFound
+	}
+	return loanRecords, nil
+}
+
+// GetByMobile 根据手机号获取借款人的所有借款记录，按创建顺序排列，未还清的借款计算还款和逾期信息
+func (d *loanDao) GetByMobile(ctx context.Context, mobile string) ([]*model.Loan, error) {
 	// 查询贷款记录
+	var loanRecords []*model.Loan
+	if err := d.db.WithContext(ctx).Where("mobile_index = ?", fieldcrypt.BlindIndex(fieldcrypt.IndexMobile, mobile)).Order("id").Find(&loanRecords).Error; err != nil {
+		return nil, err
+	}
+	if len(loanRecords) == 0 {
+		return nil, database.ErrRecordNotFound
+	}
+
+	for i, loanRecord := range loanRecords {
+		//表示还款完成
+		if loanRecord.Status == 1 {
+			//表示已经处理完毕 无需用户还款了
+			continue
+		}
+		// 未关联借款的旧订单都属于借款人的第一笔借款
+		if err := d.fillRepayment(ctx, loanRecord, i == 0); err != nil {
+			return nil, err
+		}
+	}
+
+	return loanRecords, nil
+}
+
+// GetUnsettled 获取所有未还清的借款，按创建顺序排列，并计算还款和逾期信息
+func (d *loanDao) GetUnsettled(ctx context.Context) ([]*model.Loan, error) {
+	var loanRecords []*model.Loan
+	if err := d.db.WithContext(ctx).Where("status != ?", 1).Order("id").Find(&loanRecords).Error; err != nil {
+		return nil, err
+	}
+
+	for _, loanRecord := range loanRecords {
+		// 未关联借款的旧订单都属于借款人的第一笔借款
+		isFirst, err := d.isFirstLoan(ctx, loanRecord)
+		if err != nil {
+			return nil, err
+		}
+		if err = d.fillRepayment(ctx, loanRecord, isFirst); err != nil {
+			return nil, err
+		}
+	}
+
+	return loanRecords, nil
+}
+
+// GetWithRepayment 根据id获取借款，未还清的借款计算还款和逾期信息
+func (d *loanDao) GetWithRepayment(ctx context.Context, id uint64) (*model.Loan, error) {
 	loanRecord := &model.Loan{}
-	if err := d.db.WithContext(ctx).Where("mobile = ? AND RIGHT(user_id, 6) = ?", mobile, code).First(loanRecord).Error; err != nil {
+	if err := d.db.WithContext(ctx).Where("id = ?", id).First(loanRecord).Error; err != nil {
 		return nil, err
 	}
-	//表示还款完成
 	if loanRecord.Status == 1 {
-		//表示已经处理完毕 无需用户还款了
 		return loanRecord, nil
 	}
-	// 查询支付成功的历史记录
-	paymentHistoryRecords, err := d.getSuccessPaymentHistory(ctx, mobile)
+
+	isFirst, err := d.isFirstLoan(ctx, loanRecord)
 	if err != nil {
 		return nil, err
 	}
+	if err = d.fillRepayment(ctx, loanRecord, isFirst); err != nil {
+		return nil, err
+	}
+	return loanRecord, nil
+}
+
+// fillRepayment 根据支付成功订单的汇总计算借款的已还、剩余金额和逾期信息
+func (d *loanDao) fillRepayment(ctx context.Context, loanRecord *model.Loan, withLegacy bool) error {
+	summary, err := d.getPaymentSummary(ctx, loanRecord, withLegacy)
+	if err != nil {
+		return err
+	}
 
-	// 处理支付成功记录
-	paidSuccessLength := len(paymentHistoryRecords)
-	loanRecord.PaidCount = paidSuccessLength
+	// 按期数从早到晚分配已还金额
+	schedule := repayment.NewLoanSchedule(loanRecord)
+	schedule.Allocate(paidMoney(loanRecord, summary))
+	loanRecord.PaidMoney = schedule.Paid()
+	loanRecord.RemainingMoney = schedule.Outstanding()
+	loanRecord.PaidCount = schedule.PaidCount()
 
 	overdueDays := 0
 	var lastPayDate time.Time
 
-	if paidSuccessLength > 0 {
-		lastPayRecord := paymentHistoryRecords[paidSuccessLength-1]
-		if lastPayRecord.CreateAt == nil {
+	if summary.PaidCount > 0 {
+		if summary.LastPaidAt == nil {
 			// 避免空指针解引用
-			logger.Errorf("lastPayRecord.CreateAt is nil for mobile: %s", mobile)
-			return nil, nil
+			logger.Errorf("the create time of the payments is nil for loan: %d", loanRecord.ID)
+			return nil
 		}
-		lastRepaymentDate := *lastPayRecord.CreateAt
+		lastRepaymentDate := *summary.LastPaidAt
 		lastPayDate = lastRepaymentDate
 
 		// 计算逾期天数 应还日期和当前日期做比较
@@ -321,16 +483,93 @@ func (d *loanDao) GetByMobileAndCode(ctx context.Context, mobile string, code st
 	loanRecord.LastPayDate = lastPayDate
 	loanRecord.OverDueMoney = overdueDays * 100
 
-	return loanRecord, nil
+	return nil
 }
 
-// getPaymentHistory 查询支付成功的历史记录
-func (d *loanDao) getSuccessPaymentHistory(ctx context.Context, mobile string) ([]*model.PaymentHistory, error) {
-	var paymentHistoryRecords []*model.PaymentHistory
-	if err := d.db.Model(&model.PaymentHistory{}).WithContext(ctx).Where("user_phone = ? AND status = 'SUCCESS'", mobile).Find(&paymentHistoryRecords).Error; err != nil {
+// getPaymentSummary 汇总借款支付成功的订单，withLegacy时包含未关联借款、按手机号匹配的旧订单。
+// 汇总结果会缓存，借款的订单变化时删除，见publishPaymentChanged
+func (d *loanDao) getPaymentSummary(ctx context.Context, loan *model.Loan, withLegacy bool) (*model.LoanPaymentSummary, error) {
+	if d.cache != nil {
+		summary, err := d.cache.GetSummary(ctx, loan.ID)
+		if err == nil && summary != nil {
+			return summary, nil
+		}
+	}
+
+	db := d.db.Model(&model.PaymentHistory{}).WithContext(ctx)
+	if withLegacy {
+		db = db.Where("(loan_id = ? OR (loan_id = 0 AND user_phone_index = ?)) AND status = 'SUCCESS'",
+			loan.ID, fieldcrypt.BlindIndex(fieldcrypt.IndexMobile, loan.Mobile))
+	} else {
+		db = db.Where("loan_id = ? AND status = 'SUCCESS'", loan.ID)
+	}
+
+	summary := &model.LoanPaymentSummary{}
+	err := db.Select("COUNT(*) AS paid_count, " +
+		"COALESCE(SUM(CASE WHEN amount > 0 THEN 0 ELSE 1 END), 0) AS legacy_count, " +
+		"COALESCE(SUM(CASE WHEN amount > 0 THEN amount ELSE 0 END), 0) AS paid_amount, " +
+		"MAX(create_at) AS last_paid_at").Scan(summary).Error
+	if err != nil {
 		return nil, err
 	}
-	return paymentHistoryRecords, nil
+	summary.LoanID = loan.ID
+
+	if d.cache != nil {
+		if err = d.cache.SetSummary(ctx, loan.ID, summary, cache.LoanSummaryExpireTime); err != nil {
+			logger.Warn("cache.SetSummary error", logger.Err(err), logger.Uint64("loanID", loan.ID))
+		}
+	}
+	return summary, nil
+}
+
+// publishPaymentChanged 删除订单及其所属借款、支付汇总的缓存，并广播给其他副本，订单新增、修改、删除后调用，
+// 未关联借款的旧订单属于该手机号的第一笔借款
+func publishPaymentChanged(ctx context.Context, db *gorm.DB, payments ...*model.PaymentHistory) {
+	invalidator := cache.GetInvalidator()
+	if invalidator == nil {
+		return
+	}
+	event := cache.PaymentChanged{}
+	for _, payment := range payments {
+		if payment.ID > 0 {
+			event.PaymentIDs = append(event.PaymentIDs, payment.ID)
+		}
+		if payment.LoanID > 0 {
+			event.LoanIDs = append(event.LoanIDs, payment.LoanID)
+			continue
+		}
+		if payment.UserPhoneIndex == "" {
+			continue
+		}
+		first := &model.Loan{}
+		err := db.WithContext(ctx).Select("id").Where("mobile_index = ?", payment.UserPhoneIndex).Order("id").First(first).Error
+		if err != nil {
+			if !errors.Is(err, database.ErrRecordNotFound) {
+				logger.Warn("get the first loan of the payment error", logger.Err(err), logger.Uint64("paymentID", payment.ID))
+			}
+			continue
+		}
+		event.LoanIDs = append(event.LoanIDs, first.ID)
+	}
+	if err := invalidator.Publish(ctx, event); err != nil {
+		logger.Warn("publish the cache invalidation error", logger.Err(err), logger.Any("event", event))
+	}
+}
+
+// isFirstLoan 是否该手机号的第一笔借款
+func (d *loanDao) isFirstLoan(ctx context.Context, loan *model.Loan) (bool, error) {
+	first := &model.Loan{}
+	err := d.db.WithContext(ctx).Select("id").Where("mobile_index = ?", fieldcrypt.BlindIndex(fieldcrypt.IndexMobile, loan.Mobile)).
+		Order("id").First(first).Error
+	if err != nil {
+		return false, err
+	}
+	return first.ID == loan.ID, nil
+}
+
+// paidMoney 累计已还金额，旧订单没有记录金额，每笔按一期月租计算
+func paidMoney(loan *model.Loan, summary *model.LoanPaymentSummary) float64 {
+	return summary.PaidAmount + float64(summary.LegacyCount)*loan.MonthlyPayment
 }
 
 // calculateOverdueDays 计算逾期天数
@@ -374,8 +613,24 @@ func calculateOverdueDays(lastRepaymentDate time.Time, shouldPayDay string) int
 	return overdueDays
 }
 
+// GetPaymentByTradeNo 根据订单号获取支付记录
+func (d *loanDao) GetPaymentByTradeNo(ctx context.Context, tradeNo string) (*model.PaymentHistory, error) {
+	payment := &model.PaymentHistory{}
+	if err := d.db.WithContext(ctx).Where("out_trade_no = ?", tradeNo).First(payment).Error; err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
 func (d *loanDao) CreatePaymentHistory(ctx context.Context, table *model.PaymentHistory) error {
-	return d.db.Model(&model.PaymentHistory{}).WithContext(ctx).Create(table).Error
+	setPaymentHistoryIndexes(table)
+	err := d.db.Model(&model.PaymentHistory{}).WithContext(ctx).Create(table).Error
+	if err != nil {
+		return err
+	}
+
+	publishPaymentChanged(ctx, d.db, table)
+	return nil
 }
 
 func (d *loanDao) UpdatePaymentStatusByTradeNo(ctx context.Context, tradeNo string, status string) error {
@@ -383,6 +638,7 @@ func (d *loanDao) UpdatePaymentStatusByTradeNo(ctx context.Context, tradeNo stri
 	for i := 0; i < maxRetries; i++ {
 		err := d.db.Model(&model.PaymentHistory{}).WithContext(ctx).Where("out_trade_no = ?", tradeNo).Update("status", status).Error
 		if err == nil {
+			d.publishTradeChanged(ctx, tradeNo)
 			return nil
 		}
 		if !isConnectionError(err) {
@@ -396,6 +652,64 @@ func (d *loanDao) UpdatePaymentStatusByTradeNo(ctx context.Context, tradeNo stri
 	return fmt.Errorf("更新支付状态失败，经过 %d 次重试后仍然失败", maxRetries)
 }
 
+// publishTradeChanged 订单号对应的订单变化，见publishPaymentChanged
+func (d *loanDao) publishTradeChanged(ctx context.Context, tradeNo string) {
+	if cache.GetInvalidator() == nil {
+		return
+	}
+	var payments []*model.PaymentHistory
+	err := d.db.WithContext(ctx).Select("id", "loan_id", "user_phone_index").Where("out_trade_no = ?", tradeNo).Find(&payments).Error
+	if err != nil {
+		logger.Warn("get the payments error", logger.Err(err), logger.String("tradeNo", tradeNo))
+		return
+	}
+	publishPaymentChanged(ctx, d.db, payments...)
+}
+
+// SettlePaymentByTradeNo 将支付成功的订单分配到对应借款的分期，所有分期还清后借款标记为已还完
+func (d *loanDao) SettlePaymentByTradeNo(ctx context.Context, tradeNo string) error {
+	payment := &model.PaymentHistory{}
+	if err := d.db.WithContext(ctx).Where("out_trade_no = ?", tradeNo).First(payment).Error; err != nil {
+		return err
+	}
+	if payment.LoanID == 0 {
+		// 旧订单没有关联借款
+		return nil
+	}
+
+	loanRecord := &model.Loan{}
+	if err := d.db.WithContext(ctx).Where("id = ?", payment.LoanID).First(loanRecord).Error; err != nil {
+		return err
+	}
+	withLegacy, err := d.isFirstLoan(ctx, loanRecord)
+	if err != nil {
+		return err
+	}
+	summary, err := d.getPaymentSummary(ctx, loanRecord, withLegacy)
+	if err != nil {
+		return err
+	}
+
+	schedule := repayment.NewLoanSchedule(loanRecord)
+	schedule.Allocate(paidMoney(loanRecord, summary))
+	if len(schedule.Installments) > 0 && schedule.Outstanding() <= 0 && loanRecord.Status != 1 {
+		// the version is incremented so that the edits made with the loan read before fail
+		err = d.db.WithContext(ctx).Model(loanRecord).Updates(map[string]interface{}{
+			"status":  1,
+			"version": gorm.Expr("version + 1"),
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	// delete cache
+	_ = d.deleteCache(ctx, loanRecord.ID)
+	publishPaymentChanged(ctx, d.db, payment)
+
+	return nil
+}
+
 // isConnectionError 检查错误是否是连接相关的错误
 func isConnectionError(err error) bool {
 	// 这里可以根据具体的错误信息进行判断
@@ -58,11 +58,11 @@ func Test_loanDao_DeleteByID(t *testing.T) {
 	d := newLoanDao()
 	defer d.Close()
 	testData := d.TestData.(*model.Loan)
-	expectedSQLForDeletion := "DELETE .*"
+	expectedSQLForDeletion := "UPDATE .*" // soft delete sets deleted_at
 
 	d.SQLMock.ExpectBegin()
 	d.SQLMock.ExpectExec(expectedSQLForDeletion).
-		WithArgs(testData.ID).
+		WithArgs(d.AnyTime, testData.ID).
 		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
 	d.SQLMock.ExpectCommit()
 
@@ -82,8 +82,8 @@ func Test_loanDao_UpdateByID(t *testing.T) {
 	testData := d.TestData.(*model.Loan)
 
 	d.SQLMock.ExpectBegin()
-	d.SQLMock.ExpectExec("UPDATE .*").
-		WithArgs(d.AnyTime, testData.ID).
+	d.SQLMock.ExpectExec("UPDATE .* version = \\?.*").
+		WithArgs(d.AnyTime, testData.Version, testData.ID).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 	d.SQLMock.ExpectCommit()
 
@@ -92,6 +92,19 @@ func Test_loanDao_UpdateByID(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	// version conflict error
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WithArgs(d.AnyTime, testData.Version, testData.ID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	d.SQLMock.ExpectCommit()
+	d.SQLMock.ExpectQuery("SELECT count.*").
+		WithArgs(testData.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	err = d.IDao.(LoanDao).UpdateByID(d.Ctx, testData)
+	assert.ErrorIs(t, err, ErrLoanVersionConflict)
+
 	// zero id error
 	err = d.IDao.(LoanDao).UpdateByID(d.Ctx, &model.Loan{})
 	assert.Error(t, err)
@@ -201,11 +214,11 @@ func Test_loanDao_DeleteByTx(t *testing.T) {
 	d := newLoanDao()
 	defer d.Close()
 	testData := d.TestData.(*model.Loan)
-	expectedSQLForDeletion := "DELETE .*"
+	expectedSQLForDeletion := "UPDATE .*" // soft delete sets deleted_at
 
 	d.SQLMock.ExpectBegin()
 	d.SQLMock.ExpectExec(expectedSQLForDeletion).
-		WithArgs(testData.ID).
+		WithArgs(d.AnyTime, testData.ID).
 		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
 	d.SQLMock.ExpectCommit()
 
@@ -222,7 +235,7 @@ func Test_loanDao_UpdateByTx(t *testing.T) {
 
 	d.SQLMock.ExpectBegin()
 	d.SQLMock.ExpectExec("UPDATE .*").
-		WithArgs(d.AnyTime, testData.ID).
+		WithArgs(d.AnyTime, testData.Version, testData.ID).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 	d.SQLMock.ExpectCommit()
 
@@ -231,3 +244,83 @@ func Test_loanDao_UpdateByTx(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func Test_loanDao_GetDeletedByColumns(t *testing.T) {
+	d := newLoanDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Loan)
+
+	// column names and corresponding data
+	rows := sqlmock.NewRows([]string{"id", "deleted_at"}).
+		AddRow(testData.ID, d.AnyTime)
+
+	d.SQLMock.ExpectQuery("SELECT .* deleted_at IS NOT NULL.*").WillReturnRows(rows)
+
+	_, _, err := d.IDao.(LoanDao).GetDeletedByColumns(d.Ctx, &query.Params{
+		Page:  0,
+		Limit: 10,
+		Sort:  "ignore count", // ignore test count(*)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = d.SQLMock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_loanDao_RestoreByID(t *testing.T) {
+	d := newLoanDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Loan)
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WithArgs(sqlmock.AnyArg(), testData.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(LoanDao).RestoreByID(d.Ctx, testData.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// not in the trash
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WithArgs(sqlmock.AnyArg(), testData.ID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	d.SQLMock.ExpectCommit()
+
+	err = d.IDao.(LoanDao).RestoreByID(d.Ctx, testData.ID)
+	assert.ErrorIs(t, err, database.ErrRecordNotFound)
+}
+
+func Test_loanDao_PurgeByID(t *testing.T) {
+	d := newLoanDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Loan)
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("DELETE .*").
+		WithArgs(testData.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(LoanDao).PurgeByID(d.Ctx, testData.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// not in the trash
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("DELETE .*").
+		WithArgs(testData.ID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	d.SQLMock.ExpectCommit()
+
+	err = d.IDao.(LoanDao).PurgeByID(d.Ctx, testData.ID)
+	assert.ErrorIs(t, err, database.ErrRecordNotFound)
+}
@@ -13,6 +13,7 @@ import (
 
 	"lol/internal/cache"
 	"lol/internal/database"
+	"lol/internal/fieldcrypt"
 	"lol/internal/model"
 )
 
@@ -22,13 +23,16 @@ var _ PaymentHistoryDao = (*paymentHistoryDao)(nil)
 type PaymentHistoryDao interface {
 	Create(ctx context.Context, table *model.PaymentHistory) error
 	DeleteByID(ctx context.Context, id uint64) error
-	UpdateByID(ctx context.Context, table *model.PaymentHistory) error
+	UpdateByID(ctx context.Context, table *model.PaymentHistory, columns ...string) error
 	GetByID(ctx context.Context, id uint64) (*model.PaymentHistory, error)
 	GetByColumns(ctx context.Context, params *query.Params) ([]*model.PaymentHistory, int64, error)
+	GetDeletedByColumns(ctx context.Context, params *query.Params) ([]*model.PaymentHistory, int64, error)
+	RestoreByID(ctx context.Context, id uint64) error
+	PurgeByID(ctx context.Context, id uint64) error
 
 	CreateByTx(ctx context.Context, tx *gorm.DB, table *model.PaymentHistory) (uint64, error)
 	DeleteByTx(ctx context.Context, tx *gorm.DB, id uint64) error
-	UpdateByTx(ctx context.Context, tx *gorm.DB, table *model.PaymentHistory) error
+	UpdateByTx(ctx context.Context, tx *gorm.DB, table *model.PaymentHistory, columns ...string) error
 }
 
 type paymentHistoryDao struct {
@@ -56,9 +60,45 @@ func (d *paymentHistoryDao) deleteCache(ctx context.Context, id uint64) error {
 	return nil
 }
 
+// getPayment the loan fields of a record including the soft deleted ones, nil if the cache is not invalidated
+func (d *paymentHistoryDao) getPayment(ctx context.Context, db *gorm.DB, id uint64) *model.PaymentHistory {
+	if cache.GetInvalidator() == nil {
+		return nil
+	}
+	record := &model.PaymentHistory{}
+	err := db.WithContext(ctx).Unscoped().Select("id", "loan_id", "user_phone_index").Where("id = ?", id).First(record).Error
+	if err != nil {
+		if !errors.Is(err, database.ErrRecordNotFound) {
+			logger.Warn("get the payment error", logger.Err(err), logger.Uint64("id", id))
+		}
+		return nil
+	}
+	return record
+}
+
+// publishChanged delete the cache of the records and their loans, and broadcast it to the other replicas
+func (d *paymentHistoryDao) publishChanged(ctx context.Context, db *gorm.DB, records ...*model.PaymentHistory) {
+	payments := make([]*model.PaymentHistory, 0, len(records))
+	for _, record := range records {
+		if record != nil {
+			payments = append(payments, record)
+		}
+	}
+	if len(payments) > 0 {
+		publishPaymentChanged(ctx, db, payments...)
+	}
+}
+
 // Create a record, insert the record and the id value is written back to the table
 func (d *paymentHistoryDao) Create(ctx context.Context, table *model.PaymentHistory) error {
-	return d.db.WithContext(ctx).Create(table).Error
+	setPaymentHistoryIndexes(table)
+	err := d.db.WithContext(ctx).Create(table).Error
+	if err != nil {
+		return err
+	}
+
+	d.publishChanged(ctx, d.db, table)
+	return nil
 }
 
 // DeleteByID delete a record by id
@@ -70,37 +110,56 @@ func (d *paymentHistoryDao) DeleteByID(ctx context.Context, id uint64) error {
 
 	// delete cache
 	_ = d.deleteCache(ctx, id)
+	d.publishChanged(ctx, d.db, d.getPayment(ctx, d.db, id))
 
 	return nil
 }
 
-// UpdateByID update a record by id
-func (d *paymentHistoryDao) UpdateByID(ctx context.Context, table *model.PaymentHistory) error {
-	err := d.updateDataByID(ctx, d.db, table)
+// UpdateByID update a record by id, the non-zero fields and the columns are written
+func (d *paymentHistoryDao) UpdateByID(ctx context.Context, table *model.PaymentHistory, columns ...string) error {
+	// the record may be moved to another loan
+	before := d.getPayment(ctx, d.db, table.ID)
+	err := d.updateDataByID(ctx, d.db, table, columns)
 
 	// delete cache
 	_ = d.deleteCache(ctx, table.ID)
+	d.publishChanged(ctx, d.db, before, d.getPayment(ctx, d.db, table.ID))
 
 	return err
 }
 
-func (d *paymentHistoryDao) updateDataByID(ctx context.Context, db *gorm.DB, table *model.PaymentHistory) error {
+func (d *paymentHistoryDao) updateDataByID(ctx context.Context, db *gorm.DB, table *model.PaymentHistory, columns []string) error {
 	if table.ID < 1 {
 		return errors.New("id cannot be 0")
 	}
 
 	update := map[string]interface{}{}
+	written := columnSet(columns)
 
-	if table.UserPhone != "" {
-		update["user_phone"] = table.UserPhone
+	if table.UserPhone != "" || written["user_phone"] {
+		if err := setEncrypted(update, "user_phone", fieldcrypt.IndexMobile, table.UserPhone); err != nil {
+			return err
+		}
 	}
-	if table.OutTradeNo != "" {
+	if table.OutTradeNo != "" || written["out_trade_no"] {
 		update["out_trade_no"] = table.OutTradeNo
 	}
-	if table.Status != "" {
+	if table.Status != "" || written["status"] {
 		update["status"] = table.Status
 	}
-	if table.CreateAt.IsZero() == false {
+	if table.LoanID != 0 || written["loan_id"] {
+		update["loan_id"] = table.LoanID
+	}
+	if table.Installments != 0 || written["installments"] {
+		update["installments"] = table.Installments
+	}
+	if table.Amount != 0 || written["amount"] {
+		update["amount"] = table.Amount
+	}
+	if table.TotalAmount != 0 || written["total_amount"] {
+		update["total_amount"] = table.TotalAmount
+	}
+	if table.CreateAt != nil && !table.CreateAt.IsZero() {
 		update["create_at"] = table.CreateAt
 	}
 
@@ -195,6 +254,10 @@ func (d *paymentHistoryDao) GetByID(ctx context.Context, id uint64) (*model.Paym
 //		},
 //	}
 func (d *paymentHistoryDao) GetByColumns(ctx context.Context, params *query.Params) ([]*model.PaymentHistory, int64, error) {
+	params, err := convertEncryptedColumns(params, paymentHistoryIndexColumns)
+	if err != nil {
+		return nil, 0, errors.New("query params error: " + err.Error())
+	}
 	queryStr, args, err := params.ConvertToGormConditions()
 	if err != nil {
 		return nil, 0, errors.New("query params error: " + err.Error())
@@ -221,9 +284,51 @@ func (d *paymentHistoryDao) GetByColumns(ctx context.Context, params *query.Para
 	return records, total, err
 }
 
+// GetDeletedByColumns get paging soft deleted records by column information, the params are as GetByColumns
+func (d *paymentHistoryDao) GetDeletedByColumns(ctx context.Context, params *query.Params) ([]*model.PaymentHistory, int64, error) {
+	params, err := convertEncryptedColumns(params, paymentHistoryIndexColumns)
+	if err != nil {
+		return nil, 0, errors.New("query params error: " + err.Error())
+	}
+	return getDeletedByColumns[model.PaymentHistory](ctx, d.db, params)
+}
+
+// RestoreByID restore a soft deleted record by id
+func (d *paymentHistoryDao) RestoreByID(ctx context.Context, id uint64) error {
+	err := restoreByID[model.PaymentHistory](ctx, d.db, id)
+	if err != nil {
+		return err
+	}
+
+	// delete cache
+	_ = d.deleteCache(ctx, id)
+	d.publishChanged(ctx, d.db, d.getPayment(ctx, d.db, id))
+
+	return nil
+}
+
+// PurgeByID delete a soft deleted record permanently by id
+func (d *paymentHistoryDao) PurgeByID(ctx context.Context, id uint64) error {
+	before := d.getPayment(ctx, d.db, id)
+	err := purgeByID[model.PaymentHistory](ctx, d.db, id)
+	if err != nil {
+		return err
+	}
+
+	// delete cache
+	_ = d.deleteCache(ctx, id)
+	d.publishChanged(ctx, d.db, before)
+
+	return nil
+}
+
 // CreateByTx create a record in the database using the provided transaction
 func (d *paymentHistoryDao) CreateByTx(ctx context.Context, tx *gorm.DB, table *model.PaymentHistory) (uint64, error) {
+	setPaymentHistoryIndexes(table)
 	err := tx.WithContext(ctx).Create(table).Error
+	if err == nil {
+		d.publishChanged(ctx, tx, table)
+	}
 	return table.ID, err
 }
 
@@ -236,16 +341,19 @@ func (d *paymentHistoryDao) DeleteByTx(ctx context.Context, tx *gorm.DB, id uint
 
 	// delete cache
 	_ = d.deleteCache(ctx, id)
+	d.publishChanged(ctx, tx, d.getPayment(ctx, tx, id))
 
 	return nil
 }
 
-// UpdateByTx update a record by id in the database using the provided transaction
-func (d *paymentHistoryDao) UpdateByTx(ctx context.Context, tx *gorm.DB, table *model.PaymentHistory) error {
-	err := d.updateDataByID(ctx, tx, table)
+// UpdateByTx update a record by id in the database using the provided transaction, as UpdateByID
+func (d *paymentHistoryDao) UpdateByTx(ctx context.Context, tx *gorm.DB, table *model.PaymentHistory, columns ...string) error {
+	before := d.getPayment(ctx, tx, table.ID)
+	err := d.updateDataByID(ctx, tx, table, columns)
 
 	// delete cache
 	_ = d.deleteCache(ctx, table.ID)
+	d.publishChanged(ctx, tx, before, d.getPayment(ctx, tx, table.ID))
 
 	return err
 }
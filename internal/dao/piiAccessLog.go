@@ -0,0 +1,65 @@
+package dao
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/go-dev-frame/sponge/pkg/sgorm/query"
+
+	"lol/internal/model"
+)
+
+var _ PiiAccessLogDao = (*piiAccessLogDao)(nil)
+
+// PiiAccessLogDao defining the dao interface, the access logs are only appended and never updated
+type PiiAccessLogDao interface {
+	CreateBatch(ctx context.Context, tables []*model.PiiAccessLog) error
+	GetByColumns(ctx context.Context, params *query.Params) ([]*model.PiiAccessLog, int64, error)
+}
+
+type piiAccessLogDao struct {
+	db *gorm.DB
+}
+
+// NewPiiAccessLogDao creating the dao interface
+func NewPiiAccessLogDao(db *gorm.DB) PiiAccessLogDao {
+	return &piiAccessLogDao{db: db}
+}
+
+// CreateBatch insert the records in one statement
+func (d *piiAccessLogDao) CreateBatch(ctx context.Context, tables []*model.PiiAccessLog) error {
+	if len(tables) == 0 {
+		return nil
+	}
+	return d.db.WithContext(ctx).Create(tables).Error
+}
+
+// GetByColumns get paging records by column information, see smsTemplateDao.GetByColumns for the params
+func (d *piiAccessLogDao) GetByColumns(ctx context.Context, params *query.Params) ([]*model.PiiAccessLog, int64, error) {
+	queryStr, args, err := params.ConvertToGormConditions()
+	if err != nil {
+		return nil, 0, errors.New("query params error: " + err.Error())
+	}
+
+	var total int64
+	if params.Sort != "ignore count" { // determine if count is required
+		err = d.db.WithContext(ctx).Model(&model.PiiAccessLog{}).Where(queryStr, args...).Count(&total).Error
+		if err != nil {
+			return nil, 0, err
+		}
+		if total == 0 {
+			return nil, total, nil
+		}
+	}
+
+	records := []*model.PiiAccessLog{}
+	order, limit, offset := params.ConvertToPage()
+	err = d.db.WithContext(ctx).Order(order).Limit(limit).Offset(offset).Where(queryStr, args...).Find(&records).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return records, total, err
+}
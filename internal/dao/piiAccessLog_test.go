@@ -0,0 +1,79 @@
+package dao
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-dev-frame/sponge/pkg/gotest"
+	"github.com/go-dev-frame/sponge/pkg/sgorm/query"
+	"github.com/stretchr/testify/assert"
+
+	"lol/internal/model"
+)
+
+func newPiiAccessLogDao() *gotest.Dao {
+	testData := &model.PiiAccessLog{}
+	testData.ID = 1
+
+	// the access logs are not cached
+	c := gotest.NewCache(map[string]interface{}{"no cache": testData})
+
+	// init mock dao
+	d := gotest.NewDao(c, testData)
+	d.IDao = NewPiiAccessLogDao(d.DB)
+
+	return d
+}
+
+func Test_piiAccessLogDao_CreateBatch(t *testing.T) {
+	d := newPiiAccessLogDao()
+	defer d.Close()
+	testData := d.TestData.(*model.PiiAccessLog)
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("INSERT INTO .*").
+		WithArgs(d.GetAnyArgs(testData)...).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(PiiAccessLogDao).CreateBatch(d.Ctx, []*model.PiiAccessLog{testData})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// nothing is written without records
+	err = d.IDao.(PiiAccessLogDao).CreateBatch(d.Ctx, nil)
+	assert.NoError(t, err)
+}
+
+func Test_piiAccessLogDao_GetByColumns(t *testing.T) {
+	d := newPiiAccessLogDao()
+	defer d.Close()
+	testData := d.TestData.(*model.PiiAccessLog)
+
+	// column names and corresponding data
+	rows := sqlmock.NewRows([]string{"id"}).
+		AddRow(testData.ID)
+
+	d.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(rows)
+
+	_, _, err := d.IDao.(PiiAccessLogDao).GetByColumns(d.Ctx, &query.Params{
+		Page:  0,
+		Limit: 10,
+		Sort:  "ignore count", // ignore test count(*)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = d.SQLMock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// error test
+	dao := &piiAccessLogDao{}
+	_, _, err = dao.GetByColumns(context.Background(), &query.Params{Columns: []query.Column{{}}})
+	t.Log(err)
+}
@@ -22,13 +22,16 @@ var _ ResultDao = (*resultDao)(nil)
 type ResultDao interface {
 	Create(ctx context.Context, table *model.Result) error
 	DeleteByID(ctx context.Context, id uint64) error
-	UpdateByID(ctx context.Context, table *model.Result) error
+	UpdateByID(ctx context.Context, table *model.Result, columns ...string) error
 	GetByID(ctx context.Context, id uint64) (*model.Result, error)
 	GetByColumns(ctx context.Context, params *query.Params) ([]*model.Result, int64, error)
+	GetDeletedByColumns(ctx context.Context, params *query.Params) ([]*model.Result, int64, error)
+	RestoreByID(ctx context.Context, id uint64) error
+	PurgeByID(ctx context.Context, id uint64) error
 
 	CreateByTx(ctx context.Context, tx *gorm.DB, table *model.Result) (uint64, error)
 	DeleteByTx(ctx context.Context, tx *gorm.DB, id uint64) error
-	UpdateByTx(ctx context.Context, tx *gorm.DB, table *model.Result) error
+	UpdateByTx(ctx context.Context, tx *gorm.DB, table *model.Result, columns ...string) error
 }
 
 type resultDao struct {
@@ -74,9 +77,9 @@ func (d *resultDao) DeleteByID(ctx context.Context, id uint64) error {
 	return nil
 }
 
-// UpdateByID update a record by id
-func (d *resultDao) UpdateByID(ctx context.Context, table *model.Result) error {
-	err := d.updateDataByID(ctx, d.db, table)
+// UpdateByID update a record by id, the non-zero fields and the columns are written
+func (d *resultDao) UpdateByID(ctx context.Context, table *model.Result, columns ...string) error {
+	err := d.updateDataByID(ctx, d.db, table, columns)
 
 	// delete cache
 	_ = d.deleteCache(ctx, table.ID)
@@ -84,53 +87,54 @@ func (d *resultDao) UpdateByID(ctx context.Context, table *model.Result) error {
 	return err
 }
 
-func (d *resultDao) updateDataByID(ctx context.Context, db *gorm.DB, table *model.Result) error {
+func (d *resultDao) updateDataByID(ctx context.Context, db *gorm.DB, table *model.Result, columns []string) error {
 	if table.ID < 1 {
 		return errors.New("id cannot be 0")
 	}
 
 	update := map[string]interface{}{}
+	written := columnSet(columns)
 
-	if table.EventType != "" {
+	if table.EventType != "" || written["event_type"] {
 		update["event_type"] = table.EventType
 	}
-	if table.ResourceAppid != "" {
+	if table.ResourceAppid != "" || written["resource_appid"] {
 		update["resource_appid"] = table.ResourceAppid
 	}
-	if table.ResourceMchid != "" {
+	if table.ResourceMchid != "" || written["resource_mchid"] {
 		update["resource_mchid"] = table.ResourceMchid
 	}
-	if table.OutTradeNo != "" {
+	if table.OutTradeNo != "" || written["out_trade_no"] {
 		update["out_trade_no"] = table.OutTradeNo
 	}
-	if table.TransactionID != "" {
+	if table.TransactionID != "" || written["transaction_id"] {
 		update["transaction_id"] = table.TransactionID
 	}
-	if table.TradeType != "" {
+	if table.TradeType != "" || written["trade_type"] {
 		update["trade_type"] = table.TradeType
 	}
-	if table.TradeState != "" {
+	if table.TradeState != "" || written["trade_state"] {
 		update["trade_state"] = table.TradeState
 	}
-	if table.TradeStateDesc != "" {
+	if table.TradeStateDesc != "" || written["trade_state_desc"] {
 		update["trade_state_desc"] = table.TradeStateDesc
 	}
-	if table.BankType != "" {
+	if table.BankType != "" || written["bank_type"] {
 		update["bank_type"] = table.BankType
 	}
-	if table.Attach != "" {
+	if table.Attach != "" || written["attach"] {
 		update["attach"] = table.Attach
 	}
-	if table.SuccessTime != "" {
+	if table.SuccessTime != "" || written["success_time"] {
 		update["success_time"] = table.SuccessTime
 	}
-	if table.Payer != "" {
+	if table.Payer != "" || written["payer"] {
 		update["payer"] = table.Payer
 	}
-	if table.AmountTotal != 0 {
+	if table.AmountTotal != 0 || written["amount_total"] {
 		update["amount_total"] = table.AmountTotal
 	}
-	if table.CreateAt.IsZero() == false {
+	if table.CreateAt != nil && !table.CreateAt.IsZero() {
 		update["create_at"] = table.CreateAt
 	}
 
@@ -251,6 +255,37 @@ func (d *resultDao) GetByColumns(ctx context.Context, params *query.Params) ([]*
 	return records, total, err
 }
 
+// GetDeletedByColumns get paging soft deleted records by column information, the params are as GetByColumns
+func (d *resultDao) GetDeletedByColumns(ctx context.Context, params *query.Params) ([]*model.Result, int64, error) {
+	return getDeletedByColumns[model.Result](ctx, d.db, params)
+}
+
+// RestoreByID restore a soft deleted record by id
+func (d *resultDao) RestoreByID(ctx context.Context, id uint64) error {
+	err := restoreByID[model.Result](ctx, d.db, id)
+	if err != nil {
+		return err
+	}
+
+	// delete cache
+	_ = d.deleteCache(ctx, id)
+
+	return nil
+}
+
+// PurgeByID delete a soft deleted record permanently by id
+func (d *resultDao) PurgeByID(ctx context.Context, id uint64) error {
+	err := purgeByID[model.Result](ctx, d.db, id)
+	if err != nil {
+		return err
+	}
+
+	// delete cache
+	_ = d.deleteCache(ctx, id)
+
+	return nil
+}
+
 // CreateByTx create a record in the database using the provided transaction
 func (d *resultDao) CreateByTx(ctx context.Context, tx *gorm.DB, table *model.Result) (uint64, error) {
 	err := tx.WithContext(ctx).Create(table).Error
@@ -270,9 +305,9 @@ func (d *resultDao) DeleteByTx(ctx context.Context, tx *gorm.DB, id uint64) erro
 	return nil
 }
 
-// UpdateByTx update a record by id in the database using the provided transaction
-func (d *resultDao) UpdateByTx(ctx context.Context, tx *gorm.DB, table *model.Result) error {
-	err := d.updateDataByID(ctx, tx, table)
+// UpdateByTx update a record by id in the database using the provided transaction, as UpdateByID
+func (d *resultDao) UpdateByTx(ctx context.Context, tx *gorm.DB, table *model.Result, columns ...string) error {
+	err := d.updateDataByID(ctx, tx, table, columns)
 
 	// delete cache
 	_ = d.deleteCache(ctx, table.ID)
@@ -58,11 +58,11 @@ func Test_resultDao_DeleteByID(t *testing.T) {
 	d := newResultDao()
 	defer d.Close()
 	testData := d.TestData.(*model.Result)
-	expectedSQLForDeletion := "DELETE .*"
+	expectedSQLForDeletion := "UPDATE .*" // soft delete sets deleted_at
 
 	d.SQLMock.ExpectBegin()
 	d.SQLMock.ExpectExec(expectedSQLForDeletion).
-		WithArgs(testData.ID).
+		WithArgs(d.AnyTime, testData.ID).
 		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
 	d.SQLMock.ExpectCommit()
 
@@ -201,11 +201,11 @@ func Test_resultDao_DeleteByTx(t *testing.T) {
 	d := newResultDao()
 	defer d.Close()
 	testData := d.TestData.(*model.Result)
-	expectedSQLForDeletion := "DELETE .*"
+	expectedSQLForDeletion := "UPDATE .*" // soft delete sets deleted_at
 
 	d.SQLMock.ExpectBegin()
 	d.SQLMock.ExpectExec(expectedSQLForDeletion).
-		WithArgs(testData.ID).
+		WithArgs(d.AnyTime, testData.ID).
 		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
 	d.SQLMock.ExpectCommit()
 
@@ -231,3 +231,83 @@ func Test_resultDao_UpdateByTx(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func Test_resultDao_GetDeletedByColumns(t *testing.T) {
+	d := newResultDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Result)
+
+	// column names and corresponding data
+	rows := sqlmock.NewRows([]string{"id", "deleted_at"}).
+		AddRow(testData.ID, d.AnyTime)
+
+	d.SQLMock.ExpectQuery("SELECT .* deleted_at IS NOT NULL.*").WillReturnRows(rows)
+
+	_, _, err := d.IDao.(ResultDao).GetDeletedByColumns(d.Ctx, &query.Params{
+		Page:  0,
+		Limit: 10,
+		Sort:  "ignore count", // ignore test count(*)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = d.SQLMock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_resultDao_RestoreByID(t *testing.T) {
+	d := newResultDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Result)
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WithArgs(sqlmock.AnyArg(), testData.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(ResultDao).RestoreByID(d.Ctx, testData.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// not in the trash
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WithArgs(sqlmock.AnyArg(), testData.ID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	d.SQLMock.ExpectCommit()
+
+	err = d.IDao.(ResultDao).RestoreByID(d.Ctx, testData.ID)
+	assert.ErrorIs(t, err, database.ErrRecordNotFound)
+}
+
+func Test_resultDao_PurgeByID(t *testing.T) {
+	d := newResultDao()
+	defer d.Close()
+	testData := d.TestData.(*model.Result)
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("DELETE .*").
+		WithArgs(testData.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(ResultDao).PurgeByID(d.Ctx, testData.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// not in the trash
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("DELETE .*").
+		WithArgs(testData.ID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	d.SQLMock.ExpectCommit()
+
+	err = d.IDao.(ResultDao).PurgeByID(d.Ctx, testData.ID)
+	assert.ErrorIs(t, err, database.ErrRecordNotFound)
+}
@@ -3,7 +3,9 @@ package dao
 import (
 	"context"
 	"errors"
+	"time"
 
+	"github.com/go-sql-driver/mysql"
 	"golang.org/x/sync/singleflight"
 	"gorm.io/gorm"
 
@@ -13,22 +15,33 @@ import (
 
 	"lol/internal/cache"
 	"lol/internal/database"
+	"lol/internal/fieldcrypt"
 	"lol/internal/model"
 )
 
 var _ SmsHistoryDao = (*smsHistoryDao)(nil)
 
+// ErrBizKeyExists a message with the business key has been recorded, it must not be sent again
+var ErrBizKeyExists = errors.New("sms history biz_key exists")
+
 // SmsHistoryDao defining the dao interface
 type SmsHistoryDao interface {
 	Create(ctx context.Context, table *model.SmsHistory) error
 	DeleteByID(ctx context.Context, id uint64) error
-	UpdateByID(ctx context.Context, table *model.SmsHistory) error
+	UpdateByID(ctx context.Context, table *model.SmsHistory, columns ...string) error
 	GetByID(ctx context.Context, id uint64) (*model.SmsHistory, error)
 	GetByColumns(ctx context.Context, params *query.Params) ([]*model.SmsHistory, int64, error)
+	GetDeletedByColumns(ctx context.Context, params *query.Params) ([]*model.SmsHistory, int64, error)
+	RestoreByID(ctx context.Context, id uint64) error
+	PurgeByID(ctx context.Context, id uint64) error
 
 	CreateByTx(ctx context.Context, tx *gorm.DB, table *model.SmsHistory) (uint64, error)
 	DeleteByTx(ctx context.Context, tx *gorm.DB, id uint64) error
-	UpdateByTx(ctx context.Context, tx *gorm.DB, table *model.SmsHistory) error
+	UpdateByTx(ctx context.Context, tx *gorm.DB, table *model.SmsHistory, columns ...string) error
+
+	ExistsByBizKey(ctx context.Context, bizKey string) (bool, error)
+	CountByMobileSince(ctx context.Context, mobile string, bizKeyPrefix string, since time.Time) (int64, error)
+	UpdateDelivery(ctx context.Context, provider string, messageID string, table *model.SmsHistory) error
 }
 
 type smsHistoryDao struct {
@@ -56,9 +69,15 @@ func (d *smsHistoryDao) deleteCache(ctx context.Context, id uint64) error {
 	return nil
 }
 
-// Create a record, insert the record and the id value is written back to the table
+// Create a record, insert the record and the id value is written back to the table,
+// ErrBizKeyExists is returned if the business key is recorded already, e.g. by another replica.
 func (d *smsHistoryDao) Create(ctx context.Context, table *model.SmsHistory) error {
-	return d.db.WithContext(ctx).Create(table).Error
+	setSmsHistoryIndexes(table)
+	err := d.db.WithContext(ctx).Create(table).Error
+	if table.BizKey != nil && isDuplicateKey(err) {
+		return ErrBizKeyExists
+	}
+	return err
 }
 
 // DeleteByID delete a record by id
@@ -74,9 +93,9 @@ func (d *smsHistoryDao) DeleteByID(ctx context.Context, id uint64) error {
 	return nil
 }
 
-// UpdateByID update a record by id
-func (d *smsHistoryDao) UpdateByID(ctx context.Context, table *model.SmsHistory) error {
-	err := d.updateDataByID(ctx, d.db, table)
+// UpdateByID update a record by id, the non-zero fields and the columns are written
+func (d *smsHistoryDao) UpdateByID(ctx context.Context, table *model.SmsHistory, columns ...string) error {
+	err := d.updateDataByID(ctx, d.db, table, columns)
 
 	// delete cache
 	_ = d.deleteCache(ctx, table.ID)
@@ -84,20 +103,53 @@ func (d *smsHistoryDao) UpdateByID(ctx context.Context, table *model.SmsHistory)
 	return err
 }
 
-func (d *smsHistoryDao) updateDataByID(ctx context.Context, db *gorm.DB, table *model.SmsHistory) error {
+func (d *smsHistoryDao) updateDataByID(ctx context.Context, db *gorm.DB, table *model.SmsHistory, columns []string) error {
 	if table.ID < 1 {
 		return errors.New("id cannot be 0")
 	}
 
 	update := map[string]interface{}{}
+	written := columnSet(columns)
 
-	if table.UserName != "" {
+	if table.UserName != "" || written["user_name"] {
 		update["user_name"] = table.UserName
 	}
-	if table.Mobile != "" {
-		update["mobile"] = table.Mobile
+	if table.Mobile != "" || written["mobile"] {
+		if err := setEncrypted(update, "mobile", fieldcrypt.IndexMobile, table.Mobile); err != nil {
+			return err
+		}
+	}
+	if table.Content != "" || written["content"] {
+		update["content"] = table.Content
+	}
+	if table.Template != "" || written["template"] {
+		update["template"] = table.Template
+	}
+	if table.Provider != "" || written["provider"] {
+		update["provider"] = table.Provider
 	}
-	if table.CreateAt.IsZero() == false {
+	if table.MessageID != "" || written["message_id"] {
+		update["message_id"] = table.MessageID
+	}
+	if table.LoanID != 0 || written["loan_id"] {
+		update["loan_id"] = table.LoanID
+	}
+	if table.Status != 0 || written["status"] {
+		update["status"] = table.Status
+	}
+	if table.DeliveryStatus != 0 || written["delivery_status"] {
+		update["delivery_status"] = table.DeliveryStatus
+	}
+	if table.ErrorCode != "" || written["error_code"] {
+		update["error_code"] = table.ErrorCode
+	}
+	if table.ReportAt != nil && !table.ReportAt.IsZero() {
+		update["report_at"] = table.ReportAt
+	}
+	if table.BizKey != nil || written["biz_key"] {
+		update["biz_key"] = table.BizKey
+	}
+	if table.CreateAt != nil && !table.CreateAt.IsZero() {
 		update["create_at"] = table.CreateAt
 	}
 
@@ -192,6 +244,10 @@ func (d *smsHistoryDao) GetByID(ctx context.Context, id uint64) (*model.SmsHisto
 //		},
 //	}
 func (d *smsHistoryDao) GetByColumns(ctx context.Context, params *query.Params) ([]*model.SmsHistory, int64, error) {
+	params, err := convertEncryptedColumns(params, smsHistoryIndexColumns)
+	if err != nil {
+		return nil, 0, errors.New("query params error: " + err.Error())
+	}
 	queryStr, args, err := params.ConvertToGormConditions()
 	if err != nil {
 		return nil, 0, errors.New("query params error: " + err.Error())
@@ -218,8 +274,44 @@ func (d *smsHistoryDao) GetByColumns(ctx context.Context, params *query.Params)
 	return records, total, err
 }
 
+// GetDeletedByColumns get paging soft deleted records by column information, the params are as GetByColumns
+func (d *smsHistoryDao) GetDeletedByColumns(ctx context.Context, params *query.Params) ([]*model.SmsHistory, int64, error) {
+	params, err := convertEncryptedColumns(params, smsHistoryIndexColumns)
+	if err != nil {
+		return nil, 0, errors.New("query params error: " + err.Error())
+	}
+	return getDeletedByColumns[model.SmsHistory](ctx, d.db, params)
+}
+
+// RestoreByID restore a soft deleted record by id
+func (d *smsHistoryDao) RestoreByID(ctx context.Context, id uint64) error {
+	err := restoreByID[model.SmsHistory](ctx, d.db, id)
+	if err != nil {
+		return err
+	}
+
+	// delete cache
+	_ = d.deleteCache(ctx, id)
+
+	return nil
+}
+
+// PurgeByID delete a soft deleted record permanently by id
+func (d *smsHistoryDao) PurgeByID(ctx context.Context, id uint64) error {
+	err := purgeByID[model.SmsHistory](ctx, d.db, id)
+	if err != nil {
+		return err
+	}
+
+	// delete cache
+	_ = d.deleteCache(ctx, id)
+
+	return nil
+}
+
 // CreateByTx create a record in the database using the provided transaction
 func (d *smsHistoryDao) CreateByTx(ctx context.Context, tx *gorm.DB, table *model.SmsHistory) (uint64, error) {
+	setSmsHistoryIndexes(table)
 	err := tx.WithContext(ctx).Create(table).Error
 	return table.ID, err
 }
@@ -237,12 +329,65 @@ func (d *smsHistoryDao) DeleteByTx(ctx context.Context, tx *gorm.DB, id uint64)
 	return nil
 }
 
-// UpdateByTx update a record by id in the database using the provided transaction
-func (d *smsHistoryDao) UpdateByTx(ctx context.Context, tx *gorm.DB, table *model.SmsHistory) error {
-	err := d.updateDataByID(ctx, tx, table)
+// UpdateByTx update a record by id in the database using the provided transaction, as UpdateByID
+func (d *smsHistoryDao) UpdateByTx(ctx context.Context, tx *gorm.DB, table *model.SmsHistory, columns ...string) error {
+	err := d.updateDataByID(ctx, tx, table, columns)
 
 	// delete cache
 	_ = d.deleteCache(ctx, table.ID)
 
 	return err
 }
+
+// ExistsByBizKey whether a message with the business key has been sent, whatever its status
+func (d *smsHistoryDao) ExistsByBizKey(ctx context.Context, bizKey string) (bool, error) {
+	var count int64
+	// the deleted messages count, so a message is never sent again because its record was deleted
+	err := d.db.WithContext(ctx).Unscoped().Model(&model.SmsHistory{}).Where("biz_key = ?", bizKey).Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// CountByMobileSince count the messages sent to the mobile since the time, whose business key has the prefix
+func (d *smsHistoryDao) CountByMobileSince(ctx context.Context, mobile string, bizKeyPrefix string, since time.Time) (int64, error) {
+	var count int64
+	// the deleted messages count, deleting records does not lift the limit
+	err := d.db.WithContext(ctx).Unscoped().Model(&model.SmsHistory{}).
+		Where("mobile_index = ? AND biz_key LIKE ? AND create_at >= ?",
+			fieldcrypt.BlindIndex(fieldcrypt.IndexMobile, mobile), bizKeyPrefix+"%", since).
+		Count(&count).Error
+	return count, err
+}
+
+// UpdateDelivery update the delivery fields of the message sent by the provider,
+// database.ErrRecordNotFound is returned if there is no such message.
+func (d *smsHistoryDao) UpdateDelivery(ctx context.Context, provider string, messageID string, table *model.SmsHistory) error {
+	record := &model.SmsHistory{}
+	err := d.db.WithContext(ctx).Select("id").Where("provider = ? AND message_id = ?", provider, messageID).
+		First(record).Error
+	if err != nil {
+		return err
+	}
+
+	err = d.db.WithContext(ctx).Model(record).Updates(map[string]interface{}{
+		"delivery_status": table.DeliveryStatus,
+		"error_code":      table.ErrorCode,
+		"report_at":       table.ReportAt,
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	// delete cache
+	_ = d.deleteCache(ctx, record.ID)
+
+	return nil
+}
+
+// isDuplicateKey whether the error is a violation of a unique key of mysql
+func isDuplicateKey(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062
+}
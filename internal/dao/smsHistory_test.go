@@ -3,11 +3,13 @@ package dao
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/go-dev-frame/sponge/pkg/gotest"
 	"github.com/go-dev-frame/sponge/pkg/sgorm/query"
 	"github.com/go-dev-frame/sponge/pkg/utils"
+	"github.com/go-sql-driver/mysql"
 	"github.com/stretchr/testify/assert"
 
 	"lol/internal/cache"
@@ -54,15 +56,30 @@ func Test_smsHistoryDao_Create(t *testing.T) {
 	}
 }
 
+func Test_smsHistoryDao_Create_bizKeyExists(t *testing.T) {
+	d := newSmsHistoryDao()
+	defer d.Close()
+	bizKey := "reminder:due:1:1"
+	testData := &model.SmsHistory{Mobile: "13800000000", BizKey: &bizKey}
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("INSERT INTO .*").
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry for key 'uk_biz_key'"})
+	d.SQLMock.ExpectRollback()
+
+	err := d.IDao.(SmsHistoryDao).Create(d.Ctx, testData)
+	assert.ErrorIs(t, err, ErrBizKeyExists)
+}
+
 func Test_smsHistoryDao_DeleteByID(t *testing.T) {
 	d := newSmsHistoryDao()
 	defer d.Close()
 	testData := d.TestData.(*model.SmsHistory)
-	expectedSQLForDeletion := "DELETE .*"
+	expectedSQLForDeletion := "UPDATE .*" // soft delete sets deleted_at
 
 	d.SQLMock.ExpectBegin()
 	d.SQLMock.ExpectExec(expectedSQLForDeletion).
-		WithArgs(testData.ID).
+		WithArgs(d.AnyTime, testData.ID).
 		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
 	d.SQLMock.ExpectCommit()
 
@@ -201,11 +218,11 @@ func Test_smsHistoryDao_DeleteByTx(t *testing.T) {
 	d := newSmsHistoryDao()
 	defer d.Close()
 	testData := d.TestData.(*model.SmsHistory)
-	expectedSQLForDeletion := "DELETE .*"
+	expectedSQLForDeletion := "UPDATE .*" // soft delete sets deleted_at
 
 	d.SQLMock.ExpectBegin()
 	d.SQLMock.ExpectExec(expectedSQLForDeletion).
-		WithArgs(testData.ID).
+		WithArgs(d.AnyTime, testData.ID).
 		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
 	d.SQLMock.ExpectCommit()
 
@@ -231,3 +248,135 @@ func Test_smsHistoryDao_UpdateByTx(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func Test_smsHistoryDao_ExistsByBizKey(t *testing.T) {
+	d := newSmsHistoryDao()
+	defer d.Close()
+
+	d.SQLMock.ExpectQuery("SELECT count.*").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	exists, err := d.IDao.(SmsHistoryDao).ExistsByBizKey(d.Ctx, "reminder:due:1:1")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+}
+
+func Test_smsHistoryDao_CountByMobileSince(t *testing.T) {
+	d := newSmsHistoryDao()
+	defer d.Close()
+
+	d.SQLMock.ExpectQuery("SELECT count.*").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	count, err := d.IDao.(SmsHistoryDao).CountByMobileSince(d.Ctx, "13800000000", "reminder:", time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
+
+func Test_smsHistoryDao_UpdateDelivery(t *testing.T) {
+	d := newSmsHistoryDao()
+	defer d.Close()
+	testData := d.TestData.(*model.SmsHistory)
+	reportAt := time.Now()
+
+	d.SQLMock.ExpectQuery("SELECT .*").
+		WithArgs("aliyun", "biz1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testData.ID))
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WithArgs(1, "DELIVERED", d.AnyTime, testData.ID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(SmsHistoryDao).UpdateDelivery(d.Ctx, "aliyun", "biz1", &model.SmsHistory{
+		DeliveryStatus: 1,
+		ErrorCode:      "DELIVERED",
+		ReportAt:       &reportAt,
+	})
+	assert.NoError(t, err)
+
+	// not found
+	d.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	err = d.IDao.(SmsHistoryDao).UpdateDelivery(d.Ctx, "aliyun", "biz2", &model.SmsHistory{})
+	assert.ErrorIs(t, err, database.ErrRecordNotFound)
+}
+
+func Test_smsHistoryDao_GetDeletedByColumns(t *testing.T) {
+	d := newSmsHistoryDao()
+	defer d.Close()
+	testData := d.TestData.(*model.SmsHistory)
+
+	// column names and corresponding data
+	rows := sqlmock.NewRows([]string{"id", "deleted_at"}).
+		AddRow(testData.ID, d.AnyTime)
+
+	d.SQLMock.ExpectQuery("SELECT .* deleted_at IS NOT NULL.*").WillReturnRows(rows)
+
+	_, _, err := d.IDao.(SmsHistoryDao).GetDeletedByColumns(d.Ctx, &query.Params{
+		Page:  0,
+		Limit: 10,
+		Sort:  "ignore count", // ignore test count(*)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = d.SQLMock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_smsHistoryDao_RestoreByID(t *testing.T) {
+	d := newSmsHistoryDao()
+	defer d.Close()
+	testData := d.TestData.(*model.SmsHistory)
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WithArgs(sqlmock.AnyArg(), testData.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(SmsHistoryDao).RestoreByID(d.Ctx, testData.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// not in the trash
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WithArgs(sqlmock.AnyArg(), testData.ID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	d.SQLMock.ExpectCommit()
+
+	err = d.IDao.(SmsHistoryDao).RestoreByID(d.Ctx, testData.ID)
+	assert.ErrorIs(t, err, database.ErrRecordNotFound)
+}
+
+func Test_smsHistoryDao_PurgeByID(t *testing.T) {
+	d := newSmsHistoryDao()
+	defer d.Close()
+	testData := d.TestData.(*model.SmsHistory)
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("DELETE .*").
+		WithArgs(testData.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(SmsHistoryDao).PurgeByID(d.Ctx, testData.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// not in the trash
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("DELETE .*").
+		WithArgs(testData.ID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	d.SQLMock.ExpectCommit()
+
+	err = d.IDao.(SmsHistoryDao).PurgeByID(d.Ctx, testData.ID)
+	assert.ErrorIs(t, err, database.ErrRecordNotFound)
+}
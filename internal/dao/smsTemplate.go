@@ -0,0 +1,291 @@
+package dao
+
+import (
+	"context"
+	"errors"
+
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
+
+	"github.com/go-dev-frame/sponge/pkg/logger"
+	"github.com/go-dev-frame/sponge/pkg/sgorm/query"
+	"github.com/go-dev-frame/sponge/pkg/utils"
+
+	"lol/internal/cache"
+	"lol/internal/database"
+	"lol/internal/model"
+)
+
+var _ SmsTemplateDao = (*smsTemplateDao)(nil)
+
+// SmsTemplateDao defining the dao interface
+type SmsTemplateDao interface {
+	Create(ctx context.Context, table *model.SmsTemplate) error
+	DeleteByID(ctx context.Context, id uint64) error
+	UpdateByID(ctx context.Context, table *model.SmsTemplate) error
+	GetByID(ctx context.Context, id uint64) (*model.SmsTemplate, error)
+	GetByColumns(ctx context.Context, params *query.Params) ([]*model.SmsTemplate, int64, error)
+
+	CreateByTx(ctx context.Context, tx *gorm.DB, table *model.SmsTemplate) (uint64, error)
+	DeleteByTx(ctx context.Context, tx *gorm.DB, id uint64) error
+	UpdateByTx(ctx context.Context, tx *gorm.DB, table *model.SmsTemplate) error
+
+	GetByCode(ctx context.Context, code string) (*model.SmsTemplate, error)
+	SetEnabled(ctx context.Context, id uint64, enabled bool) error
+	SetSensitive(ctx context.Context, id uint64, sensitive bool) error
+}
+
+type smsTemplateDao struct {
+	db    *gorm.DB
+	cache cache.SmsTemplateCache // if nil, the cache is not used.
+	sfg   *singleflight.Group    // if cache is nil, the sfg is not used.
+}
+
+// NewSmsTemplateDao creating the dao interface
+func NewSmsTemplateDao(db *gorm.DB, xCache cache.SmsTemplateCache) SmsTemplateDao {
+	if xCache == nil {
+		return &smsTemplateDao{db: db}
+	}
+	return &smsTemplateDao{
+		db:    db,
+		cache: xCache,
+		sfg:   new(singleflight.Group),
+	}
+}
+
+func (d *smsTemplateDao) deleteCache(ctx context.Context, id uint64) error {
+	if d.cache != nil {
+		return d.cache.Del(ctx, id)
+	}
+	return nil
+}
+
+// Create a record, insert the record and the id value is written back to the table
+func (d *smsTemplateDao) Create(ctx context.Context, table *model.SmsTemplate) error {
+	return d.db.WithContext(ctx).Create(table).Error
+}
+
+// DeleteByID delete a record by id
+func (d *smsTemplateDao) DeleteByID(ctx context.Context, id uint64) error {
+	err := d.db.WithContext(ctx).Where("id = ?", id).Delete(&model.SmsTemplate{}).Error
+	if err != nil {
+		return err
+	}
+
+	// delete cache
+	_ = d.deleteCache(ctx, id)
+
+	return nil
+}
+
+// UpdateByID update a record by id
+func (d *smsTemplateDao) UpdateByID(ctx context.Context, table *model.SmsTemplate) error {
+	err := d.updateDataByID(ctx, d.db, table)
+
+	// delete cache
+	_ = d.deleteCache(ctx, table.ID)
+
+	return err
+}
+
+func (d *smsTemplateDao) updateDataByID(ctx context.Context, db *gorm.DB, table *model.SmsTemplate) error {
+	if table.ID < 1 {
+		return errors.New("id cannot be 0")
+	}
+
+	update := map[string]interface{}{}
+
+	if table.Code != "" {
+		update["code"] = table.Code
+	}
+	if table.TemplateID != "" {
+		update["template_id"] = table.TemplateID
+	}
+	if table.Content != "" {
+		update["content"] = table.Content
+	}
+	if table.CreateAt.IsZero() == false {
+		update["create_at"] = table.CreateAt
+	}
+
+	return db.WithContext(ctx).Model(table).Updates(update).Error
+}
+
+// GetByID get a record by id
+func (d *smsTemplateDao) GetByID(ctx context.Context, id uint64) (*model.SmsTemplate, error) {
+	// no cache
+	if d.cache == nil {
+		record := &model.SmsTemplate{}
+		err := d.db.WithContext(ctx).Where("id = ?", id).First(record).Error
+		return record, err
+	}
+
+	// get from cache
+	record, err := d.cache.Get(ctx, id)
+	if err == nil {
+		return record, nil
+	}
+
+	// get from database
+	if errors.Is(err, database.ErrCacheNotFound) {
+		// for the same id, prevent high concurrent simultaneous access to database
+		val, err, _ := d.sfg.Do(utils.Uint64ToStr(id), func() (interface{}, error) { //nolint
+			table := &model.SmsTemplate{}
+			err = d.db.WithContext(ctx).Where("id = ?", id).First(table).Error
+			if err != nil {
+				if errors.Is(err, database.ErrRecordNotFound) {
+					// set placeholder cache to prevent cache penetration, default expiration time 10 minutes
+					if err = d.cache.SetPlaceholder(ctx, id); err != nil {
+						logger.Warn("cache.SetPlaceholder error", logger.Err(err), logger.Any("id", id))
+					}
+					return nil, database.ErrRecordNotFound
+				}
+				return nil, err
+			}
+			// set cache
+			if err = d.cache.Set(ctx, id, table, cache.SmsTemplateExpireTime); err != nil {
+				logger.Warn("cache.Set error", logger.Err(err), logger.Any("id", id))
+			}
+			return table, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		table, ok := val.(*model.SmsTemplate)
+		if !ok {
+			return nil, database.ErrRecordNotFound
+		}
+		return table, nil
+	}
+
+	if d.cache.IsPlaceholderErr(err) {
+		return nil, database.ErrRecordNotFound
+	}
+
+	return nil, err
+}
+
+// GetByColumns get paging records by column information,
+// Note: query performance degrades when table rows are very large because of the use of offset.
+//
+// params includes paging parameters and query parameters
+// paging parameters (required):
+//
+//	page: page number, starting from 0
+//	limit: lines per page
+//	sort: sort fields, default is id backwards, you can add - sign before the field to indicate reverse order, no - sign to indicate ascending order, multiple fields separated by comma
+//
+// query parameters (not required):
+//
+//	name: column name
+//	exp: expressions, which default is "=",  support =, !=, >, >=, <, <=, like, in, notin, isnull, isnotnull
+//	value: column value, if exp=in, multiple values are separated by commas
+//	logic: logical type, default value is "and", support &, and, ||, or
+//
+// example: search for a male over 20 years of age
+//
+//	params = &query.Params{
+//	    Page: 0,
+//	    Limit: 20,
+//	    Columns: []query.Column{
+//		{
+//			Name:    "age",
+//			Exp: ">",
+//			Value:   20,
+//		},
+//		{
+//			Name:  "gender",
+//			Value: "male",
+//		},
+//	}
+func (d *smsTemplateDao) GetByColumns(ctx context.Context, params *query.Params) ([]*model.SmsTemplate, int64, error) {
+	queryStr, args, err := params.ConvertToGormConditions()
+	if err != nil {
+		return nil, 0, errors.New("query params error: " + err.Error())
+	}
+
+	var total int64
+	if params.Sort != "ignore count" { // determine if count is required
+		err = d.db.WithContext(ctx).Model(&model.SmsTemplate{}).Where(queryStr, args...).Count(&total).Error
+		if err != nil {
+			return nil, 0, err
+		}
+		if total == 0 {
+			return nil, total, nil
+		}
+	}
+
+	records := []*model.SmsTemplate{}
+	order, limit, offset := params.ConvertToPage()
+	err = d.db.WithContext(ctx).Order(order).Limit(limit).Offset(offset).Where(queryStr, args...).Find(&records).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return records, total, err
+}
+
+// CreateByTx create a record in the database using the provided transaction
+func (d *smsTemplateDao) CreateByTx(ctx context.Context, tx *gorm.DB, table *model.SmsTemplate) (uint64, error) {
+	err := tx.WithContext(ctx).Create(table).Error
+	return table.ID, err
+}
+
+// DeleteByTx delete a record by id in the database using the provided transaction
+func (d *smsTemplateDao) DeleteByTx(ctx context.Context, tx *gorm.DB, id uint64) error {
+	err := tx.WithContext(ctx).Where("id = ?", id).Delete(&model.SmsTemplate{}).Error
+	if err != nil {
+		return err
+	}
+
+	// delete cache
+	_ = d.deleteCache(ctx, id)
+
+	return nil
+}
+
+// UpdateByTx update a record by id in the database using the provided transaction
+func (d *smsTemplateDao) UpdateByTx(ctx context.Context, tx *gorm.DB, table *model.SmsTemplate) error {
+	err := d.updateDataByID(ctx, tx, table)
+
+	// delete cache
+	_ = d.deleteCache(ctx, table.ID)
+
+	return err
+}
+
+// GetByCode get the template by code
+func (d *smsTemplateDao) GetByCode(ctx context.Context, code string) (*model.SmsTemplate, error) {
+	table := &model.SmsTemplate{}
+	err := d.db.WithContext(ctx).Where("code = ?", code).First(table).Error
+	if err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+// SetEnabled enable or disable the template, the enabled flag is not updated by UpdateByID because false is a zero value
+func (d *smsTemplateDao) SetEnabled(ctx context.Context, id uint64, enabled bool) error {
+	err := d.db.WithContext(ctx).Model(&model.SmsTemplate{}).Where("id = ?", id).Update("enabled", enabled).Error
+	if err != nil {
+		return err
+	}
+
+	// delete cache
+	_ = d.deleteCache(ctx, id)
+
+	return nil
+}
+
+// SetSensitive mark the template sensitive or not, the flag is not updated by UpdateByID because false is a zero value
+func (d *smsTemplateDao) SetSensitive(ctx context.Context, id uint64, sensitive bool) error {
+	err := d.db.WithContext(ctx).Model(&model.SmsTemplate{}).Where("id = ?", id).Update("sensitive", sensitive).Error
+	if err != nil {
+		return err
+	}
+
+	// delete cache
+	_ = d.deleteCache(ctx, id)
+
+	return nil
+}
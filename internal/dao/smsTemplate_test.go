@@ -0,0 +1,262 @@
+package dao
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-dev-frame/sponge/pkg/gotest"
+	"github.com/go-dev-frame/sponge/pkg/sgorm/query"
+	"github.com/go-dev-frame/sponge/pkg/utils"
+	"github.com/stretchr/testify/assert"
+
+	"lol/internal/cache"
+	"lol/internal/database"
+	"lol/internal/model"
+)
+
+func newSmsTemplateDao() *gotest.Dao {
+	testData := &model.SmsTemplate{}
+	testData.ID = 1
+	// you can set the other fields of testData here, such as:
+	//testData.CreatedAt = time.Now()
+	//testData.UpdatedAt = testData.CreatedAt
+
+	// init mock cache
+	//c := gotest.NewCache(map[string]interface{}{"no cache": testData}) // to test mysql, disable caching
+	c := gotest.NewCache(map[string]interface{}{utils.Uint64ToStr(testData.ID): testData})
+	c.ICache = cache.NewSmsTemplateCache(&database.CacheType{
+		CType: "redis",
+		Rdb:   c.RedisClient,
+	})
+
+	// init mock dao
+	d := gotest.NewDao(c, testData)
+	d.IDao = NewSmsTemplateDao(d.DB, c.ICache.(cache.SmsTemplateCache))
+
+	return d
+}
+
+func Test_smsTemplateDao_Create(t *testing.T) {
+	d := newSmsTemplateDao()
+	defer d.Close()
+	testData := d.TestData.(*model.SmsTemplate)
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("INSERT INTO .*").
+		WithArgs(d.GetAnyArgs(testData)...).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(SmsTemplateDao).Create(d.Ctx, testData)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_smsTemplateDao_DeleteByID(t *testing.T) {
+	d := newSmsTemplateDao()
+	defer d.Close()
+	testData := d.TestData.(*model.SmsTemplate)
+	expectedSQLForDeletion := "DELETE .*"
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec(expectedSQLForDeletion).
+		WithArgs(testData.ID).
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(SmsTemplateDao).DeleteByID(d.Ctx, testData.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// zero id error
+	err = d.IDao.(SmsTemplateDao).DeleteByID(d.Ctx, 0)
+	assert.Error(t, err)
+}
+
+func Test_smsTemplateDao_UpdateByID(t *testing.T) {
+	d := newSmsTemplateDao()
+	defer d.Close()
+	testData := d.TestData.(*model.SmsTemplate)
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WithArgs(d.AnyTime, testData.ID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(SmsTemplateDao).UpdateByID(d.Ctx, testData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// zero id error
+	err = d.IDao.(SmsTemplateDao).UpdateByID(d.Ctx, &model.SmsTemplate{})
+	assert.Error(t, err)
+
+}
+
+func Test_smsTemplateDao_GetByID(t *testing.T) {
+	d := newSmsTemplateDao()
+	defer d.Close()
+	testData := d.TestData.(*model.SmsTemplate)
+
+	// column names and corresponding data
+	rows := sqlmock.NewRows([]string{"id"}).
+		AddRow(testData.ID)
+
+	d.SQLMock.ExpectQuery("SELECT .*").
+		WithArgs(testData.ID).
+		WillReturnRows(rows)
+
+	_, err := d.IDao.(SmsTemplateDao).GetByID(d.Ctx, testData.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = d.SQLMock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// notfound error
+	d.SQLMock.ExpectQuery("SELECT .*").
+		WithArgs(2).
+		WillReturnRows(rows)
+	_, err = d.IDao.(SmsTemplateDao).GetByID(d.Ctx, 2)
+	assert.Error(t, err)
+
+	d.SQLMock.ExpectQuery("SELECT .*").
+		WithArgs(3, 4).
+		WillReturnRows(rows)
+	_, err = d.IDao.(SmsTemplateDao).GetByID(d.Ctx, 4)
+	assert.Error(t, err)
+}
+
+func Test_smsTemplateDao_GetByColumns(t *testing.T) {
+	d := newSmsTemplateDao()
+	defer d.Close()
+	testData := d.TestData.(*model.SmsTemplate)
+
+	// column names and corresponding data
+	rows := sqlmock.NewRows([]string{"id"}).
+		AddRow(testData.ID)
+
+	d.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(rows)
+
+	_, _, err := d.IDao.(SmsTemplateDao).GetByColumns(d.Ctx, &query.Params{
+		Page:  0,
+		Limit: 10,
+		Sort:  "ignore count", // ignore test count(*)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = d.SQLMock.ExpectationsWereMet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// err test
+	_, _, err = d.IDao.(SmsTemplateDao).GetByColumns(d.Ctx, &query.Params{
+		Page:  0,
+		Limit: 10,
+		Columns: []query.Column{
+			{
+				Name:  "id",
+				Exp:   "<",
+				Value: 0,
+			},
+		},
+	})
+	assert.Error(t, err)
+
+	// error test
+	dao := &smsTemplateDao{}
+	_, _, err = dao.GetByColumns(context.Background(), &query.Params{Columns: []query.Column{{}}})
+	t.Log(err)
+}
+
+func Test_smsTemplateDao_CreateByTx(t *testing.T) {
+	d := newSmsTemplateDao()
+	defer d.Close()
+	testData := d.TestData.(*model.SmsTemplate)
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("INSERT INTO .*").
+		WithArgs(d.GetAnyArgs(testData)...).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	d.SQLMock.ExpectCommit()
+
+	_, err := d.IDao.(SmsTemplateDao).CreateByTx(d.Ctx, d.DB, testData)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_smsTemplateDao_DeleteByTx(t *testing.T) {
+	d := newSmsTemplateDao()
+	defer d.Close()
+	testData := d.TestData.(*model.SmsTemplate)
+	expectedSQLForDeletion := "DELETE .*"
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec(expectedSQLForDeletion).
+		WithArgs(testData.ID).
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(SmsTemplateDao).DeleteByTx(d.Ctx, d.DB, testData.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_smsTemplateDao_UpdateByTx(t *testing.T) {
+	d := newSmsTemplateDao()
+	defer d.Close()
+	testData := d.TestData.(*model.SmsTemplate)
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WithArgs(d.AnyTime, testData.ID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(SmsTemplateDao).UpdateByTx(d.Ctx, d.DB, testData)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_smsTemplateDao_GetByCode(t *testing.T) {
+	d := newSmsTemplateDao()
+	defer d.Close()
+	testData := d.TestData.(*model.SmsTemplate)
+
+	rows := sqlmock.NewRows([]string{"id", "code"}).
+		AddRow(testData.ID, "loginCode")
+	d.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(rows)
+
+	record, err := d.IDao.(SmsTemplateDao).GetByCode(d.Ctx, "loginCode")
+	assert.NoError(t, err)
+	assert.Equal(t, "loginCode", record.Code)
+}
+
+func Test_smsTemplateDao_SetEnabled(t *testing.T) {
+	d := newSmsTemplateDao()
+	defer d.Close()
+	testData := d.TestData.(*model.SmsTemplate)
+
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").
+		WithArgs(false, testData.ID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	d.SQLMock.ExpectCommit()
+
+	err := d.IDao.(SmsTemplateDao).SetEnabled(d.Ctx, testData.ID, false)
+	assert.NoError(t, err)
+}
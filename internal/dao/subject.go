@@ -0,0 +1,239 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"lol/internal/audit"
+	"lol/internal/cache"
+	"lol/internal/fieldcrypt"
+	"lol/internal/model"
+)
+
+// SubjectData the personal data held about a data subject, i.e. a borrower identified by mobile or ID number
+type SubjectData struct {
+	Loans            []*model.Loan           `json:"loans"`
+	PaymentHistories []*model.PaymentHistory `json:"paymentHistories"`
+	Results          []*model.Result         `json:"results"`
+	SmsHistories     []*model.SmsHistory     `json:"smsHistories"`
+}
+
+var _ SubjectDao = (*subjectDao)(nil)
+
+// SubjectDao gather and anonymize the personal data of a data subject across the tables
+type SubjectDao interface {
+	GetData(ctx context.Context, mobile string, userID string) (*SubjectData, error)
+	Anonymize(ctx context.Context, data *SubjectData, pseudonym string) error
+}
+
+type subjectDao struct {
+	db                  *gorm.DB
+	loanCache           cache.LoanCache           // if nil, the cache is not used.
+	paymentHistoryCache cache.PaymentHistoryCache // if nil, the cache is not used.
+	resultCache         cache.ResultCache         // if nil, the cache is not used.
+	smsHistoryCache     cache.SmsHistoryCache     // if nil, the cache is not used.
+}
+
+// NewSubjectDao creating the dao interface, the caches of the records are deleted after they are anonymized
+func NewSubjectDao(db *gorm.DB, loanCache cache.LoanCache, paymentHistoryCache cache.PaymentHistoryCache,
+	resultCache cache.ResultCache, smsHistoryCache cache.SmsHistoryCache) SubjectDao {
+	return &subjectDao{
+		db:                  db,
+		loanCache:           loanCache,
+		paymentHistoryCache: paymentHistoryCache,
+		resultCache:         resultCache,
+		smsHistoryCache:     smsHistoryCache,
+	}
+}
+
+// GetData gather the data of the subject: the loans of the mobile or the ID number, the payments and the messages
+// of these loans or of the mobiles of these loans, and the payment results of the payments.
+// The records in the trash are included, they are personal data held all the same.
+func (d *subjectDao) GetData(ctx context.Context, mobile string, userID string) (*SubjectData, error) {
+	var conditions []string
+	var args []interface{}
+	if mobile != "" {
+		conditions = append(conditions, "mobile_index = ?")
+		args = append(args, fieldcrypt.BlindIndex(fieldcrypt.IndexMobile, mobile))
+	}
+	if userID != "" {
+		conditions = append(conditions, "user_id_index = ?")
+		args = append(args, fieldcrypt.BlindIndex(fieldcrypt.IndexUserID, userID))
+	}
+	if len(conditions) == 0 {
+		return nil, errors.New("mobile or userID is required")
+	}
+
+	db := d.db.WithContext(ctx).Unscoped()
+	data := &SubjectData{}
+	err := db.Where(strings.Join(conditions, " OR "), args...).Order("id").Find(&data.Loans).Error
+	if err != nil {
+		return nil, err
+	}
+
+	loanIDs := []uint64{}
+	mobileIndexes := []string{}
+	addMobile := func(v string) {
+		index := fieldcrypt.BlindIndex(fieldcrypt.IndexMobile, v)
+		for _, existing := range mobileIndexes {
+			if existing == index {
+				return
+			}
+		}
+		if index != "" {
+			mobileIndexes = append(mobileIndexes, index)
+		}
+	}
+	addMobile(mobile)
+	for _, loan := range data.Loans {
+		loanIDs = append(loanIDs, loan.ID)
+		addMobile(loan.Mobile)
+	}
+	if len(loanIDs) == 0 && len(mobileIndexes) == 0 {
+		return data, nil
+	}
+	// IN with an empty list matches nothing
+	if len(loanIDs) == 0 {
+		loanIDs = append(loanIDs, 0)
+	}
+	if len(mobileIndexes) == 0 {
+		mobileIndexes = append(mobileIndexes, "")
+	}
+
+	err = db.Where("(loan_id IN ? AND loan_id > 0) OR user_phone_index IN ?", loanIDs, mobileIndexes).
+		Order("id").Find(&data.PaymentHistories).Error
+	if err != nil {
+		return nil, err
+	}
+	err = db.Where("(loan_id IN ? AND loan_id > 0) OR mobile_index IN ?", loanIDs, mobileIndexes).
+		Order("id").Find(&data.SmsHistories).Error
+	if err != nil {
+		return nil, err
+	}
+
+	tradeNos := []string{}
+	for _, payment := range data.PaymentHistories {
+		if payment.OutTradeNo != "" {
+			tradeNos = append(tradeNos, payment.OutTradeNo)
+		}
+	}
+	if len(tradeNos) > 0 {
+		err = db.Where("out_trade_no IN ?", tradeNos).Order("id").Find(&data.Results).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
+
+// Anonymize replace the identifying fields of the loans and the payments with the pseudonym and clear the payers
+// of the payment results, the amounts are kept for the retention of financial records. The messages are deleted
+// permanently. The records in the trash are anonymized too.
+func (d *subjectDao) Anonymize(ctx context.Context, data *SubjectData, pseudonym string) error {
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		tx = tx.Unscoped()
+		for _, loan := range data.Loans {
+			update := map[string]interface{}{"car_plate": "", "version": gorm.Expr("version + 1")}
+			for column, kind := range loanIndexColumns {
+				if err := setEncrypted(update, column, kind, pseudonym); err != nil {
+					return err
+				}
+			}
+			update["user_code_index"] = fieldcrypt.BlindIndex(fieldcrypt.IndexUserCode, fieldcrypt.UserCode(pseudonym))
+			if err := tx.Model(&model.Loan{}).Where("id = ?", loan.ID).Updates(update).Error; err != nil {
+				return err
+			}
+		}
+
+		for _, payment := range data.PaymentHistories {
+			update := map[string]interface{}{}
+			if err := setEncrypted(update, "user_phone", fieldcrypt.IndexMobile, pseudonym); err != nil {
+				return err
+			}
+			if err := tx.Model(&model.PaymentHistory{}).Where("id = ?", payment.ID).Updates(update).Error; err != nil {
+				return err
+			}
+		}
+
+		for _, result := range data.Results {
+			err := tx.Model(&model.Result{}).Where("id = ?", result.ID).Updates(map[string]interface{}{"payer": ""}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(data.SmsHistories) > 0 {
+			ids := make([]uint64, 0, len(data.SmsHistories))
+			for _, v := range data.SmsHistories {
+				ids = append(ids, v.ID)
+			}
+			if err := tx.Where("id IN ?", ids).Delete(&model.SmsHistory{}).Error; err != nil {
+				return err
+			}
+		}
+
+		return scrubAuditLogs(tx, data)
+	})
+	if err != nil {
+		return err
+	}
+
+	// delete cache
+	for _, v := range data.Loans {
+		if d.loanCache != nil {
+			_ = d.loanCache.Del(ctx, v.ID)
+		}
+	}
+	for _, v := range data.PaymentHistories {
+		if d.paymentHistoryCache != nil {
+			_ = d.paymentHistoryCache.Del(ctx, v.ID)
+		}
+	}
+	for _, v := range data.Results {
+		if d.resultCache != nil {
+			_ = d.resultCache.Del(ctx, v.ID)
+		}
+	}
+	for _, v := range data.SmsHistories {
+		if d.smsHistoryCache != nil {
+			_ = d.smsHistoryCache.Del(ctx, v.ID)
+		}
+	}
+
+	return nil
+}
+
+// scrubAuditLogs redact the personal information in the past audit logs of the records of the subject,
+// including the logs of the anonymization itself
+func scrubAuditLogs(tx *gorm.DB, data *SubjectData) error {
+	loanIDs := make([]uint64, 0, len(data.Loans))
+	for _, v := range data.Loans {
+		loanIDs = append(loanIDs, v.ID)
+	}
+	if err := audit.Scrub(tx, &model.Loan{}, loanIDs, "car_plate"); err != nil {
+		return err
+	}
+	paymentIDs := make([]uint64, 0, len(data.PaymentHistories))
+	for _, v := range data.PaymentHistories {
+		paymentIDs = append(paymentIDs, v.ID)
+	}
+	if err := audit.Scrub(tx, &model.PaymentHistory{}, paymentIDs); err != nil {
+		return err
+	}
+	resultIDs := make([]uint64, 0, len(data.Results))
+	for _, v := range data.Results {
+		resultIDs = append(resultIDs, v.ID)
+	}
+	if err := audit.Scrub(tx, &model.Result{}, resultIDs, "payer"); err != nil {
+		return err
+	}
+	smsIDs := make([]uint64, 0, len(data.SmsHistories))
+	for _, v := range data.SmsHistories {
+		smsIDs = append(smsIDs, v.ID)
+	}
+	return audit.Scrub(tx, &model.SmsHistory{}, smsIDs, "content")
+}
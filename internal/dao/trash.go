@@ -0,0 +1,72 @@
+package dao
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/go-dev-frame/sponge/pkg/sgorm/query"
+
+	"lol/internal/database"
+)
+
+// the models with gorm.DeletedAt are soft deleted, gorm sets deleted_at instead of deleting the row and
+// skips the deleted rows in queries. The deleted rows are in the trash, they can be listed, restored or purged.
+
+// getDeletedByColumns get paging soft deleted records by column information, see smsTemplateDao.GetByColumns
+// for the params
+func getDeletedByColumns[T any](ctx context.Context, db *gorm.DB, params *query.Params) ([]*T, int64, error) {
+	queryStr, args, err := params.ConvertToGormConditions()
+	if err != nil {
+		return nil, 0, errors.New("query params error: " + err.Error())
+	}
+
+	var total int64
+	if params.Sort != "ignore count" { // determine if count is required
+		err = db.WithContext(ctx).Unscoped().Model(new(T)).Where("deleted_at IS NOT NULL").
+			Where(queryStr, args...).Count(&total).Error
+		if err != nil {
+			return nil, 0, err
+		}
+		if total == 0 {
+			return nil, total, nil
+		}
+	}
+
+	records := []*T{}
+	order, limit, offset := params.ConvertToPage()
+	err = db.WithContext(ctx).Unscoped().Order(order).Limit(limit).Offset(offset).Where("deleted_at IS NOT NULL").
+		Where(queryStr, args...).Find(&records).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return records, total, err
+}
+
+// restoreByID restore a soft deleted record by id, database.ErrRecordNotFound if it is not in the trash
+func restoreByID[T any](ctx context.Context, db *gorm.DB, id uint64) error {
+	result := db.WithContext(ctx).Unscoped().Model(new(T)).Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return database.ErrRecordNotFound
+	}
+	return nil
+}
+
+// purgeByID delete a soft deleted record permanently by id, database.ErrRecordNotFound if it is not in the trash,
+// records must be deleted before they can be purged
+func purgeByID[T any](ctx context.Context, db *gorm.DB, id uint64) error {
+	result := db.WithContext(ctx).Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).Delete(new(T))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return database.ErrRecordNotFound
+	}
+	return nil
+}
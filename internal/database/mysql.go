@@ -8,7 +8,9 @@ import (
 	"github.com/go-dev-frame/sponge/pkg/sgorm/mysql"
 	"github.com/go-dev-frame/sponge/pkg/utils"
 
+	"lol/internal/audit"
 	"lol/internal/config"
+	"lol/internal/model"
 )
 
 // InitMysql connect mysql
@@ -36,8 +38,12 @@ func InitMysql() *sgorm.DB {
 	//	mysqlCfg.MastersDsn...,
 	//))
 
-	// add custom gorm plugin
-	//opts = append(opts, mysql.WithGormPlugin(yourPlugin))
+	// audit the changes of the admin users, the password hashes and the two factor secrets are not logged,
+	// neither are the personal information and its blind indexes, the personal information access logs are only appended
+	opts = append(opts, mysql.WithGormPlugin(audit.NewPlugin(
+		[]string{(&model.PiiAccessLog{}).TableName()},
+		[]string{"password", "totp_secret", "recovery_codes"},
+	)))
 
 	dsn := utils.AdaptiveMysqlDsn(mysqlCfg.Dsn)
 	db, err := mysql.Init(dsn, opts...)
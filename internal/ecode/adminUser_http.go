@@ -0,0 +1,31 @@
+package ecode
+
+import (
+	"github.com/go-dev-frame/sponge/pkg/errcode"
+)
+
+// adminUser business-level http error codes.
+// the adminUserNO value range is 1~100, if the same error code is used, it will cause panic.
+var (
+	adminUserNO       = 68
+	adminUserName     = "adminUser"
+	adminUserBaseCode = errcode.HCode(adminUserNO)
+
+	ErrCreateAdminUser     = errcode.NewError(adminUserBaseCode+1, "failed to create "+adminUserName)
+	ErrDeleteByIDAdminUser = errcode.NewError(adminUserBaseCode+2, "failed to delete "+adminUserName)
+	ErrUpdateByIDAdminUser = errcode.NewError(adminUserBaseCode+3, "failed to update "+adminUserName)
+	ErrGetByIDAdminUser    = errcode.NewError(adminUserBaseCode+4, "failed to get "+adminUserName+" details")
+	ErrListAdminUser       = errcode.NewError(adminUserBaseCode+5, "failed to list of "+adminUserName)
+	ErrAdminLogin          = errcode.NewError(adminUserBaseCode+6, "username or password is wrong")
+	ErrAdminUserExists     = errcode.NewError(adminUserBaseCode+7, adminUserName+" already exists")
+	ErrTwoFactorCode       = errcode.NewError(adminUserBaseCode+8, "two-factor code is wrong")
+	ErrTwoFactorChallenge  = errcode.NewError(adminUserBaseCode+9, "login challenge is invalid or expired, please login again")
+	ErrTwoFactorEnabled    = errcode.NewError(adminUserBaseCode+10, "two-factor authentication is already enabled")
+	ErrTwoFactorNotSetup   = errcode.NewError(adminUserBaseCode+11, "two-factor authentication is not set up")
+	ErrTwoFactorRequired   = errcode.NewError(adminUserBaseCode+12, "two-factor authentication is required for the role")
+	ErrPasswordChange      = errcode.NewError(adminUserBaseCode+13, "the password must be changed first")
+	ErrAdminPassword       = errcode.NewError(adminUserBaseCode+14, "password is wrong")
+	ErrSamePassword        = errcode.NewError(adminUserBaseCode+15, "the new password must be different from the old one")
+
+	// error codes are globally unique, adding 1 to the previous error code
+)
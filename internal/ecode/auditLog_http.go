@@ -0,0 +1,17 @@
+package ecode
+
+import (
+	"github.com/go-dev-frame/sponge/pkg/errcode"
+)
+
+// auditLog business-level http error codes.
+// the auditLogNO value range is 1~100, if the same error code is used, it will cause panic.
+var (
+	auditLogNO       = 93
+	auditLogName     = "auditLog"
+	auditLogBaseCode = errcode.HCode(auditLogNO)
+
+	ErrListAuditLog = errcode.NewError(auditLogBaseCode+1, "failed to list of "+auditLogName)
+
+	// error codes are globally unique, adding 1 to the previous error code
+)
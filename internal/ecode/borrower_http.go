@@ -0,0 +1,25 @@
+package ecode
+
+import (
+	"github.com/go-dev-frame/sponge/pkg/errcode"
+)
+
+// borrower business-level http error codes.
+// the borrowerNO value range is 1~100, if the same error code is used, it will cause panic.
+var (
+	borrowerNO       = 62
+	borrowerName     = "borrower"
+	borrowerBaseCode = errcode.HCode(borrowerNO)
+
+	ErrBorrowerNotFound    = errcode.NewError(borrowerBaseCode+1, borrowerName+" not found, maybe mobile or code is wrong")
+	ErrSendBorrowerOtp     = errcode.NewError(borrowerBaseCode+2, "failed to send login code")
+	ErrBorrowerOtpInterval = errcode.NewError(borrowerBaseCode+3, "login code sent too frequently, please try again later")
+	ErrBorrowerOtpLimit    = errcode.NewError(borrowerBaseCode+4, "login code daily limit exceeded")
+	ErrBorrowerOtp         = errcode.NewError(borrowerBaseCode+5, "login code is wrong or expired")
+	ErrBorrowerLogin       = errcode.NewError(borrowerBaseCode+6, "failed to login "+borrowerName)
+	ErrBorrowerLocked      = errcode.NewError(borrowerBaseCode+7, "too many failed attempts, please try again later")
+	ErrBorrowerCaptcha     = errcode.NewError(borrowerBaseCode+8, "captcha is required or wrong")
+	ErrBorrowerCaptchaGen  = errcode.NewError(borrowerBaseCode+9, "failed to generate captcha")
+
+	// error codes are globally unique, adding 1 to the previous error code
+)
@@ -0,0 +1,23 @@
+package ecode
+
+import (
+	"github.com/go-dev-frame/sponge/pkg/errcode"
+)
+
+// loanProduct business-level http error codes.
+// the loanProductNO value range is 1~100, if the same error code is used, it will cause panic.
+var (
+	loanProductNO       = 56
+	loanProductName     = "loanProduct"
+	loanProductBaseCode = errcode.HCode(loanProductNO)
+
+	ErrCreateLoanProduct     = errcode.NewError(loanProductBaseCode+1, "failed to create "+loanProductName)
+	ErrDeleteByIDLoanProduct = errcode.NewError(loanProductBaseCode+2, "failed to delete "+loanProductName)
+	ErrUpdateByIDLoanProduct = errcode.NewError(loanProductBaseCode+3, "failed to update "+loanProductName)
+	ErrGetByIDLoanProduct    = errcode.NewError(loanProductBaseCode+4, "failed to get "+loanProductName+" details")
+	ErrListLoanProduct       = errcode.NewError(loanProductBaseCode+5, "failed to list of "+loanProductName)
+	ErrLoanProductTerm       = errcode.NewError(loanProductBaseCode+6, "loan period is not offered by "+loanProductName)
+	ErrLoanProductTerms      = errcode.NewError(loanProductBaseCode+7, "invalid terms of "+loanProductName)
+
+	// error codes are globally unique, adding 1 to the previous error code
+)
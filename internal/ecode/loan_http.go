@@ -18,6 +18,8 @@ var (
 	ErrListLoan       = errcode.NewError(loanBaseCode+5, "failed to list of "+loanName+",maybe username or password is wrong!")
 	ErrLoanStatus     = errcode.NewError(loanBaseCode+6, "loan status error")
 	ErrCreatePayment  = errcode.NewError(loanBaseCode+7, "failed to create payment")
+	ErrPayAmount      = errcode.NewError(loanBaseCode+8, "invalid installments or amount")
+	ErrPayLoan        = errcode.NewError(loanBaseCode+9, "loan not found, please specify the loan to pay")
 
 	// error codes are globally unique, adding 1 to the previous error code
 )
@@ -0,0 +1,17 @@
+package ecode
+
+import (
+	"github.com/go-dev-frame/sponge/pkg/errcode"
+)
+
+// piiAccessLog business-level http error codes.
+// the piiAccessLogNO value range is 1~100, if the same error code is used, it will cause panic.
+var (
+	piiAccessLogNO       = 88
+	piiAccessLogName     = "piiAccessLog"
+	piiAccessLogBaseCode = errcode.HCode(piiAccessLogNO)
+
+	ErrListPiiAccessLog = errcode.NewError(piiAccessLogBaseCode+1, "failed to list of "+piiAccessLogName)
+
+	// error codes are globally unique, adding 1 to the previous error code
+)
@@ -0,0 +1,19 @@
+package ecode
+
+import (
+	"github.com/go-dev-frame/sponge/pkg/errcode"
+)
+
+// session business-level http error codes.
+// the sessionNO value range is 1~100, if the same error code is used, it will cause panic.
+var (
+	sessionNO       = 73
+	sessionName     = "session"
+	sessionBaseCode = errcode.HCode(sessionNO)
+
+	ErrListSession   = errcode.NewError(sessionBaseCode+1, "failed to list of "+sessionName)
+	ErrRevokeSession = errcode.NewError(sessionBaseCode+2, "failed to revoke "+sessionName)
+	ErrLogout        = errcode.NewError(sessionBaseCode+3, "failed to logout")
+
+	// error codes are globally unique, adding 1 to the previous error code
+)
@@ -0,0 +1,24 @@
+package ecode
+
+import (
+	"github.com/go-dev-frame/sponge/pkg/errcode"
+)
+
+// smsTemplate business-level http error codes.
+// the smsTemplateNO value range is 1~100, if the same error code is used, it will cause panic.
+var (
+	smsTemplateNO       = 84
+	smsTemplateName     = "smsTemplate"
+	smsTemplateBaseCode = errcode.HCode(smsTemplateNO)
+
+	ErrCreateSmsTemplate     = errcode.NewError(smsTemplateBaseCode+1, "failed to create "+smsTemplateName)
+	ErrDeleteByIDSmsTemplate = errcode.NewError(smsTemplateBaseCode+2, "failed to delete "+smsTemplateName)
+	ErrUpdateByIDSmsTemplate = errcode.NewError(smsTemplateBaseCode+3, "failed to update "+smsTemplateName)
+	ErrGetByIDSmsTemplate    = errcode.NewError(smsTemplateBaseCode+4, "failed to get "+smsTemplateName+" details")
+	ErrListSmsTemplate       = errcode.NewError(smsTemplateBaseCode+5, "failed to list of "+smsTemplateName)
+	ErrSmsTemplateVariable   = errcode.NewError(smsTemplateBaseCode+6, "invalid variables of "+smsTemplateName)
+	ErrSmsTemplateCodeExists = errcode.NewError(smsTemplateBaseCode+7, smsTemplateName+" code already exists")
+	ErrPreviewSmsTemplate    = errcode.NewError(smsTemplateBaseCode+8, "failed to preview "+smsTemplateName)
+
+	// error codes are globally unique, adding 1 to the previous error code
+)
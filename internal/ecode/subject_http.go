@@ -0,0 +1,20 @@
+package ecode
+
+import (
+	"github.com/go-dev-frame/sponge/pkg/errcode"
+)
+
+// subject business-level http error codes, the requests of data subjects to export or erase their data.
+// the subjectNO value range is 1~100, if the same error code is used, it will cause panic.
+var (
+	subjectNO       = 91
+	subjectName     = "subject"
+	subjectBaseCode = errcode.HCode(subjectNO)
+
+	ErrExportSubject     = errcode.NewError(subjectBaseCode+1, "failed to export the data of "+subjectName)
+	ErrAnonymizeSubject  = errcode.NewError(subjectBaseCode+2, "failed to anonymize the data of "+subjectName)
+	ErrSubjectNotFound   = errcode.NewError(subjectBaseCode+3, "no data of the "+subjectName)
+	ErrSubjectActiveLoan = errcode.NewError(subjectBaseCode+4, "the "+subjectName+" has loans not paid off, the data can't be anonymized")
+
+	// error codes are globally unique, adding 1 to the previous error code
+)
@@ -0,0 +1,306 @@
+// Package fieldcrypt encrypt personal information columns at rest with AES-GCM, and compute HMAC blind indexes
+// of them, so the encrypted columns can still be searched by exact value.
+//
+// Model fields tagged with `gorm:"serializer:encrypt"` are encrypted when they are written by gorm with a struct
+// and decrypted when they are read, map updates must encrypt the values with Encrypt.
+// An encrypted value is "enc:v{version}:" followed by the base64 of nonce and ciphertext, the version selects
+// the key, so keys can be rotated by adding a new version and migrating the rows with cmd/encrypt-pii.
+// Values without the prefix are plaintext written before the encryption was enabled, they are read as they are.
+package fieldcrypt
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"gorm.io/gorm/schema"
+
+	"lol/internal/config"
+)
+
+// SerializerName the gorm serializer of the encrypted fields
+const SerializerName = "encrypt"
+
+const prefix = "enc:v"
+
+// the kinds of the blind indexes, values of the same kind in different tables have the same index
+const (
+	IndexMobile   = "mobile"
+	IndexUserID   = "userID"
+	IndexUserCode = "userCode" // the last 6 characters of the ID number, used by borrowers to identify themselves
+	IndexName     = "name"
+)
+
+// UserCodeLength the length of the tail of the ID number indexed by IndexUserCode
+const UserCodeLength = 6
+
+// Cipher encrypt and decrypt the values with the keys of the config
+type Cipher struct {
+	version  int // the key version of new values, 0 means values are written in plaintext
+	aeads    map[int]cipher.AEAD
+	indexKey []byte
+}
+
+var std atomic.Pointer[Cipher]
+
+func init() {
+	std.Store(&Cipher{})
+	schema.RegisterSerializer(SerializerName, Serializer{})
+}
+
+// Init set the keys of the config, values are stored in plaintext if it is not called
+func Init(conf config.FieldCrypt) error {
+	c, err := New(conf)
+	if err != nil {
+		return err
+	}
+	std.Store(c)
+	return nil
+}
+
+// New create a cipher with the keys of the config
+func New(conf config.FieldCrypt) (*Cipher, error) {
+	c := &Cipher{version: conf.Version, aeads: map[int]cipher.AEAD{}}
+	for _, k := range conf.Keys {
+		if k.Version <= 0 {
+			return nil, fmt.Errorf("fieldCrypt key version %d must be greater than 0", k.Version)
+		}
+		if _, ok := c.aeads[k.Version]; ok {
+			return nil, fmt.Errorf("fieldCrypt key version %d is duplicated", k.Version)
+		}
+		key, err := base64.StdEncoding.DecodeString(k.Key)
+		if err != nil {
+			return nil, fmt.Errorf("fieldCrypt key version %d is not base64: %v", k.Version, err)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("fieldCrypt key version %d must be 32 bytes", k.Version)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		c.aeads[k.Version], err = cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if c.version != 0 {
+		if _, ok := c.aeads[c.version]; !ok {
+			return nil, fmt.Errorf("fieldCrypt key version %d is not configured", c.version)
+		}
+	}
+
+	indexKey, err := base64.StdEncoding.DecodeString(conf.IndexKey)
+	if err != nil {
+		return nil, fmt.Errorf("fieldCrypt indexKey is not base64: %v", err)
+	}
+	if c.version != 0 && len(indexKey) < 32 {
+		return nil, fmt.Errorf("fieldCrypt indexKey must be at least 32 bytes when encryption is enabled")
+	}
+	c.indexKey = indexKey
+	return c, nil
+}
+
+// Encrypt the value with the current key, empty values and values of a disabled cipher are returned as they are
+func (c *Cipher) Encrypt(value string) (string, error) {
+	if value == "" || c.version == 0 {
+		return value, nil
+	}
+	aead := c.aeads[c.version]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(value), nil)
+	return prefix + strconv.Itoa(c.version) + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt the value with the key of its version, plaintext values are returned as they are
+func (c *Cipher) Decrypt(value string) (string, error) {
+	version, data, ok := parse(value)
+	if !ok {
+		return value, nil
+	}
+	aead, ok := c.aeads[version]
+	if !ok {
+		return "", fmt.Errorf("fieldCrypt key version %d is not configured", version)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return "", fmt.Errorf("decode encrypted value error: %v", err)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("encrypted value is too short")
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("decrypt value of key version %d error: %v", version, err)
+	}
+	return string(plaintext), nil
+}
+
+// IsCurrent whether the value is stored as new values are, encrypted with the current key or plaintext if disabled
+func (c *Cipher) IsCurrent(value string) bool {
+	if value == "" {
+		return true
+	}
+	version, _, ok := parse(value)
+	if !ok {
+		return c.version == 0
+	}
+	return version == c.version
+}
+
+// BlindIndex the hex HMAC-SHA256 of the normalized value, empty values have an empty index.
+// The index key must never change, otherwise every index must be migrated.
+func (c *Cipher) BlindIndex(kind string, value string) string {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if value == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, c.indexKey)
+	mac.Write([]byte(kind + ":" + value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// parse the version and the base64 data of an encrypted value
+func parse(value string) (int, string, bool) {
+	if !strings.HasPrefix(value, prefix) {
+		return 0, "", false
+	}
+	version, data, ok := strings.Cut(value[len(prefix):], ":")
+	if !ok {
+		return 0, "", false
+	}
+	v, err := strconv.Atoi(version)
+	if err != nil {
+		return 0, "", false
+	}
+	return v, data, true
+}
+
+// Encrypt the value with the cipher of Init
+func Encrypt(value string) (string, error) {
+	return std.Load().Encrypt(value)
+}
+
+// Decrypt the value with the cipher of Init
+func Decrypt(value string) (string, error) {
+	return std.Load().Decrypt(value)
+}
+
+// IsCurrent whether the value is stored with the cipher of Init
+func IsCurrent(value string) bool {
+	return std.Load().IsCurrent(value)
+}
+
+// BlindIndex the blind index of the value with the cipher of Init
+func BlindIndex(kind string, value string) string {
+	return std.Load().BlindIndex(kind, value)
+}
+
+// UserCode the tail of the ID number that borrowers identify themselves with
+func UserCode(userID string) string {
+	userID = strings.TrimSpace(userID)
+	if len(userID) < UserCodeLength {
+		return userID
+	}
+	return userID[len(userID)-UserCodeLength:]
+}
+
+// Serializer the gorm serializer of encrypted string fields
+type Serializer struct{}
+
+// Scan decrypt the value of the column into the field
+func (Serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var value string
+	switch v := dbValue.(type) {
+	case nil:
+	case []byte:
+		value = string(v)
+	case string:
+		value = v
+	default:
+		return fmt.Errorf("unsupported value %T of encrypted field %s", dbValue, field.Name)
+	}
+	plaintext, err := Decrypt(value)
+	if err != nil {
+		return fmt.Errorf("field %s: %v", field.Name, err)
+	}
+	field.ReflectValueOf(ctx, dst).SetString(plaintext)
+	return nil
+}
+
+// Value encrypt the field to write to the column
+func (Serializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	value, ok := fieldValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("encrypted field %s must be a string", field.Name)
+	}
+	return Encrypt(value)
+}
+
+// EncryptFields encrypt the string fields tagged with the encrypt serializer of the struct v points to, in place,
+// e.g. to keep a model in a cache without its personal information in plaintext
+func EncryptFields(v interface{}) error {
+	return cryptFields(v, Encrypt)
+}
+
+// DecryptFields decrypt the string fields tagged with the encrypt serializer of the struct v points to, in place
+func DecryptFields(v interface{}) error {
+	return cryptFields(v, Decrypt)
+}
+
+func cryptFields(v interface{}, fn func(string) (string, error)) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct || !rv.CanSet() {
+		return nil
+	}
+	for _, i := range encryptedFields(rv.Type()) {
+		field := rv.Field(i)
+		value, err := fn(field.String())
+		if err != nil {
+			return fmt.Errorf("field %s: %v", rv.Type().Field(i).Name, err)
+		}
+		field.SetString(value)
+	}
+	return nil
+}
+
+// encryptedFieldsCache the indexes of the encrypted fields of the struct types
+var encryptedFieldsCache sync.Map
+
+func encryptedFields(t reflect.Type) []int {
+	if v, ok := encryptedFieldsCache.Load(t); ok {
+		return v.([]int)
+	}
+	var fields []int
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Type.Kind() != reflect.String {
+			continue
+		}
+		if schema.ParseTagSetting(field.Tag.Get("gorm"), ";")["SERIALIZER"] == SerializerName {
+			fields = append(fields, i)
+		}
+	}
+	encryptedFieldsCache.Store(t, fields)
+	return fields
+}
@@ -0,0 +1,158 @@
+package fieldcrypt
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"lol/internal/config"
+)
+
+var (
+	testKey1     = base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+	testKey2     = base64.StdEncoding.EncodeToString([]byte("fedcba9876543210fedcba9876543210"))
+	testIndexKey = base64.StdEncoding.EncodeToString([]byte("index-key-index-key-index-key-32"))
+)
+
+func newTestCipher(t *testing.T, version int) *Cipher {
+	c, err := New(config.FieldCrypt{
+		Version:  version,
+		Keys:     []config.FieldCryptKey{{Version: 1, Key: testKey1}, {Version: 2, Key: testKey2}},
+		IndexKey: testIndexKey,
+	})
+	assert.NoError(t, err)
+	return c
+}
+
+func TestNew(t *testing.T) {
+	c, err := New(config.FieldCrypt{})
+	assert.NoError(t, err)
+	v, err := c.Encrypt("13800000000")
+	assert.NoError(t, err)
+	assert.Equal(t, "13800000000", v)
+
+	for _, conf := range []config.FieldCrypt{
+		{Version: 1, IndexKey: testIndexKey},
+		{Version: 1, Keys: []config.FieldCryptKey{{Version: 1, Key: testKey1}}},
+		{Version: 1, Keys: []config.FieldCryptKey{{Version: 1, Key: "short"}}, IndexKey: testIndexKey},
+		{Version: 1, Keys: []config.FieldCryptKey{{Version: 0, Key: testKey1}}, IndexKey: testIndexKey},
+		{Version: 1, Keys: []config.FieldCryptKey{{Version: 1, Key: testKey1}, {Version: 1, Key: testKey2}}, IndexKey: testIndexKey},
+	} {
+		_, err = New(conf)
+		assert.Error(t, err)
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	c := newTestCipher(t, 1)
+
+	encrypted, err := c.Encrypt("13800000000")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encrypted, "enc:v1:"))
+	assert.NotContains(t, encrypted, "13800000000")
+	again, _ := c.Encrypt("13800000000")
+	assert.NotEqual(t, encrypted, again)
+	assert.True(t, c.IsCurrent(encrypted))
+	assert.False(t, c.IsCurrent("13800000000"))
+
+	plaintext, err := c.Decrypt(encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, "13800000000", plaintext)
+
+	// plaintext written before the encryption is enabled is read as it is
+	plaintext, err = c.Decrypt("13800000000")
+	assert.NoError(t, err)
+	assert.Equal(t, "13800000000", plaintext)
+
+	// values of the old key are still readable after the rotation
+	rotated := newTestCipher(t, 2)
+	assert.False(t, rotated.IsCurrent(encrypted))
+	plaintext, err = rotated.Decrypt(encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, "13800000000", plaintext)
+
+	_, err = c.Decrypt("enc:v3:" + encrypted[len("enc:v1:"):])
+	assert.Error(t, err)
+	_, err = c.Decrypt(encrypted[:len(encrypted)-4] + "AAAA")
+	assert.Error(t, err)
+
+	v, err := c.Encrypt("")
+	assert.NoError(t, err)
+	assert.Equal(t, "", v)
+}
+
+func TestBlindIndex(t *testing.T) {
+	c := newTestCipher(t, 1)
+	index := c.BlindIndex(IndexUserID, "11010119900101123x")
+	assert.Len(t, index, 64)
+	assert.Equal(t, index, c.BlindIndex(IndexUserID, " 11010119900101123X "))
+	assert.Equal(t, index, newTestCipher(t, 2).BlindIndex(IndexUserID, "11010119900101123X"))
+	assert.NotEqual(t, index, c.BlindIndex(IndexMobile, "11010119900101123X"))
+	assert.Equal(t, "", c.BlindIndex(IndexMobile, " "))
+
+	assert.Equal(t, "01123X", UserCode("11010119900101123X"))
+	assert.Equal(t, "123", UserCode("123"))
+}
+
+func TestEncryptFields(t *testing.T) {
+	assert.NoError(t, Init(config.FieldCrypt{
+		Version:  1,
+		Keys:     []config.FieldCryptKey{{Version: 1, Key: testKey1}},
+		IndexKey: testIndexKey,
+	}))
+	defer func() { _ = Init(config.FieldCrypt{}) }()
+
+	type record struct {
+		Name   string `gorm:"column:name;serializer:encrypt"`
+		Remark string `gorm:"column:remark"`
+	}
+	v := &record{Name: "张三", Remark: "note"}
+	assert.NoError(t, EncryptFields(v))
+	assert.True(t, strings.HasPrefix(v.Name, "enc:v1:"))
+	assert.Equal(t, "note", v.Remark)
+
+	p := &v
+	assert.NoError(t, DecryptFields(p))
+	assert.Equal(t, &record{Name: "张三", Remark: "note"}, v)
+
+	// not a pointer to a struct
+	assert.NoError(t, EncryptFields(record{Name: "张三"}))
+	assert.NoError(t, DecryptFields((*record)(nil)))
+	assert.Error(t, DecryptFields(&record{Name: "enc:v9:xxx"}))
+}
+
+func TestMigrateRow(t *testing.T) {
+	old := newTestCipher(t, 1)
+	c := newTestCipher(t, 2)
+	table := Table{Name: "loan", Columns: []Column{
+		{Name: "user_id", Indexes: []Index{
+			{Column: "user_id_index", Kind: IndexUserID},
+			{Column: "user_code_index", Kind: IndexUserCode, Value: UserCode},
+		}},
+		{Name: "mobile", Indexes: []Index{{Column: "mobile_index", Kind: IndexMobile}}},
+	}}
+
+	encrypted, _ := old.Encrypt("11010119900101123X")
+	update, err := c.migrateRow(table, map[string]interface{}{
+		"id":      int64(1),
+		"user_id": []byte(encrypted),
+		"mobile":  "13800000000",
+	})
+	assert.NoError(t, err)
+	assert.Len(t, update, 5)
+	userID, _ := c.Decrypt(update["user_id"].(string))
+	assert.Equal(t, "11010119900101123X", userID)
+	assert.True(t, c.IsCurrent(update["mobile"].(string)))
+	assert.Equal(t, c.BlindIndex(IndexUserCode, "01123X"), update["user_code_index"])
+
+	// a migrated row is not changed again
+	row := map[string]interface{}{"id": int64(1)}
+	for k, v := range update {
+		row[k] = v
+	}
+	update, err = c.migrateRow(table, row)
+	assert.NoError(t, err)
+	assert.Empty(t, update)
+}
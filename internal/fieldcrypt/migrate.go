@@ -0,0 +1,143 @@
+package fieldcrypt
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// Table the encrypted columns of a table, its primary key must be the column id
+type Table struct {
+	Name    string
+	Columns []Column
+}
+
+// Column an encrypted column and the blind indexes computed from its plaintext
+type Column struct {
+	Name    string
+	Indexes []Index
+}
+
+// Index a blind index column, Value converts the plaintext before it is indexed, nil means the plaintext itself
+type Index struct {
+	Column string
+	Kind   string
+	Value  func(plaintext string) string
+}
+
+// MigrateTable encrypt the rows of the table that are not stored with the current key and recompute all their
+// blind indexes, the rows are read in batches by id so it can be run again safely after it is interrupted.
+// The number of updated rows is returned.
+func MigrateTable(ctx context.Context, db *gorm.DB, table Table, batchSize int) (int, error) {
+	return std.Load().MigrateTable(ctx, db, table, batchSize)
+}
+
+// MigrateTable encrypt the rows of the table with the cipher, see the package function MigrateTable
+func (c *Cipher) MigrateTable(ctx context.Context, db *gorm.DB, table Table, batchSize int) (int, error) {
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	columns := []string{"id"}
+	for _, column := range table.Columns {
+		columns = append(columns, column.Name)
+		for _, index := range column.Indexes {
+			columns = append(columns, index.Column)
+		}
+	}
+
+	updated := 0
+	var lastID uint64
+	for {
+		var rows []map[string]interface{}
+		err := db.WithContext(ctx).Table(table.Name).Select(columns).Where("id > ?", lastID).
+			Order("id").Limit(batchSize).Find(&rows).Error
+		if err != nil {
+			return updated, err
+		}
+		if len(rows) == 0 {
+			return updated, nil
+		}
+
+		for _, row := range rows {
+			id, err := toUint64(row["id"])
+			if err != nil {
+				return updated, fmt.Errorf("table %s: %v", table.Name, err)
+			}
+			lastID = id
+
+			update, err := c.migrateRow(table, row)
+			if err != nil {
+				return updated, fmt.Errorf("table %s id %d: %v", table.Name, id, err)
+			}
+			if len(update) == 0 {
+				continue
+			}
+			err = db.WithContext(ctx).Table(table.Name).Where("id = ?", id).Updates(update).Error
+			if err != nil {
+				return updated, err
+			}
+			updated++
+		}
+	}
+}
+
+// migrateRow the changed columns of the row, values that are not current are encrypted again
+// and the indexes that differ from the plaintext are recomputed
+func (c *Cipher) migrateRow(table Table, row map[string]interface{}) (map[string]interface{}, error) {
+	update := map[string]interface{}{}
+	for _, column := range table.Columns {
+		stored := toString(row[column.Name])
+		plaintext, err := c.Decrypt(stored)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %v", column.Name, err)
+		}
+		if !c.IsCurrent(stored) {
+			if update[column.Name], err = c.Encrypt(plaintext); err != nil {
+				return nil, err
+			}
+		}
+		for _, index := range column.Indexes {
+			value := plaintext
+			if index.Value != nil {
+				value = index.Value(plaintext)
+			}
+			if blindIndex := c.BlindIndex(index.Kind, value); blindIndex != toString(row[index.Column]) {
+				update[index.Column] = blindIndex
+			}
+		}
+	}
+	return update, nil
+}
+
+func toString(v interface{}) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case []byte:
+		return string(s)
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+func toUint64(v interface{}) (uint64, error) {
+	switch n := v.(type) {
+	case int64:
+		return uint64(n), nil
+	case int32:
+		return uint64(n), nil
+	case int:
+		return uint64(n), nil
+	case uint64:
+		return n, nil
+	case uint32:
+		return uint64(n), nil
+	case []byte:
+		var id uint64
+		_, err := fmt.Sscan(string(n), &id)
+		return id, err
+	}
+	return 0, fmt.Errorf("unsupported id %T", v)
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/go-dev-frame/sponge/pkg/gin/middleware"
+	"github.com/go-dev-frame/sponge/pkg/gin/response"
+	"github.com/go-dev-frame/sponge/pkg/jwt"
+	"github.com/go-dev-frame/sponge/pkg/logger"
+	"github.com/go-dev-frame/sponge/pkg/utils"
+
+	"lol/internal/audit"
+	"lol/internal/cache"
+	"lol/internal/dao"
+	"lol/internal/database"
+	"lol/internal/ecode"
+	"lol/internal/model"
+	"lol/internal/rbac"
+)
+
+const (
+	// adminTokenName the name of admin tokens, distinguishes them from borrower tokens
+	adminTokenName = "admin"
+	// adminUserKey the gin context key of the logged in admin user
+	adminUserKey = "adminUser"
+
+	adminUserStatusNormal = 1
+)
+
+// AdminAuth check the admin token and the role permission of every route
+type AdminAuth struct {
+	userDao  dao.AdminUserDao
+	sessions cache.SessionCache
+}
+
+// NewAdminAuth creating the admin auth
+func NewAdminAuth() *AdminAuth {
+	return &AdminAuth{
+		userDao: dao.NewAdminUserDao(
+			database.GetDB(), // db driver is mysql
+			cache.NewAdminUserCache(database.GetCacheType()),
+		),
+		sessions: cache.NewSessionCache(database.GetCacheType()),
+	}
+}
+
+// Middleware returns a middleware which looks up the permission of the matched route by "METHOD fullPath",
+// public routes are passed, the other routes require an enabled admin user whose role has the permission,
+// the admin users who must change the password are only allowed the onboarding routes,
+// routes missing from permissions are denied.
+func (a *AdminAuth) Middleware(permissions map[string]rbac.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.Request.Method + " " + c.FullPath()
+		permission, ok := permissions[route]
+		if !ok {
+			logger.Warn("route has no permission", logger.String("route", route), middleware.GCtxRequestIDField(c))
+			response.Output(c, ecode.Forbidden.ToHTTPCode())
+			c.Abort()
+			return
+		}
+		if permission == rbac.Public {
+			c.Next()
+			return
+		}
+
+		adminUser, ok := a.verify(c)
+		if !ok {
+			response.Output(c, ecode.Unauthorized.ToHTTPCode())
+			c.Abort()
+			return
+		}
+		if adminUser.MustChangePassword && permission != rbac.Onboarding {
+			logger.Warn("password change required", logger.Uint64("adminUserID", adminUser.ID),
+				logger.String("route", route), middleware.GCtxRequestIDField(c))
+			response.Error(c, ecode.ErrPasswordChange)
+			c.Abort()
+			return
+		}
+		if !rbac.HasPermission(adminUser.Role, permission) {
+			logger.Warn("permission denied", logger.Uint64("adminUserID", adminUser.ID), logger.String("role", adminUser.Role),
+				logger.String("route", route), middleware.GCtxRequestIDField(c))
+			response.Output(c, ecode.Forbidden.ToHTTPCode())
+			c.Abort()
+			return
+		}
+
+		c.Set(adminUserKey, adminUser)
+		// the changes made with middleware.WrapCtx(c) are audited as made by the admin user
+		c.Request = c.Request.WithContext(audit.WithActor(c.Request.Context(),
+			audit.Actor{ID: adminUser.ID, Username: adminUser.Username}))
+		c.Next()
+	}
+}
+
+// verify parse the admin token in the Authorization header, check its session and load the admin user
+func (a *AdminAuth) verify(c *gin.Context) (*model.AdminUser, bool) {
+	token := bearerToken(c)
+	if token == "" {
+		return nil, false
+	}
+	claims, err := jwt.ParseToken(token)
+	if err != nil {
+		logger.Warn("ParseToken error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		return nil, false
+	}
+	if userType, _ := parseTokenName(claims.Name); userType != adminTokenName {
+		return nil, false
+	}
+	err = checkSession(c, a.sessions, token, claims)
+	if err != nil {
+		logger.Warn("checkSession error", logger.Err(err), logger.String("uid", claims.UID), middleware.GCtxRequestIDField(c))
+		return nil, false
+	}
+
+	adminUser, err := a.userDao.GetByID(middleware.WrapCtx(c), utils.StrToUint64(claims.UID))
+	if err != nil {
+		logger.Warn("GetByID error", logger.Err(err), logger.String("uid", claims.UID), middleware.GCtxRequestIDField(c))
+		return nil, false
+	}
+	if adminUser.Status != adminUserStatusNormal {
+		return nil, false
+	}
+	return adminUser, true
+}
+
+// getAdminUser get the logged in admin user, nil on public routes
+func getAdminUser(c *gin.Context) *model.AdminUser {
+	if v, ok := c.Get(adminUserKey); ok {
+		if adminUser, ok := v.(*model.AdminUser); ok {
+			return adminUser
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"lol/internal/rbac"
+)
+
+func TestAdminAuth_Middleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	a := &AdminAuth{}
+	r.Use(a.Middleware(map[string]rbac.Permission{
+		"GET /public":  rbac.Public,
+		"GET /private": rbac.LoanRead,
+	}))
+	ok := func(c *gin.Context) { c.String(http.StatusOK, "ok") }
+	r.GET("/public", ok)
+	r.GET("/private", ok)
+	r.GET("/unknown", ok)
+
+	tests := []struct {
+		path  string
+		token string
+		want  int
+	}{
+		{path: "/public", want: http.StatusOK},
+		{path: "/private", want: http.StatusUnauthorized},
+		{path: "/private", token: "Bearer invalid", want: http.StatusUnauthorized},
+		{path: "/unknown", want: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", tt.token)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
+
+func TestNewAdminAuth(t *testing.T) {
+	defer func() {
+		recover()
+	}()
+	_ = NewAdminAuth()
+}
@@ -0,0 +1,502 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/go-dev-frame/sponge/pkg/gin/middleware"
+	"github.com/go-dev-frame/sponge/pkg/gin/response"
+	"github.com/go-dev-frame/sponge/pkg/logger"
+
+	"lol/internal/cache"
+	"lol/internal/database"
+	"lol/internal/ecode"
+	"lol/internal/model"
+	"lol/internal/totp"
+	"lol/internal/types"
+)
+
+const (
+	// recoveryCodeCount number of recovery codes issued when two-factor authentication is enabled
+	recoveryCodeCount = 10
+	// challengeMaxAttempts the challenge is dropped after too many wrong codes
+	challengeMaxAttempts = 5
+)
+
+// SetupChallenge create the two-factor secret during login, used when the role requires two-factor authentication
+// but the admin user has not enabled it yet
+// @Summary set up two-factor authentication during login
+// @Description create a two-factor secret with the challenge token returned by login, then verify the challenge with a code of the authenticator app
+// @Tags adminUser
+// @accept json
+// @Produce json
+// @Param data body types.AdminChallengeRequest true "challenge token"
+// @Success 200 {object} types.TwoFactorSetupReply{}
+// @Router /api/v1/adminUser/2fa/challenge/setup [post]
+func (h *adminUserHandler) SetupChallenge(c *gin.Context) {
+	form := &types.AdminChallengeRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		responseInvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	challenge, err := h.challengeCache.Get(ctx, form.ChallengeToken)
+	if err != nil {
+		if errors.Is(err, database.ErrCacheNotFound) {
+			response.Error(c, ecode.ErrTwoFactorChallenge)
+		} else {
+			logger.Error("challengeCache.Get error", logger.Err(err), middleware.GCtxRequestIDField(c))
+			response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		}
+		return
+	}
+
+	adminUser, ok := h.getCredential(c, challenge.UserID)
+	if !ok {
+		return
+	}
+	if adminUser.TotpEnabled {
+		response.Error(c, ecode.ErrTwoFactorEnabled)
+		return
+	}
+
+	h.setupTwoFactor(c, adminUser)
+}
+
+// VerifyChallenge verify the two-factor code of the login challenge and issue an admin token
+// @Summary verify two-factor code during login
+// @Description verify the code of the authenticator app or a recovery code, then issue an admin token,
+// @Description if the secret was set up during login, two-factor authentication is enabled and the recovery codes are returned
+// @Tags adminUser
+// @accept json
+// @Produce json
+// @Param data body types.VerifyAdminChallengeRequest true "challenge token and code"
+// @Success 200 {object} types.VerifyAdminChallengeReply{}
+// @Router /api/v1/adminUser/2fa/challenge/verify [post]
+func (h *adminUserHandler) VerifyChallenge(c *gin.Context) {
+	form := &types.VerifyAdminChallengeRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		responseInvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	challenge, err := h.challengeCache.Get(ctx, form.ChallengeToken)
+	if err != nil {
+		if errors.Is(err, database.ErrCacheNotFound) {
+			response.Error(c, ecode.ErrTwoFactorChallenge)
+		} else {
+			logger.Error("challengeCache.Get error", logger.Err(err), middleware.GCtxRequestIDField(c))
+			response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		}
+		return
+	}
+	ttl := time.Until(challenge.CreatedAt.Add(cache.AdminChallengeExpireTime))
+	if ttl <= 0 {
+		_ = h.challengeCache.Del(ctx, form.ChallengeToken)
+		response.Error(c, ecode.ErrTwoFactorChallenge)
+		return
+	}
+	// 先计数再验证，并发的验证也不能超过次数上限
+	attempts, err := h.challengeCache.IncrAttempts(ctx, form.ChallengeToken, ttl)
+	if err != nil {
+		logger.Error("challengeCache.IncrAttempts error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+	if attempts > challengeMaxAttempts {
+		_ = h.challengeCache.Del(ctx, form.ChallengeToken)
+		response.Error(c, ecode.ErrTwoFactorChallenge)
+		return
+	}
+
+	adminUser, ok := h.getCredential(c, challenge.UserID)
+	if !ok {
+		return
+	}
+	if adminUser.Status != adminUserStatusNormal {
+		_ = h.challengeCache.Del(ctx, form.ChallengeToken)
+		response.Error(c, ecode.ErrAdminLogin)
+		return
+	}
+	if adminUser.TotpSecret == "" {
+		response.Error(c, ecode.ErrTwoFactorNotSetup)
+		return
+	}
+
+	var recoveryCodes []string
+	if adminUser.TotpEnabled {
+		ok = h.verifyTwoFactorCode(c, adminUser, form.Code)
+	} else {
+		// 登录时设置的密钥，验证通过后开启两步验证
+		ok = h.validateTotpCode(c, adminUser, form.Code)
+		if ok {
+			recoveryCodes, ok = h.enableTwoFactor(c, adminUser)
+			if !ok {
+				return
+			}
+		}
+	}
+	if !ok {
+		// 验证失败次数达到上限后令牌作废，需要重新登录
+		if attempts >= challengeMaxAttempts {
+			if err = h.challengeCache.Del(ctx, form.ChallengeToken); err != nil {
+				logger.Error("challengeCache.Del error", logger.Err(err), middleware.GCtxRequestIDField(c))
+			}
+		}
+		logger.Warn("wrong two-factor code", logger.Uint64("adminUserID", adminUser.ID), logger.Int("attempts", attempts), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.ErrTwoFactorCode)
+		return
+	}
+	_ = h.challengeCache.Del(ctx, form.ChallengeToken)
+
+	token, err := h.issueToken(c, adminUser)
+	if err != nil {
+		logger.Error("issueToken error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+
+	response.Success(c, gin.H{
+		"token":         token,
+		"recoveryCodes": recoveryCodes,
+	})
+}
+
+// SetupTwoFactor create a two-factor secret for the logged in admin user
+// @Summary set up two-factor authentication
+// @Description create a two-factor secret for the logged in admin user, it takes effect after enabling with a code of the authenticator app
+// @Tags adminUser
+// @accept json
+// @Produce json
+// @Success 200 {object} types.TwoFactorSetupReply{}
+// @Router /api/v1/adminUser/2fa/setup [post]
+// @Security BearerAuth
+func (h *adminUserHandler) SetupTwoFactor(c *gin.Context) {
+	adminUser, ok := h.getCredential(c, getAdminUser(c).ID)
+	if !ok {
+		return
+	}
+	if adminUser.TotpEnabled {
+		response.Error(c, ecode.ErrTwoFactorEnabled)
+		return
+	}
+
+	h.setupTwoFactor(c, adminUser)
+}
+
+// EnableTwoFactor enable two-factor authentication for the logged in admin user
+// @Summary enable two-factor authentication
+// @Description verify a code of the authenticator app and enable two-factor authentication, the recovery codes are only returned once
+// @Tags adminUser
+// @accept json
+// @Produce json
+// @Param data body types.TwoFactorCodeRequest true "code"
+// @Success 200 {object} types.TwoFactorEnableReply{}
+// @Router /api/v1/adminUser/2fa/enable [post]
+// @Security BearerAuth
+func (h *adminUserHandler) EnableTwoFactor(c *gin.Context) {
+	form := &types.TwoFactorCodeRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		responseInvalidParams(c, err)
+		return
+	}
+
+	adminUser, ok := h.getCredential(c, getAdminUser(c).ID)
+	if !ok {
+		return
+	}
+	if adminUser.TotpEnabled {
+		response.Error(c, ecode.ErrTwoFactorEnabled)
+		return
+	}
+	if adminUser.TotpSecret == "" {
+		response.Error(c, ecode.ErrTwoFactorNotSetup)
+		return
+	}
+	if !h.validateTotpCode(c, adminUser, form.Code) {
+		response.Error(c, ecode.ErrTwoFactorCode)
+		return
+	}
+
+	recoveryCodes, ok := h.enableTwoFactor(c, adminUser)
+	if !ok {
+		return
+	}
+
+	response.Success(c, gin.H{"recoveryCodes": recoveryCodes})
+}
+
+// DisableTwoFactor disable two-factor authentication for the logged in admin user
+// @Summary disable two-factor authentication
+// @Description verify a code of the authenticator app or a recovery code and disable two-factor authentication,
+// @Description it is not allowed if the role requires two-factor authentication
+// @Tags adminUser
+// @accept json
+// @Produce json
+// @Param data body types.TwoFactorCodeRequest true "code"
+// @Success 200 {object} types.Result{}
+// @Router /api/v1/adminUser/2fa/disable [post]
+// @Security BearerAuth
+func (h *adminUserHandler) DisableTwoFactor(c *gin.Context) {
+	form := &types.TwoFactorCodeRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		responseInvalidParams(c, err)
+		return
+	}
+
+	adminUser, ok := h.getCredential(c, getAdminUser(c).ID)
+	if !ok {
+		return
+	}
+	if !adminUser.TotpEnabled {
+		response.Error(c, ecode.ErrTwoFactorNotSetup)
+		return
+	}
+	if h.isTwoFactorRequired(adminUser.Role) {
+		response.Error(c, ecode.ErrTwoFactorRequired)
+		return
+	}
+	if !h.verifyTwoFactorCode(c, adminUser, form.Code) {
+		response.Error(c, ecode.ErrTwoFactorCode)
+		return
+	}
+
+	h.clearTwoFactor(c, adminUser)
+}
+
+// ResetTwoFactor reset two-factor authentication of an admin user who lost the authenticator app and the recovery codes
+// @Summary reset two-factor authentication
+// @Description clear the two-factor secret and recovery codes of the admin user, the user sets it up again at the next login if the role requires it
+// @Tags adminUser
+// @accept json
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} types.Result{}
+// @Router /api/v1/adminUser/{id}/2fa/reset [post]
+// @Security BearerAuth
+func (h *adminUserHandler) ResetTwoFactor(c *gin.Context) {
+	_, id, isAbort := getAdminUserIDFromPath(c)
+	if isAbort {
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	adminUser, ok := h.getCredential(c, id)
+	if !ok {
+		return
+	}
+	logger.Info("reset two-factor authentication", logger.Uint64("adminUserID", id),
+		logger.Uint64("operatorID", getAdminUser(c).ID), middleware.GCtxRequestIDField(c))
+
+	h.clearTwoFactor(c, adminUser)
+}
+
+// getCredential load the admin user with the two-factor secrets, the response is written if failed
+func (h *adminUserHandler) getCredential(c *gin.Context, id uint64) (*model.AdminUser, bool) {
+	adminUser, err := h.iDao.GetCredentialByID(middleware.WrapCtx(c), id)
+	if err != nil {
+		if errors.Is(err, database.ErrRecordNotFound) {
+			logger.Warn("GetCredentialByID not found", logger.Uint64("id", id), middleware.GCtxRequestIDField(c))
+			response.Error(c, ecode.NotFound)
+		} else {
+			logger.Error("GetCredentialByID error", logger.Err(err), logger.Uint64("id", id), middleware.GCtxRequestIDField(c))
+			response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		}
+		return nil, false
+	}
+	return adminUser, true
+}
+
+// setupTwoFactor save a new secret which is not enabled yet and respond the provisioning uri
+func (h *adminUserHandler) setupTwoFactor(c *gin.Context, adminUser *model.AdminUser) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		logger.Error("GenerateSecret error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+	adminUser.TotpSecret = secret
+	adminUser.TotpEnabled = false
+	adminUser.RecoveryCodes = ""
+	err = h.iDao.UpdateTwoFactor(middleware.WrapCtx(c), adminUser)
+	if err != nil {
+		logger.Error("UpdateTwoFactor error", logger.Err(err), logger.Uint64("id", adminUser.ID), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+
+	response.Success(c, gin.H{
+		"secret": secret,
+		"uri":    totp.URI(secret, h.issuer(), adminUser.Username),
+	})
+}
+
+// enableTwoFactor enable two-factor authentication and return the new recovery codes, the response is written if failed
+func (h *adminUserHandler) enableTwoFactor(c *gin.Context, adminUser *model.AdminUser) ([]string, bool) {
+	recoveryCodes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		logger.Error("newRecoveryCodes error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return nil, false
+	}
+	adminUser.TotpEnabled = true
+	adminUser.RecoveryCodes = hashes
+	err = h.iDao.UpdateTwoFactor(middleware.WrapCtx(c), adminUser)
+	if err != nil {
+		logger.Error("UpdateTwoFactor error", logger.Err(err), logger.Uint64("id", adminUser.ID), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return nil, false
+	}
+	logger.Info("two-factor authentication enabled", logger.Uint64("adminUserID", adminUser.ID), middleware.GCtxRequestIDField(c))
+	return recoveryCodes, true
+}
+
+// clearTwoFactor clear the secret and recovery codes
+func (h *adminUserHandler) clearTwoFactor(c *gin.Context, adminUser *model.AdminUser) {
+	adminUser.TotpSecret = ""
+	adminUser.TotpEnabled = false
+	adminUser.RecoveryCodes = ""
+	err := h.iDao.UpdateTwoFactor(middleware.WrapCtx(c), adminUser)
+	if err != nil {
+		logger.Error("UpdateTwoFactor error", logger.Err(err), logger.Uint64("id", adminUser.ID), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+
+	response.Success(c)
+}
+
+// verifyTwoFactorCode check the code of the authenticator app, or a recovery code which is removed after use
+func (h *adminUserHandler) verifyTwoFactorCode(c *gin.Context, adminUser *model.AdminUser, code string) bool {
+	if h.validateTotpCode(c, adminUser, code) {
+		return true
+	}
+
+	rest, ok := useRecoveryCode(adminUser.RecoveryCodes, code)
+	if !ok {
+		return false
+	}
+	adminUser.RecoveryCodes = rest
+	err := h.iDao.UpdateTwoFactor(middleware.WrapCtx(c), adminUser)
+	if err != nil {
+		// 恢复码未能作废时不允许使用
+		logger.Error("UpdateTwoFactor error", logger.Err(err), logger.Uint64("id", adminUser.ID), middleware.GCtxRequestIDField(c))
+		return false
+	}
+	remaining := 0
+	if rest != "" {
+		remaining = strings.Count(rest, ",") + 1
+	}
+	logger.Info("recovery code used", logger.Uint64("adminUserID", adminUser.ID), logger.Int("remaining", remaining), middleware.GCtxRequestIDField(c))
+	return true
+}
+
+// validateTotpCode check the code of the authenticator app, a code is only accepted once,
+// the codes of the same or an earlier time step as the last accepted one are rejected
+func (h *adminUserHandler) validateTotpCode(c *gin.Context, adminUser *model.AdminUser, code string) bool {
+	counter, ok := totp.ValidateCounter(adminUser.TotpSecret, code, time.Now())
+	if !ok {
+		return false
+	}
+	ok, err := h.iDao.UseTotpCounter(middleware.WrapCtx(c), adminUser.ID, counter)
+	if err != nil {
+		logger.Error("UseTotpCounter error", logger.Err(err), logger.Uint64("id", adminUser.ID), middleware.GCtxRequestIDField(c))
+		return false
+	}
+	if !ok {
+		logger.Warn("two-factor code used again", logger.Uint64("adminUserID", adminUser.ID), middleware.GCtxRequestIDField(c))
+	}
+	return ok
+}
+
+// newChallenge save a login challenge of the admin user and return its token
+func (h *adminUserHandler) newChallenge(ctx context.Context, userID uint64) (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+	challenge := &cache.AdminChallenge{UserID: userID, CreatedAt: time.Now()}
+	err = h.challengeCache.Set(ctx, token, challenge, cache.AdminChallengeExpireTime)
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// isTwoFactorRequired whether the role must login with two-factor authentication
+func (h *adminUserHandler) isTwoFactorRequired(role string) bool {
+	for _, v := range h.twoFactor.RequiredRoles {
+		if v == role {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *adminUserHandler) issuer() string {
+	if h.twoFactor.Issuer != "" {
+		return h.twoFactor.Issuer
+	}
+	return "lol"
+}
+
+// newRecoveryCodes generate recovery codes like "a1b2c-3d4e5", the sha256 hashes are joined by commas for storage
+func newRecoveryCodes() ([]string, string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 5)
+		_, err := rand.Read(b)
+		if err != nil {
+			return nil, "", err
+		}
+		s := hex.EncodeToString(b)
+		code := s[:5] + "-" + s[5:]
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, strings.Join(hashes, ","), nil
+}
+
+// hashRecoveryCode the code is case-insensitive and the hyphen is optional
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// useRecoveryCode check the code against the stored hashes and return the hashes without it
+func useRecoveryCode(hashes string, code string) (string, bool) {
+	if hashes == "" {
+		return "", false
+	}
+	hash := hashRecoveryCode(code)
+	list := strings.Split(hashes, ",")
+	for i, v := range list {
+		if subtle.ConstantTimeCompare([]byte(v), []byte(hash)) == 1 {
+			rest := append(list[:i:i], list[i+1:]...)
+			return strings.Join(rest, ","), true
+		}
+	}
+	return hashes, false
+}
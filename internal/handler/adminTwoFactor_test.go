@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-dev-frame/sponge/pkg/httpcli"
+
+	"lol/internal/cache"
+	"lol/internal/model"
+	"lol/internal/totp"
+	"lol/internal/types"
+)
+
+func Test_adminUserHandler_VerifyChallenge(t *testing.T) {
+	h := newAdminUserHandler()
+	defer h.Close()
+	testData := h.TestData.(*model.AdminUser)
+	challengeCache := h.IHandler.(*adminUserHandler).challengeCache
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := h.IHandler.(*adminUserHandler).newChallenge(context.Background(), testData.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	columns := []string{"id", "username", "role", "status", "totp_secret", "totp_enabled"}
+
+	// wrong code error test
+	rows := sqlmock.NewRows(columns).AddRow(testData.ID, testData.Username, testData.Role, testData.Status, secret, true)
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(rows)
+	result := &httpcli.StdResult{}
+	err = httpcli.Post(result, h.GetRequestURL("VerifyChallenge"), &types.VerifyAdminChallengeRequest{
+		ChallengeToken: token,
+		Code:           "000000x",
+	})
+	assert.NoError(t, err)
+	assert.NotEqual(t, 0, result.Code)
+	attempts, err := challengeCache.IncrAttempts(context.Background(), token, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+
+	code, err := totp.Code(secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows = sqlmock.NewRows(columns).AddRow(testData.ID, testData.Username, testData.Role, testData.Status, secret, true)
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(rows)
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectExec("UPDATE .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	h.MockDao.SQLMock.ExpectCommit()
+	reply := &types.VerifyAdminChallengeReply{}
+	err = httpcli.Post(reply, h.GetRequestURL("VerifyChallenge"), &types.VerifyAdminChallengeRequest{
+		ChallengeToken: token,
+		Code:           code,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.Code != 0 {
+		t.Fatalf("%+v", reply)
+	}
+	assert.NotEmpty(t, reply.Data.Token)
+
+	// the challenge can only be used once
+	err = httpcli.Post(result, h.GetRequestURL("VerifyChallenge"), &types.VerifyAdminChallengeRequest{
+		ChallengeToken: token,
+		Code:           code,
+	})
+	assert.NoError(t, err)
+	assert.NotEqual(t, 0, result.Code)
+
+	// the code cannot be used again with another challenge
+	token, err = h.IHandler.(*adminUserHandler).newChallenge(context.Background(), testData.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows = sqlmock.NewRows(columns).AddRow(testData.ID, testData.Username, testData.Role, testData.Status, secret, true)
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(rows)
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectExec("UPDATE .*").WillReturnResult(sqlmock.NewResult(1, 0))
+	h.MockDao.SQLMock.ExpectCommit()
+	err = httpcli.Post(result, h.GetRequestURL("VerifyChallenge"), &types.VerifyAdminChallengeRequest{
+		ChallengeToken: token,
+		Code:           code,
+	})
+	assert.NoError(t, err)
+	assert.NotEqual(t, 0, result.Code)
+
+	// too many attempts error test
+	err = challengeCache.Set(context.Background(), token, &cache.AdminChallenge{
+		UserID:    testData.ID,
+		CreatedAt: time.Now(),
+	}, time.Minute)
+	assert.NoError(t, err)
+	for i := 0; i < challengeMaxAttempts; i++ {
+		_, err = challengeCache.IncrAttempts(context.Background(), token, time.Minute)
+		assert.NoError(t, err)
+	}
+	err = httpcli.Post(result, h.GetRequestURL("VerifyChallenge"), &types.VerifyAdminChallengeRequest{
+		ChallengeToken: token,
+		Code:           code,
+	})
+	assert.NoError(t, err)
+	assert.NotEqual(t, 0, result.Code)
+}
+
+func Test_recoveryCodes(t *testing.T) {
+	codes, hashes, err := newRecoveryCodes()
+	assert.NoError(t, err)
+	assert.Len(t, codes, recoveryCodeCount)
+	assert.Len(t, strings.Split(hashes, ","), recoveryCodeCount)
+
+	// case-insensitive and the hyphen is optional
+	rest, ok := useRecoveryCode(hashes, strings.ToUpper(strings.ReplaceAll(codes[3], "-", "")))
+	assert.True(t, ok)
+	assert.Len(t, strings.Split(rest, ","), recoveryCodeCount-1)
+
+	// a recovery code can only be used once
+	_, ok = useRecoveryCode(rest, codes[3])
+	assert.False(t, ok)
+
+	_, ok = useRecoveryCode("", codes[0])
+	assert.False(t, ok)
+}
+
+func Test_adminUserHandler_isTwoFactorRequired(t *testing.T) {
+	h := &adminUserHandler{}
+	h.twoFactor.RequiredRoles = []string{"admin"}
+	assert.True(t, h.isTwoFactorRequired("admin"))
+	assert.False(t, h.isTwoFactorRequired("viewer"))
+	assert.Equal(t, "lol", h.issuer())
+}
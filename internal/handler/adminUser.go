@@ -0,0 +1,460 @@
+package handler
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/copier"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/go-dev-frame/sponge/pkg/gin/middleware"
+	"github.com/go-dev-frame/sponge/pkg/gin/response"
+	"github.com/go-dev-frame/sponge/pkg/logger"
+	"github.com/go-dev-frame/sponge/pkg/utils"
+
+	"lol/internal/cache"
+	"lol/internal/config"
+	"lol/internal/dao"
+	"lol/internal/database"
+	"lol/internal/ecode"
+	"lol/internal/model"
+	"lol/internal/pii"
+	"lol/internal/types"
+)
+
+var _ AdminUserHandler = (*adminUserHandler)(nil)
+
+// AdminUserHandler defining the handler interface
+type AdminUserHandler interface {
+	Create(c *gin.Context)
+	DeleteByID(c *gin.Context)
+	UpdateByID(c *gin.Context)
+	GetByID(c *gin.Context)
+	List(c *gin.Context)
+	Login(c *gin.Context)
+	ChangePassword(c *gin.Context)
+
+	SetupChallenge(c *gin.Context)
+	VerifyChallenge(c *gin.Context)
+	SetupTwoFactor(c *gin.Context)
+	EnableTwoFactor(c *gin.Context)
+	DisableTwoFactor(c *gin.Context)
+	ResetTwoFactor(c *gin.Context)
+}
+
+type adminUserHandler struct {
+	iDao           dao.AdminUserDao
+	challengeCache cache.AdminChallengeCache
+	sessions       cache.SessionCache
+	twoFactor      config.TwoFactor
+	tokenExpire    time.Duration
+}
+
+// NewAdminUserHandler creating the handler interface
+func NewAdminUserHandler() AdminUserHandler {
+	return &adminUserHandler{
+		iDao: dao.NewAdminUserDao(
+			database.GetDB(), // db driver is mysql
+			cache.NewAdminUserCache(database.GetCacheType()),
+		),
+		challengeCache: cache.NewAdminChallengeCache(database.GetCacheType()),
+		sessions:       cache.NewSessionCache(database.GetCacheType()),
+		twoFactor:      config.Get().TwoFactor,
+		tokenExpire:    tokenExpire(config.Get().Jwt),
+	}
+}
+
+// Create a record
+// @Summary create adminUser
+// @Description submit information to create adminUser
+// @Tags adminUser
+// @accept json
+// @Produce json
+// @Param data body types.CreateAdminUserRequest true "adminUser information"
+// @Success 200 {object} types.CreateAdminUserReply{}
+// @Router /api/v1/adminUser [post]
+// @Security BearerAuth
+func (h *adminUserHandler) Create(c *gin.Context) {
+	form := &types.CreateAdminUserRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		responseInvalidParams(c, err)
+		return
+	}
+
+	adminUser := &model.AdminUser{}
+	err = copier.Copy(adminUser, form)
+	if err != nil {
+		response.Error(c, ecode.ErrCreateAdminUser)
+		return
+	}
+	// Note: if copier.Copy cannot assign a value to a field, add it here
+	adminUser.Password, err = hashPassword(form.Password)
+	if err != nil {
+		logger.Error("hashPassword error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+	adminUser.Status = adminUserStatusNormal
+	now := time.Now()
+	adminUser.CreateAt = &now
+	adminUser.UpdateAt = &now
+
+	ctx := middleware.WrapCtx(c)
+	_, err = h.iDao.GetByUsername(ctx, form.Username)
+	if err == nil {
+		response.Error(c, ecode.ErrAdminUserExists)
+		return
+	}
+	if !errors.Is(err, database.ErrRecordNotFound) {
+		logger.Error("GetByUsername error", logger.Err(err), logger.String("username", form.Username), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+
+	err = h.iDao.Create(ctx, adminUser)
+	if err != nil {
+		logger.Error("Create error", logger.Err(err), logger.String("username", form.Username), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+
+	response.Success(c, gin.H{"id": adminUser.ID})
+}
+
+// DeleteByID delete a record by id
+// @Summary delete adminUser
+// @Description delete adminUser by id
+// @Tags adminUser
+// @accept json
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} types.DeleteAdminUserByIDReply{}
+// @Router /api/v1/adminUser/{id} [delete]
+// @Security BearerAuth
+func (h *adminUserHandler) DeleteByID(c *gin.Context) {
+	_, id, isAbort := getAdminUserIDFromPath(c)
+	if isAbort {
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	err := h.iDao.DeleteByID(ctx, id)
+	if err != nil {
+		logger.Error("DeleteByID error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+	revokeAdminSessions(c, h.sessions, id)
+
+	response.Success(c)
+}
+
+// UpdateByID update information by id
+// @Summary update adminUser
+// @Description update adminUser information by id
+// @Tags adminUser
+// @accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param data body types.UpdateAdminUserByIDRequest true "adminUser information"
+// @Success 200 {object} types.UpdateAdminUserByIDReply{}
+// @Router /api/v1/adminUser/{id} [put]
+// @Security BearerAuth
+func (h *adminUserHandler) UpdateByID(c *gin.Context) {
+	_, id, isAbort := getAdminUserIDFromPath(c)
+	if isAbort {
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	form := &types.UpdateAdminUserByIDRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		responseInvalidParams(c, err)
+		return
+	}
+	form.ID = id
+
+	adminUser := &model.AdminUser{}
+	err = copier.Copy(adminUser, form)
+	if err != nil {
+		response.Error(c, ecode.ErrUpdateByIDAdminUser)
+		return
+	}
+	// Note: if copier.Copy cannot assign a value to a field, add it here
+	if form.Password != "" {
+		adminUser.Password, err = hashPassword(form.Password)
+		if err != nil {
+			logger.Error("hashPassword error", logger.Err(err), middleware.GCtxRequestIDField(c))
+			response.Output(c, ecode.InternalServerError.ToHTTPCode())
+			return
+		}
+	}
+	now := time.Now()
+	adminUser.UpdateAt = &now
+
+	ctx := middleware.WrapCtx(c)
+	err = h.iDao.UpdateByID(ctx, adminUser)
+	if err != nil {
+		logger.Error("UpdateByID error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+	// 修改密码或禁用后，已登录的会话全部失效
+	if form.Password != "" || (form.Status != 0 && form.Status != adminUserStatusNormal) {
+		revokeAdminSessions(c, h.sessions, id)
+	}
+
+	response.Success(c)
+}
+
+// GetByID get a record by id
+// @Summary get adminUser detail
+// @Description get adminUser detail by id
+// @Tags adminUser
+// @Param id path string true "id"
+// @Accept json
+// @Produce json
+// @Success 200 {object} types.GetAdminUserByIDReply{}
+// @Router /api/v1/adminUser/{id} [get]
+// @Security BearerAuth
+func (h *adminUserHandler) GetByID(c *gin.Context) {
+	_, id, isAbort := getAdminUserIDFromPath(c)
+	if isAbort {
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	adminUser, err := h.iDao.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, database.ErrRecordNotFound) {
+			logger.Warn("GetByID not found", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Error(c, ecode.NotFound)
+		} else {
+			logger.Error("GetByID error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		}
+		return
+	}
+
+	data := &types.AdminUserObjDetail{}
+	err = copier.Copy(data, adminUser)
+	if err != nil {
+		response.Error(c, ecode.ErrGetByIDAdminUser)
+		return
+	}
+	// Note: if copier.Copy cannot assign a value to a field, add it here
+
+	response.Success(c, gin.H{"adminUser": data})
+}
+
+// List of records by query parameters
+// @Summary list of adminUsers by query parameters
+// @Description list of adminUsers by paging and conditions
+// @Tags adminUser
+// @accept json
+// @Produce json
+// @Param data body types.Params true "query parameters"
+// @Success 200 {object} types.ListAdminUsersReply{}
+// @Router /api/v1/adminUser/list [post]
+// @Security BearerAuth
+func (h *adminUserHandler) List(c *gin.Context) {
+	form := &types.ListAdminUsersRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		responseInvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	adminUsers, total, err := h.iDao.GetByColumns(ctx, &form.Params)
+	if err != nil {
+		logger.Error("GetByColumns error", logger.Err(err), pii.Any("form", form), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+
+	data, err := convertAdminUsers(adminUsers)
+	if err != nil {
+		response.Error(c, ecode.ErrListAdminUser)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"adminUsers": data,
+		"total":      total,
+	})
+}
+
+// Login login with username and password and issue an admin token
+// @Summary admin login
+// @Description login with username and password and issue an admin token
+// @Tags adminUser
+// @accept json
+// @Produce json
+// @Param data body types.AdminLoginRequest true "login information"
+// @Success 200 {object} types.AdminLoginReply{}
+// @Router /api/v1/adminUser/login [post]
+func (h *adminUserHandler) Login(c *gin.Context) {
+	form := &types.AdminLoginRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		responseInvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	adminUser, err := h.iDao.GetByUsername(ctx, form.Username)
+	if err != nil {
+		if errors.Is(err, database.ErrRecordNotFound) {
+			logger.Warn("admin user not found", logger.String("username", form.Username), middleware.GCtxRequestIDField(c))
+			response.Error(c, ecode.ErrAdminLogin)
+		} else {
+			logger.Error("GetByUsername error", logger.Err(err), logger.String("username", form.Username), middleware.GCtxRequestIDField(c))
+			response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		}
+		return
+	}
+	if bcrypt.CompareHashAndPassword([]byte(adminUser.Password), []byte(form.Password)) != nil {
+		logger.Warn("wrong admin password", logger.String("username", form.Username), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.ErrAdminLogin)
+		return
+	}
+	if adminUser.Status != adminUserStatusNormal {
+		logger.Warn("admin user is disabled", logger.String("username", form.Username), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.ErrAdminLogin)
+		return
+	}
+
+	// 开启了两步验证、角色要求两步验证或使用初始密码时，先返回两步验证令牌
+	if adminUser.TotpEnabled || h.isTwoFactorRequired(adminUser.Role) || adminUser.MustChangePassword {
+		challengeToken, err := h.newChallenge(ctx, adminUser.ID)
+		if err != nil {
+			logger.Error("newChallenge error", logger.Err(err), middleware.GCtxRequestIDField(c))
+			response.Output(c, ecode.InternalServerError.ToHTTPCode())
+			return
+		}
+		response.Success(c, gin.H{
+			"challengeToken": challengeToken,
+			"enrollRequired": !adminUser.TotpEnabled,
+		})
+		return
+	}
+
+	token, err := h.issueToken(c, adminUser)
+	if err != nil {
+		logger.Error("issueToken error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+
+	response.Success(c, gin.H{"token": token})
+}
+
+// ChangePassword change the password of the logged in admin user
+// @Summary change password
+// @Description change the password of the logged in admin user with the current one, the admin users created
+// @Description with a bootstrap password must change it before any other route is allowed
+// @Tags adminUser
+// @accept json
+// @Produce json
+// @Param data body types.ChangeAdminPasswordRequest true "current and new password"
+// @Success 200 {object} types.Result{}
+// @Router /api/v1/adminUser/password [put]
+// @Security BearerAuth
+func (h *adminUserHandler) ChangePassword(c *gin.Context) {
+	form := &types.ChangeAdminPasswordRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		responseInvalidParams(c, err)
+		return
+	}
+
+	adminUser, ok := h.getCredential(c, getAdminUser(c).ID)
+	if !ok {
+		return
+	}
+	if bcrypt.CompareHashAndPassword([]byte(adminUser.Password), []byte(form.Password)) != nil {
+		logger.Warn("wrong admin password", logger.Uint64("adminUserID", adminUser.ID), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.ErrAdminPassword)
+		return
+	}
+	if form.NewPassword == form.Password {
+		response.Error(c, ecode.ErrSamePassword)
+		return
+	}
+
+	password, err := hashPassword(form.NewPassword)
+	if err != nil {
+		logger.Error("hashPassword error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+	err = h.iDao.UpdatePassword(middleware.WrapCtx(c), adminUser.ID, password)
+	if err != nil {
+		logger.Error("UpdatePassword error", logger.Err(err), logger.Uint64("id", adminUser.ID), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+	logger.Info("password changed", logger.Uint64("adminUserID", adminUser.ID), middleware.GCtxRequestIDField(c))
+
+	response.Success(c)
+}
+
+// issueToken issue the admin token and save its session after all login factors are verified
+func (h *adminUserHandler) issueToken(c *gin.Context, adminUser *model.AdminUser) (string, error) {
+	return newSessionToken(c, h.sessions, h.tokenExpire, adminTokenName, utils.Uint64ToStr(adminUser.ID))
+}
+
+// hashPassword hash the password with bcrypt
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func getAdminUserIDFromPath(c *gin.Context) (string, uint64, bool) {
+	idStr := c.Param("id")
+	id, err := utils.StrToUint64E(idStr)
+	if err != nil || id == 0 {
+		logger.Warn("StrToUint64E error: ", logger.String("idStr", idStr), middleware.GCtxRequestIDField(c))
+		return "", 0, true
+	}
+
+	return idStr, id, false
+}
+
+func convertAdminUser(adminUser *model.AdminUser) (*types.AdminUserObjDetail, error) {
+	data := &types.AdminUserObjDetail{}
+	err := copier.Copy(data, adminUser)
+	if err != nil {
+		return nil, err
+	}
+	// Note: if copier.Copy cannot assign a value to a field, add it here
+
+	return data, nil
+}
+
+func convertAdminUsers(fromValues []*model.AdminUser) ([]*types.AdminUserObjDetail, error) {
+	toValues := []*types.AdminUserObjDetail{}
+	for _, v := range fromValues {
+		data, err := convertAdminUser(v)
+		if err != nil {
+			return nil, err
+		}
+		toValues = append(toValues, data)
+	}
+
+	return toValues, nil
+}
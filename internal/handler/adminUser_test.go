@@ -0,0 +1,391 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/copier"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-dev-frame/sponge/pkg/gotest"
+	"github.com/go-dev-frame/sponge/pkg/httpcli"
+	"github.com/go-dev-frame/sponge/pkg/sgorm/query"
+	"github.com/go-dev-frame/sponge/pkg/utils"
+
+	"lol/internal/cache"
+	"lol/internal/config"
+	"lol/internal/dao"
+	"lol/internal/database"
+	"lol/internal/model"
+	"lol/internal/rbac"
+	"lol/internal/types"
+)
+
+func newAdminUserHandler() *gotest.Handler {
+	testData := &model.AdminUser{}
+	testData.ID = 1
+	testData.Username = "admin"
+	testData.Role = rbac.RoleAdmin
+	testData.Status = adminUserStatusNormal
+
+	// init mock cache
+	c := gotest.NewCache(map[string]interface{}{utils.Uint64ToStr(testData.ID): testData})
+	c.ICache = cache.NewAdminUserCache(&database.CacheType{
+		CType: "redis",
+		Rdb:   c.RedisClient,
+	})
+
+	// init mock dao
+	d := gotest.NewDao(c, testData)
+	d.IDao = dao.NewAdminUserDao(d.DB, c.ICache.(cache.AdminUserCache))
+
+	// init mock handler
+	h := gotest.NewHandler(d, testData)
+	h.IHandler = &adminUserHandler{
+		iDao: d.IDao.(dao.AdminUserDao),
+		challengeCache: cache.NewAdminChallengeCache(&database.CacheType{
+			CType: "redis",
+			Rdb:   c.RedisClient,
+		}),
+		sessions: cache.NewSessionCache(&database.CacheType{
+			CType: "redis",
+			Rdb:   c.RedisClient,
+		}),
+		twoFactor: config.TwoFactor{RequiredRoles: []string{rbac.RoleFinance}},
+	}
+	iHandler := h.IHandler.(AdminUserHandler)
+
+	testFns := []gotest.RouterInfo{
+		{
+			FuncName:    "Create",
+			Method:      http.MethodPost,
+			Path:        "/adminUser",
+			HandlerFunc: iHandler.Create,
+		},
+		{
+			FuncName:    "DeleteByID",
+			Method:      http.MethodDelete,
+			Path:        "/adminUser/:id",
+			HandlerFunc: iHandler.DeleteByID,
+		},
+		{
+			FuncName:    "UpdateByID",
+			Method:      http.MethodPut,
+			Path:        "/adminUser/:id",
+			HandlerFunc: iHandler.UpdateByID,
+		},
+		{
+			FuncName:    "GetByID",
+			Method:      http.MethodGet,
+			Path:        "/adminUser/:id",
+			HandlerFunc: iHandler.GetByID,
+		},
+		{
+			FuncName:    "List",
+			Method:      http.MethodPost,
+			Path:        "/adminUser/list",
+			HandlerFunc: iHandler.List,
+		},
+		{
+			FuncName:    "Login",
+			Method:      http.MethodPost,
+			Path:        "/adminUser/login",
+			HandlerFunc: iHandler.Login,
+		},
+		{
+			FuncName: "ChangePassword",
+			Method:   http.MethodPut,
+			Path:     "/adminUser/password",
+			HandlerFunc: func(c *gin.Context) {
+				c.Set(adminUserKey, testData)
+				iHandler.ChangePassword(c)
+			},
+		},
+		{
+			FuncName:    "VerifyChallenge",
+			Method:      http.MethodPost,
+			Path:        "/adminUser/2fa/challenge/verify",
+			HandlerFunc: iHandler.VerifyChallenge,
+		},
+	}
+
+	h.GoRunHTTPServer(testFns)
+
+	time.Sleep(time.Millisecond * 200)
+	return h
+}
+
+func Test_adminUserHandler_Create(t *testing.T) {
+	h := newAdminUserHandler()
+	defer h.Close()
+	testData := &types.CreateAdminUserRequest{
+		Username: "operator",
+		Password: "12345678",
+		Role:     rbac.RoleOperator,
+	}
+
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectExec("INSERT INTO .*").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	h.MockDao.SQLMock.ExpectCommit()
+
+	result := &httpcli.StdResult{}
+	err := httpcli.Post(result, h.GetRequestURL("Create"), testData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Logf("%+v", result)
+
+	// invalid role error test
+	testData.Role = "root"
+	err = httpcli.Post(result, h.GetRequestURL("Create"), testData)
+	assert.NoError(t, err)
+	assert.NotEqual(t, 0, result.Code)
+}
+
+func Test_adminUserHandler_DeleteByID(t *testing.T) {
+	h := newAdminUserHandler()
+	defer h.Close()
+	testData := h.TestData.(*model.AdminUser)
+	expectedSQLForDeletion := "DELETE .*"
+
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectExec(expectedSQLForDeletion).
+		WithArgs(testData.ID). // adjusted for the amount of test data
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
+	h.MockDao.SQLMock.ExpectCommit()
+
+	result := &httpcli.StdResult{}
+	err := httpcli.Delete(result, h.GetRequestURL("DeleteByID", testData.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+
+	// zero id error test
+	err = httpcli.Delete(result, h.GetRequestURL("DeleteByID", 0))
+	assert.NoError(t, err)
+
+	// delete error test
+	err = httpcli.Delete(result, h.GetRequestURL("DeleteByID", 111))
+	assert.Error(t, err)
+}
+
+func Test_adminUserHandler_UpdateByID(t *testing.T) {
+	h := newAdminUserHandler()
+	defer h.Close()
+	testData := &types.UpdateAdminUserByIDRequest{}
+	_ = copier.Copy(testData, h.TestData.(*model.AdminUser))
+
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectExec("UPDATE .*").
+		WithArgs(h.MockDao.AnyTime, testData.ID). // adjusted for the amount of test data
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
+	h.MockDao.SQLMock.ExpectCommit()
+
+	result := &httpcli.StdResult{}
+	err := httpcli.Put(result, h.GetRequestURL("UpdateByID", testData.ID), testData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+
+	// zero id error test
+	err = httpcli.Put(result, h.GetRequestURL("UpdateByID", 0), testData)
+	assert.NoError(t, err)
+
+	// update error test
+	err = httpcli.Put(result, h.GetRequestURL("UpdateByID", 111), testData)
+	assert.Error(t, err)
+}
+
+func Test_adminUserHandler_GetByID(t *testing.T) {
+	h := newAdminUserHandler()
+	defer h.Close()
+	testData := h.TestData.(*model.AdminUser)
+
+	// column names and corresponding data
+	rows := sqlmock.NewRows([]string{"id"}).
+		AddRow(testData.ID)
+
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").
+		WithArgs(testData.ID).
+		WillReturnRows(rows)
+
+	result := &httpcli.StdResult{}
+	err := httpcli.Get(result, h.GetRequestURL("GetByID", testData.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+
+	// zero id error test
+	err = httpcli.Get(result, h.GetRequestURL("GetByID", 0))
+	assert.NoError(t, err)
+
+	// get error test
+	err = httpcli.Get(result, h.GetRequestURL("GetByID", 111))
+	assert.Error(t, err)
+}
+
+func Test_adminUserHandler_List(t *testing.T) {
+	h := newAdminUserHandler()
+	defer h.Close()
+	testData := h.TestData.(*model.AdminUser)
+
+	// column names and corresponding data
+	rows := sqlmock.NewRows([]string{"id"}).
+		AddRow(testData.ID)
+
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(rows)
+
+	result := &httpcli.StdResult{}
+	err := httpcli.Post(result, h.GetRequestURL("List"), &types.ListAdminUsersRequest{query.Params{
+		Page:  0,
+		Limit: 10,
+		Sort:  "ignore count", // ignore test count
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+
+	// nil params error test
+	err = httpcli.Post(result, h.GetRequestURL("List"), nil)
+	assert.NoError(t, err)
+
+	// get error test
+	err = httpcli.Post(result, h.GetRequestURL("List"), &types.ListAdminUsersRequest{query.Params{
+		Page:  0,
+		Limit: 10,
+		Sort:  "unknown-column",
+	}})
+	assert.Error(t, err)
+}
+
+func Test_adminUserHandler_Login(t *testing.T) {
+	h := newAdminUserHandler()
+	defer h.Close()
+	testData := h.TestData.(*model.AdminUser)
+	password, err := hashPassword("12345678")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows := sqlmock.NewRows([]string{"id", "username", "password", "role", "status"}).
+		AddRow(testData.ID, testData.Username, password, testData.Role, testData.Status)
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(rows)
+
+	result := &httpcli.StdResult{}
+	err = httpcli.Post(result, h.GetRequestURL("Login"), &types.AdminLoginRequest{
+		Username: testData.Username,
+		Password: "12345678",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+
+	// wrong password error test
+	rows = sqlmock.NewRows([]string{"id", "username", "password", "role", "status"}).
+		AddRow(testData.ID, testData.Username, password, testData.Role, testData.Status)
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(rows)
+	err = httpcli.Post(result, h.GetRequestURL("Login"), &types.AdminLoginRequest{
+		Username: testData.Username,
+		Password: "87654321",
+	})
+	assert.NoError(t, err)
+	assert.NotEqual(t, 0, result.Code)
+
+	// the role requires two-factor authentication, a challenge token is returned instead of the token
+	rows = sqlmock.NewRows([]string{"id", "username", "password", "role", "status"}).
+		AddRow(testData.ID, testData.Username, password, rbac.RoleFinance, testData.Status)
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(rows)
+	reply := &types.AdminLoginReply{}
+	err = httpcli.Post(reply, h.GetRequestURL("Login"), &types.AdminLoginRequest{
+		Username: testData.Username,
+		Password: "12345678",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, reply.Code)
+	assert.Empty(t, reply.Data.Token)
+	assert.NotEmpty(t, reply.Data.ChallengeToken)
+	assert.True(t, reply.Data.EnrollRequired)
+
+	// the bootstrap password, two-factor authentication must be set up to login whatever the role
+	rows = sqlmock.NewRows([]string{"id", "username", "password", "role", "status", "must_change_password"}).
+		AddRow(testData.ID, testData.Username, password, testData.Role, testData.Status, true)
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(rows)
+	reply = &types.AdminLoginReply{}
+	err = httpcli.Post(reply, h.GetRequestURL("Login"), &types.AdminLoginRequest{
+		Username: testData.Username,
+		Password: "12345678",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, reply.Code)
+	assert.Empty(t, reply.Data.Token)
+	assert.NotEmpty(t, reply.Data.ChallengeToken)
+}
+
+func Test_adminUserHandler_ChangePassword(t *testing.T) {
+	h := newAdminUserHandler()
+	defer h.Close()
+	testData := h.TestData.(*model.AdminUser)
+	password, err := hashPassword("12345678")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows := sqlmock.NewRows([]string{"id", "username", "password", "role", "status", "must_change_password"}).
+		AddRow(testData.ID, testData.Username, password, testData.Role, testData.Status, true)
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(rows)
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectExec("UPDATE .*").
+		WithArgs(false, sqlmock.AnyArg(), sqlmock.AnyArg(), testData.ID).
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
+	h.MockDao.SQLMock.ExpectCommit()
+
+	result := &httpcli.StdResult{}
+	err = httpcli.Put(result, h.GetRequestURL("ChangePassword"), &types.ChangeAdminPasswordRequest{
+		Password:    "12345678",
+		NewPassword: "a-new-password",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+
+	// wrong password error test
+	rows = sqlmock.NewRows([]string{"id", "username", "password"}).AddRow(testData.ID, testData.Username, password)
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(rows)
+	err = httpcli.Put(result, h.GetRequestURL("ChangePassword"), &types.ChangeAdminPasswordRequest{
+		Password:    "87654321",
+		NewPassword: "a-new-password",
+	})
+	assert.NoError(t, err)
+	assert.NotEqual(t, 0, result.Code)
+}
+
+func TestNewAdminUserHandler(t *testing.T) {
+	defer func() {
+		recover()
+	}()
+	_ = NewAdminUserHandler()
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/copier"
+
+	"github.com/go-dev-frame/sponge/pkg/gin/middleware"
+	"github.com/go-dev-frame/sponge/pkg/gin/response"
+	"github.com/go-dev-frame/sponge/pkg/logger"
+	"github.com/go-dev-frame/sponge/pkg/sgorm/query"
+
+	"lol/internal/dao"
+	"lol/internal/database"
+	"lol/internal/ecode"
+	"lol/internal/types"
+)
+
+// auditLogPeriod the period listed by default
+const auditLogPeriod = 90 * 24 * time.Hour
+
+var _ AuditLogHandler = (*auditLogHandler)(nil)
+
+// AuditLogHandler defining the handler interface
+type AuditLogHandler interface {
+	List(c *gin.Context)
+}
+
+type auditLogHandler struct {
+	iDao dao.AuditLogDao
+}
+
+// NewAuditLogHandler creating the handler interface
+func NewAuditLogHandler() AuditLogHandler {
+	return &auditLogHandler{
+		iDao: dao.NewAuditLogDao(database.GetDB()),
+	}
+}
+
+// List of the changes made by the admin users
+// @Summary list of audit logs
+// @Description list of who created, updated or deleted which records and the changed values,
+// @Description by operator, table, record, request and time, the last 90 days are listed by default
+// @Tags auditLog
+// @accept json
+// @Produce json
+// @Param data body types.ListAuditLogsRequest true "query parameters and filters"
+// @Success 200 {object} types.ListAuditLogsReply{}
+// @Router /api/v1/auditLog/list [post]
+// @Security BearerAuth
+func (h *auditLogHandler) List(c *gin.Context) {
+	form := &types.ListAuditLogsRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		responseInvalidParams(c, err)
+		return
+	}
+
+	form.Columns = append(form.Columns, auditLogFilterColumns(form, time.Now())...)
+	auditLogs, total, err := h.iDao.GetByColumns(middleware.WrapCtx(c), &form.Params)
+	if err != nil {
+		logger.Error("GetByColumns error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+
+	data := []*types.AuditLogObjDetail{}
+	err = copier.Copy(&data, auditLogs)
+	if err != nil {
+		response.Error(c, ecode.ErrListAuditLog)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"auditLogs": data,
+		"total":     total,
+	})
+}
+
+// auditLogFilterColumns convert the filters of the list request to query columns
+func auditLogFilterColumns(form *types.ListAuditLogsRequest, now time.Time) []query.Column {
+	var columns []query.Column
+	add := func(name string, exp string, value interface{}) {
+		columns = append(columns, query.Column{Name: name, Exp: exp, Value: value, Logic: "and"})
+	}
+	if form.AdminUserID != 0 {
+		add("admin_user_id", "=", form.AdminUserID)
+	}
+	if form.Action != "" {
+		add("action", "=", form.Action)
+	}
+	if form.Table != "" {
+		add("table_name", "=", form.Table)
+	}
+	if form.RecordID != 0 {
+		add("record_id", "=", form.RecordID)
+	}
+	if form.RequestID != "" {
+		add("request_id", "=", form.RequestID)
+	}
+	if form.StartTime == nil && form.EndTime == nil {
+		add("create_at", ">=", now.Add(-auditLogPeriod))
+	}
+	if form.StartTime != nil {
+		add("create_at", ">=", *form.StartTime)
+	}
+	if form.EndTime != nil {
+		add("create_at", "<", *form.EndTime)
+	}
+	if len(columns) > 0 && len(form.Columns) > 0 {
+		// the filters are combined with the last column of params by and
+		form.Columns[len(form.Columns)-1].Logic = "and"
+	}
+	return columns
+}
@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-dev-frame/sponge/pkg/sgorm/query"
+	"github.com/stretchr/testify/assert"
+
+	"lol/internal/types"
+)
+
+func Test_auditLogFilterColumns(t *testing.T) {
+	now := time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)
+	form := &types.ListAuditLogsRequest{AdminUserID: 3, Action: "update", Table: "loan", RecordID: 1}
+	columns := auditLogFilterColumns(form, now)
+	assert.Equal(t, []query.Column{
+		{Name: "admin_user_id", Exp: "=", Value: uint64(3), Logic: "and"},
+		{Name: "action", Exp: "=", Value: "update", Logic: "and"},
+		{Name: "table_name", Exp: "=", Value: "loan", Logic: "and"},
+		{Name: "record_id", Exp: "=", Value: uint64(1), Logic: "and"},
+		{Name: "create_at", Exp: ">=", Value: now.Add(-auditLogPeriod), Logic: "and"},
+	}, columns)
+
+	start, end := now.Add(-time.Hour), now
+	form = &types.ListAuditLogsRequest{RequestID: "abc", StartTime: &start, EndTime: &end}
+	form.Columns = []query.Column{{Name: "username", Value: "alice", Logic: "or"}}
+	columns = auditLogFilterColumns(form, now)
+	assert.Equal(t, []query.Column{
+		{Name: "request_id", Exp: "=", Value: "abc", Logic: "and"},
+		{Name: "create_at", Exp: ">=", Value: start, Logic: "and"},
+		{Name: "create_at", Exp: "<", Value: end, Logic: "and"},
+	}, columns)
+	assert.Equal(t, "and", form.Columns[0].Logic)
+}
@@ -0,0 +1,457 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/go-dev-frame/sponge/pkg/gin/middleware"
+	"github.com/go-dev-frame/sponge/pkg/gin/response"
+	"github.com/go-dev-frame/sponge/pkg/logger"
+
+	"lol/internal/attempt"
+	"lol/internal/cache"
+	"lol/internal/captcha"
+	"lol/internal/config"
+	"lol/internal/dao"
+	"lol/internal/database"
+	"lol/internal/ecode"
+	"lol/internal/pii"
+	"lol/internal/sms"
+	"lol/internal/types"
+)
+
+const (
+	// borrowerTokenName the name of borrower tokens, distinguishes them from admin tokens
+	borrowerTokenName = "borrower"
+	// borrowerMobileKey the gin context key of the mobile of the logged in borrower
+	borrowerMobileKey = "borrowerMobile"
+	// the kinds of the failed lookup counters
+	borrowerMobileAttempt = "borrowerMobile"
+	borrowerIPAttempt     = "borrowerIP"
+)
+
+var _ BorrowerHandler = (*borrowerHandler)(nil)
+
+// BorrowerHandler defining the handler interface
+type BorrowerHandler interface {
+	SendOtp(c *gin.Context)
+	Login(c *gin.Context)
+	Logout(c *gin.Context)
+	Captcha(c *gin.Context)
+}
+
+type borrowerHandler struct {
+	loanDao     dao.LoanDao
+	smsService  *sms.Service
+	otpCache    cache.BorrowerOtpCache
+	sessions    cache.SessionCache
+	otp         config.Otp
+	tokenExpire time.Duration
+
+	// failed lookups by mobile and ID number are limited per mobile and per client ip
+	mobileAttempts *attempt.Limiter
+	ipAttempts     *attempt.Limiter
+	captchas       cache.CaptchaCache
+	bruteForce     config.BruteForce
+}
+
+// NewBorrowerHandler creating the handler interface
+func NewBorrowerHandler() BorrowerHandler {
+	attemptCache := cache.NewAttemptCache(database.GetCacheType())
+	return &borrowerHandler{
+		loanDao: dao.NewLoanDao(
+			database.GetDB(), // db driver is mysql
+			cache.NewLoanCache(database.GetCacheType()),
+		),
+		smsService: sms.NewService(
+			dao.NewSmsHistoryDao(database.GetDB(), cache.NewSmsHistoryCache(database.GetCacheType())),
+			dao.NewSmsTemplateDao(database.GetDB(), cache.NewSmsTemplateCache(database.GetCacheType())),
+		),
+		otpCache:    cache.NewBorrowerOtpCache(database.GetCacheType()),
+		sessions:    cache.NewSessionCache(database.GetCacheType()),
+		otp:         config.Get().Otp,
+		tokenExpire: tokenExpire(config.Get().Jwt),
+
+		mobileAttempts: attempt.NewLimiter(borrowerMobileAttempt, config.Get().BruteForce, 0, attemptCache),
+		ipAttempts:     attempt.NewLimiter(borrowerIPAttempt, config.Get().BruteForce, config.Get().BruteForce.IPMaxFailures, attemptCache),
+		captchas:       cache.NewCaptchaCache(database.GetCacheType()),
+		bruteForce:     config.Get().BruteForce,
+	}
+}
+
+// SendOtp send a login code to the mobile of the borrower
+// @Summary send borrower login code
+// @Description check the mobile and the last 6 digits of the ID number, then send a login code to the mobile.
+// @Description failed checks are limited per mobile and per ip, a captcha is required after several failures.
+// @Tags borrower
+// @accept json
+// @Produce json
+// @Param data body types.SendBorrowerOtpRequest true "borrower information"
+// @Success 200 {object} types.SendBorrowerOtpReply{}
+// @Router /api/v1/borrower/otp [post]
+func (h *borrowerHandler) SendOtp(c *gin.Context) {
+	// 找到和找不到借款人的响应时间保持一致，避免通过响应时间判断手机号是否存在
+	defer padDuration(time.Now(), time.Duration(h.bruteForce.MinDuration)*time.Millisecond)
+
+	form := &types.SendBorrowerOtpRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		responseInvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	ip := c.ClientIP()
+	mobileAttempt, err := h.mobileAttempts.Get(ctx, form.Mobile)
+	if err != nil {
+		logger.Error("mobileAttempts.Get error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+	ipAttempt, err := h.ipAttempts.Get(ctx, ip)
+	if err != nil {
+		logger.Error("ipAttempts.Get error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+	retryAfter := max(h.mobileAttempts.RetryAfter(mobileAttempt), h.ipAttempts.RetryAfter(ipAttempt))
+	if retryAfter > 0 {
+		auditLookup(c, "locked", form.Mobile, ip, mobileAttempt, ipAttempt)
+		response.Error(c, ecode.ErrBorrowerLocked.RewriteMsg(
+			fmt.Sprintf("too many failed attempts, please try again after %d seconds", int(retryAfter.Seconds())+1)))
+		return
+	}
+	if h.isCaptchaRequired(mobileAttempt, ipAttempt) && !h.verifyCaptcha(ctx, form.CaptchaID, form.Captcha) {
+		auditLookup(c, "captcha failed", form.Mobile, ip, mobileAttempt, ipAttempt)
+		response.Error(c, ecode.ErrBorrowerCaptcha)
+		return
+	}
+
+	loans, err := h.loanDao.GetByMobileAndCode(ctx, form.Mobile, form.Code)
+	if err != nil {
+		if errors.Is(err, database.ErrRecordNotFound) {
+			// 手机号不存在和身份证号码错误的应答相同
+			h.lookupFailed(c, "not found", form.Mobile, ip)
+			response.Error(c, ecode.ErrBorrowerNotFound)
+		} else {
+			logger.Error("GetByMobileAndCode error", logger.Err(err), pii.String("mobile", pii.KindMobile, form.Mobile), middleware.GCtxRequestIDField(c))
+			response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		}
+		return
+	}
+	if err = h.mobileAttempts.Reset(ctx, form.Mobile); err != nil {
+		logger.Warn("mobileAttempts.Reset error", logger.Err(err), middleware.GCtxRequestIDField(c))
+	}
+
+	// 限制同一手机号的发送间隔和每天的发送次数
+	now := time.Now()
+	otp, err := h.otpCache.Get(ctx, form.Mobile)
+	if err != nil && !errors.Is(err, database.ErrCacheNotFound) {
+		logger.Error("otpCache.Get error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+	if otp != nil && now.Sub(otp.SentAt) < h.interval() {
+		response.Error(c, ecode.ErrBorrowerOtpInterval)
+		return
+	}
+	count, err := h.otpCache.GetCount(ctx, form.Mobile)
+	if err != nil && !errors.Is(err, database.ErrCacheNotFound) {
+		logger.Error("otpCache.GetCount error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+	today := now.Format(time.DateOnly)
+	if count == nil || count.Date != today {
+		count = &cache.BorrowerOtpCount{Date: today}
+	}
+	if count.Count >= h.dailyLimit() {
+		response.Error(c, ecode.ErrBorrowerOtpLimit)
+		return
+	}
+
+	code, err := generateOtp(h.length())
+	if err != nil {
+		logger.Error("generateOtp error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+	err = h.otpCache.Set(ctx, form.Mobile, &cache.BorrowerOtp{Code: code, SentAt: now}, h.expire())
+	if err != nil {
+		logger.Error("otpCache.Set error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+	count.Count++
+	err = h.otpCache.SetCount(ctx, form.Mobile, count, cache.BorrowerOtpCountExpireTime)
+	if err != nil {
+		logger.Error("otpCache.SetCount error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+
+	_, err = h.smsService.Send(ctx, &sms.Request{
+		UserName: loans[0].Name,
+		Mobile:   form.Mobile,
+		Template: sms.TemplateLoginCode,
+		Params: map[string]string{
+			"code":   code,
+			"expire": strconv.Itoa(int(h.expire().Minutes())),
+		},
+	})
+	if err != nil {
+		logger.Error("sendOtp error", logger.Err(err), pii.String("mobile", pii.KindMobile, form.Mobile), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.ErrSendBorrowerOtp)
+		return
+	}
+
+	response.Success(c, gin.H{"expire": int(h.expire().Seconds())})
+}
+
+// Login verify the login code and issue a borrower token
+// @Summary borrower login
+// @Description verify the login code sent to the mobile and issue a borrower token
+// @Tags borrower
+// @accept json
+// @Produce json
+// @Param data body types.BorrowerLoginRequest true "login information"
+// @Success 200 {object} types.BorrowerLoginReply{}
+// @Router /api/v1/borrower/login [post]
+func (h *borrowerHandler) Login(c *gin.Context) {
+	form := &types.BorrowerLoginRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		responseInvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	otp, err := h.otpCache.Get(ctx, form.Mobile)
+	if err != nil {
+		if errors.Is(err, database.ErrCacheNotFound) {
+			response.Error(c, ecode.ErrBorrowerOtp)
+		} else {
+			logger.Error("otpCache.Get error", logger.Err(err), middleware.GCtxRequestIDField(c))
+			response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		}
+		return
+	}
+
+	ttl := time.Until(otp.SentAt.Add(h.expire()))
+	if ttl <= 0 {
+		_ = h.otpCache.Del(ctx, form.Mobile)
+		response.Error(c, ecode.ErrBorrowerOtp)
+		return
+	}
+	// 先计数再比较验证码，并发的验证也不能超过次数上限
+	attempts, err := h.otpCache.IncrAttempts(ctx, form.Mobile, ttl)
+	if err != nil {
+		logger.Error("otpCache.IncrAttempts error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+	if attempts > h.maxAttempts() {
+		_ = h.otpCache.Del(ctx, form.Mobile)
+		response.Error(c, ecode.ErrBorrowerOtp)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(otp.Code), []byte(form.Otp)) != 1 {
+		// 验证失败次数达到上限后验证码作废
+		if attempts >= h.maxAttempts() {
+			if err = h.otpCache.Del(ctx, form.Mobile); err != nil {
+				logger.Error("otpCache.Del error", logger.Err(err), middleware.GCtxRequestIDField(c))
+			}
+		}
+		logger.Warn("wrong login code", pii.String("mobile", pii.KindMobile, form.Mobile), logger.Int("attempts", attempts), middleware.GCtxRequestIDField(c))
+		h.lookupFailed(c, "wrong login code", form.Mobile, c.ClientIP())
+		response.Error(c, ecode.ErrBorrowerOtp)
+		return
+	}
+	_ = h.otpCache.Del(ctx, form.Mobile)
+	if err = h.mobileAttempts.Reset(ctx, form.Mobile); err != nil {
+		logger.Warn("mobileAttempts.Reset error", logger.Err(err), middleware.GCtxRequestIDField(c))
+	}
+
+	token, err := newSessionToken(c, h.sessions, h.tokenExpire, borrowerTokenName, form.Mobile)
+	if err != nil {
+		logger.Error("newSessionToken error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.ErrBorrowerLogin)
+		return
+	}
+
+	response.Success(c, gin.H{"token": token})
+}
+
+// Logout revoke the session of the logged in borrower
+// @Summary borrower logout
+// @Description revoke the session of the borrower token in the request
+// @Tags borrower
+// @accept json
+// @Produce json
+// @Success 200 {object} types.LogoutReply{}
+// @Router /api/v1/borrower/logout [post]
+// @Security BearerAuth
+func (h *borrowerHandler) Logout(c *gin.Context) {
+	logout(c, h.sessions)
+}
+
+// Captcha generate an image captcha
+// @Summary get borrower captcha
+// @Description generate an image captcha, required to send login codes after several failed attempts, it can be used once
+// @Tags borrower
+// @accept json
+// @Produce json
+// @Success 200 {object} types.BorrowerCaptchaReply{}
+// @Router /api/v1/borrower/captcha [post]
+func (h *borrowerHandler) Captcha(c *gin.Context) {
+	answer, img, err := captcha.Generate(captcha.DefaultLength)
+	if err != nil {
+		logger.Error("captcha.Generate error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.ErrBorrowerCaptchaGen)
+		return
+	}
+	b := make([]byte, 16)
+	if _, err = rand.Read(b); err != nil {
+		logger.Error("rand.Read error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.ErrBorrowerCaptchaGen)
+		return
+	}
+	id := hex.EncodeToString(b)
+
+	ctx := middleware.WrapCtx(c)
+	err = h.captchas.Set(ctx, id, &cache.Captcha{Answer: answer}, cache.CaptchaExpireTime)
+	if err != nil {
+		logger.Error("captchas.Set error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+
+	response.Success(c, gin.H{
+		"captchaID": id,
+		"image":     "data:image/png;base64," + base64.StdEncoding.EncodeToString(img),
+		"expire":    int(cache.CaptchaExpireTime.Seconds()),
+	})
+}
+
+// isCaptchaRequired whether the mobile or the ip failed captchaAfter times
+func (h *borrowerHandler) isCaptchaRequired(mobileAttempt *cache.Attempt, ipAttempt *cache.Attempt) bool {
+	after := h.bruteForce.CaptchaAfter
+	return after > 0 && (mobileAttempt.Failures >= after || ipAttempt.Failures >= after)
+}
+
+// verifyCaptcha check the answer of the captcha, a captcha can only be verified once
+func (h *borrowerHandler) verifyCaptcha(ctx context.Context, id string, answer string) bool {
+	if id == "" || answer == "" {
+		return false
+	}
+	data, err := h.captchas.Get(ctx, id)
+	if err != nil {
+		return false
+	}
+	_ = h.captchas.Del(ctx, id)
+	return subtle.ConstantTimeCompare([]byte(data.Answer), []byte(answer)) == 1
+}
+
+// lookupFailed count the failed lookup or login for the mobile and the ip, the mobile is locked and
+// a captcha is required to send login codes after several failures
+func (h *borrowerHandler) lookupFailed(c *gin.Context, result string, mobile string, ip string) {
+	ctx := middleware.WrapCtx(c)
+	mobileAttempt, err := h.mobileAttempts.Fail(ctx, mobile)
+	if err != nil {
+		logger.Error("mobileAttempts.Fail error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		mobileAttempt = &cache.Attempt{}
+	}
+	ipAttempt, err := h.ipAttempts.Fail(ctx, ip)
+	if err != nil {
+		logger.Error("ipAttempts.Fail error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		ipAttempt = &cache.Attempt{}
+	}
+	auditLookup(c, result, mobile, ip, mobileAttempt, ipAttempt)
+}
+
+// auditLookup the audit log of a rejected borrower lookup
+func auditLookup(c *gin.Context, result string, mobile string, ip string, mobileAttempt *cache.Attempt, ipAttempt *cache.Attempt) {
+	logger.Warn("borrower lookup rejected",
+		logger.String("audit", "borrowerLookup"),
+		logger.String("result", result),
+		pii.String("mobile", pii.KindMobile, mobile),
+		logger.String("ip", ip),
+		logger.Int("mobileFailures", mobileAttempt.Failures),
+		logger.Int("ipFailures", ipAttempt.Failures),
+		logger.Int("mobileLockouts", mobileAttempt.Lockouts),
+		logger.Int("ipLockouts", ipAttempt.Lockouts),
+		middleware.GCtxRequestIDField(c),
+	)
+}
+
+// padDuration sleep until minDuration has passed since start, so the response time does not depend on the result
+func padDuration(start time.Time, minDuration time.Duration) {
+	if d := minDuration - time.Since(start); d > 0 {
+		time.Sleep(d)
+	}
+}
+
+func (h *borrowerHandler) length() int {
+	if h.otp.Length > 0 {
+		return h.otp.Length
+	}
+	return 6
+}
+
+func (h *borrowerHandler) expire() time.Duration {
+	if h.otp.Expire > 0 {
+		return time.Duration(h.otp.Expire) * time.Second
+	}
+	return 5 * time.Minute
+}
+
+func (h *borrowerHandler) interval() time.Duration {
+	if h.otp.Interval > 0 {
+		return time.Duration(h.otp.Interval) * time.Second
+	}
+	return time.Minute
+}
+
+func (h *borrowerHandler) dailyLimit() int {
+	if h.otp.DailyLimit > 0 {
+		return h.otp.DailyLimit
+	}
+	return 10
+}
+
+func (h *borrowerHandler) maxAttempts() int {
+	if h.otp.MaxAttempts > 0 {
+		return h.otp.MaxAttempts
+	}
+	return 5
+}
+
+// generateOtp generate a random numeric code
+func generateOtp(length int) (string, error) {
+	code := make([]byte, length)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		code[i] = byte('0' + n.Int64())
+	}
+	return string(code), nil
+}
+
+// getBorrowerMobile get the mobile of the logged in borrower
+func getBorrowerMobile(c *gin.Context) string {
+	return c.GetString(borrowerMobileKey)
+}
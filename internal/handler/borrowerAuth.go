@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/go-dev-frame/sponge/pkg/jwt"
+
+	"lol/internal/cache"
+	"lol/internal/database"
+)
+
+// BorrowerAuth check the borrower token and its session
+type BorrowerAuth struct {
+	sessions cache.SessionCache
+}
+
+// NewBorrowerAuth creating the borrower auth
+func NewBorrowerAuth() *BorrowerAuth {
+	return &BorrowerAuth{
+		sessions: cache.NewSessionCache(database.GetCacheType()),
+	}
+}
+
+// Verify used by middleware.Auth, only borrower tokens whose session is not revoked are accepted,
+// the mobile of the borrower is saved in the context.
+func (a *BorrowerAuth) Verify(claims *jwt.Claims, tokenTail10 string, c *gin.Context) error {
+	if userType, _ := parseTokenName(claims.Name); userType != borrowerTokenName || claims.UID == "" {
+		return errors.New("not a borrower token")
+	}
+	err := checkSession(c, a.sessions, bearerToken(c), claims)
+	if err != nil {
+		return err
+	}
+	c.Set(borrowerMobileKey, claims.UID)
+	return nil
+}
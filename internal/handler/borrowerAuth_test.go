@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-dev-frame/sponge/pkg/jwt"
+
+	"lol/internal/cache"
+	"lol/internal/database"
+)
+
+func TestBorrowerAuth_Verify(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	a := &BorrowerAuth{sessions: cache.NewSessionCache(&database.CacheType{})}
+	newContext := func(token string) *gin.Context {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/loan/detail", nil)
+		c.Request.Header.Set("Authorization", "Bearer "+token)
+		return c
+	}
+
+	// two logins of the borrower in the same second have different sessions
+	mobile := "13800000099"
+	c := newContext("")
+	token1, err := newSessionToken(c, a.sessions, 0, borrowerTokenName, mobile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = newSessionToken(c, a.sessions, 0, borrowerTokenName, mobile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions, err := a.sessions.List(context.Background(), borrowerTokenName, mobile)
+	assert.NoError(t, err)
+	if !assert.Len(t, sessions, 2) {
+		return
+	}
+	assert.NotEqual(t, sessions[0].ID, sessions[1].ID)
+	claims1 := &jwt.Claims{UID: mobile, Name: tokenName(borrowerTokenName, sessions[0].ID)}
+	claims2 := &jwt.Claims{UID: mobile, Name: tokenName(borrowerTokenName, sessions[1].ID)}
+
+	c = newContext(token1)
+	err = a.Verify(claims1, "", c)
+	assert.NoError(t, err)
+	assert.Equal(t, mobile, getBorrowerMobile(c))
+	assert.Equal(t, sessions[0].ID, getSessionID(c))
+
+	// not a borrower token
+	err = a.Verify(&jwt.Claims{UID: "1", Name: tokenName(adminTokenName, sessions[0].ID)}, "", newContext(token1))
+	assert.Error(t, err)
+
+	// revoked token, the other session is kept
+	err = a.sessions.Del(context.Background(), sessions[0].ID)
+	assert.NoError(t, err)
+	err = a.Verify(claims1, "", newContext(token1))
+	assert.Error(t, err)
+	err = a.Verify(claims2, "", newContext(token1))
+	assert.NoError(t, err)
+
+	// the session of a token issued without a session id is the hash of the token
+	err = a.sessions.Set(context.Background(), &cache.Session{ID: tokenID("legacy"), UserType: borrowerTokenName, UID: mobile,
+		CreatedAt: time.Now(), ExpireAt: time.Now().Add(time.Minute)})
+	assert.NoError(t, err)
+	err = a.Verify(&jwt.Claims{UID: mobile, Name: borrowerTokenName}, "", newContext("legacy"))
+	assert.NoError(t, err)
+}
+
+func TestNewBorrowerAuth(t *testing.T) {
+	defer func() {
+		recover()
+	}()
+	_ = NewBorrowerAuth()
+}
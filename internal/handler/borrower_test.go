@@ -0,0 +1,243 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-dev-frame/sponge/pkg/gotest"
+	"github.com/go-dev-frame/sponge/pkg/httpcli"
+	"github.com/go-dev-frame/sponge/pkg/utils"
+
+	"lol/internal/attempt"
+	"lol/internal/cache"
+	"lol/internal/config"
+	"lol/internal/dao"
+	"lol/internal/database"
+	"lol/internal/ecode"
+	"lol/internal/model"
+	"lol/internal/sms"
+	"lol/internal/types"
+)
+
+func newBorrowerHandler() *gotest.Handler {
+	testData := &model.Loan{}
+	testData.ID = 1
+	testData.Name = "张三"
+	testData.Mobile = "13800000000"
+	testData.UserID = "110101199003071234"
+
+	// init mock cache
+	c := gotest.NewCache(map[string]interface{}{utils.Uint64ToStr(testData.ID): testData})
+	c.ICache = cache.NewLoanCache(&database.CacheType{
+		CType: "redis",
+		Rdb:   c.RedisClient,
+	})
+
+	// init mock dao
+	d := gotest.NewDao(c, testData)
+	d.IDao = dao.NewLoanDao(d.DB, c.ICache.(cache.LoanCache))
+
+	// init mock handler
+	h := gotest.NewHandler(d, testData)
+	redisCacheType := &database.CacheType{
+		CType: "redis",
+		Rdb:   c.RedisClient,
+	}
+	bruteForce := config.BruteForce{MaxFailures: 2, CaptchaAfter: 1}
+	h.IHandler = &borrowerHandler{
+		loanDao: d.IDao.(dao.LoanDao),
+		smsService: sms.NewServiceWithSender(config.Sms{
+			Templates: map[string]config.SmsTemplate{
+				sms.TemplateLoginCode: {Content: "code {code}, expire {expire} minutes"},
+			},
+		}, sms.NewLogSender(""), dao.NewSmsHistoryDao(d.DB, nil), nil),
+		otpCache: cache.NewBorrowerOtpCache(&database.CacheType{
+			CType: "redis",
+			Rdb:   c.RedisClient,
+		}),
+		sessions: cache.NewSessionCache(&database.CacheType{
+			CType: "redis",
+			Rdb:   c.RedisClient,
+		}),
+		mobileAttempts: attempt.NewLimiter(borrowerMobileAttempt, bruteForce, 0, cache.NewAttemptCache(redisCacheType)),
+		ipAttempts:     attempt.NewLimiter(borrowerIPAttempt, bruteForce, 10, cache.NewAttemptCache(redisCacheType)),
+		captchas:       cache.NewCaptchaCache(redisCacheType),
+		bruteForce:     bruteForce,
+	}
+	iHandler := h.IHandler.(BorrowerHandler)
+
+	testFns := []gotest.RouterInfo{
+		{
+			FuncName:    "SendOtp",
+			Method:      http.MethodPost,
+			Path:        "/borrower/otp",
+			HandlerFunc: iHandler.SendOtp,
+		},
+		{
+			FuncName:    "Login",
+			Method:      http.MethodPost,
+			Path:        "/borrower/login",
+			HandlerFunc: iHandler.Login,
+		},
+		{
+			FuncName:    "Captcha",
+			Method:      http.MethodPost,
+			Path:        "/borrower/captcha",
+			HandlerFunc: iHandler.Captcha,
+		},
+	}
+
+	h.GoRunHTTPServer(testFns)
+
+	time.Sleep(time.Millisecond * 200)
+	return h
+}
+
+func Test_borrowerHandler_SendOtp(t *testing.T) {
+	h := newBorrowerHandler()
+	defer h.Close()
+	testData := h.TestData.(*model.Loan)
+
+	rows := sqlmock.NewRows([]string{"id", "name", "mobile", "user_id"}).
+		AddRow(testData.ID, testData.Name, testData.Mobile, testData.UserID)
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(rows)
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectExec("INSERT INTO .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	h.MockDao.SQLMock.ExpectCommit()
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectExec("UPDATE .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	h.MockDao.SQLMock.ExpectCommit()
+
+	result := &httpcli.StdResult{}
+	err := httpcli.Post(result, h.GetRequestURL("SendOtp"), &types.SendBorrowerOtpRequest{
+		Mobile: testData.Mobile,
+		Code:   "071234",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+
+	// sent too frequently error test
+	rows = sqlmock.NewRows([]string{"id"}).AddRow(testData.ID)
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(rows)
+	err = httpcli.Post(result, h.GetRequestURL("SendOtp"), &types.SendBorrowerOtpRequest{
+		Mobile: testData.Mobile,
+		Code:   "071234",
+	})
+	assert.NoError(t, err)
+	assert.NotEqual(t, 0, result.Code)
+}
+
+func Test_borrowerHandler_SendOtp_bruteForce(t *testing.T) {
+	h := newBorrowerHandler()
+	defer h.Close()
+	testData := h.TestData.(*model.Loan)
+	bh := h.IHandler.(*borrowerHandler)
+	form := &types.SendBorrowerOtpRequest{Mobile: testData.Mobile, Code: "000000"}
+
+	// not found, the captcha is required after the first failure
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	result := &httpcli.StdResult{}
+	err := httpcli.Post(result, h.GetRequestURL("SendOtp"), form)
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.ErrBorrowerNotFound.Code(), result.Code)
+
+	err = httpcli.Post(result, h.GetRequestURL("SendOtp"), form)
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.ErrBorrowerCaptcha.Code(), result.Code)
+
+	err = httpcli.Post(result, h.GetRequestURL("Captcha"), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.Code)
+	captchaID := result.Data.(map[string]interface{})["captchaID"].(string)
+	answer, err := bh.captchas.Get(context.Background(), captchaID)
+	assert.NoError(t, err)
+
+	// the second failure locks the mobile
+	form.CaptchaID, form.Captcha = captchaID, answer.Answer
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	err = httpcli.Post(result, h.GetRequestURL("SendOtp"), form)
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.ErrBorrowerNotFound.Code(), result.Code)
+
+	// locked, a correct code is rejected too
+	form.Code = "071234"
+	err = httpcli.Post(result, h.GetRequestURL("SendOtp"), form)
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.ErrBorrowerLocked.Code(), result.Code)
+
+	record, err := bh.mobileAttempts.Get(context.Background(), testData.Mobile)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, record.Failures)
+	assert.Equal(t, 1, record.Lockouts)
+}
+
+func Test_borrowerHandler_Login(t *testing.T) {
+	h := newBorrowerHandler()
+	defer h.Close()
+	testData := h.TestData.(*model.Loan)
+	otpCache := h.IHandler.(*borrowerHandler).otpCache
+	err := otpCache.Set(context.Background(), testData.Mobile, &cache.BorrowerOtp{Code: "123456", SentAt: time.Now()}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// wrong code error test
+	result := &httpcli.StdResult{}
+	err = httpcli.Post(result, h.GetRequestURL("Login"), &types.BorrowerLoginRequest{
+		Mobile: testData.Mobile,
+		Otp:    "654321",
+	})
+	assert.NoError(t, err)
+	assert.NotEqual(t, 0, result.Code)
+	attempts, err := otpCache.IncrAttempts(context.Background(), testData.Mobile, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+	// the wrong code is counted by the lockout of the mobile
+	record, err := h.IHandler.(*borrowerHandler).mobileAttempts.Get(context.Background(), testData.Mobile)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, record.Failures)
+
+	err = httpcli.Post(result, h.GetRequestURL("Login"), &types.BorrowerLoginRequest{
+		Mobile: testData.Mobile,
+		Otp:    "123456",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+
+	// the code can only be used once
+	err = httpcli.Post(result, h.GetRequestURL("Login"), &types.BorrowerLoginRequest{
+		Mobile: testData.Mobile,
+		Otp:    "123456",
+	})
+	assert.NoError(t, err)
+	assert.NotEqual(t, 0, result.Code)
+}
+
+func Test_generateOtp(t *testing.T) {
+	code, err := generateOtp(6)
+	assert.NoError(t, err)
+	assert.Len(t, code, 6)
+	for _, v := range code {
+		assert.True(t, v >= '0' && v <= '9')
+	}
+}
+
+func TestNewBorrowerHandler(t *testing.T) {
+	defer func() {
+		recover()
+	}()
+	_ = NewBorrowerHandler()
+}
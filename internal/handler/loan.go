@@ -13,9 +13,11 @@ import (
 	"github.com/wechatpay-apiv3/wechatpay-go/services/payments"
 	wechatUtils "github.com/wechatpay-apiv3/wechatpay-go/utils"
 	"log"
+	"math"
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -25,7 +27,10 @@ import (
 	"lol/internal/database"
 	"lol/internal/ecode"
 	"lol/internal/model"
+	"lol/internal/notice"
 	"lol/internal/payment"
+	"lol/internal/pii"
+	"lol/internal/repayment"
 	"lol/internal/types"
 
 	"github.com/go-dev-frame/sponge/pkg/gin/middleware"
@@ -49,18 +54,26 @@ var _ LoanHandler = (*loanHandler)(nil)
 type LoanHandler interface {
 	Create(c *gin.Context)
 	DeleteByID(c *gin.Context)
+	ListDeleted(c *gin.Context)
+	RestoreByID(c *gin.Context)
+	PurgeByID(c *gin.Context)
 	UpdateByID(c *gin.Context)
 	GetByID(c *gin.Context)
 	List(c *gin.Context)
+	Quote(c *gin.Context)
 	GetDetail(c *gin.Context)
 	Pay(c *gin.Context)
+	GetPayment(c *gin.Context)
 	Notify(c *gin.Context)
 }
 
 type loanHandler struct {
-	iDao      dao.LoanDao
-	alipay    *alipay.Client
-	wechatPay *core.Client
+	iDao       dao.LoanDao
+	productDao dao.LoanProductDao
+	alipay     *alipay.Client
+	wechatPay  *core.Client
+	notifier   *notice.PaymentNotifier // if nil, borrowers are not notified of their payments
+	accessLogs dao.PiiAccessLogDao
 }
 
 // NewLoanHandler creating the handler interface
@@ -70,8 +83,14 @@ func NewLoanHandler() LoanHandler {
 			database.GetDB(), // db driver is mysql
 			cache.NewLoanCache(database.GetCacheType()),
 		),
-		alipay:    payment.GetAlipayClient(),
-		wechatPay: payment.GetWechatClient(),
+		productDao: dao.NewLoanProductDao(
+			database.GetDB(),
+			cache.NewLoanProductCache(database.GetCacheType()),
+		),
+		alipay:     payment.GetAlipayClient(),
+		wechatPay:  payment.GetWechatClient(),
+		notifier:   notice.NewPaymentNotifier(),
+		accessLogs: dao.NewPiiAccessLogDao(database.GetDB()),
 	}
 }
 
@@ -90,7 +109,7 @@ func (h *loanHandler) Create(c *gin.Context) {
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		responseInvalidParams(c, err)
 		return
 	}
 
@@ -104,18 +123,26 @@ func (h *loanHandler) Create(c *gin.Context) {
 	// Note: if copier.Copy cannot assign a value to a field, add it here
 
 	ctx := middleware.WrapCtx(c)
+	now := time.Now()
+
+	plan, schedule, isAbort := h.newSchedule(c, form.ProductID, float64(form.LoanMoney), form.LoanPeriod,
+		now, utils.StrToInt(form.LoanReturnDate))
+	if isAbort {
+		return
+	}
 
-	// Ensure the calculation is done with floating-point numbers
-	// Convert form.LoanMoney to float64
-	loan.MonthlyPayment = float64(form.LoanMoney)*2/100 + float64(form.LoanMoney)/float64(form.LoanPeriod)
+	loan.ProductID = form.ProductID
+	loan.AnnualRate = plan.AnnualRate
+	loan.RepaymentMethod = plan.Method
+	loan.BalloonRatio = plan.BalloonRatio
+	loan.MonthlyPayment = schedule.Installments[0].Amount
 
 	loan.Status = 0
 
-	now := time.Now()
 	loan.CreateAt = &now
 	err = h.iDao.Create(ctx, loan)
 	if err != nil {
-		logger.Error("Create error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+		logger.Error("Create error", logger.Err(err), pii.Any("form", form), middleware.GCtxRequestIDField(c))
 		response.Output(c, ecode.InternalServerError.ToHTTPCode())
 		return
 	}
@@ -123,9 +150,112 @@ func (h *loanHandler) Create(c *gin.Context) {
 	response.Success(c, gin.H{"id": loan.ID})
 }
 
+// Quote calculate the repayment schedule of a loan without creating it
+// @Summary quote loan
+// @Description calculate the repayment schedule, total interest, total fees and APR of a loan without creating it
+// @Tags loan
+// @accept json
+// @Produce json
+// @Param data body types.QuoteLoanRequest true "loan quote information"
+// @Success 200 {object} types.QuoteLoanReply{}
+// @Router /api/v1/loan/quote [post]
+// @Security BearerAuth
+func (h *loanHandler) Quote(c *gin.Context) {
+	form := &types.QuoteLoanRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		responseInvalidParams(c, err)
+		return
+	}
+
+	start := time.Now()
+	if form.StartDate != "" {
+		start, err = time.ParseInLocation(time.DateOnly, form.StartDate, time.Local)
+		if err != nil {
+			logger.Warn("ParseInLocation error", logger.Err(err), logger.String("startDate", form.StartDate), middleware.GCtxRequestIDField(c))
+			response.Error(c, ecode.InvalidParams)
+			return
+		}
+	}
+	dueDay := start.Day()
+	if form.LoanReturnDate != "" {
+		dueDay = utils.StrToInt(form.LoanReturnDate)
+	}
+
+	plan, schedule, isAbort := h.newSchedule(c, form.ProductID, float64(form.LoanMoney), form.LoanPeriod, start, dueDay)
+	if isAbort {
+		return
+	}
+
+	quote := &types.LoanQuote{
+		AnnualRate:      plan.AnnualRate,
+		RepaymentMethod: plan.Method,
+		MonthlyPayment:  schedule.Installments[0].Amount,
+		TotalAmount:     schedule.Total(),
+		TotalInterest:   schedule.TotalInterest(),
+		TotalFees:       schedule.TotalFees(),
+		APR:             schedule.APR(plan.Principal),
+	}
+	for _, v := range schedule.Installments {
+		quote.Installments = append(quote.Installments, &types.LoanQuoteInstallment{
+			Seq:       v.Seq,
+			DueDate:   v.DueDate.Format(time.DateOnly),
+			Amount:    v.Amount,
+			Principal: v.Principal,
+			Interest:  v.Interest,
+			Fee:       v.Fee,
+		})
+	}
+
+	response.Success(c, gin.H{"quote": quote})
+}
+
+// newSchedule 按产品的利率和还款方式生成还款计划，未指定产品时按平息法月息2%计算。
+// Create和Quote共用，保证报价与实际生成的借款一致，返回isAbort时已写入错误响应。
+func (h *loanHandler) newSchedule(c *gin.Context, productID uint64, principal float64, period int,
+	start time.Time, dueDay int) (*repayment.Plan, *repayment.Schedule, bool) {
+	plan := &repayment.Plan{
+		Principal:  principal,
+		Period:     period,
+		AnnualRate: repayment.DefaultAnnualRate,
+		Method:     repayment.MethodFlat,
+		Start:      start,
+		DueDay:     dueDay,
+	}
+	if productID > 0 {
+		product, err := h.productDao.GetByID(middleware.WrapCtx(c), productID)
+		if err != nil {
+			if errors.Is(err, database.ErrRecordNotFound) {
+				logger.Warn("GetByID not found", logger.Err(err), logger.Any("productID", productID), middleware.GCtxRequestIDField(c))
+				response.Error(c, ecode.NotFound)
+			} else {
+				logger.Error("GetByID error", logger.Err(err), logger.Any("productID", productID), middleware.GCtxRequestIDField(c))
+				response.Output(c, ecode.InternalServerError.ToHTTPCode())
+			}
+			return nil, nil, true
+		}
+		if !isProductTerm(product, period) {
+			response.Error(c, ecode.ErrLoanProductTerm)
+			return nil, nil, true
+		}
+		plan.AnnualRate = product.AnnualRate
+		plan.Method = product.RepaymentMethod
+		plan.BalloonRatio = product.BalloonRatio
+	}
+
+	schedule, err := repayment.NewSchedule(plan)
+	if err != nil {
+		logger.Warn("NewSchedule error", logger.Err(err), logger.Any("plan", plan), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.InvalidParams)
+		return nil, nil, true
+	}
+	return plan, schedule, false
+}
+
 // DeleteByID delete a record by id
 // @Summary delete loan
-// @Description delete loan by id
+// @Description delete loan by id, it is moved to the trash and can be restored
 // @Tags loan
 // @accept json
 // @Produce json
@@ -151,15 +281,126 @@ func (h *loanHandler) DeleteByID(c *gin.Context) {
 	response.Success(c)
 }
 
+// ListDeleted of the records in the trash by query parameters
+// @Summary list of deleted loans by query parameters
+// @Description list of the soft deleted loans in the trash by paging and conditions, they can be restored or purged
+// @Tags loan
+// @accept json
+// @Produce json
+// @Param data body types.Params true "query parameters"
+// @Success 200 {object} types.ListLoansReply{}
+// @Router /api/v1/loan/trash/list [post]
+// @Security BearerAuth
+func (h *loanHandler) ListDeleted(c *gin.Context) {
+	form := &types.ListLoansRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		responseInvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	loans, total, err := h.iDao.GetDeletedByColumns(ctx, &form.Params)
+	if err != nil {
+		logger.Error("GetDeletedByColumns error", logger.Err(err), pii.Any("form", form), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+
+	data, err := convertLoans(loans)
+	if err != nil {
+		response.Error(c, ecode.ErrListLoan)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"loans": revealPII(c, h.accessLogs, data, loanPIIRecords(data...)),
+		"total": total,
+	})
+}
+
+// RestoreByID restore a record in the trash by id
+// @Summary restore loan
+// @Description restore the deleted loan by id from the trash
+// @Tags loan
+// @accept json
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} types.RestoreLoanByIDReply{}
+// @Router /api/v1/loan/{id}/restore [put]
+// @Security BearerAuth
+func (h *loanHandler) RestoreByID(c *gin.Context) {
+	_, id, isAbort := getLoanIDFromPath(c)
+	if isAbort {
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	err := h.iDao.RestoreByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, database.ErrRecordNotFound) {
+			logger.Warn("RestoreByID not found", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Error(c, ecode.NotFound)
+		} else {
+			logger.Error("RestoreByID error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		}
+		return
+	}
+
+	response.Success(c)
+}
+
+// PurgeByID delete a record in the trash permanently by id
+// @Summary purge loan
+// @Description delete the deleted loan by id from the trash permanently, it can't be restored any more
+// @Tags loan
+// @accept json
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} types.PurgeLoanByIDReply{}
+// @Router /api/v1/loan/{id}/purge [delete]
+// @Security BearerAuth
+func (h *loanHandler) PurgeByID(c *gin.Context) {
+	_, id, isAbort := getLoanIDFromPath(c)
+	if isAbort {
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	err := h.iDao.PurgeByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, database.ErrRecordNotFound) {
+			logger.Warn("PurgeByID not found", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Error(c, ecode.NotFound)
+		} else {
+			logger.Error("PurgeByID error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		}
+		return
+	}
+
+	response.Success(c)
+}
+
 // UpdateByID update information by id
 // @Summary update loan
-// @Description update loan information by id
+// @Description update loan information by id, the version the loan was read with is required, either in the If-Match header
+// @Description as the ETag of the detail or as the version of the request, if the loan has been updated since, 409 is returned,
+// @Description the fields given are updated even if they are zero values, the fields left out are kept,
+// @Description if loanMoney, loanPeriod or loanReturnDate is given, the repayment terms and monthlyPayment are computed again
+// @Description by the product of the loan like creating it
 // @Tags loan
 // @accept json
 // @Produce json
 // @Param id path string true "id"
+// @Param If-Match header string false "the ETag of the loan detail"
 // @Param data body types.UpdateLoanByIDRequest true "loan information"
 // @Success 200 {object} types.UpdateLoanByIDReply{}
+// @Failure 409 "the loan has been updated since it was read"
 // @Router /api/v1/loan/{id} [put]
 // @Security BearerAuth
 func (h *loanHandler) UpdateByID(c *gin.Context) {
@@ -173,10 +414,16 @@ func (h *loanHandler) UpdateByID(c *gin.Context) {
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		responseInvalidParams(c, err)
 		return
 	}
 	form.ID = id
+	version, ok := getLoanVersion(c.GetHeader("If-Match"), form.Version)
+	if !ok {
+		logger.Warn("the version of the loan is required", logger.String("If-Match", c.GetHeader("If-Match")), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
 
 	loan := &model.Loan{}
 	err = copier.Copy(loan, form)
@@ -185,26 +432,104 @@ func (h *loanHandler) UpdateByID(c *gin.Context) {
 		return
 	}
 	// Note: if copier.Copy cannot assign a value to a field, add it here
+	loan.Version = version
+
+	columns, isAbort := h.reschedule(c, form, loan, updatedColumns(form, loan))
+	if isAbort {
+		return
+	}
 
 	ctx := middleware.WrapCtx(c)
-	err = h.iDao.UpdateByID(ctx, loan)
+	err = h.iDao.UpdateByID(ctx, loan, columns...)
 	if err != nil {
-		logger.Error("UpdateByID error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
-		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		switch {
+		case errors.Is(err, dao.ErrLoanVersionConflict):
+			logger.Warn("UpdateByID version conflict", logger.Uint64("id", id), logger.Uint64("version", version), middleware.GCtxRequestIDField(c))
+			response.Output(c, ecode.Conflict.ToHTTPCode())
+		case errors.Is(err, database.ErrRecordNotFound):
+			logger.Warn("UpdateByID not found", logger.Uint64("id", id), middleware.GCtxRequestIDField(c))
+			response.Error(c, ecode.NotFound)
+		default:
+			logger.Error("UpdateByID error", logger.Err(err), pii.Any("form", form), middleware.GCtxRequestIDField(c))
+			response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		}
 		return
 	}
 
+	c.Header("ETag", loanETag(loan.Version))
 	response.Success(c)
 }
 
+// reschedule compute the repayment terms and MonthlyPayment of the loan again if the update changes the principal,
+// the period or the due day, so the schedule of the loan matches them. The columns of the update are returned
+// with the recomputed ones, the response is written if failed.
+func (h *loanHandler) reschedule(c *gin.Context, form *types.UpdateLoanByIDRequest, loan *model.Loan, columns []string) ([]string, bool) {
+	if form.LoanMoney == nil && form.LoanPeriod == nil && form.LoanReturnDate == nil {
+		return columns, false
+	}
+
+	current, err := h.iDao.GetByID(middleware.WrapCtx(c), loan.ID)
+	if err != nil {
+		if errors.Is(err, database.ErrRecordNotFound) {
+			logger.Warn("GetByID not found", logger.Uint64("id", loan.ID), middleware.GCtxRequestIDField(c))
+			response.Error(c, ecode.NotFound)
+		} else {
+			logger.Error("GetByID error", logger.Err(err), logger.Uint64("id", loan.ID), middleware.GCtxRequestIDField(c))
+			response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		}
+		return nil, true
+	}
+	if current.Version != loan.Version {
+		logger.Warn("UpdateByID version conflict", logger.Uint64("id", loan.ID), logger.Uint64("version", loan.Version), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.Conflict.ToHTTPCode())
+		return nil, true
+	}
+
+	principal, period, dueDay := current.LoanMoney, current.LoanPeriod, current.LoanReturnDate
+	if form.LoanMoney != nil {
+		principal = *form.LoanMoney
+	}
+	if form.LoanPeriod != nil {
+		period = *form.LoanPeriod
+	}
+	if form.LoanReturnDate != nil {
+		dueDay = *form.LoanReturnDate
+	}
+	start := time.Now()
+	if loan.CreateAt != nil && !loan.CreateAt.IsZero() {
+		start = *loan.CreateAt
+	} else if current.CreateAt != nil && !current.CreateAt.IsZero() {
+		start = *current.CreateAt
+	}
+
+	// 借款金额改为0时没有需要还的钱
+	if principal <= 0 {
+		loan.MonthlyPayment = 0
+		return append(columns, "monthly_payment"), false
+	}
+
+	plan, schedule, isAbort := h.newSchedule(c, current.ProductID, principal, period, start, utils.StrToInt(dueDay))
+	if isAbort {
+		return nil, true
+	}
+	loan.AnnualRate = plan.AnnualRate
+	loan.RepaymentMethod = plan.Method
+	loan.BalloonRatio = plan.BalloonRatio
+	loan.MonthlyPayment = schedule.Installments[0].Amount
+	return append(columns, "annual_rate", "repayment_method", "balloon_ratio", "monthly_payment"), false
+}
+
 // GetByID get a record by id
 // @Summary get loan detail
-// @Description get loan detail by id
+// @Description get loan detail by id, the ETag header is the version of the loan, if the If-Match header
+// @Description doesn't match it, 412 is returned
 // @Tags loan
 // @Param id path string true "id"
+// @Param If-Match header string false "the ETag of the loan detail read before"
 // @Accept json
 // @Produce json
 // @Success 200 {object} types.GetLoanByIDReply{}
+// @Failure 412 "the loan has been updated since the If-Match ETag was read"
 // @Router /api/v1/loan/{id} [get]
 // @Security BearerAuth
 func (h *loanHandler) GetByID(c *gin.Context) {
@@ -227,6 +552,12 @@ func (h *loanHandler) GetByID(c *gin.Context) {
 		return
 	}
 
+	etag := loanETag(loan.Version)
+	if !matchETag(c.GetHeader("If-Match"), etag) {
+		response.Output(c, http.StatusPreconditionFailed)
+		return
+	}
+
 	data := &types.LoanObjDetail{}
 	err = copier.Copy(data, loan)
 	if err != nil {
@@ -235,7 +566,8 @@ func (h *loanHandler) GetByID(c *gin.Context) {
 	}
 	// Note: if copier.Copy cannot assign a value to a field, add it here
 
-	response.Success(c, gin.H{"loan": data})
+	c.Header("ETag", etag)
+	response.Success(c, gin.H{"loan": revealPII(c, h.accessLogs, data, loanPIIRecords(data))})
 }
 
 // List of records by query parameters
@@ -253,14 +585,14 @@ func (h *loanHandler) List(c *gin.Context) {
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		responseInvalidParams(c, err)
 		return
 	}
 
 	ctx := middleware.WrapCtx(c)
 	loans, total, err := h.iDao.GetByColumns(ctx, &form.Params)
 	if err != nil {
-		logger.Error("GetByColumns error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+		logger.Error("GetByColumns error", logger.Err(err), pii.Any("form", form), middleware.GCtxRequestIDField(c))
 		response.Output(c, ecode.InternalServerError.ToHTTPCode())
 		return
 	}
@@ -272,64 +604,108 @@ func (h *loanHandler) List(c *gin.Context) {
 	}
 
 	response.Success(c, gin.H{
-		"loans": data,
+		"loans": revealPII(c, h.accessLogs, data, loanPIIRecords(data...)),
 		"total": total,
 	})
 }
 
 func (h *loanHandler) GetDetail(c *gin.Context) {
-	form := &types.GetDetailRequest{}
-	err := c.ShouldBindJSON(form)
-	if err != nil {
-		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
-		return
-	}
+	mobile := getBorrowerMobile(c)
 	ctx := middleware.WrapCtx(c)
-	loan, err := h.iDao.GetByMobileAndCode(ctx, form.Mobile, form.Code)
+	loans, err := h.iDao.GetByMobile(ctx, mobile)
 	if err != nil {
-		logger.Warnf("failed to get user loan detail ,user mobile:%s,user code:%s", form.Mobile, form.Code)
+		logger.Warn("GetByMobile error", logger.Err(err), pii.String("mobile", pii.KindMobile, mobile), middleware.GCtxRequestIDField(c))
 		response.Error(c, ecode.ErrListLoan)
 		return
 	}
+
+	// 只返回未还清的借款
+	activeLoans := []*model.Loan{}
+	for _, loan := range loans {
+		if loan.Status != 1 {
+			activeLoans = append(activeLoans, loan)
+		}
+	}
 	response.Success(c, gin.H{
-		"detail": loan,
+		"loans": pii.Mask(activeLoans),
+		"total": len(activeLoans),
 	})
 }
 
+// getBorrowerLoan 从借款人的借款中找到要支付的借款，未指定借款时借款人只能有一笔未还清的借款
+func getBorrowerLoan(loans []*model.Loan, loanID uint64) *model.Loan {
+	if loanID > 0 {
+		for _, loan := range loans {
+			if loan.ID == loanID {
+				return loan
+			}
+		}
+		return nil
+	}
+
+	var activeLoan *model.Loan
+	for _, loan := range loans {
+		if loan.Status != 1 {
+			if activeLoan != nil {
+				return nil
+			}
+			activeLoan = loan
+		}
+	}
+	return activeLoan
+}
+
 func (h *loanHandler) Pay(c *gin.Context) {
 	form := &types.PayRequest{}
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		responseInvalidParams(c, err)
 		return
 	}
+	mobile := getBorrowerMobile(c)
 	ctx := middleware.WrapCtx(c)
-	loan, err := h.iDao.GetByMobileAndCode(ctx, form.Mobile, form.Code)
+	loans, err := h.iDao.GetByMobile(ctx, mobile)
 	if err != nil {
-
+		logger.Warn("GetByMobile error", logger.Err(err), pii.String("mobile", pii.KindMobile, mobile), middleware.GCtxRequestIDField(c))
 		response.Error(c, ecode.ErrListLoan)
 		return
 	}
+	loan := getBorrowerLoan(loans, form.LoanID)
+	if loan == nil {
+		logger.Warn("loan not found for borrower", logger.Uint64("loanID", form.LoanID), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.ErrPayLoan)
+		return
+	}
 	if loan.Status == 1 {
 		//表示已經還完
 		response.Error(c, ecode.ErrLoanStatus)
 		return
 	}
+	// 按还款计划校验本次支付的期数或金额
+	schedule := repayment.NewLoanSchedule(loan)
+	schedule.Allocate(loan.PaidMoney)
+	amount, installments, err := getPayAmount(form, schedule)
+	if err != nil {
+		logger.Warn("getPayAmount error", logger.Err(err), logger.Int("installments", form.Installments),
+			logger.Float64("amount", form.Amount), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.ErrPayAmount)
+		return
+	}
+
 	//開始調用支付寶/微信網頁支付接口
 	var subject string
 
-	baseMoney := loan.MonthlyPayment + float64(loan.OverDueMoney)
+	baseMoney := amount + float64(loan.OverDueMoney)
 
-	totalMoney := baseMoney + baseMoney*(6.0/1000)
+	totalMoney := math.Round((baseMoney+repayment.Fee(baseMoney))*100) / 100
 	money := fmt.Sprintf("%.2f", totalMoney)
 
 	extInfo := ""
 	if loan.OverDueMoney > 0 {
 		extInfo = "（逾期费用：" + strconv.Itoa(loan.OverDueMoney) + "元）"
 	}
-	subject = loan.Name + "支付【" + loan.CarModel + "】月租" + money + "元" + extInfo
+	subject = loan.Name + "支付【" + loan.CarModel + "】" + getPaySubject(form) + money + "元" + extInfo
 
 	var url string
 	tradeNo := generateTradeNo()
@@ -352,11 +728,15 @@ func (h *loanHandler) Pay(c *gin.Context) {
 	}
 	now := time.Now()
 	payments := &model.PaymentHistory{
-		UserPhone:  form.Mobile,
-		OutTradeNo: tradeNo,
-		Status:     "PAYING",
-		Method:     form.Method,
-		CreateAt:   &now,
+		UserPhone:    mobile,
+		OutTradeNo:   tradeNo,
+		Status:       "PAYING",
+		Method:       form.Method,
+		LoanID:       loan.ID,
+		Installments: installments,
+		Amount:       amount,
+		TotalAmount:  totalMoney,
+		CreateAt:     &now,
 	}
 	err = h.iDao.CreatePaymentHistory(ctx, payments)
 	if err != nil {
@@ -368,6 +748,46 @@ func (h *loanHandler) Pay(c *gin.Context) {
 	})
 }
 
+// GetPayment get the status of a payment of the logged in borrower
+// @Summary get payment status
+// @Description get the status of a payment by trade no, only payments of the logged in borrower can be got
+// @Tags loan
+// @Param tradeNo path string true "trade no"
+// @Produce json
+// @Success 200 {object} types.GetPaymentHistoryByIDReply{}
+// @Router /api/v1/loan/payment/{tradeNo} [get]
+// @Security BearerAuth
+func (h *loanHandler) GetPayment(c *gin.Context) {
+	tradeNo := c.Param("tradeNo")
+	ctx := middleware.WrapCtx(c)
+	payment, err := h.iDao.GetPaymentByTradeNo(ctx, tradeNo)
+	if err != nil {
+		if errors.Is(err, database.ErrRecordNotFound) {
+			logger.Warn("GetPaymentByTradeNo not found", logger.Err(err), logger.String("tradeNo", tradeNo), middleware.GCtxRequestIDField(c))
+			response.Error(c, ecode.NotFound)
+		} else {
+			logger.Error("GetPaymentByTradeNo error", logger.Err(err), logger.String("tradeNo", tradeNo), middleware.GCtxRequestIDField(c))
+			response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		}
+		return
+	}
+	// 只能查询自己的订单
+	if payment.UserPhone != getBorrowerMobile(c) {
+		logger.Warn("payment of other borrower", logger.String("tradeNo", tradeNo), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.NotFound)
+		return
+	}
+
+	data := &types.PaymentHistoryObjDetail{}
+	err = copier.Copy(data, payment)
+	if err != nil {
+		response.Error(c, ecode.ErrGetByIDPaymentHistory)
+		return
+	}
+
+	response.Success(c, gin.H{"paymentHistory": pii.Mask(data)})
+}
+
 func (h *loanHandler) Notify(c *gin.Context) {
 	// 解析表单数据并检查错误
 	if err := c.Request.ParseForm(); err != nil {
@@ -419,6 +839,9 @@ func (h *loanHandler) Notify(c *gin.Context) {
 			c.String(http.StatusInternalServerError, "fail")
 			return
 		}
+		if status == "SUCCESS" {
+			h.settlePayment(ctx, result.OutTradeNo, "")
+		}
 	} else {
 		// 记录未支持的支付渠道
 		log.Printf("收到未支持的支付渠道通知: %s", bandName)
@@ -459,7 +882,13 @@ func (h *loanHandler) Notify(c *gin.Context) {
 			h.iDao.UpdatePaymentStatusByTradeNo(ctx, *transaction.OutTradeNo, "FAILED")
 		} else {
 			logger.Warnf("微信支付成功：%s", notifyReq.Summary)
-			h.iDao.UpdatePaymentStatusByTradeNo(ctx, *transaction.OutTradeNo, "SUCCESS")
+			if err = h.iDao.UpdatePaymentStatusByTradeNo(ctx, *transaction.OutTradeNo, "SUCCESS"); err == nil {
+				openid := ""
+				if transaction.Payer != nil && transaction.Payer.Openid != nil {
+					openid = *transaction.Payer.Openid
+				}
+				h.settlePayment(ctx, *transaction.OutTradeNo, openid)
+			}
 		}
 		logger.Infof("微信交易单号 %s 交易状态 %s", transaction.TransactionId, transaction.TradeState)
 	}
@@ -540,13 +969,68 @@ func trackWechatOrder(h *loanHandler, ctx context.Context, client *core.Client,
 				err := h.iDao.UpdatePaymentStatusByTradeNo(ctx, outTradeNo, "SUCCESS")
 				if err != nil {
 					log.Printf("更新订单状态失败: %v", err)
+					return
 				}
+				h.settlePayment(ctx, outTradeNo, "")
 				return
 			}
 			log.Printf("订单未支付，继续跟踪，订单号: %s，第 %d 次查询", outTradeNo, attempts)
 		}
 	}
 }
+
+// settlePayment 将支付成功的订单金额分配到借款分期，并异步通知借款人，失败不影响支付回调的应答。
+// openid 为微信支付的付款人，用于发送订阅消息，其他支付方式为空
+func (h *loanHandler) settlePayment(ctx context.Context, tradeNo string, openid string) {
+	if err := h.iDao.SettlePaymentByTradeNo(ctx, tradeNo); err != nil {
+		logger.Error("SettlePaymentByTradeNo error", logger.Err(err), logger.String("tradeNo", tradeNo))
+	}
+	h.notifier.Notify(tradeNo, openid)
+}
+
+// isProductTerm whether the product offers the loan period
+func isProductTerm(product *model.LoanProduct, period int) bool {
+	terms, err := repayment.ParseTerms(product.Terms)
+	if err != nil {
+		return false
+	}
+	for _, term := range terms {
+		if term == period {
+			return true
+		}
+	}
+	return false
+}
+
+// getPayAmount 本次支付分配到分期的金额和期数，默认支付一期，自定义金额的期数为0
+func getPayAmount(form *types.PayRequest, schedule *repayment.Schedule) (float64, int, error) {
+	if form.Amount > 0 {
+		if form.Installments > 0 {
+			return 0, 0, errors.New("installments and amount cannot be set at the same time")
+		}
+		return form.Amount, 0, schedule.CheckAmount(form.Amount)
+	}
+
+	installments := form.Installments
+	if installments == 0 {
+		installments = 1
+	}
+	amount, err := schedule.AmountForInstallments(installments)
+	return amount, installments, err
+}
+
+// getPaySubject 订单标题中的支付内容
+func getPaySubject(form *types.PayRequest) string {
+	switch {
+	case form.Amount > 0:
+		return "租金"
+	case form.Installments > 1:
+		return strconv.Itoa(form.Installments) + "期月租"
+	default:
+		return "月租"
+	}
+}
+
 func getLoanIDFromPath(c *gin.Context) (string, uint64, bool) {
 	idStr := c.Param("id")
 	id, err := utils.StrToUint64E(idStr)
@@ -558,6 +1042,43 @@ func getLoanIDFromPath(c *gin.Context) (string, uint64, bool) {
 	return idStr, id, false
 }
 
+// loanETag the entity tag of a version of a loan
+func loanETag(version uint64) string {
+	return `"` + strconv.FormatUint(version, 10) + `"`
+}
+
+// matchETag whether the If-Match header matches the etag, an empty header or * matches any etag
+func matchETag(ifMatch string, etag string) bool {
+	ifMatch = strings.TrimSpace(ifMatch)
+	if ifMatch == "" || ifMatch == "*" {
+		return true
+	}
+	for _, tag := range strings.Split(ifMatch, ",") {
+		// weak tags are compared as strong ones, a version of a loan has a single representation
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// getLoanVersion the version the loan to update was read with, the ETag of the If-Match header is preferred
+// to the version of the request, false if neither is given
+func getLoanVersion(ifMatch string, version *uint64) (uint64, bool) {
+	ifMatch = strings.TrimPrefix(strings.TrimSpace(ifMatch), "W/")
+	if ifMatch != "" && ifMatch != "*" {
+		v, err := strconv.ParseUint(strings.Trim(ifMatch, `"`), 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		return v, true
+	}
+	if version == nil {
+		return 0, false
+	}
+	return *version, true
+}
+
 func convertLoan(loan *model.Loan) (*types.LoanObjDetail, error) {
 	data := &types.LoanObjDetail{}
 	err := copier.Copy(data, loan)
@@ -565,6 +1086,9 @@ func convertLoan(loan *model.Loan) (*types.LoanObjDetail, error) {
 		return nil, err
 	}
 	// Note: if copier.Copy cannot assign a value to a field, add it here
+	if loan.DeletedAt.Valid {
+		data.DeletedAt = &loan.DeletedAt.Time
+	}
 
 	return data, nil
 }
@@ -661,3 +1185,12 @@ func CloseOrder(wechatClient *core.Client, outTradeNo string, ctx context.Contex
 func generateTradeNo() string {
 	return time.Now().Format("20060102150405")
 }
+
+// loanPIIRecords the loans revealing personal information in the response
+func loanPIIRecords(loans ...*types.LoanObjDetail) []piiRecord {
+	records := make([]piiRecord, 0, len(loans))
+	for _, v := range loans {
+		records = append(records, piiRecord{resource: piiResourceLoan, resourceID: v.ID, loanID: v.ID, value: v})
+	}
+	return records
+}
@@ -0,0 +1,290 @@
+package handler
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/copier"
+
+	"github.com/go-dev-frame/sponge/pkg/gin/middleware"
+	"github.com/go-dev-frame/sponge/pkg/gin/response"
+	"github.com/go-dev-frame/sponge/pkg/logger"
+	"github.com/go-dev-frame/sponge/pkg/utils"
+
+	"lol/internal/cache"
+	"lol/internal/dao"
+	"lol/internal/database"
+	"lol/internal/ecode"
+	"lol/internal/model"
+	"lol/internal/pii"
+	"lol/internal/repayment"
+	"lol/internal/types"
+)
+
+var _ LoanProductHandler = (*loanProductHandler)(nil)
+
+// LoanProductHandler defining the handler interface
+type LoanProductHandler interface {
+	Create(c *gin.Context)
+	DeleteByID(c *gin.Context)
+	UpdateByID(c *gin.Context)
+	GetByID(c *gin.Context)
+	List(c *gin.Context)
+}
+
+type loanProductHandler struct {
+	iDao dao.LoanProductDao
+}
+
+// NewLoanProductHandler creating the handler interface
+func NewLoanProductHandler() LoanProductHandler {
+	return &loanProductHandler{
+		iDao: dao.NewLoanProductDao(
+			database.GetDB(), // db driver is mysql
+			cache.NewLoanProductCache(database.GetCacheType()),
+		),
+	}
+}
+
+// Create a record
+// @Summary create loanProduct
+// @Description submit information to create loanProduct
+// @Tags loanProduct
+// @accept json
+// @Produce json
+// @Param data body types.CreateLoanProductRequest true "loanProduct information"
+// @Success 200 {object} types.CreateLoanProductReply{}
+// @Router /api/v1/loanProduct [post]
+// @Security BearerAuth
+func (h *loanProductHandler) Create(c *gin.Context) {
+	form := &types.CreateLoanProductRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		responseInvalidParams(c, err)
+		return
+	}
+
+	if _, err = repayment.ParseTerms(form.Terms); err != nil {
+		logger.Warn("ParseTerms error: ", logger.Err(err), logger.String("terms", form.Terms), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.ErrLoanProductTerms)
+		return
+	}
+
+	loanProduct := &model.LoanProduct{}
+	err = copier.Copy(loanProduct, form)
+	if err != nil {
+		response.Error(c, ecode.ErrCreateLoanProduct)
+		return
+	}
+	// Note: if copier.Copy cannot assign a value to a field, add it here
+	now := time.Now()
+	loanProduct.CreateAt = &now
+
+	ctx := middleware.WrapCtx(c)
+	err = h.iDao.Create(ctx, loanProduct)
+	if err != nil {
+		logger.Error("Create error", logger.Err(err), pii.Any("form", form), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+
+	response.Success(c, gin.H{"id": loanProduct.ID})
+}
+
+// DeleteByID delete a record by id
+// @Summary delete loanProduct
+// @Description delete loanProduct by id
+// @Tags loanProduct
+// @accept json
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} types.DeleteLoanProductByIDReply{}
+// @Router /api/v1/loanProduct/{id} [delete]
+// @Security BearerAuth
+func (h *loanProductHandler) DeleteByID(c *gin.Context) {
+	_, id, isAbort := getLoanProductIDFromPath(c)
+	if isAbort {
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	err := h.iDao.DeleteByID(ctx, id)
+	if err != nil {
+		logger.Error("DeleteByID error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+
+	response.Success(c)
+}
+
+// UpdateByID update information by id
+// @Summary update loanProduct
+// @Description update loanProduct information by id
+// @Tags loanProduct
+// @accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param data body types.UpdateLoanProductByIDRequest true "loanProduct information"
+// @Success 200 {object} types.UpdateLoanProductByIDReply{}
+// @Router /api/v1/loanProduct/{id} [put]
+// @Security BearerAuth
+func (h *loanProductHandler) UpdateByID(c *gin.Context) {
+	_, id, isAbort := getLoanProductIDFromPath(c)
+	if isAbort {
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	form := &types.UpdateLoanProductByIDRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		responseInvalidParams(c, err)
+		return
+	}
+	form.ID = id
+	if form.Terms != "" {
+		if _, err = repayment.ParseTerms(form.Terms); err != nil {
+			logger.Warn("ParseTerms error: ", logger.Err(err), logger.String("terms", form.Terms), middleware.GCtxRequestIDField(c))
+			response.Error(c, ecode.ErrLoanProductTerms)
+			return
+		}
+	}
+
+	loanProduct := &model.LoanProduct{}
+	err = copier.Copy(loanProduct, form)
+	if err != nil {
+		response.Error(c, ecode.ErrUpdateByIDLoanProduct)
+		return
+	}
+	// Note: if copier.Copy cannot assign a value to a field, add it here
+
+	ctx := middleware.WrapCtx(c)
+	err = h.iDao.UpdateByID(ctx, loanProduct)
+	if err != nil {
+		logger.Error("UpdateByID error", logger.Err(err), pii.Any("form", form), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+
+	response.Success(c)
+}
+
+// GetByID get a record by id
+// @Summary get loanProduct detail
+// @Description get loanProduct detail by id
+// @Tags loanProduct
+// @Param id path string true "id"
+// @Accept json
+// @Produce json
+// @Success 200 {object} types.GetLoanProductByIDReply{}
+// @Router /api/v1/loanProduct/{id} [get]
+// @Security BearerAuth
+func (h *loanProductHandler) GetByID(c *gin.Context) {
+	_, id, isAbort := getLoanProductIDFromPath(c)
+	if isAbort {
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	loanProduct, err := h.iDao.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, database.ErrRecordNotFound) {
+			logger.Warn("GetByID not found", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Error(c, ecode.NotFound)
+		} else {
+			logger.Error("GetByID error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		}
+		return
+	}
+
+	data := &types.LoanProductObjDetail{}
+	err = copier.Copy(data, loanProduct)
+	if err != nil {
+		response.Error(c, ecode.ErrGetByIDLoanProduct)
+		return
+	}
+	// Note: if copier.Copy cannot assign a value to a field, add it here
+
+	response.Success(c, gin.H{"loanProduct": data})
+}
+
+// List of records by query parameters
+// @Summary list of loanProducts by query parameters
+// @Description list of loanProducts by paging and conditions
+// @Tags loanProduct
+// @accept json
+// @Produce json
+// @Param data body types.Params true "query parameters"
+// @Success 200 {object} types.ListLoanProductsReply{}
+// @Router /api/v1/loanProduct/list [post]
+// @Security BearerAuth
+func (h *loanProductHandler) List(c *gin.Context) {
+	form := &types.ListLoanProductsRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		responseInvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	loanProducts, total, err := h.iDao.GetByColumns(ctx, &form.Params)
+	if err != nil {
+		logger.Error("GetByColumns error", logger.Err(err), pii.Any("form", form), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+
+	data, err := convertLoanProducts(loanProducts)
+	if err != nil {
+		response.Error(c, ecode.ErrListLoanProduct)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"loanProducts": data,
+		"total":        total,
+	})
+}
+
+func getLoanProductIDFromPath(c *gin.Context) (string, uint64, bool) {
+	idStr := c.Param("id")
+	id, err := utils.StrToUint64E(idStr)
+	if err != nil || id == 0 {
+		logger.Warn("StrToUint64E error: ", logger.String("idStr", idStr), middleware.GCtxRequestIDField(c))
+		return "", 0, true
+	}
+
+	return idStr, id, false
+}
+
+func convertLoanProduct(loanProduct *model.LoanProduct) (*types.LoanProductObjDetail, error) {
+	data := &types.LoanProductObjDetail{}
+	err := copier.Copy(data, loanProduct)
+	if err != nil {
+		return nil, err
+	}
+	// Note: if copier.Copy cannot assign a value to a field, add it here
+
+	return data, nil
+}
+
+func convertLoanProducts(fromValues []*model.LoanProduct) ([]*types.LoanProductObjDetail, error) {
+	toValues := []*types.LoanProductObjDetail{}
+	for _, v := range fromValues {
+		data, err := convertLoanProduct(v)
+		if err != nil {
+			return nil, err
+		}
+		toValues = append(toValues, data)
+	}
+
+	return toValues, nil
+}
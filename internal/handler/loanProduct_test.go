@@ -0,0 +1,242 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/copier"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-dev-frame/sponge/pkg/gotest"
+	"github.com/go-dev-frame/sponge/pkg/httpcli"
+	"github.com/go-dev-frame/sponge/pkg/sgorm/query"
+	"github.com/go-dev-frame/sponge/pkg/utils"
+
+	"lol/internal/cache"
+	"lol/internal/dao"
+	"lol/internal/database"
+	"lol/internal/model"
+	"lol/internal/types"
+)
+
+func newLoanProductHandler() *gotest.Handler {
+	testData := &model.LoanProduct{}
+	testData.ID = 1
+	// you can set the other fields of testData here, such as:
+	//testData.CreatedAt = time.Now()
+	//testData.UpdatedAt = testData.CreatedAt
+
+	// init mock cache
+	c := gotest.NewCache(map[string]interface{}{utils.Uint64ToStr(testData.ID): testData})
+	c.ICache = cache.NewLoanProductCache(&database.CacheType{
+		CType: "redis",
+		Rdb:   c.RedisClient,
+	})
+
+	// init mock dao
+	d := gotest.NewDao(c, testData)
+	d.IDao = dao.NewLoanProductDao(d.DB, c.ICache.(cache.LoanProductCache))
+
+	// init mock handler
+	h := gotest.NewHandler(d, testData)
+	h.IHandler = &loanProductHandler{iDao: d.IDao.(dao.LoanProductDao)}
+	iHandler := h.IHandler.(LoanProductHandler)
+
+	testFns := []gotest.RouterInfo{
+		{
+			FuncName:    "Create",
+			Method:      http.MethodPost,
+			Path:        "/loanProduct",
+			HandlerFunc: iHandler.Create,
+		},
+		{
+			FuncName:    "DeleteByID",
+			Method:      http.MethodDelete,
+			Path:        "/loanProduct/:id",
+			HandlerFunc: iHandler.DeleteByID,
+		},
+		{
+			FuncName:    "UpdateByID",
+			Method:      http.MethodPut,
+			Path:        "/loanProduct/:id",
+			HandlerFunc: iHandler.UpdateByID,
+		},
+		{
+			FuncName:    "GetByID",
+			Method:      http.MethodGet,
+			Path:        "/loanProduct/:id",
+			HandlerFunc: iHandler.GetByID,
+		},
+		{
+			FuncName:    "List",
+			Method:      http.MethodPost,
+			Path:        "/loanProduct/list",
+			HandlerFunc: iHandler.List,
+		},
+	}
+
+	h.GoRunHTTPServer(testFns)
+
+	time.Sleep(time.Millisecond * 200)
+	return h
+}
+
+func Test_loanProductHandler_Create(t *testing.T) {
+	h := newLoanProductHandler()
+	defer h.Close()
+	testData := &types.CreateLoanProductRequest{}
+	_ = copier.Copy(testData, h.TestData.(*model.LoanProduct))
+
+	h.MockDao.SQLMock.ExpectBegin()
+	args := h.MockDao.GetAnyArgs(h.TestData)
+	h.MockDao.SQLMock.ExpectExec("INSERT INTO .*").
+		WithArgs(args[:len(args)-1]...). // adjusted for the amount of test data
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	h.MockDao.SQLMock.ExpectCommit()
+
+	result := &httpcli.StdResult{}
+	err := httpcli.Post(result, h.GetRequestURL("Create"), testData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Logf("%+v", result)
+
+}
+
+func Test_loanProductHandler_DeleteByID(t *testing.T) {
+	h := newLoanProductHandler()
+	defer h.Close()
+	testData := h.TestData.(*model.LoanProduct)
+	expectedSQLForDeletion := "DELETE .*"
+
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectExec(expectedSQLForDeletion).
+		WithArgs(testData.ID). // adjusted for the amount of test data
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
+	h.MockDao.SQLMock.ExpectCommit()
+
+	result := &httpcli.StdResult{}
+	err := httpcli.Delete(result, h.GetRequestURL("DeleteByID", testData.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+
+	// zero id error test
+	err = httpcli.Delete(result, h.GetRequestURL("DeleteByID", 0))
+	assert.NoError(t, err)
+
+	// delete error test
+	err = httpcli.Delete(result, h.GetRequestURL("DeleteByID", 111))
+	assert.Error(t, err)
+}
+
+func Test_loanProductHandler_UpdateByID(t *testing.T) {
+	h := newLoanProductHandler()
+	defer h.Close()
+	testData := &types.UpdateLoanProductByIDRequest{}
+	_ = copier.Copy(testData, h.TestData.(*model.LoanProduct))
+
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectExec("UPDATE .*").
+		WithArgs(h.MockDao.AnyTime, testData.ID). // adjusted for the amount of test data
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
+	h.MockDao.SQLMock.ExpectCommit()
+
+	result := &httpcli.StdResult{}
+	err := httpcli.Put(result, h.GetRequestURL("UpdateByID", testData.ID), testData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+
+	// zero id error test
+	err = httpcli.Put(result, h.GetRequestURL("UpdateByID", 0), testData)
+	assert.NoError(t, err)
+
+	// update error test
+	err = httpcli.Put(result, h.GetRequestURL("UpdateByID", 111), testData)
+	assert.Error(t, err)
+}
+
+func Test_loanProductHandler_GetByID(t *testing.T) {
+	h := newLoanProductHandler()
+	defer h.Close()
+	testData := h.TestData.(*model.LoanProduct)
+
+	// column names and corresponding data
+	rows := sqlmock.NewRows([]string{"id"}).
+		AddRow(testData.ID)
+
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").
+		WithArgs(testData.ID).
+		WillReturnRows(rows)
+
+	result := &httpcli.StdResult{}
+	err := httpcli.Get(result, h.GetRequestURL("GetByID", testData.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+
+	// zero id error test
+	err = httpcli.Get(result, h.GetRequestURL("GetByID", 0))
+	assert.NoError(t, err)
+
+	// get error test
+	err = httpcli.Get(result, h.GetRequestURL("GetByID", 111))
+	assert.Error(t, err)
+}
+
+func Test_loanProductHandler_List(t *testing.T) {
+	h := newLoanProductHandler()
+	defer h.Close()
+	testData := h.TestData.(*model.LoanProduct)
+
+	// column names and corresponding data
+	rows := sqlmock.NewRows([]string{"id"}).
+		AddRow(testData.ID)
+
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(rows)
+
+	result := &httpcli.StdResult{}
+	err := httpcli.Post(result, h.GetRequestURL("List"), &types.ListLoanProductsRequest{query.Params{
+		Page:  0,
+		Limit: 10,
+		Sort:  "ignore count", // ignore test count
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+
+	// nil params error test
+	err = httpcli.Post(result, h.GetRequestURL("List"), nil)
+	assert.NoError(t, err)
+
+	// get error test
+	err = httpcli.Post(result, h.GetRequestURL("List"), &types.ListLoanProductsRequest{query.Params{
+		Page:  0,
+		Limit: 10,
+		Sort:  "unknown-column",
+	}})
+	assert.Error(t, err)
+}
+
+func TestNewLoanProductHandler(t *testing.T) {
+	defer func() {
+		recover()
+	}()
+	_ = NewLoanProductHandler()
+}
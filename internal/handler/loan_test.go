@@ -18,6 +18,7 @@ import (
 	"lol/internal/dao"
 	"lol/internal/database"
 	"lol/internal/model"
+	"lol/internal/repayment"
 	"lol/internal/types"
 )
 
@@ -75,6 +76,24 @@ func newLoanHandler() *gotest.Handler {
 			Path:        "/loan/list",
 			HandlerFunc: iHandler.List,
 		},
+		{
+			FuncName:    "Quote",
+			Method:      http.MethodPost,
+			Path:        "/loan/quote",
+			HandlerFunc: iHandler.Quote,
+		},
+		{
+			FuncName:    "RestoreByID",
+			Method:      http.MethodPut,
+			Path:        "/loan/:id/restore",
+			HandlerFunc: iHandler.RestoreByID,
+		},
+		{
+			FuncName:    "PurgeByID",
+			Method:      http.MethodDelete,
+			Path:        "/loan/:id/purge",
+			HandlerFunc: iHandler.PurgeByID,
+		},
 	}
 
 	h.GoRunHTTPServer(testFns)
@@ -88,6 +107,11 @@ func Test_loanHandler_Create(t *testing.T) {
 	defer h.Close()
 	testData := &types.CreateLoanRequest{}
 	_ = copier.Copy(testData, h.TestData.(*model.Loan))
+	testData.Name = "张三"
+	testData.UserID = "11010519491231002X"
+	testData.Mobile = "13812345678"
+	testData.LoanMoney = 12000
+	testData.LoanPeriod = 12
 
 	h.MockDao.SQLMock.ExpectBegin()
 	args := h.MockDao.GetAnyArgs(h.TestData)
@@ -110,11 +134,11 @@ func Test_loanHandler_DeleteByID(t *testing.T) {
 	h := newLoanHandler()
 	defer h.Close()
 	testData := h.TestData.(*model.Loan)
-	expectedSQLForDeletion := "DELETE .*"
+	expectedSQLForDeletion := "UPDATE .*" // soft delete sets deleted_at
 
 	h.MockDao.SQLMock.ExpectBegin()
 	h.MockDao.SQLMock.ExpectExec(expectedSQLForDeletion).
-		WithArgs(testData.ID). // adjusted for the amount of test data
+		WithArgs(h.MockDao.AnyTime, testData.ID). // adjusted for the amount of test data
 		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
 	h.MockDao.SQLMock.ExpectCommit()
 
@@ -139,12 +163,16 @@ func Test_loanHandler_DeleteByID(t *testing.T) {
 func Test_loanHandler_UpdateByID(t *testing.T) {
 	h := newLoanHandler()
 	defer h.Close()
-	testData := &types.UpdateLoanByIDRequest{}
-	_ = copier.Copy(testData, h.TestData.(*model.Loan))
+	status := 0 // zero values given are updated
+	testData := &types.UpdateLoanByIDRequest{
+		ID:      h.TestData.(*model.Loan).ID,
+		Status:  &status,
+		Version: &h.TestData.(*model.Loan).Version,
+	}
 
 	h.MockDao.SQLMock.ExpectBegin()
 	h.MockDao.SQLMock.ExpectExec("UPDATE .*").
-		WithArgs(h.MockDao.AnyTime, testData.ID). // adjusted for the amount of test data
+		WithArgs(status, *testData.Version, testData.ID). // adjusted for the amount of test data
 		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
 	h.MockDao.SQLMock.ExpectCommit()
 
@@ -166,6 +194,43 @@ func Test_loanHandler_UpdateByID(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func Test_loanHandler_UpdateByID_reschedule(t *testing.T) {
+	h := newLoanHandler()
+	defer h.Close()
+	createAt := time.Date(2024, 1, 10, 0, 0, 0, 0, time.Local)
+	loanMoney := 2400.0
+	testData := &types.UpdateLoanByIDRequest{
+		ID:        h.TestData.(*model.Loan).ID,
+		LoanMoney: &loanMoney,
+		Version:   &h.TestData.(*model.Loan).Version,
+	}
+
+	// the monthly payment of the new principal is computed by the default plan of loans without a product
+	schedule, err := repayment.NewSchedule(&repayment.Plan{Principal: loanMoney, Period: 12, AnnualRate: repayment.DefaultAnnualRate,
+		Method: repayment.MethodFlat, Start: createAt, DueDay: 15})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := sqlmock.NewRows([]string{"id", "loan_money", "loan_period", "loan_return_date", "monthly_payment", "create_at", "version"}).
+		AddRow(testData.ID, 1200, 12, "15", 110, createAt, *testData.Version)
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(rows)
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectExec("UPDATE .*").
+		WithArgs(repayment.DefaultAnnualRate, 0.0, loanMoney, schedule.Installments[0].Amount, repayment.MethodFlat,
+			*testData.Version, testData.ID).
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
+	h.MockDao.SQLMock.ExpectCommit()
+
+	result := &httpcli.StdResult{}
+	err = httpcli.Put(result, h.GetRequestURL("UpdateByID", testData.ID), testData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+}
+
 func Test_loanHandler_GetByID(t *testing.T) {
 	h := newLoanHandler()
 	defer h.Close()
@@ -234,6 +299,149 @@ func Test_loanHandler_List(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func Test_loanHandler_Quote(t *testing.T) {
+	h := newLoanHandler()
+	defer h.Close()
+
+	result := &httpcli.StdResult{}
+	err := httpcli.Post(result, h.GetRequestURL("Quote"), &types.QuoteLoanRequest{
+		LoanMoney:      12000,
+		LoanPeriod:     12,
+		LoanReturnDate: "15",
+		StartDate:      "2024-01-15",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+
+	// invalid start date error test
+	err = httpcli.Post(result, h.GetRequestURL("Quote"), &types.QuoteLoanRequest{
+		LoanMoney:  12000,
+		LoanPeriod: 12,
+		StartDate:  "2024/01/15",
+	})
+	assert.NoError(t, err)
+}
+
+func Test_loanHandler_RestoreByID(t *testing.T) {
+	h := newLoanHandler()
+	defer h.Close()
+	testData := h.TestData.(*model.Loan)
+
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectExec("UPDATE .*").
+		WithArgs(sqlmock.AnyArg(), testData.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	h.MockDao.SQLMock.ExpectCommit()
+
+	result := &httpcli.StdResult{}
+	err := httpcli.Put(result, h.GetRequestURL("RestoreByID", testData.ID), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+
+	// zero id error test
+	err = httpcli.Put(result, h.GetRequestURL("RestoreByID", 0), nil)
+	assert.NoError(t, err)
+
+	// restore error test
+	err = httpcli.Put(result, h.GetRequestURL("RestoreByID", 111), nil)
+	assert.Error(t, err)
+}
+
+func Test_loanHandler_PurgeByID(t *testing.T) {
+	h := newLoanHandler()
+	defer h.Close()
+	testData := h.TestData.(*model.Loan)
+
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectExec("DELETE .*").
+		WithArgs(testData.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	h.MockDao.SQLMock.ExpectCommit()
+
+	result := &httpcli.StdResult{}
+	err := httpcli.Delete(result, h.GetRequestURL("PurgeByID", testData.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+
+	// zero id error test
+	err = httpcli.Delete(result, h.GetRequestURL("PurgeByID", 0))
+	assert.NoError(t, err)
+
+	// purge error test
+	err = httpcli.Delete(result, h.GetRequestURL("PurgeByID", 111))
+	assert.Error(t, err)
+}
+
+func Test_getBorrowerLoan(t *testing.T) {
+	loans := []*model.Loan{{ID: 1, Status: 1}, {ID: 2}, {ID: 3}}
+	assert.Equal(t, uint64(3), getBorrowerLoan(loans, 3).ID)
+	assert.Nil(t, getBorrowerLoan(loans, 4))
+	// the loan must be specified when the borrower has several active loans
+	assert.Nil(t, getBorrowerLoan(loans, 0))
+	assert.Equal(t, uint64(2), getBorrowerLoan(loans[:2], 0).ID)
+}
+
+func Test_getPayAmount(t *testing.T) {
+	createAt := time.Date(2024, 1, 10, 0, 0, 0, 0, time.Local)
+	schedule := repayment.NewLoanSchedule(&model.Loan{LoanMoney: 1200, LoanPeriod: 12, LoanReturnDate: "15",
+		MonthlyPayment: 110, CreateAt: &createAt})
+
+	// one installment by default, its count is recorded
+	amount, installments, err := getPayAmount(&types.PayRequest{}, schedule)
+	assert.NoError(t, err)
+	assert.Equal(t, 110.0, amount)
+	assert.Equal(t, 1, installments)
+
+	amount, installments, err = getPayAmount(&types.PayRequest{Installments: 2}, schedule)
+	assert.NoError(t, err)
+	assert.Equal(t, 220.0, amount)
+	assert.Equal(t, 2, installments)
+
+	// custom amounts have no installments
+	amount, installments, err = getPayAmount(&types.PayRequest{Amount: 50}, schedule)
+	assert.NoError(t, err)
+	assert.Equal(t, 50.0, amount)
+	assert.Equal(t, 0, installments)
+
+	_, _, err = getPayAmount(&types.PayRequest{Installments: 1, Amount: 50}, schedule)
+	assert.Error(t, err)
+}
+
+func Test_matchETag(t *testing.T) {
+	etag := loanETag(3)
+	assert.Equal(t, `"3"`, etag)
+	assert.True(t, matchETag("", etag))
+	assert.True(t, matchETag("*", etag))
+	assert.True(t, matchETag(`"2", W/"3"`, etag))
+	assert.False(t, matchETag(`"2"`, etag))
+}
+
+func Test_getLoanVersion(t *testing.T) {
+	version := uint64(5)
+	v, ok := getLoanVersion(`"3"`, &version)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(3), v)
+	v, ok = getLoanVersion("", &version)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(5), v)
+	_, ok = getLoanVersion("", nil)
+	assert.False(t, ok)
+	_, ok = getLoanVersion(`"x"`, &version)
+	assert.False(t, ok)
+}
+
 func TestNewLoanHandler(t *testing.T) {
 	defer func() {
 		recover()
@@ -16,6 +16,7 @@ import (
 	"lol/internal/database"
 	"lol/internal/ecode"
 	"lol/internal/model"
+	"lol/internal/pii"
 	"lol/internal/types"
 )
 
@@ -25,13 +26,17 @@ var _ PaymentHistoryHandler = (*paymentHistoryHandler)(nil)
 type PaymentHistoryHandler interface {
 	Create(c *gin.Context)
 	DeleteByID(c *gin.Context)
+	ListDeleted(c *gin.Context)
+	RestoreByID(c *gin.Context)
+	PurgeByID(c *gin.Context)
 	UpdateByID(c *gin.Context)
 	GetByID(c *gin.Context)
 	List(c *gin.Context)
 }
 
 type paymentHistoryHandler struct {
-	iDao dao.PaymentHistoryDao
+	iDao       dao.PaymentHistoryDao
+	accessLogs dao.PiiAccessLogDao
 }
 
 // NewPaymentHistoryHandler creating the handler interface
@@ -41,6 +46,7 @@ func NewPaymentHistoryHandler() PaymentHistoryHandler {
 			database.GetDB(), // db driver is mysql
 			cache.NewPaymentHistoryCache(database.GetCacheType()),
 		),
+		accessLogs: dao.NewPiiAccessLogDao(database.GetDB()),
 	}
 }
 
@@ -59,7 +65,7 @@ func (h *paymentHistoryHandler) Create(c *gin.Context) {
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		responseInvalidParams(c, err)
 		return
 	}
 
@@ -74,7 +80,7 @@ func (h *paymentHistoryHandler) Create(c *gin.Context) {
 	ctx := middleware.WrapCtx(c)
 	err = h.iDao.Create(ctx, paymentHistory)
 	if err != nil {
-		logger.Error("Create error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+		logger.Error("Create error", logger.Err(err), pii.Any("form", form), middleware.GCtxRequestIDField(c))
 		response.Output(c, ecode.InternalServerError.ToHTTPCode())
 		return
 	}
@@ -84,7 +90,7 @@ func (h *paymentHistoryHandler) Create(c *gin.Context) {
 
 // DeleteByID delete a record by id
 // @Summary delete paymentHistory
-// @Description delete paymentHistory by id
+// @Description delete paymentHistory by id, it is moved to the trash and can be restored
 // @Tags paymentHistory
 // @accept json
 // @Produce json
@@ -110,9 +116,115 @@ func (h *paymentHistoryHandler) DeleteByID(c *gin.Context) {
 	response.Success(c)
 }
 
+// ListDeleted of the records in the trash by query parameters
+// @Summary list of deleted paymentHistorys by query parameters
+// @Description list of the soft deleted paymentHistorys in the trash by paging and conditions, they can be restored or purged
+// @Tags paymentHistory
+// @accept json
+// @Produce json
+// @Param data body types.Params true "query parameters"
+// @Success 200 {object} types.ListPaymentHistorysReply{}
+// @Router /api/v1/paymentHistory/trash/list [post]
+// @Security BearerAuth
+func (h *paymentHistoryHandler) ListDeleted(c *gin.Context) {
+	form := &types.ListPaymentHistorysRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		responseInvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	paymentHistorys, total, err := h.iDao.GetDeletedByColumns(ctx, &form.Params)
+	if err != nil {
+		logger.Error("GetDeletedByColumns error", logger.Err(err), pii.Any("form", form), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+
+	data, err := convertPaymentHistorys(paymentHistorys)
+	if err != nil {
+		response.Error(c, ecode.ErrListPaymentHistory)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"paymentHistorys": revealPII(c, h.accessLogs, data, paymentHistoryPIIRecords(data...)),
+		"total":           total,
+	})
+}
+
+// RestoreByID restore a record in the trash by id
+// @Summary restore paymentHistory
+// @Description restore the deleted paymentHistory by id from the trash
+// @Tags paymentHistory
+// @accept json
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} types.RestorePaymentHistoryByIDReply{}
+// @Router /api/v1/paymentHistory/{id}/restore [put]
+// @Security BearerAuth
+func (h *paymentHistoryHandler) RestoreByID(c *gin.Context) {
+	_, id, isAbort := getPaymentHistoryIDFromPath(c)
+	if isAbort {
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	err := h.iDao.RestoreByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, database.ErrRecordNotFound) {
+			logger.Warn("RestoreByID not found", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Error(c, ecode.NotFound)
+		} else {
+			logger.Error("RestoreByID error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		}
+		return
+	}
+
+	response.Success(c)
+}
+
+// PurgeByID delete a record in the trash permanently by id
+// @Summary purge paymentHistory
+// @Description delete the deleted paymentHistory by id from the trash permanently, it can't be restored any more
+// @Tags paymentHistory
+// @accept json
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} types.PurgePaymentHistoryByIDReply{}
+// @Router /api/v1/paymentHistory/{id}/purge [delete]
+// @Security BearerAuth
+func (h *paymentHistoryHandler) PurgeByID(c *gin.Context) {
+	_, id, isAbort := getPaymentHistoryIDFromPath(c)
+	if isAbort {
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	err := h.iDao.PurgeByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, database.ErrRecordNotFound) {
+			logger.Warn("PurgeByID not found", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Error(c, ecode.NotFound)
+		} else {
+			logger.Error("PurgeByID error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		}
+		return
+	}
+
+	response.Success(c)
+}
+
 // UpdateByID update information by id
 // @Summary update paymentHistory
-// @Description update paymentHistory information by id
+// @Description update paymentHistory information by id,
+// @Description the fields given are updated even if they are zero values, the fields left out are kept
 // @Tags paymentHistory
 // @accept json
 // @Produce json
@@ -132,7 +244,7 @@ func (h *paymentHistoryHandler) UpdateByID(c *gin.Context) {
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		responseInvalidParams(c, err)
 		return
 	}
 	form.ID = id
@@ -146,9 +258,9 @@ func (h *paymentHistoryHandler) UpdateByID(c *gin.Context) {
 	// Note: if copier.Copy cannot assign a value to a field, add it here
 
 	ctx := middleware.WrapCtx(c)
-	err = h.iDao.UpdateByID(ctx, paymentHistory)
+	err = h.iDao.UpdateByID(ctx, paymentHistory, updatedColumns(form, paymentHistory)...)
 	if err != nil {
-		logger.Error("UpdateByID error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+		logger.Error("UpdateByID error", logger.Err(err), pii.Any("form", form), middleware.GCtxRequestIDField(c))
 		response.Output(c, ecode.InternalServerError.ToHTTPCode())
 		return
 	}
@@ -194,7 +306,7 @@ func (h *paymentHistoryHandler) GetByID(c *gin.Context) {
 	}
 	// Note: if copier.Copy cannot assign a value to a field, add it here
 
-	response.Success(c, gin.H{"paymentHistory": data})
+	response.Success(c, gin.H{"paymentHistory": revealPII(c, h.accessLogs, data, paymentHistoryPIIRecords(data))})
 }
 
 // List of records by query parameters
@@ -212,14 +324,14 @@ func (h *paymentHistoryHandler) List(c *gin.Context) {
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		responseInvalidParams(c, err)
 		return
 	}
 
 	ctx := middleware.WrapCtx(c)
 	paymentHistorys, total, err := h.iDao.GetByColumns(ctx, &form.Params)
 	if err != nil {
-		logger.Error("GetByColumns error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+		logger.Error("GetByColumns error", logger.Err(err), pii.Any("form", form), middleware.GCtxRequestIDField(c))
 		response.Output(c, ecode.InternalServerError.ToHTTPCode())
 		return
 	}
@@ -231,7 +343,7 @@ func (h *paymentHistoryHandler) List(c *gin.Context) {
 	}
 
 	response.Success(c, gin.H{
-		"paymentHistorys": data,
+		"paymentHistorys": revealPII(c, h.accessLogs, data, paymentHistoryPIIRecords(data...)),
 		"total":           total,
 	})
 }
@@ -254,6 +366,9 @@ func convertPaymentHistory(paymentHistory *model.PaymentHistory) (*types.Payment
 		return nil, err
 	}
 	// Note: if copier.Copy cannot assign a value to a field, add it here
+	if paymentHistory.DeletedAt.Valid {
+		data.DeletedAt = &paymentHistory.DeletedAt.Time
+	}
 
 	return data, nil
 }
@@ -270,3 +385,12 @@ func convertPaymentHistorys(fromValues []*model.PaymentHistory) ([]*types.Paymen
 
 	return toValues, nil
 }
+
+// paymentHistoryPIIRecords the payment records revealing personal information in the response
+func paymentHistoryPIIRecords(paymentHistorys ...*types.PaymentHistoryObjDetail) []piiRecord {
+	records := make([]piiRecord, 0, len(paymentHistorys))
+	for _, v := range paymentHistorys {
+		records = append(records, piiRecord{resource: piiResourcePaymentHistory, resourceID: v.ID, loanID: v.LoanID, value: v})
+	}
+	return records
+}
@@ -110,11 +110,11 @@ func Test_paymentHistoryHandler_DeleteByID(t *testing.T) {
 	h := newPaymentHistoryHandler()
 	defer h.Close()
 	testData := h.TestData.(*model.PaymentHistory)
-	expectedSQLForDeletion := "DELETE .*"
+	expectedSQLForDeletion := "UPDATE .*" // soft delete sets deleted_at
 
 	h.MockDao.SQLMock.ExpectBegin()
 	h.MockDao.SQLMock.ExpectExec(expectedSQLForDeletion).
-		WithArgs(testData.ID). // adjusted for the amount of test data
+		WithArgs(h.MockDao.AnyTime, testData.ID). // adjusted for the amount of test data
 		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
 	h.MockDao.SQLMock.ExpectCommit()
 
@@ -139,12 +139,12 @@ func Test_paymentHistoryHandler_DeleteByID(t *testing.T) {
 func Test_paymentHistoryHandler_UpdateByID(t *testing.T) {
 	h := newPaymentHistoryHandler()
 	defer h.Close()
-	testData := &types.UpdatePaymentHistoryByIDRequest{}
-	_ = copier.Copy(testData, h.TestData.(*model.PaymentHistory))
+	status := "" // zero values given are updated
+	testData := &types.UpdatePaymentHistoryByIDRequest{ID: h.TestData.(*model.PaymentHistory).ID, Status: &status}
 
 	h.MockDao.SQLMock.ExpectBegin()
 	h.MockDao.SQLMock.ExpectExec("UPDATE .*").
-		WithArgs(h.MockDao.AnyTime, testData.ID). // adjusted for the amount of test data
+		WithArgs(status, testData.ID). // adjusted for the amount of test data
 		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
 	h.MockDao.SQLMock.ExpectCommit()
 
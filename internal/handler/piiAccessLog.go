@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/copier"
+
+	"github.com/go-dev-frame/sponge/pkg/gin/middleware"
+	"github.com/go-dev-frame/sponge/pkg/gin/response"
+	"github.com/go-dev-frame/sponge/pkg/logger"
+	"github.com/go-dev-frame/sponge/pkg/sgorm/query"
+
+	"lol/internal/cache"
+	"lol/internal/dao"
+	"lol/internal/database"
+	"lol/internal/ecode"
+	"lol/internal/model"
+	"lol/internal/pii"
+	"lol/internal/rbac"
+	"lol/internal/types"
+)
+
+// piiAccessLogPeriod the period listed by default, compliance answers who viewed the data in it
+const piiAccessLogPeriod = 90 * 24 * time.Hour
+
+// resources of the pii access logs
+const (
+	piiResourceLoan           = "loan"
+	piiResourcePaymentHistory = "paymentHistory"
+	piiResourceSmsHistory     = "smsHistory"
+)
+
+// piiRecord a record of the response data which may reveal personal information
+type piiRecord struct {
+	resource   string
+	resourceID uint64
+	loanID     uint64
+	value      interface{} // the marked fields of the value that are not empty are revealed
+}
+
+// revealPII returns the data unmasked to the admin users who can view personal information, and writes an access log
+// for every record revealing personal information. The data is masked if the access logs can't be written,
+// so nothing is revealed without a trace.
+func revealPII[T any](c *gin.Context, accessLogs dao.PiiAccessLogDao, data T, records []piiRecord) T {
+	adminUser := getAdminUser(c)
+	if adminUser == nil || !rbac.HasPermission(adminUser.Role, rbac.PIIView) {
+		return pii.Mask(data)
+	}
+
+	now := time.Now()
+	route := c.Request.Method + " " + c.FullPath()
+	logs := make([]*model.PiiAccessLog, 0, len(records))
+	for _, r := range records {
+		fields := pii.Fields(r.value)
+		if len(fields) == 0 {
+			continue
+		}
+		logs = append(logs, &model.PiiAccessLog{
+			AdminUserID: adminUser.ID,
+			Username:    adminUser.Username,
+			Resource:    r.resource,
+			ResourceID:  r.resourceID,
+			LoanID:      r.loanID,
+			Fields:      strings.Join(fields, ","),
+			Route:       route,
+			RequestID:   middleware.GCtxRequestID(c),
+			IP:          c.ClientIP(),
+			CreateAt:    &now,
+		})
+	}
+
+	err := accessLogs.CreateBatch(middleware.WrapCtx(c), logs)
+	if err != nil {
+		logger.Error("write pii access logs error, the data is masked", logger.Err(err), logger.Uint64("adminUserID", adminUser.ID),
+			logger.String("route", route), middleware.GCtxRequestIDField(c))
+		return pii.Mask(data)
+	}
+	return data
+}
+
+var _ PiiAccessLogHandler = (*piiAccessLogHandler)(nil)
+
+// PiiAccessLogHandler defining the handler interface
+type PiiAccessLogHandler interface {
+	List(c *gin.Context)
+}
+
+type piiAccessLogHandler struct {
+	iDao    dao.PiiAccessLogDao
+	loanDao dao.LoanDao
+}
+
+// NewPiiAccessLogHandler creating the handler interface
+func NewPiiAccessLogHandler() PiiAccessLogHandler {
+	return &piiAccessLogHandler{
+		iDao: dao.NewPiiAccessLogDao(database.GetDB()),
+		loanDao: dao.NewLoanDao(
+			database.GetDB(), // db driver is mysql
+			cache.NewLoanCache(database.GetCacheType()),
+		),
+	}
+}
+
+// List of the accesses to personal information
+// @Summary list of pii access logs
+// @Description list of who viewed the unmasked personal information, by loan, borrower mobile, operator and time,
+// @Description the last 90 days are listed by default
+// @Tags piiAccessLog
+// @accept json
+// @Produce json
+// @Param data body types.ListPiiAccessLogsRequest true "query parameters and filters"
+// @Success 200 {object} types.ListPiiAccessLogsReply{}
+// @Router /api/v1/piiAccessLog/list [post]
+// @Security BearerAuth
+func (h *piiAccessLogHandler) List(c *gin.Context) {
+	form := &types.ListPiiAccessLogsRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		responseInvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	var loanIDs []uint64
+	if form.Mobile != "" {
+		loans, err := h.loanDao.GetByMobile(ctx, form.Mobile)
+		if err != nil {
+			logger.Error("GetByMobile error", logger.Err(err), pii.Any("form", form), middleware.GCtxRequestIDField(c))
+			response.Output(c, ecode.InternalServerError.ToHTTPCode())
+			return
+		}
+		if len(loans) == 0 {
+			response.Success(c, gin.H{"piiAccessLogs": []*types.PiiAccessLogObjDetail{}, "total": 0})
+			return
+		}
+		for _, loan := range loans {
+			loanIDs = append(loanIDs, loan.ID)
+		}
+	}
+
+	form.Columns = append(form.Columns, piiAccessLogFilterColumns(form, loanIDs, time.Now())...)
+	accessLogs, total, err := h.iDao.GetByColumns(ctx, &form.Params)
+	if err != nil {
+		logger.Error("GetByColumns error", logger.Err(err), pii.Any("form", form), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+
+	data := []*types.PiiAccessLogObjDetail{}
+	err = copier.Copy(&data, accessLogs)
+	if err != nil {
+		response.Error(c, ecode.ErrListPiiAccessLog)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"piiAccessLogs": data,
+		"total":         total,
+	})
+}
+
+// piiAccessLogFilterColumns convert the filters of the list request to query columns,
+// loanIDs are the loans of the mobile of the request
+func piiAccessLogFilterColumns(form *types.ListPiiAccessLogsRequest, loanIDs []uint64, now time.Time) []query.Column {
+	var columns []query.Column
+	add := func(name string, exp string, value interface{}) {
+		columns = append(columns, query.Column{Name: name, Exp: exp, Value: value, Logic: "and"})
+	}
+	if form.LoanID != 0 {
+		add("loan_id", "=", form.LoanID)
+	}
+	if len(loanIDs) > 0 {
+		ids := make([]string, 0, len(loanIDs))
+		for _, id := range loanIDs {
+			ids = append(ids, strconv.FormatUint(id, 10))
+		}
+		add("loan_id", "in", strings.Join(ids, ","))
+	}
+	if form.AdminUserID != 0 {
+		add("admin_user_id", "=", form.AdminUserID)
+	}
+	if form.Resource != "" {
+		add("resource", "=", form.Resource)
+	}
+	if form.StartTime == nil && form.EndTime == nil {
+		add("create_at", ">=", now.Add(-piiAccessLogPeriod))
+	}
+	if form.StartTime != nil {
+		add("create_at", ">=", *form.StartTime)
+	}
+	if form.EndTime != nil {
+		add("create_at", "<", *form.EndTime)
+	}
+	if len(columns) > 0 && len(form.Columns) > 0 {
+		// the filters are combined with the last column of params by and
+		form.Columns[len(form.Columns)-1].Logic = "and"
+	}
+	return columns
+}
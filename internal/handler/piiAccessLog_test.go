@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-dev-frame/sponge/pkg/sgorm/query"
+	"github.com/stretchr/testify/assert"
+
+	"lol/internal/model"
+	"lol/internal/rbac"
+	"lol/internal/types"
+)
+
+type fakePiiAccessLogDao struct {
+	logs []*model.PiiAccessLog
+	err  error
+}
+
+func (f *fakePiiAccessLogDao) CreateBatch(ctx context.Context, tables []*model.PiiAccessLog) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.logs = append(f.logs, tables...)
+	return nil
+}
+
+func (f *fakePiiAccessLogDao) GetByColumns(ctx context.Context, params *query.Params) ([]*model.PiiAccessLog, int64, error) {
+	return f.logs, int64(len(f.logs)), nil
+}
+
+func Test_revealPII(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	data := []*types.LoanObjDetail{
+		{ID: 1, UserID: "440101199001011234", Mobile: "13812345678"},
+		{ID: 2},
+	}
+	accessLogs := &fakePiiAccessLogDao{}
+	newContext := func(role string) *gin.Context {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/loan/list", nil)
+		if role != "" {
+			c.Set(adminUserKey, &model.AdminUser{ID: 3, Username: "alice", Role: role})
+		}
+		return c
+	}
+
+	// masked without the permission, nothing is logged
+	masked := revealPII(newContext(""), accessLogs, data, loanPIIRecords(data...))
+	assert.Equal(t, "4401**********1234", masked[0].UserID)
+	assert.Equal(t, "440101199001011234", data[0].UserID)
+	masked = revealPII(newContext(rbac.RoleOperator), accessLogs, data, loanPIIRecords(data...))
+	assert.Equal(t, "138****5678", masked[0].Mobile)
+	assert.Empty(t, accessLogs.logs)
+
+	// revealed and logged, the loan without personal information is not logged
+	revealed := revealPII(newContext(rbac.RoleAdmin), accessLogs, data, loanPIIRecords(data...))
+	assert.Equal(t, "440101199001011234", revealed[0].UserID)
+	assert.Len(t, accessLogs.logs, 1)
+	log := accessLogs.logs[0]
+	assert.Equal(t, uint64(3), log.AdminUserID)
+	assert.Equal(t, "alice", log.Username)
+	assert.Equal(t, piiResourceLoan, log.Resource)
+	assert.Equal(t, uint64(1), log.LoanID)
+	assert.Equal(t, "userID,mobile", log.Fields)
+
+	// masked if the access can't be logged
+	accessLogs.err = errors.New("db error")
+	masked = revealPII(newContext(rbac.RoleAdmin), accessLogs, data, loanPIIRecords(data...))
+	assert.Equal(t, "4401**********1234", masked[0].UserID)
+}
+
+func Test_piiAccessLogFilterColumns(t *testing.T) {
+	now := time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)
+	form := &types.ListPiiAccessLogsRequest{LoanID: 1, AdminUserID: 3}
+	columns := piiAccessLogFilterColumns(form, []uint64{1, 2}, now)
+	assert.Equal(t, []query.Column{
+		{Name: "loan_id", Exp: "=", Value: uint64(1), Logic: "and"},
+		{Name: "loan_id", Exp: "in", Value: "1,2", Logic: "and"},
+		{Name: "admin_user_id", Exp: "=", Value: uint64(3), Logic: "and"},
+		{Name: "create_at", Exp: ">=", Value: now.Add(-piiAccessLogPeriod), Logic: "and"},
+	}, columns)
+
+	start := now.Add(-time.Hour)
+	form = &types.ListPiiAccessLogsRequest{StartTime: &start}
+	columns = piiAccessLogFilterColumns(form, nil, now)
+	assert.Equal(t, []query.Column{{Name: "create_at", Exp: ">=", Value: start, Logic: "and"}}, columns)
+}
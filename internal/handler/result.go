@@ -16,6 +16,7 @@ import (
 	"lol/internal/database"
 	"lol/internal/ecode"
 	"lol/internal/model"
+	"lol/internal/pii"
 	"lol/internal/types"
 )
 
@@ -25,6 +26,9 @@ var _ ResultHandler = (*resultHandler)(nil)
 type ResultHandler interface {
 	Create(c *gin.Context)
 	DeleteByID(c *gin.Context)
+	ListDeleted(c *gin.Context)
+	RestoreByID(c *gin.Context)
+	PurgeByID(c *gin.Context)
 	UpdateByID(c *gin.Context)
 	GetByID(c *gin.Context)
 	List(c *gin.Context)
@@ -59,7 +63,7 @@ func (h *resultHandler) Create(c *gin.Context) {
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		responseInvalidParams(c, err)
 		return
 	}
 
@@ -74,7 +78,7 @@ func (h *resultHandler) Create(c *gin.Context) {
 	ctx := middleware.WrapCtx(c)
 	err = h.iDao.Create(ctx, result)
 	if err != nil {
-		logger.Error("Create error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+		logger.Error("Create error", logger.Err(err), pii.Any("form", form), middleware.GCtxRequestIDField(c))
 		response.Output(c, ecode.InternalServerError.ToHTTPCode())
 		return
 	}
@@ -84,7 +88,7 @@ func (h *resultHandler) Create(c *gin.Context) {
 
 // DeleteByID delete a record by id
 // @Summary delete result
-// @Description delete result by id
+// @Description delete result by id, it is moved to the trash and can be restored
 // @Tags result
 // @accept json
 // @Produce json
@@ -110,9 +114,115 @@ func (h *resultHandler) DeleteByID(c *gin.Context) {
 	response.Success(c)
 }
 
+// ListDeleted of the records in the trash by query parameters
+// @Summary list of deleted results by query parameters
+// @Description list of the soft deleted results in the trash by paging and conditions, they can be restored or purged
+// @Tags result
+// @accept json
+// @Produce json
+// @Param data body types.Params true "query parameters"
+// @Success 200 {object} types.ListResultsReply{}
+// @Router /api/v1/result/trash/list [post]
+// @Security BearerAuth
+func (h *resultHandler) ListDeleted(c *gin.Context) {
+	form := &types.ListResultsRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		responseInvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	results, total, err := h.iDao.GetDeletedByColumns(ctx, &form.Params)
+	if err != nil {
+		logger.Error("GetDeletedByColumns error", logger.Err(err), pii.Any("form", form), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+
+	data, err := convertResults(results)
+	if err != nil {
+		response.Error(c, ecode.ErrListResult)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"results": data,
+		"total":   total,
+	})
+}
+
+// RestoreByID restore a record in the trash by id
+// @Summary restore result
+// @Description restore the deleted result by id from the trash
+// @Tags result
+// @accept json
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} types.RestoreResultByIDReply{}
+// @Router /api/v1/result/{id}/restore [put]
+// @Security BearerAuth
+func (h *resultHandler) RestoreByID(c *gin.Context) {
+	_, id, isAbort := getResultIDFromPath(c)
+	if isAbort {
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	err := h.iDao.RestoreByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, database.ErrRecordNotFound) {
+			logger.Warn("RestoreByID not found", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Error(c, ecode.NotFound)
+		} else {
+			logger.Error("RestoreByID error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		}
+		return
+	}
+
+	response.Success(c)
+}
+
+// PurgeByID delete a record in the trash permanently by id
+// @Summary purge result
+// @Description delete the deleted result by id from the trash permanently, it can't be restored any more
+// @Tags result
+// @accept json
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} types.PurgeResultByIDReply{}
+// @Router /api/v1/result/{id}/purge [delete]
+// @Security BearerAuth
+func (h *resultHandler) PurgeByID(c *gin.Context) {
+	_, id, isAbort := getResultIDFromPath(c)
+	if isAbort {
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	err := h.iDao.PurgeByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, database.ErrRecordNotFound) {
+			logger.Warn("PurgeByID not found", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Error(c, ecode.NotFound)
+		} else {
+			logger.Error("PurgeByID error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		}
+		return
+	}
+
+	response.Success(c)
+}
+
 // UpdateByID update information by id
 // @Summary update result
-// @Description update result information by id
+// @Description update result information by id,
+// @Description the fields given are updated even if they are zero values, the fields left out are kept
 // @Tags result
 // @accept json
 // @Produce json
@@ -132,7 +242,7 @@ func (h *resultHandler) UpdateByID(c *gin.Context) {
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		responseInvalidParams(c, err)
 		return
 	}
 	form.ID = id
@@ -146,9 +256,9 @@ func (h *resultHandler) UpdateByID(c *gin.Context) {
 	// Note: if copier.Copy cannot assign a value to a field, add it here
 
 	ctx := middleware.WrapCtx(c)
-	err = h.iDao.UpdateByID(ctx, result)
+	err = h.iDao.UpdateByID(ctx, result, updatedColumns(form, result)...)
 	if err != nil {
-		logger.Error("UpdateByID error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+		logger.Error("UpdateByID error", logger.Err(err), pii.Any("form", form), middleware.GCtxRequestIDField(c))
 		response.Output(c, ecode.InternalServerError.ToHTTPCode())
 		return
 	}
@@ -212,14 +322,14 @@ func (h *resultHandler) List(c *gin.Context) {
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		responseInvalidParams(c, err)
 		return
 	}
 
 	ctx := middleware.WrapCtx(c)
 	results, total, err := h.iDao.GetByColumns(ctx, &form.Params)
 	if err != nil {
-		logger.Error("GetByColumns error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+		logger.Error("GetByColumns error", logger.Err(err), pii.Any("form", form), middleware.GCtxRequestIDField(c))
 		response.Output(c, ecode.InternalServerError.ToHTTPCode())
 		return
 	}
@@ -254,6 +364,9 @@ func convertResult(result *model.Result) (*types.ResultObjDetail, error) {
 		return nil, err
 	}
 	// Note: if copier.Copy cannot assign a value to a field, add it here
+	if result.DeletedAt.Valid {
+		data.DeletedAt = &result.DeletedAt.Time
+	}
 
 	return data, nil
 }
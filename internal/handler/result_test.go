@@ -110,11 +110,11 @@ func Test_resultHandler_DeleteByID(t *testing.T) {
 	h := newResultHandler()
 	defer h.Close()
 	testData := h.TestData.(*model.Result)
-	expectedSQLForDeletion := "DELETE .*"
+	expectedSQLForDeletion := "UPDATE .*" // soft delete sets deleted_at
 
 	h.MockDao.SQLMock.ExpectBegin()
 	h.MockDao.SQLMock.ExpectExec(expectedSQLForDeletion).
-		WithArgs(testData.ID). // adjusted for the amount of test data
+		WithArgs(h.MockDao.AnyTime, testData.ID). // adjusted for the amount of test data
 		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
 	h.MockDao.SQLMock.ExpectCommit()
 
@@ -139,12 +139,12 @@ func Test_resultHandler_DeleteByID(t *testing.T) {
 func Test_resultHandler_UpdateByID(t *testing.T) {
 	h := newResultHandler()
 	defer h.Close()
-	testData := &types.UpdateResultByIDRequest{}
-	_ = copier.Copy(testData, h.TestData.(*model.Result))
+	tradeState := "" // zero values given are updated
+	testData := &types.UpdateResultByIDRequest{ID: h.TestData.(*model.Result).ID, TradeState: &tradeState}
 
 	h.MockDao.SQLMock.ExpectBegin()
 	h.MockDao.SQLMock.ExpectExec("UPDATE .*").
-		WithArgs(h.MockDao.AnyTime, testData.ID). // adjusted for the amount of test data
+		WithArgs(tradeState, testData.ID). // adjusted for the amount of test data
 		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
 	h.MockDao.SQLMock.ExpectCommit()
 
@@ -0,0 +1,330 @@
+package handler
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/go-dev-frame/sponge/pkg/gin/middleware"
+	"github.com/go-dev-frame/sponge/pkg/gin/response"
+	"github.com/go-dev-frame/sponge/pkg/jwt"
+	"github.com/go-dev-frame/sponge/pkg/logger"
+	"github.com/go-dev-frame/sponge/pkg/utils"
+
+	"lol/internal/cache"
+	"lol/internal/config"
+	"lol/internal/database"
+	"lol/internal/ecode"
+	"lol/internal/pii"
+	"lol/internal/types"
+)
+
+const (
+	// sessionIDKey the gin context key of the session id of the request token
+	sessionIDKey = "sessionID"
+	// defaultTokenExpire the default expire time of sponge jwt
+	defaultTokenExpire = 2 * time.Hour
+)
+
+var _ SessionHandler = (*sessionHandler)(nil)
+
+// SessionHandler defining the handler interface
+type SessionHandler interface {
+	List(c *gin.Context)
+	ListAll(c *gin.Context)
+	Revoke(c *gin.Context)
+	DeleteByID(c *gin.Context)
+	Logout(c *gin.Context)
+}
+
+type sessionHandler struct {
+	sessions cache.SessionCache
+}
+
+// NewSessionHandler creating the handler interface
+func NewSessionHandler() SessionHandler {
+	return &sessionHandler{
+		sessions: cache.NewSessionCache(database.GetCacheType()),
+	}
+}
+
+// List of the active sessions of a user
+// @Summary list of sessions of a user
+// @Description list of the active sessions of an admin user or a borrower, with login ip and user agent
+// @Tags session
+// @accept json
+// @Produce json
+// @Param data body types.SessionUserRequest true "user"
+// @Success 200 {object} types.ListSessionsReply{}
+// @Router /api/v1/session/list [post]
+// @Security BearerAuth
+func (h *sessionHandler) List(c *gin.Context) {
+	form := &types.SessionUserRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		responseInvalidParams(c, err)
+		return
+	}
+
+	sessions, err := h.sessions.List(middleware.WrapCtx(c), form.UserType, form.UID)
+	if err != nil {
+		logger.Error("sessions.List error", logger.Err(err), pii.Any("form", form), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.ErrListSession)
+		return
+	}
+
+	response.Success(c, gin.H{"sessions": convertSessions(sessions)})
+}
+
+// ListAll of the active sessions of all users
+// @Summary list of sessions of all users
+// @Description list of the active sessions of all admin users and borrowers, or of the user type, the latest logins first
+// @Tags session
+// @accept json
+// @Produce json
+// @Param data body types.ListAllSessionsRequest true "user type and paging"
+// @Success 200 {object} types.ListAllSessionsReply{}
+// @Router /api/v1/session/all [post]
+// @Security BearerAuth
+func (h *sessionHandler) ListAll(c *gin.Context) {
+	form := &types.ListAllSessionsRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		responseInvalidParams(c, err)
+		return
+	}
+
+	sessions, err := h.sessions.ListAll(middleware.WrapCtx(c), form.UserType)
+	if err != nil {
+		logger.Error("sessions.ListAll error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.ErrListSession)
+		return
+	}
+
+	total := len(sessions)
+	start := form.Page * form.Limit
+	if start > total {
+		start = total
+	}
+	end := start + form.Limit
+	if end > total {
+		end = total
+	}
+
+	response.Success(c, gin.H{
+		"sessions": convertSessions(sessions[start:end]),
+		"total":    total,
+	})
+}
+
+// Revoke all sessions of a user
+// @Summary revoke all sessions of a user
+// @Description revoke all sessions of an admin user or a borrower, the tokens become invalid immediately
+// @Tags session
+// @accept json
+// @Produce json
+// @Param data body types.SessionUserRequest true "user"
+// @Success 200 {object} types.RevokeSessionsReply{}
+// @Router /api/v1/session/revoke [post]
+// @Security BearerAuth
+func (h *sessionHandler) Revoke(c *gin.Context) {
+	form := &types.SessionUserRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		responseInvalidParams(c, err)
+		return
+	}
+
+	count, err := h.sessions.DelAll(middleware.WrapCtx(c), form.UserType, form.UID)
+	if err != nil {
+		logger.Error("sessions.DelAll error", logger.Err(err), pii.Any("form", form), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.ErrRevokeSession)
+		return
+	}
+	logger.Info("sessions revoked", pii.Any("form", form), logger.Int("count", count), middleware.GCtxRequestIDField(c))
+
+	response.Success(c, gin.H{"count": count})
+}
+
+// DeleteByID revoke a session by id
+// @Summary revoke a session
+// @Description revoke a session by id, the token becomes invalid immediately
+// @Tags session
+// @accept json
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} types.DeleteSessionByIDReply{}
+// @Router /api/v1/session/{id} [delete]
+// @Security BearerAuth
+func (h *sessionHandler) DeleteByID(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	err := h.sessions.Del(middleware.WrapCtx(c), id)
+	if err != nil {
+		logger.Error("sessions.Del error", logger.Err(err), logger.String("id", id), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.ErrRevokeSession)
+		return
+	}
+
+	response.Success(c)
+}
+
+// Logout revoke the session of the logged in admin user
+// @Summary admin logout
+// @Description revoke the session of the token in the request
+// @Tags session
+// @accept json
+// @Produce json
+// @Success 200 {object} types.LogoutReply{}
+// @Router /api/v1/session/logout [post]
+// @Security BearerAuth
+func (h *sessionHandler) Logout(c *gin.Context) {
+	logout(c, h.sessions)
+}
+
+// logout delete the session of the request token
+func logout(c *gin.Context, sessions cache.SessionCache) {
+	err := sessions.Del(middleware.WrapCtx(c), getSessionID(c))
+	if err != nil {
+		logger.Error("sessions.Del error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.ErrLogout)
+		return
+	}
+
+	response.Success(c)
+}
+
+// newSessionToken issue a token and save its session, the name claim of the token is the user type and the session id
+func newSessionToken(c *gin.Context, sessions cache.SessionCache, expire time.Duration, userType string, uid string) (string, error) {
+	sessionID, err := newSessionID()
+	if err != nil {
+		return "", err
+	}
+	token, err := jwt.GenerateToken(uid, tokenName(userType, sessionID))
+	if err != nil {
+		return "", err
+	}
+	if expire <= 0 {
+		expire = defaultTokenExpire
+	}
+
+	now := time.Now()
+	err = sessions.Set(middleware.WrapCtx(c), &cache.Session{
+		ID:        sessionID,
+		UserType:  userType,
+		UID:       uid,
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		CreatedAt: now,
+		ExpireAt:  now.Add(expire),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// checkSession the token is only valid while its session exists, the session id is saved in the context
+func checkSession(c *gin.Context, sessions cache.SessionCache, token string, claims *jwt.Claims) error {
+	userType, sessionID := parseTokenName(claims.Name)
+	if sessionID == "" {
+		sessionID = tokenID(token)
+	}
+	session, err := sessions.Get(middleware.WrapCtx(c), sessionID)
+	if err != nil {
+		return err
+	}
+	if session.UserType != userType || session.UID != claims.UID {
+		return errors.New("session does not match the token")
+	}
+	c.Set(sessionIDKey, session.ID)
+	return nil
+}
+
+func convertSessions(sessions []*cache.Session) []*types.SessionObjDetail {
+	data := make([]*types.SessionObjDetail, 0, len(sessions))
+	for _, v := range sessions {
+		data = append(data, &types.SessionObjDetail{
+			ID:        v.ID,
+			UserType:  v.UserType,
+			UID:       v.UID,
+			IP:        v.IP,
+			UserAgent: v.UserAgent,
+			CreatedAt: v.CreatedAt,
+			ExpireAt:  v.ExpireAt,
+		})
+	}
+	return data
+}
+
+// getSessionID get the session id of the request token
+func getSessionID(c *gin.Context) string {
+	return c.GetString(sessionIDKey)
+}
+
+// newSessionID a random session id
+func newSessionID() (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// tokenName the name claim of a token is {userType}:{sessionID}, sponge tokens have no id claim, so the tokens
+// issued to a user in the same second would be the same without the random session id.
+func tokenName(userType string, sessionID string) string {
+	return userType + ":" + sessionID
+}
+
+// parseTokenName the user type and the session id of the name claim of a token,
+// the session id is empty for the tokens issued before it was added to the claim
+func parseTokenName(name string) (string, string) {
+	userType, sessionID, _ := strings.Cut(name, ":")
+	return userType, sessionID
+}
+
+// tokenID the session id of the tokens without one in the name claim, the sha256 of the token
+// was used as the session id, so the token itself is never stored.
+func tokenID(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:16])
+}
+
+// bearerToken get the token in the Authorization header
+func bearerToken(c *gin.Context) string {
+	return strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+}
+
+// tokenExpire the expire time of issued tokens, same as the jwt settings
+func tokenExpire(conf config.Jwt) time.Duration {
+	if conf.Expire > 0 {
+		return time.Hour * time.Duration(conf.Expire)
+	}
+	return defaultTokenExpire
+}
+
+// revokeAdminSessions delete all sessions of an admin user, used when the user is disabled or the credential is changed
+func revokeAdminSessions(c *gin.Context, sessions cache.SessionCache, id uint64) {
+	count, err := sessions.DelAll(middleware.WrapCtx(c), adminTokenName, utils.Uint64ToStr(id))
+	if err != nil {
+		logger.Error("sessions.DelAll error", logger.Err(err), logger.Uint64("adminUserID", id), middleware.GCtxRequestIDField(c))
+		return
+	}
+	if count > 0 {
+		logger.Info("sessions revoked", logger.Uint64("adminUserID", id), logger.Int("count", count), middleware.GCtxRequestIDField(c))
+	}
+}
@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-dev-frame/sponge/pkg/gotest"
+	"github.com/go-dev-frame/sponge/pkg/httpcli"
+
+	"lol/internal/cache"
+	"lol/internal/config"
+	"lol/internal/database"
+	"lol/internal/types"
+)
+
+func newSessionHandler() *gotest.Handler {
+	testData := &cache.Session{
+		ID:        "id1",
+		UserType:  adminTokenName,
+		UID:       "1",
+		IP:        "127.0.0.1",
+		CreatedAt: time.Now(),
+		ExpireAt:  time.Now().Add(time.Hour),
+	}
+
+	// init mock cache
+	c := gotest.NewCache(map[string]interface{}{testData.ID: testData})
+	c.ICache = cache.NewSessionCache(&database.CacheType{
+		CType: "redis",
+		Rdb:   c.RedisClient,
+	})
+
+	// init mock dao, sessions are only stored in cache
+	d := gotest.NewDao(c, testData)
+
+	// init mock handler
+	h := gotest.NewHandler(d, testData)
+	h.IHandler = &sessionHandler{sessions: c.ICache.(cache.SessionCache)}
+	iHandler := h.IHandler.(SessionHandler)
+
+	testFns := []gotest.RouterInfo{
+		{
+			FuncName:    "List",
+			Method:      http.MethodPost,
+			Path:        "/session/list",
+			HandlerFunc: iHandler.List,
+		},
+		{
+			FuncName:    "ListAll",
+			Method:      http.MethodPost,
+			Path:        "/session/all",
+			HandlerFunc: iHandler.ListAll,
+		},
+		{
+			FuncName:    "Revoke",
+			Method:      http.MethodPost,
+			Path:        "/session/revoke",
+			HandlerFunc: iHandler.Revoke,
+		},
+		{
+			FuncName:    "DeleteByID",
+			Method:      http.MethodDelete,
+			Path:        "/session/:id",
+			HandlerFunc: iHandler.DeleteByID,
+		},
+	}
+
+	h.GoRunHTTPServer(testFns)
+
+	time.Sleep(time.Millisecond * 200)
+	return h
+}
+
+func Test_sessionHandler_List(t *testing.T) {
+	h := newSessionHandler()
+	defer h.Close()
+	testData := h.TestData.(*cache.Session)
+	sessions := h.IHandler.(*sessionHandler).sessions
+	err := sessions.Set(context.Background(), testData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reply := &types.ListSessionsReply{}
+	err = httpcli.Post(reply, h.GetRequestURL("List"), &types.SessionUserRequest{
+		UserType: testData.UserType,
+		UID:      testData.UID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 0, reply.Code)
+	assert.Len(t, reply.Data.Sessions, 1)
+
+	// invalid user type error test
+	err = httpcli.Post(reply, h.GetRequestURL("List"), &types.SessionUserRequest{
+		UserType: "unknown",
+		UID:      testData.UID,
+	})
+	assert.NoError(t, err)
+	assert.NotEqual(t, 0, reply.Code)
+}
+
+func Test_sessionHandler_ListAll(t *testing.T) {
+	h := newSessionHandler()
+	defer h.Close()
+	testData := h.TestData.(*cache.Session)
+	sessions := h.IHandler.(*sessionHandler).sessions
+	err := sessions.Set(context.Background(), testData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reply := &types.ListAllSessionsReply{}
+	err = httpcli.Post(reply, h.GetRequestURL("ListAll"), &types.ListAllSessionsRequest{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 0, reply.Code)
+	assert.Equal(t, 1, reply.Data.Total)
+	assert.Len(t, reply.Data.Sessions, 1)
+
+	// out of range page
+	err = httpcli.Post(reply, h.GetRequestURL("ListAll"), &types.ListAllSessionsRequest{Page: 3, Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, reply.Data.Total)
+	assert.Empty(t, reply.Data.Sessions)
+
+	// limit error test
+	err = httpcli.Post(reply, h.GetRequestURL("ListAll"), &types.ListAllSessionsRequest{})
+	assert.NoError(t, err)
+	assert.NotEqual(t, 0, reply.Code)
+}
+
+func Test_sessionHandler_Revoke(t *testing.T) {
+	h := newSessionHandler()
+	defer h.Close()
+	testData := h.TestData.(*cache.Session)
+
+	reply := &types.RevokeSessionsReply{}
+	err := httpcli.Post(reply, h.GetRequestURL("Revoke"), &types.SessionUserRequest{
+		UserType: testData.UserType,
+		UID:      testData.UID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 0, reply.Code)
+}
+
+func Test_sessionHandler_DeleteByID(t *testing.T) {
+	h := newSessionHandler()
+	defer h.Close()
+	testData := h.TestData.(*cache.Session)
+
+	result := &httpcli.StdResult{}
+	err := httpcli.Delete(result, h.GetRequestURL("DeleteByID", testData.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+}
+
+func Test_tokenExpire(t *testing.T) {
+	assert.Equal(t, defaultTokenExpire, tokenExpire(config.Jwt{}))
+	assert.Equal(t, 24*time.Hour, tokenExpire(config.Jwt{Expire: 24}))
+	assert.Len(t, tokenID("token"), 32)
+	assert.NotEqual(t, tokenID("token1"), tokenID("token2"))
+}
+
+func Test_tokenName(t *testing.T) {
+	id1, err := newSessionID()
+	assert.NoError(t, err)
+	id2, err := newSessionID()
+	assert.NoError(t, err)
+	assert.Len(t, id1, 32)
+	assert.NotEqual(t, id1, id2)
+
+	userType, sessionID := parseTokenName(tokenName(adminTokenName, id1))
+	assert.Equal(t, adminTokenName, userType)
+	assert.Equal(t, id1, sessionID)
+
+	userType, sessionID = parseTokenName(borrowerTokenName)
+	assert.Equal(t, borrowerTokenName, userType)
+	assert.Equal(t, "", sessionID)
+}
+
+func TestNewSessionHandler(t *testing.T) {
+	defer func() {
+		recover()
+	}()
+	_ = NewSessionHandler()
+}
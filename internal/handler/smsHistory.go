@@ -1,7 +1,9 @@
 package handler
 
 import (
+	"crypto/subtle"
 	"errors"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/copier"
@@ -9,29 +11,46 @@ import (
 	"github.com/go-dev-frame/sponge/pkg/gin/middleware"
 	"github.com/go-dev-frame/sponge/pkg/gin/response"
 	"github.com/go-dev-frame/sponge/pkg/logger"
+	"github.com/go-dev-frame/sponge/pkg/sgorm/query"
 	"github.com/go-dev-frame/sponge/pkg/utils"
 
 	"lol/internal/cache"
+	"lol/internal/config"
 	"lol/internal/dao"
 	"lol/internal/database"
 	"lol/internal/ecode"
 	"lol/internal/model"
+	"lol/internal/pii"
+	"lol/internal/sms"
 	"lol/internal/types"
 )
 
 var _ SmsHistoryHandler = (*smsHistoryHandler)(nil)
 
+const (
+	// reportPath the route of the delivery report callback
+	reportPath = "/api/v1/smsHistory/report/:provider"
+	// reportTokenHeader the header of the token of the delivery report callback
+	reportTokenHeader = "X-Report-Token"
+)
+
 // SmsHistoryHandler defining the handler interface
 type SmsHistoryHandler interface {
 	Create(c *gin.Context)
 	DeleteByID(c *gin.Context)
+	ListDeleted(c *gin.Context)
+	RestoreByID(c *gin.Context)
+	PurgeByID(c *gin.Context)
 	UpdateByID(c *gin.Context)
 	GetByID(c *gin.Context)
 	List(c *gin.Context)
+	Report(c *gin.Context)
 }
 
 type smsHistoryHandler struct {
-	iDao dao.SmsHistoryDao
+	iDao        dao.SmsHistoryDao
+	accessLogs  dao.PiiAccessLogDao
+	reportToken string
 }
 
 // NewSmsHistoryHandler creating the handler interface
@@ -41,6 +60,8 @@ func NewSmsHistoryHandler() SmsHistoryHandler {
 			database.GetDB(), // db driver is mysql
 			cache.NewSmsHistoryCache(database.GetCacheType()),
 		),
+		accessLogs:  dao.NewPiiAccessLogDao(database.GetDB()),
+		reportToken: config.Get().Sms.ReportToken,
 	}
 }
 
@@ -59,7 +80,7 @@ func (h *smsHistoryHandler) Create(c *gin.Context) {
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		responseInvalidParams(c, err)
 		return
 	}
 
@@ -74,7 +95,7 @@ func (h *smsHistoryHandler) Create(c *gin.Context) {
 	ctx := middleware.WrapCtx(c)
 	err = h.iDao.Create(ctx, smsHistory)
 	if err != nil {
-		logger.Error("Create error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+		logger.Error("Create error", logger.Err(err), pii.Any("form", form), middleware.GCtxRequestIDField(c))
 		response.Output(c, ecode.InternalServerError.ToHTTPCode())
 		return
 	}
@@ -84,7 +105,7 @@ func (h *smsHistoryHandler) Create(c *gin.Context) {
 
 // DeleteByID delete a record by id
 // @Summary delete smsHistory
-// @Description delete smsHistory by id
+// @Description delete smsHistory by id, it is moved to the trash and can be restored
 // @Tags smsHistory
 // @accept json
 // @Produce json
@@ -110,9 +131,117 @@ func (h *smsHistoryHandler) DeleteByID(c *gin.Context) {
 	response.Success(c)
 }
 
+// ListDeleted of the records in the trash by query parameters
+// @Summary list of deleted smsHistorys by query parameters
+// @Description list of the soft deleted smsHistorys in the trash by paging and conditions, they can be restored or purged
+// @Tags smsHistory
+// @accept json
+// @Produce json
+// @Param data body types.ListSmsHistorysRequest true "query parameters"
+// @Success 200 {object} types.ListSmsHistorysReply{}
+// @Router /api/v1/smsHistory/trash/list [post]
+// @Security BearerAuth
+func (h *smsHistoryHandler) ListDeleted(c *gin.Context) {
+	form := &types.ListSmsHistorysRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		responseInvalidParams(c, err)
+		return
+	}
+
+	form.Columns = append(form.Columns, smsHistoryFilterColumns(form)...)
+
+	ctx := middleware.WrapCtx(c)
+	smsHistorys, total, err := h.iDao.GetDeletedByColumns(ctx, &form.Params)
+	if err != nil {
+		logger.Error("GetDeletedByColumns error", logger.Err(err), pii.Any("form", form), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+
+	data, err := convertSmsHistorys(smsHistorys)
+	if err != nil {
+		response.Error(c, ecode.ErrListSmsHistory)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"smsHistorys": revealPII(c, h.accessLogs, data, smsHistoryPIIRecords(data...)),
+		"total":       total,
+	})
+}
+
+// RestoreByID restore a record in the trash by id
+// @Summary restore smsHistory
+// @Description restore the deleted smsHistory by id from the trash
+// @Tags smsHistory
+// @accept json
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} types.RestoreSmsHistoryByIDReply{}
+// @Router /api/v1/smsHistory/{id}/restore [put]
+// @Security BearerAuth
+func (h *smsHistoryHandler) RestoreByID(c *gin.Context) {
+	_, id, isAbort := getSmsHistoryIDFromPath(c)
+	if isAbort {
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	err := h.iDao.RestoreByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, database.ErrRecordNotFound) {
+			logger.Warn("RestoreByID not found", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Error(c, ecode.NotFound)
+		} else {
+			logger.Error("RestoreByID error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		}
+		return
+	}
+
+	response.Success(c)
+}
+
+// PurgeByID delete a record in the trash permanently by id
+// @Summary purge smsHistory
+// @Description delete the deleted smsHistory by id from the trash permanently, it can't be restored any more
+// @Tags smsHistory
+// @accept json
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} types.PurgeSmsHistoryByIDReply{}
+// @Router /api/v1/smsHistory/{id}/purge [delete]
+// @Security BearerAuth
+func (h *smsHistoryHandler) PurgeByID(c *gin.Context) {
+	_, id, isAbort := getSmsHistoryIDFromPath(c)
+	if isAbort {
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	err := h.iDao.PurgeByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, database.ErrRecordNotFound) {
+			logger.Warn("PurgeByID not found", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Error(c, ecode.NotFound)
+		} else {
+			logger.Error("PurgeByID error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		}
+		return
+	}
+
+	response.Success(c)
+}
+
 // UpdateByID update information by id
 // @Summary update smsHistory
-// @Description update smsHistory information by id
+// @Description update smsHistory information by id,
+// @Description the fields given are updated even if they are zero values, the fields left out are kept
 // @Tags smsHistory
 // @accept json
 // @Produce json
@@ -132,7 +261,7 @@ func (h *smsHistoryHandler) UpdateByID(c *gin.Context) {
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		responseInvalidParams(c, err)
 		return
 	}
 	form.ID = id
@@ -146,9 +275,9 @@ func (h *smsHistoryHandler) UpdateByID(c *gin.Context) {
 	// Note: if copier.Copy cannot assign a value to a field, add it here
 
 	ctx := middleware.WrapCtx(c)
-	err = h.iDao.UpdateByID(ctx, smsHistory)
+	err = h.iDao.UpdateByID(ctx, smsHistory, updatedColumns(form, smsHistory)...)
 	if err != nil {
-		logger.Error("UpdateByID error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+		logger.Error("UpdateByID error", logger.Err(err), pii.Any("form", form), middleware.GCtxRequestIDField(c))
 		response.Output(c, ecode.InternalServerError.ToHTTPCode())
 		return
 	}
@@ -194,16 +323,16 @@ func (h *smsHistoryHandler) GetByID(c *gin.Context) {
 	}
 	// Note: if copier.Copy cannot assign a value to a field, add it here
 
-	response.Success(c, gin.H{"smsHistory": data})
+	response.Success(c, gin.H{"smsHistory": revealPII(c, h.accessLogs, data, smsHistoryPIIRecords(data))})
 }
 
 // List of records by query parameters
 // @Summary list of smsHistorys by query parameters
-// @Description list of smsHistorys by paging and conditions
+// @Description list of smsHistorys by paging and conditions, the filters are combined with the columns by and
 // @Tags smsHistory
 // @accept json
 // @Produce json
-// @Param data body types.Params true "query parameters"
+// @Param data body types.ListSmsHistorysRequest true "query parameters and filters"
 // @Success 200 {object} types.ListSmsHistorysReply{}
 // @Router /api/v1/smsHistory/list [post]
 // @Security BearerAuth
@@ -212,14 +341,16 @@ func (h *smsHistoryHandler) List(c *gin.Context) {
 	err := c.ShouldBindJSON(form)
 	if err != nil {
 		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
-		response.Error(c, ecode.InvalidParams)
+		responseInvalidParams(c, err)
 		return
 	}
 
+	form.Columns = append(form.Columns, smsHistoryFilterColumns(form)...)
+
 	ctx := middleware.WrapCtx(c)
 	smsHistorys, total, err := h.iDao.GetByColumns(ctx, &form.Params)
 	if err != nil {
-		logger.Error("GetByColumns error", logger.Err(err), logger.Any("form", form), middleware.GCtxRequestIDField(c))
+		logger.Error("GetByColumns error", logger.Err(err), pii.Any("form", form), middleware.GCtxRequestIDField(c))
 		response.Output(c, ecode.InternalServerError.ToHTTPCode())
 		return
 	}
@@ -231,11 +362,108 @@ func (h *smsHistoryHandler) List(c *gin.Context) {
 	}
 
 	response.Success(c, gin.H{
-		"smsHistorys": data,
+		"smsHistorys": revealPII(c, h.accessLogs, data, smsHistoryPIIRecords(data...)),
 		"total":       total,
 	})
 }
 
+// Report receive the delivery reports pushed by the provider
+// @Summary sms delivery report callback
+// @Description the delivery report callback of the provider, provider is aliyun, tencent or log,
+// @Description the token of the X-Report-Token header must be sms.reportToken of the config, providers which can't
+// @Description set headers pass it as the token of the query, see HideReportToken. The reply is the format the provider expects
+// @Tags smsHistory
+// @accept json
+// @Produce json
+// @Param provider path string true "sms provider"
+// @Param X-Report-Token header string true "report token"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/smsHistory/report/{provider} [post]
+func (h *smsHistoryHandler) Report(c *gin.Context) {
+	provider := c.Param("provider")
+	token := c.GetHeader(reportTokenHeader)
+	if h.reportToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(h.reportToken)) != 1 {
+		logger.Warn("invalid sms report token", logger.String("provider", provider), middleware.GCtxRequestIDField(c))
+		response.Output(c, http.StatusUnauthorized)
+		return
+	}
+
+	body, err := c.GetRawData()
+	if err != nil {
+		logger.Warn("GetRawData error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+	reports, err := sms.ParseReports(provider, body)
+	if err != nil {
+		logger.Warn("ParseReports error: ", logger.Err(err), logger.String("provider", provider), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	for _, report := range reports {
+		reportAt := report.ReportAt
+		err = h.iDao.UpdateDelivery(ctx, provider, report.MessageID, &model.SmsHistory{
+			DeliveryStatus: report.DeliveryStatus(),
+			ErrorCode:      report.ErrorCode,
+			ReportAt:       &reportAt,
+		})
+		if err != nil {
+			if errors.Is(err, database.ErrRecordNotFound) {
+				// 不是本系统发送的短信，或者发送结果还没有写入，忽略
+				logger.Warn("sms of the report not found", logger.String("provider", provider),
+					logger.String("messageID", report.MessageID), middleware.GCtxRequestIDField(c))
+				continue
+			}
+			// 返回失败，服务商会重新推送
+			logger.Error("UpdateDelivery error", logger.Err(err), logger.String("provider", provider),
+				logger.String("messageID", report.MessageID), middleware.GCtxRequestIDField(c))
+			response.Output(c, ecode.InternalServerError.ToHTTPCode())
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, sms.ReportAck(provider))
+}
+
+// smsHistoryFilterColumns convert the filters of the list request to query columns
+func smsHistoryFilterColumns(form *types.ListSmsHistorysRequest) []query.Column {
+	var columns []query.Column
+	add := func(name string, exp string, value interface{}) {
+		columns = append(columns, query.Column{Name: name, Exp: exp, Value: value, Logic: "and"})
+	}
+	if form.Mobile != "" {
+		add("mobile", "=", form.Mobile)
+	}
+	if form.Template != "" {
+		add("template", "=", form.Template)
+	}
+	if form.LoanID != 0 {
+		add("loan_id", "=", form.LoanID)
+	}
+	if form.Status != nil {
+		add("status", "=", *form.Status)
+	}
+	if form.DeliveryStatus != nil {
+		add("delivery_status", "=", *form.DeliveryStatus)
+	}
+	if form.ErrorCode != "" {
+		add("error_code", "=", form.ErrorCode)
+	}
+	if form.StartTime != nil {
+		add("create_at", ">=", *form.StartTime)
+	}
+	if form.EndTime != nil {
+		add("create_at", "<", *form.EndTime)
+	}
+	if len(columns) > 0 && len(form.Columns) > 0 {
+		// the filters are combined with the last column of params by and
+		form.Columns[len(form.Columns)-1].Logic = "and"
+	}
+	return columns
+}
+
 func getSmsHistoryIDFromPath(c *gin.Context) (string, uint64, bool) {
 	idStr := c.Param("id")
 	id, err := utils.StrToUint64E(idStr)
@@ -254,6 +482,9 @@ func convertSmsHistory(smsHistory *model.SmsHistory) (*types.SmsHistoryObjDetail
 		return nil, err
 	}
 	// Note: if copier.Copy cannot assign a value to a field, add it here
+	if smsHistory.DeletedAt.Valid {
+		data.DeletedAt = &smsHistory.DeletedAt.Time
+	}
 
 	return data, nil
 }
@@ -270,3 +501,34 @@ func convertSmsHistorys(fromValues []*model.SmsHistory) ([]*types.SmsHistoryObjD
 
 	return toValues, nil
 }
+
+// smsHistoryPIIRecords the messages revealing personal information in the response
+func smsHistoryPIIRecords(smsHistorys ...*types.SmsHistoryObjDetail) []piiRecord {
+	records := make([]piiRecord, 0, len(smsHistorys))
+	for _, v := range smsHistorys {
+		records = append(records, piiRecord{resource: piiResourceSmsHistory, resourceID: v.ID, loanID: v.LoanID, value: v})
+	}
+	return records
+}
+
+// HideReportToken move the token of the query of the delivery report callback to the X-Report-Token header,
+// so the secret in the callback url of the providers which can't set headers is not written to the request logs,
+// it must be used before the logging middleware.
+func HideReportToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.FullPath() != reportPath {
+			c.Next()
+			return
+		}
+		values := c.Request.URL.Query()
+		if values.Has("token") {
+			if c.GetHeader(reportTokenHeader) == "" {
+				c.Request.Header.Set(reportTokenHeader, values.Get("token"))
+			}
+			values.Del("token")
+			c.Request.URL.RawQuery = values.Encode()
+			c.Request.RequestURI = c.Request.URL.RequestURI()
+		}
+		c.Next()
+	}
+}
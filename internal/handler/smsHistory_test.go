@@ -2,10 +2,12 @@ package handler
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/copier"
 	"github.com/stretchr/testify/assert"
 
@@ -41,7 +43,10 @@ func newSmsHistoryHandler() *gotest.Handler {
 
 	// init mock handler
 	h := gotest.NewHandler(d, testData)
-	h.IHandler = &smsHistoryHandler{iDao: d.IDao.(dao.SmsHistoryDao)}
+	h.IHandler = &smsHistoryHandler{
+		iDao:        d.IDao.(dao.SmsHistoryDao),
+		reportToken: "token1",
+	}
 	iHandler := h.IHandler.(SmsHistoryHandler)
 
 	testFns := []gotest.RouterInfo{
@@ -75,6 +80,12 @@ func newSmsHistoryHandler() *gotest.Handler {
 			Path:        "/smsHistory/list",
 			HandlerFunc: iHandler.List,
 		},
+		{
+			FuncName:    "Report",
+			Method:      http.MethodPost,
+			Path:        "/smsHistory/report/:provider",
+			HandlerFunc: iHandler.Report,
+		},
 	}
 
 	h.GoRunHTTPServer(testFns)
@@ -110,11 +121,11 @@ func Test_smsHistoryHandler_DeleteByID(t *testing.T) {
 	h := newSmsHistoryHandler()
 	defer h.Close()
 	testData := h.TestData.(*model.SmsHistory)
-	expectedSQLForDeletion := "DELETE .*"
+	expectedSQLForDeletion := "UPDATE .*" // soft delete sets deleted_at
 
 	h.MockDao.SQLMock.ExpectBegin()
 	h.MockDao.SQLMock.ExpectExec(expectedSQLForDeletion).
-		WithArgs(testData.ID). // adjusted for the amount of test data
+		WithArgs(h.MockDao.AnyTime, testData.ID). // adjusted for the amount of test data
 		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
 	h.MockDao.SQLMock.ExpectCommit()
 
@@ -139,12 +150,12 @@ func Test_smsHistoryHandler_DeleteByID(t *testing.T) {
 func Test_smsHistoryHandler_UpdateByID(t *testing.T) {
 	h := newSmsHistoryHandler()
 	defer h.Close()
-	testData := &types.UpdateSmsHistoryByIDRequest{}
-	_ = copier.Copy(testData, h.TestData.(*model.SmsHistory))
+	userName := "" // zero values given are updated
+	testData := &types.UpdateSmsHistoryByIDRequest{ID: h.TestData.(*model.SmsHistory).ID, UserName: &userName}
 
 	h.MockDao.SQLMock.ExpectBegin()
 	h.MockDao.SQLMock.ExpectExec("UPDATE .*").
-		WithArgs(h.MockDao.AnyTime, testData.ID). // adjusted for the amount of test data
+		WithArgs(userName, testData.ID). // adjusted for the amount of test data
 		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
 	h.MockDao.SQLMock.ExpectCommit()
 
@@ -209,7 +220,7 @@ func Test_smsHistoryHandler_List(t *testing.T) {
 	h.MockDao.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(rows)
 
 	result := &httpcli.StdResult{}
-	err := httpcli.Post(result, h.GetRequestURL("List"), &types.ListSmsHistorysRequest{query.Params{
+	err := httpcli.Post(result, h.GetRequestURL("List"), &types.ListSmsHistorysRequest{Params: query.Params{
 		Page:  0,
 		Limit: 10,
 		Sort:  "ignore count", // ignore test count
@@ -221,12 +232,24 @@ func Test_smsHistoryHandler_List(t *testing.T) {
 		t.Fatalf("%+v", result)
 	}
 
+	// filters
+	status := 3
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testData.ID))
+	err = httpcli.Post(result, h.GetRequestURL("List"), &types.ListSmsHistorysRequest{
+		Params: query.Params{Page: 0, Limit: 10, Sort: "ignore count"},
+		Mobile: "13800000000",
+		LoanID: 1,
+		Status: &status,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.Code)
+
 	// nil params error test
 	err = httpcli.Post(result, h.GetRequestURL("List"), nil)
 	assert.NoError(t, err)
 
 	// get error test
-	err = httpcli.Post(result, h.GetRequestURL("List"), &types.ListSmsHistorysRequest{query.Params{
+	err = httpcli.Post(result, h.GetRequestURL("List"), &types.ListSmsHistorysRequest{Params: query.Params{
 		Page:  0,
 		Limit: 10,
 		Sort:  "unknown-column",
@@ -234,6 +257,72 @@ func Test_smsHistoryHandler_List(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func Test_smsHistoryHandler_Report(t *testing.T) {
+	h := newSmsHistoryHandler()
+	defer h.Close()
+	testData := h.TestData.(*model.SmsHistory)
+
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testData.ID))
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectExec("UPDATE .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	h.MockDao.SQLMock.ExpectCommit()
+	// the second report is not of this system
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	reports := []map[string]interface{}{
+		{"phone_number": "13800000000", "success": true, "biz_id": "biz1", "report_time": "2024-01-15 10:00:05", "err_code": "DELIVERED"},
+		{"phone_number": "13800000001", "success": false, "biz_id": "biz2", "report_time": "2024-01-15 10:00:05", "err_code": "MK:0001"},
+	}
+	// the routes of the test server have no middleware, the token is sent in the header
+	reply := map[string]interface{}{}
+	headers := httpcli.WithHeaders(map[string]string{reportTokenHeader: "token1"})
+	err := httpcli.Post(&reply, h.GetRequestURL("Report", "aliyun"), reports, headers)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(0), reply["code"])
+
+	// invalid token
+	err = httpcli.Post(&reply, h.GetRequestURL("Report", "aliyun"), reports,
+		httpcli.WithHeaders(map[string]string{reportTokenHeader: "token2"}))
+	assert.Error(t, err)
+	err = httpcli.Post(&reply, h.GetRequestURL("Report", "aliyun")+"?token=token1", reports)
+	assert.Error(t, err)
+
+	// unknown provider
+	result := &httpcli.StdResult{}
+	err = httpcli.Post(result, h.GetRequestURL("Report", "unknown"), reports, headers)
+	assert.NoError(t, err)
+	assert.NotEqual(t, 0, result.Code)
+}
+
+func TestHideReportToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(HideReportToken())
+	var url, token string
+	r.POST(reportPath, func(c *gin.Context) {
+		url, token = c.Request.URL.String(), c.GetHeader(reportTokenHeader)
+	})
+	r.POST("/api/v1/other", func(c *gin.Context) {
+		url, token = c.Request.URL.String(), c.GetHeader(reportTokenHeader)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/v1/smsHistory/report/aliyun?token=token1&a=1", nil))
+	assert.Equal(t, "/api/v1/smsHistory/report/aliyun?a=1", url)
+	assert.Equal(t, "token1", token)
+
+	// the header takes precedence
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/smsHistory/report/aliyun?token=token1", nil)
+	req.Header.Set(reportTokenHeader, "token2")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "/api/v1/smsHistory/report/aliyun", url)
+	assert.Equal(t, "token2", token)
+
+	// other routes are left as they are
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/v1/other?token=token1", nil))
+	assert.Equal(t, "/api/v1/other?token=token1", url)
+	assert.Empty(t, token)
+}
+
 func TestNewSmsHistoryHandler(t *testing.T) {
 	defer func() {
 		recover()
@@ -0,0 +1,443 @@
+package handler
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/copier"
+
+	"github.com/go-dev-frame/sponge/pkg/gin/middleware"
+	"github.com/go-dev-frame/sponge/pkg/gin/response"
+	"github.com/go-dev-frame/sponge/pkg/logger"
+	"github.com/go-dev-frame/sponge/pkg/utils"
+
+	"lol/internal/cache"
+	"lol/internal/dao"
+	"lol/internal/database"
+	"lol/internal/ecode"
+	"lol/internal/model"
+	"lol/internal/pii"
+	"lol/internal/sms"
+	"lol/internal/types"
+)
+
+var _ SmsTemplateHandler = (*smsTemplateHandler)(nil)
+
+// SmsTemplateHandler defining the handler interface
+type SmsTemplateHandler interface {
+	Create(c *gin.Context)
+	DeleteByID(c *gin.Context)
+	UpdateByID(c *gin.Context)
+	GetByID(c *gin.Context)
+	List(c *gin.Context)
+	Preview(c *gin.Context)
+}
+
+type smsTemplateHandler struct {
+	iDao       dao.SmsTemplateDao
+	loanDao    dao.LoanDao
+	accessLogs dao.PiiAccessLogDao
+}
+
+// NewSmsTemplateHandler creating the handler interface
+func NewSmsTemplateHandler() SmsTemplateHandler {
+	return &smsTemplateHandler{
+		iDao: dao.NewSmsTemplateDao(
+			database.GetDB(), // db driver is mysql
+			cache.NewSmsTemplateCache(database.GetCacheType()),
+		),
+		loanDao: dao.NewLoanDao(
+			database.GetDB(),
+			cache.NewLoanCache(database.GetCacheType()),
+		),
+		accessLogs: dao.NewPiiAccessLogDao(database.GetDB()),
+	}
+}
+
+// Create a record
+// @Summary create smsTemplate
+// @Description submit information to create smsTemplate
+// @Tags smsTemplate
+// @accept json
+// @Produce json
+// @Param data body types.CreateSmsTemplateRequest true "smsTemplate information"
+// @Success 200 {object} types.CreateSmsTemplateReply{}
+// @Router /api/v1/smsTemplate [post]
+// @Security BearerAuth
+func (h *smsTemplateHandler) Create(c *gin.Context) {
+	form := &types.CreateSmsTemplateRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		responseInvalidParams(c, err)
+		return
+	}
+	if err = sms.Validate(form.Code, form.Content); err != nil {
+		logger.Warn("Validate error: ", logger.Err(err), logger.String("code", form.Code), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.ErrSmsTemplateVariable.RewriteMsg(err.Error()))
+		return
+	}
+
+	smsTemplate := &model.SmsTemplate{}
+	err = copier.Copy(smsTemplate, form)
+	if err != nil {
+		response.Error(c, ecode.ErrCreateSmsTemplate)
+		return
+	}
+	// Note: if copier.Copy cannot assign a value to a field, add it here
+	smsTemplate.Enabled = form.Enabled == nil || *form.Enabled
+	smsTemplate.Sensitive = form.Sensitive != nil && *form.Sensitive
+	now := time.Now()
+	smsTemplate.CreateAt = &now
+
+	ctx := middleware.WrapCtx(c)
+	if h.isCodeTaken(c, form.Code, 0) {
+		return
+	}
+	err = h.iDao.Create(ctx, smsTemplate)
+	if err != nil {
+		logger.Error("Create error", logger.Err(err), pii.Any("form", form), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+
+	response.Success(c, gin.H{"id": smsTemplate.ID})
+}
+
+// DeleteByID delete a record by id
+// @Summary delete smsTemplate
+// @Description delete smsTemplate by id
+// @Tags smsTemplate
+// @accept json
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} types.DeleteSmsTemplateByIDReply{}
+// @Router /api/v1/smsTemplate/{id} [delete]
+// @Security BearerAuth
+func (h *smsTemplateHandler) DeleteByID(c *gin.Context) {
+	_, id, isAbort := getSmsTemplateIDFromPath(c)
+	if isAbort {
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	err := h.iDao.DeleteByID(ctx, id)
+	if err != nil {
+		logger.Error("DeleteByID error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+
+	response.Success(c)
+}
+
+// UpdateByID update information by id
+// @Summary update smsTemplate
+// @Description update smsTemplate information by id
+// @Tags smsTemplate
+// @accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param data body types.UpdateSmsTemplateByIDRequest true "smsTemplate information"
+// @Success 200 {object} types.UpdateSmsTemplateByIDReply{}
+// @Router /api/v1/smsTemplate/{id} [put]
+// @Security BearerAuth
+func (h *smsTemplateHandler) UpdateByID(c *gin.Context) {
+	_, id, isAbort := getSmsTemplateIDFromPath(c)
+	if isAbort {
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	form := &types.UpdateSmsTemplateByIDRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		responseInvalidParams(c, err)
+		return
+	}
+	form.ID = id
+
+	ctx := middleware.WrapCtx(c)
+	if form.Code != "" || form.Content != "" {
+		// 编码和内容任一修改时，按修改后的编码校验修改后的内容
+		record, err := h.iDao.GetByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, database.ErrRecordNotFound) {
+				response.Error(c, ecode.NotFound)
+			} else {
+				logger.Error("GetByID error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+				response.Output(c, ecode.InternalServerError.ToHTTPCode())
+			}
+			return
+		}
+		code, content := record.Code, record.Content
+		if form.Code != "" {
+			code = form.Code
+		}
+		if form.Content != "" {
+			content = form.Content
+		}
+		if err = sms.Validate(code, content); err != nil {
+			logger.Warn("Validate error: ", logger.Err(err), logger.String("code", code), middleware.GCtxRequestIDField(c))
+			response.Error(c, ecode.ErrSmsTemplateVariable.RewriteMsg(err.Error()))
+			return
+		}
+		if form.Code != "" && form.Code != record.Code && h.isCodeTaken(c, form.Code, id) {
+			return
+		}
+	}
+
+	smsTemplate := &model.SmsTemplate{}
+	err = copier.Copy(smsTemplate, form)
+	if err != nil {
+		response.Error(c, ecode.ErrUpdateByIDSmsTemplate)
+		return
+	}
+	// Note: if copier.Copy cannot assign a value to a field, add it here
+
+	err = h.iDao.UpdateByID(ctx, smsTemplate)
+	if err != nil {
+		logger.Error("UpdateByID error", logger.Err(err), pii.Any("form", form), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+	if form.Enabled != nil {
+		err = h.iDao.SetEnabled(ctx, id, *form.Enabled)
+		if err != nil {
+			logger.Error("SetEnabled error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Output(c, ecode.InternalServerError.ToHTTPCode())
+			return
+		}
+	}
+	if form.Sensitive != nil {
+		err = h.iDao.SetSensitive(ctx, id, *form.Sensitive)
+		if err != nil {
+			logger.Error("SetSensitive error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Output(c, ecode.InternalServerError.ToHTTPCode())
+			return
+		}
+	}
+
+	response.Success(c)
+}
+
+// GetByID get a record by id
+// @Summary get smsTemplate detail
+// @Description get smsTemplate detail by id
+// @Tags smsTemplate
+// @Param id path string true "id"
+// @Accept json
+// @Produce json
+// @Success 200 {object} types.GetSmsTemplateByIDReply{}
+// @Router /api/v1/smsTemplate/{id} [get]
+// @Security BearerAuth
+func (h *smsTemplateHandler) GetByID(c *gin.Context) {
+	_, id, isAbort := getSmsTemplateIDFromPath(c)
+	if isAbort {
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	smsTemplate, err := h.iDao.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, database.ErrRecordNotFound) {
+			logger.Warn("GetByID not found", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Error(c, ecode.NotFound)
+		} else {
+			logger.Error("GetByID error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		}
+		return
+	}
+
+	data, err := convertSmsTemplate(smsTemplate)
+	if err != nil {
+		response.Error(c, ecode.ErrGetByIDSmsTemplate)
+		return
+	}
+
+	response.Success(c, gin.H{"smsTemplate": data})
+}
+
+// List of records by query parameters
+// @Summary list of smsTemplates by query parameters
+// @Description list of smsTemplates by paging and conditions
+// @Tags smsTemplate
+// @accept json
+// @Produce json
+// @Param data body types.Params true "query parameters"
+// @Success 200 {object} types.ListSmsTemplatesReply{}
+// @Router /api/v1/smsTemplate/list [post]
+// @Security BearerAuth
+func (h *smsTemplateHandler) List(c *gin.Context) {
+	form := &types.ListSmsTemplatesRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		responseInvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	smsTemplates, total, err := h.iDao.GetByColumns(ctx, &form.Params)
+	if err != nil {
+		logger.Error("GetByColumns error", logger.Err(err), pii.Any("form", form), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+
+	data, err := convertSmsTemplates(smsTemplates)
+	if err != nil {
+		response.Error(c, ecode.ErrListSmsTemplate)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"smsTemplates": data,
+		"total":        total,
+	})
+}
+
+// Preview render the template with the data of a loan
+// @Summary preview smsTemplate
+// @Description render the saved content of the template, or the content of the request, with the data of a loan,
+// @Description amount and dueDate are of the next unpaid installment, code and expire are sample values,
+// @Description name and carPlate are masked unless the role can view personal information
+// @Tags smsTemplate
+// @accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param data body types.PreviewSmsTemplateRequest true "loan id and the content to preview"
+// @Success 200 {object} types.PreviewSmsTemplateReply{}
+// @Router /api/v1/smsTemplate/{id}/preview [post]
+// @Security BearerAuth
+func (h *smsTemplateHandler) Preview(c *gin.Context) {
+	_, id, isAbort := getSmsTemplateIDFromPath(c)
+	if isAbort {
+		response.Error(c, ecode.InvalidParams)
+		return
+	}
+
+	form := &types.PreviewSmsTemplateRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		responseInvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	smsTemplate, err := h.iDao.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, database.ErrRecordNotFound) {
+			response.Error(c, ecode.NotFound)
+		} else {
+			logger.Error("GetByID error", logger.Err(err), logger.Any("id", id), middleware.GCtxRequestIDField(c))
+			response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		}
+		return
+	}
+	content := smsTemplate.Content
+	if form.Content != "" {
+		content = form.Content
+	}
+	if err = sms.Validate(smsTemplate.Code, content); err != nil {
+		logger.Warn("Validate error: ", logger.Err(err), logger.String("code", smsTemplate.Code), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.ErrSmsTemplateVariable.RewriteMsg(err.Error()))
+		return
+	}
+
+	loan, err := h.loanDao.GetWithRepayment(ctx, form.LoanID)
+	if err != nil {
+		if errors.Is(err, database.ErrRecordNotFound) {
+			logger.Warn("GetWithRepayment not found", logger.Any("loanID", form.LoanID), middleware.GCtxRequestIDField(c))
+			response.Error(c, ecode.NotFound)
+		} else {
+			logger.Error("GetWithRepayment error", logger.Err(err), logger.Any("loanID", form.LoanID), middleware.GCtxRequestIDField(c))
+			response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		}
+		return
+	}
+
+	params := sms.PreviewParams(loan)
+	borrower := &previewBorrower{Name: params["name"], CarPlate: params["carPlate"]}
+	borrower = revealPII(c, h.accessLogs, borrower, []piiRecord{
+		{resource: piiResourceLoan, resourceID: loan.ID, loanID: loan.ID, value: borrower},
+	})
+	params["name"], params["carPlate"] = borrower.Name, borrower.CarPlate
+	rendered, _, err := sms.Render(content, params)
+	if err != nil {
+		logger.Warn("Render error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.ErrPreviewSmsTemplate)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"content": rendered,
+		"params":  params,
+	})
+}
+
+// previewBorrower the personal information of the loan in the preview params
+type previewBorrower struct {
+	Name     string `json:"name" pii:"name"`
+	CarPlate string `json:"carPlate" pii:"carplate"`
+}
+
+// isCodeTaken whether the code is used by another template, the error is responded if true
+func (h *smsTemplateHandler) isCodeTaken(c *gin.Context, code string, id uint64) bool {
+	record, err := h.iDao.GetByCode(middleware.WrapCtx(c), code)
+	if err == nil {
+		if record.ID == id {
+			return false
+		}
+		response.Error(c, ecode.ErrSmsTemplateCodeExists)
+		return true
+	}
+	if !errors.Is(err, database.ErrRecordNotFound) {
+		logger.Error("GetByCode error", logger.Err(err), logger.String("code", code), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return true
+	}
+	return false
+}
+
+func getSmsTemplateIDFromPath(c *gin.Context) (string, uint64, bool) {
+	idStr := c.Param("id")
+	id, err := utils.StrToUint64E(idStr)
+	if err != nil || id == 0 {
+		logger.Warn("StrToUint64E error: ", logger.String("idStr", idStr), middleware.GCtxRequestIDField(c))
+		return "", 0, true
+	}
+
+	return idStr, id, false
+}
+
+func convertSmsTemplate(smsTemplate *model.SmsTemplate) (*types.SmsTemplateObjDetail, error) {
+	data := &types.SmsTemplateObjDetail{}
+	err := copier.Copy(data, smsTemplate)
+	if err != nil {
+		return nil, err
+	}
+	// Note: if copier.Copy cannot assign a value to a field, add it here
+	data.Variables = sms.Variables(smsTemplate.Code)
+
+	return data, nil
+}
+
+func convertSmsTemplates(fromValues []*model.SmsTemplate) ([]*types.SmsTemplateObjDetail, error) {
+	toValues := []*types.SmsTemplateObjDetail{}
+	for _, v := range fromValues {
+		data, err := convertSmsTemplate(v)
+		if err != nil {
+			return nil, err
+		}
+		toValues = append(toValues, data)
+	}
+
+	return toValues, nil
+}
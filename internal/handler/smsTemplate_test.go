@@ -0,0 +1,307 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/copier"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-dev-frame/sponge/pkg/gotest"
+	"github.com/go-dev-frame/sponge/pkg/httpcli"
+	"github.com/go-dev-frame/sponge/pkg/sgorm/query"
+	"github.com/go-dev-frame/sponge/pkg/utils"
+
+	"lol/internal/cache"
+	"lol/internal/dao"
+	"lol/internal/database"
+	"lol/internal/ecode"
+	"lol/internal/model"
+	"lol/internal/types"
+)
+
+func newSmsTemplateHandler() *gotest.Handler {
+	testData := &model.SmsTemplate{}
+	testData.ID = 1
+	testData.Code = "repaymentReminder"
+	testData.Content = "{name}您好，您的车辆{carPlate}应还{amount}元，还款日为{dueDate}"
+	testData.Enabled = true
+
+	// init mock cache
+	c := gotest.NewCache(map[string]interface{}{utils.Uint64ToStr(testData.ID): testData})
+	c.ICache = cache.NewSmsTemplateCache(&database.CacheType{
+		CType: "redis",
+		Rdb:   c.RedisClient,
+	})
+
+	// init mock dao
+	d := gotest.NewDao(c, testData)
+	d.IDao = dao.NewSmsTemplateDao(d.DB, c.ICache.(cache.SmsTemplateCache))
+
+	// init mock handler
+	h := gotest.NewHandler(d, testData)
+	h.IHandler = &smsTemplateHandler{
+		iDao:       d.IDao.(dao.SmsTemplateDao),
+		loanDao:    dao.NewLoanDao(d.DB, nil),
+		accessLogs: dao.NewPiiAccessLogDao(d.DB),
+	}
+	iHandler := h.IHandler.(SmsTemplateHandler)
+
+	testFns := []gotest.RouterInfo{
+		{
+			FuncName:    "Create",
+			Method:      http.MethodPost,
+			Path:        "/smsTemplate",
+			HandlerFunc: iHandler.Create,
+		},
+		{
+			FuncName:    "DeleteByID",
+			Method:      http.MethodDelete,
+			Path:        "/smsTemplate/:id",
+			HandlerFunc: iHandler.DeleteByID,
+		},
+		{
+			FuncName:    "UpdateByID",
+			Method:      http.MethodPut,
+			Path:        "/smsTemplate/:id",
+			HandlerFunc: iHandler.UpdateByID,
+		},
+		{
+			FuncName:    "GetByID",
+			Method:      http.MethodGet,
+			Path:        "/smsTemplate/:id",
+			HandlerFunc: iHandler.GetByID,
+		},
+		{
+			FuncName:    "List",
+			Method:      http.MethodPost,
+			Path:        "/smsTemplate/list",
+			HandlerFunc: iHandler.List,
+		},
+		{
+			FuncName:    "Preview",
+			Method:      http.MethodPost,
+			Path:        "/smsTemplate/:id/preview",
+			HandlerFunc: iHandler.Preview,
+		},
+	}
+
+	h.GoRunHTTPServer(testFns)
+
+	time.Sleep(time.Millisecond * 200)
+	return h
+}
+
+func Test_smsTemplateHandler_Create(t *testing.T) {
+	h := newSmsTemplateHandler()
+	defer h.Close()
+	testData := &types.CreateSmsTemplateRequest{}
+	_ = copier.Copy(testData, h.TestData.(*model.SmsTemplate))
+
+	// the code is not used
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectExec("INSERT INTO .*").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	h.MockDao.SQLMock.ExpectCommit()
+
+	result := &httpcli.StdResult{}
+	err := httpcli.Post(result, h.GetRequestURL("Create"), testData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+
+	// the code is used
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id", "code"}).AddRow(2, testData.Code))
+	err = httpcli.Post(result, h.GetRequestURL("Create"), testData)
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.ErrSmsTemplateCodeExists.Code(), result.Code)
+
+	// the variable is not available to the login code template
+	testData.Code = "loginCode"
+	err = httpcli.Post(result, h.GetRequestURL("Create"), testData)
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.ErrSmsTemplateVariable.Code(), result.Code)
+}
+
+func Test_smsTemplateHandler_DeleteByID(t *testing.T) {
+	h := newSmsTemplateHandler()
+	defer h.Close()
+	testData := h.TestData.(*model.SmsTemplate)
+	expectedSQLForDeletion := "DELETE .*"
+
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectExec(expectedSQLForDeletion).
+		WithArgs(testData.ID). // adjusted for the amount of test data
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
+	h.MockDao.SQLMock.ExpectCommit()
+
+	result := &httpcli.StdResult{}
+	err := httpcli.Delete(result, h.GetRequestURL("DeleteByID", testData.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+
+	// zero id error test
+	err = httpcli.Delete(result, h.GetRequestURL("DeleteByID", 0))
+	assert.NoError(t, err)
+
+	// delete error test
+	err = httpcli.Delete(result, h.GetRequestURL("DeleteByID", 111))
+	assert.Error(t, err)
+}
+
+func Test_smsTemplateHandler_UpdateByID(t *testing.T) {
+	h := newSmsTemplateHandler()
+	defer h.Close()
+	testData := &types.UpdateSmsTemplateByIDRequest{}
+	_ = copier.Copy(testData, h.TestData.(*model.SmsTemplate))
+	enabled := false
+	testData.Enabled = &enabled
+
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectExec("UPDATE .*").
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
+	h.MockDao.SQLMock.ExpectCommit()
+	h.MockDao.SQLMock.ExpectBegin()
+	h.MockDao.SQLMock.ExpectExec("UPDATE .*").
+		WithArgs(false, testData.ID).
+		WillReturnResult(sqlmock.NewResult(int64(testData.ID), 1))
+	h.MockDao.SQLMock.ExpectCommit()
+
+	result := &httpcli.StdResult{}
+	err := httpcli.Put(result, h.GetRequestURL("UpdateByID", testData.ID), testData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+
+	// zero id error test
+	err = httpcli.Put(result, h.GetRequestURL("UpdateByID", 0), testData)
+	assert.NoError(t, err)
+
+	// update error test
+	err = httpcli.Put(result, h.GetRequestURL("UpdateByID", 111), testData)
+	assert.Error(t, err)
+}
+
+func Test_smsTemplateHandler_GetByID(t *testing.T) {
+	h := newSmsTemplateHandler()
+	defer h.Close()
+	testData := h.TestData.(*model.SmsTemplate)
+
+	// column names and corresponding data
+	rows := sqlmock.NewRows([]string{"id"}).
+		AddRow(testData.ID)
+
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").
+		WithArgs(testData.ID).
+		WillReturnRows(rows)
+
+	result := &httpcli.StdResult{}
+	err := httpcli.Get(result, h.GetRequestURL("GetByID", testData.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+
+	// zero id error test
+	err = httpcli.Get(result, h.GetRequestURL("GetByID", 0))
+	assert.NoError(t, err)
+
+	// get error test
+	err = httpcli.Get(result, h.GetRequestURL("GetByID", 111))
+	assert.Error(t, err)
+}
+
+func Test_smsTemplateHandler_List(t *testing.T) {
+	h := newSmsTemplateHandler()
+	defer h.Close()
+	testData := h.TestData.(*model.SmsTemplate)
+
+	// column names and corresponding data
+	rows := sqlmock.NewRows([]string{"id"}).
+		AddRow(testData.ID)
+
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(rows)
+
+	result := &httpcli.StdResult{}
+	err := httpcli.Post(result, h.GetRequestURL("List"), &types.ListSmsTemplatesRequest{query.Params{
+		Page:  0,
+		Limit: 10,
+		Sort:  "ignore count", // ignore test count
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+
+	// nil params error test
+	err = httpcli.Post(result, h.GetRequestURL("List"), nil)
+	assert.NoError(t, err)
+
+	// get error test
+	err = httpcli.Post(result, h.GetRequestURL("List"), &types.ListSmsTemplatesRequest{query.Params{
+		Page:  0,
+		Limit: 10,
+		Sort:  "unknown-column",
+	}})
+	assert.Error(t, err)
+}
+
+func Test_smsTemplateHandler_Preview(t *testing.T) {
+	h := newSmsTemplateHandler()
+	defer h.Close()
+	testData := h.TestData.(*model.SmsTemplate)
+
+	createAt := time.Date(2024, 1, 10, 0, 0, 0, 0, time.Local)
+	loanRows := sqlmock.NewRows([]string{"id", "name", "mobile", "car_plate", "loan_money", "loan_period",
+		"loan_return_date", "monthly_payment", "create_at", "status"}).
+		AddRow(1, "张三", "13800000000", "粤A12345", 1200, 12, "15", 110, createAt, 0)
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(loanRows)
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	h.MockDao.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id", "amount", "status"}))
+
+	result := &httpcli.StdResult{}
+	err := httpcli.Post(result, h.GetRequestURL("Preview", testData.ID), &types.PreviewSmsTemplateRequest{LoanID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != 0 {
+		t.Fatalf("%+v", result)
+	}
+	data := result.Data.(map[string]interface{})
+	// the role can't view personal information
+	assert.Equal(t, "张*您好，您的车辆粤A*****应还110.00元，还款日为2024-02-15", data["content"])
+	assert.Equal(t, "张*", data["params"].(map[string]interface{})["name"])
+
+	// the variable of the content is not available
+	err = httpcli.Post(result, h.GetRequestURL("Preview", testData.ID), &types.PreviewSmsTemplateRequest{LoanID: 1, Content: "{code}"})
+	assert.NoError(t, err)
+	assert.Equal(t, ecode.ErrSmsTemplateVariable.Code(), result.Code)
+
+	// zero id error test
+	err = httpcli.Post(result, h.GetRequestURL("Preview", 0), &types.PreviewSmsTemplateRequest{LoanID: 1})
+	assert.NoError(t, err)
+}
+
+func TestNewSmsTemplateHandler(t *testing.T) {
+	defer func() {
+		recover()
+	}()
+	_ = NewSmsTemplateHandler()
+}
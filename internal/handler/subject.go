@@ -0,0 +1,236 @@
+package handler
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/go-dev-frame/sponge/pkg/gin/middleware"
+	"github.com/go-dev-frame/sponge/pkg/gin/response"
+	"github.com/go-dev-frame/sponge/pkg/logger"
+
+	"lol/internal/cache"
+	"lol/internal/dao"
+	"lol/internal/database"
+	"lol/internal/ecode"
+	"lol/internal/fieldcrypt"
+	"lol/internal/pii"
+	"lol/internal/types"
+)
+
+const (
+	subjectFormatZip = "zip"
+
+	// subjectPseudonymKind the blind index kind of the pseudonyms of anonymized subjects
+	subjectPseudonymKind = "subject"
+	// subjectPseudonymPrefix anonymized values start with it, the pseudonyms are 16 characters
+	subjectPseudonymPrefix = "anon-"
+)
+
+var _ SubjectHandler = (*subjectHandler)(nil)
+
+// SubjectHandler defining the handler interface, the requests of data subjects under PIPL
+type SubjectHandler interface {
+	Export(c *gin.Context)
+	Anonymize(c *gin.Context)
+}
+
+type subjectHandler struct {
+	iDao       dao.SubjectDao
+	accessLogs dao.PiiAccessLogDao
+}
+
+// NewSubjectHandler creating the handler interface
+func NewSubjectHandler() SubjectHandler {
+	return &subjectHandler{
+		iDao: dao.NewSubjectDao(
+			database.GetDB(), // db driver is mysql
+			cache.NewLoanCache(database.GetCacheType()),
+			cache.NewPaymentHistoryCache(database.GetCacheType()),
+			cache.NewResultCache(database.GetCacheType()),
+			cache.NewSmsHistoryCache(database.GetCacheType()),
+		),
+		accessLogs: dao.NewPiiAccessLogDao(database.GetDB()),
+	}
+}
+
+// Export the data of a subject
+// @Summary export the data of a data subject
+// @Description export the loans, payments, payment results and messages of a borrower by mobile or ID number,
+// @Description as json or as a zip file, the export is recorded in the pii access log
+// @Tags subject
+// @accept json
+// @Produce json
+// @Param data body types.ExportSubjectRequest true "data subject"
+// @Success 200 {object} types.ExportSubjectReply{}
+// @Router /api/v1/subject/export [post]
+// @Security BearerAuth
+func (h *subjectHandler) Export(c *gin.Context) {
+	form := &types.ExportSubjectRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		responseInvalidParams(c, err)
+		return
+	}
+
+	data, err := h.iDao.GetData(middleware.WrapCtx(c), form.Mobile, form.UserID)
+	if err != nil {
+		logger.Error("GetData error", logger.Err(err), pii.Any("form", form), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+	if subjectCounts(data) == (types.SubjectCounts{}) {
+		response.Error(c, ecode.ErrSubjectNotFound)
+		return
+	}
+	data = revealPII(c, h.accessLogs, data, subjectPIIRecords(data))
+
+	manifest := types.SubjectManifest{ExportedAt: time.Now(), Counts: subjectCounts(data)}
+	if form.Format != subjectFormatZip {
+		response.Success(c, gin.H{
+			"manifest":         manifest,
+			"loans":            data.Loans,
+			"paymentHistories": data.PaymentHistories,
+			"results":          data.Results,
+			"smsHistories":     data.SmsHistories,
+		})
+		return
+	}
+
+	content, err := subjectZip(manifest, data)
+	if err != nil {
+		logger.Error("subjectZip error", logger.Err(err), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.ErrExportSubject)
+		return
+	}
+	filename := fmt.Sprintf("subject-%s.zip", manifest.ExportedAt.Format("20060102150405"))
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Data(http.StatusOK, "application/zip", content)
+}
+
+// Anonymize the data of a subject
+// @Summary anonymize the data of a data subject
+// @Description replace the name, ID number, mobile and car plate of the loans and the mobile of the payments with a pseudonym,
+// @Description clear the payers of the payment results and delete the messages, the amounts are kept for the retention
+// @Description of financial records. Subjects with loans not paid off can't be anonymized.
+// @Tags subject
+// @accept json
+// @Produce json
+// @Param data body types.AnonymizeSubjectRequest true "data subject"
+// @Success 200 {object} types.AnonymizeSubjectReply{}
+// @Router /api/v1/subject/anonymize [post]
+// @Security BearerAuth
+func (h *subjectHandler) Anonymize(c *gin.Context) {
+	form := &types.AnonymizeSubjectRequest{}
+	err := c.ShouldBindJSON(form)
+	if err != nil {
+		logger.Warn("ShouldBindJSON error: ", logger.Err(err), middleware.GCtxRequestIDField(c))
+		responseInvalidParams(c, err)
+		return
+	}
+
+	ctx := middleware.WrapCtx(c)
+	data, err := h.iDao.GetData(ctx, form.Mobile, form.UserID)
+	if err != nil {
+		logger.Error("GetData error", logger.Err(err), pii.Any("form", form), middleware.GCtxRequestIDField(c))
+		response.Output(c, ecode.InternalServerError.ToHTTPCode())
+		return
+	}
+	counts := subjectCounts(data)
+	if counts == (types.SubjectCounts{}) {
+		response.Error(c, ecode.ErrSubjectNotFound)
+		return
+	}
+	for _, loan := range data.Loans {
+		if loan.Status != 1 {
+			response.Error(c, ecode.ErrSubjectActiveLoan)
+			return
+		}
+	}
+
+	err = h.iDao.Anonymize(ctx, data, subjectPseudonym(form.Mobile, form.UserID))
+	if err != nil {
+		logger.Error("Anonymize error", logger.Err(err), pii.Any("form", form), middleware.GCtxRequestIDField(c))
+		response.Error(c, ecode.ErrAnonymizeSubject)
+		return
+	}
+
+	var adminUserID uint64
+	if adminUser := getAdminUser(c); adminUser != nil {
+		adminUserID = adminUser.ID
+	}
+	logger.Info("subject anonymized", pii.Any("form", form), logger.Uint64("adminUserID", adminUserID),
+		logger.Any("counts", counts), middleware.GCtxRequestIDField(c))
+
+	response.Success(c, gin.H{"counts": counts})
+}
+
+// subjectPseudonym the pseudonym of the subject, the same subject always gets the same pseudonym,
+// it can't be reversed without the index key of fieldCrypt
+func subjectPseudonym(mobile string, userID string) string {
+	index := fieldcrypt.BlindIndex(subjectPseudonymKind, mobile+"|"+userID)
+	return subjectPseudonymPrefix + index[:16-len(subjectPseudonymPrefix)]
+}
+
+func subjectCounts(data *dao.SubjectData) types.SubjectCounts {
+	return types.SubjectCounts{
+		Loans:            len(data.Loans),
+		PaymentHistories: len(data.PaymentHistories),
+		Results:          len(data.Results),
+		SmsHistories:     len(data.SmsHistories),
+	}
+}
+
+// subjectPIIRecords the records of the export revealing personal information
+func subjectPIIRecords(data *dao.SubjectData) []piiRecord {
+	var records []piiRecord
+	for _, v := range data.Loans {
+		records = append(records, piiRecord{resource: piiResourceLoan, resourceID: v.ID, loanID: v.ID, value: v})
+	}
+	for _, v := range data.PaymentHistories {
+		records = append(records, piiRecord{resource: piiResourcePaymentHistory, resourceID: v.ID, loanID: v.LoanID, value: v})
+	}
+	for _, v := range data.SmsHistories {
+		records = append(records, piiRecord{resource: piiResourceSmsHistory, resourceID: v.ID, loanID: v.LoanID, value: v})
+	}
+	return records
+}
+
+// subjectZip a zip file of the manifest and a json file per table
+func subjectZip(manifest types.SubjectManifest, data *dao.SubjectData) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	files := []struct {
+		name  string
+		value interface{}
+	}{
+		{"manifest.json", manifest},
+		{"loans.json", data.Loans},
+		{"paymentHistories.json", data.PaymentHistories},
+		{"results.json", data.Results},
+		{"smsHistories.json", data.SmsHistories},
+	}
+	for _, file := range files {
+		content, err := json.MarshalIndent(file.value, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		f, err := w.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: manifest.ExportedAt})
+		if err != nil {
+			return nil, err
+		}
+		if _, err = f.Write(content); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"lol/internal/dao"
+	"lol/internal/model"
+	"lol/internal/rbac"
+)
+
+type fakeSubjectDao struct {
+	data       *dao.SubjectData
+	err        error
+	anonymized string
+}
+
+func (f *fakeSubjectDao) GetData(ctx context.Context, mobile string, userID string) (*dao.SubjectData, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.data, nil
+}
+
+func (f *fakeSubjectDao) Anonymize(ctx context.Context, data *dao.SubjectData, pseudonym string) error {
+	f.anonymized = pseudonym
+	return nil
+}
+
+func newSubjectRouter(h *subjectHandler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set(adminUserKey, &model.AdminUser{ID: 1, Username: "admin", Role: rbac.RoleAdmin})
+	})
+	r.POST("/subject/export", h.Export)
+	r.POST("/subject/anonymize", h.Anonymize)
+	return r
+}
+
+func newSubjectData(status int) *dao.SubjectData {
+	return &dao.SubjectData{
+		Loans:            []*model.Loan{{ID: 1, Name: "张三", UserID: "110101199003071234", Mobile: "13800000000", LoanMoney: 50000, Status: status}},
+		PaymentHistories: []*model.PaymentHistory{{ID: 2, UserPhone: "13800000000", OutTradeNo: "T1", LoanID: 1, TotalAmount: 2000}},
+		Results:          []*model.Result{{ID: 3, OutTradeNo: "T1", AmountTotal: 2000}},
+		SmsHistories:     []*model.SmsHistory{{ID: 4, Mobile: "13800000000", LoanID: 1}},
+	}
+}
+
+func Test_subjectHandler_Export(t *testing.T) {
+	accessLogs := &fakePiiAccessLogDao{}
+	h := &subjectHandler{iDao: &fakeSubjectDao{data: newSubjectData(1)}, accessLogs: accessLogs}
+	r := newSubjectRouter(h)
+
+	req := httptest.NewRequest(http.MethodPost, "/subject/export", strings.NewReader(`{"mobile":"13800000000","format":"zip"}`))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+
+	zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	assert.NoError(t, err)
+	names := []string{}
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	assert.Equal(t, []string{"manifest.json", "loans.json", "paymentHistories.json", "results.json", "smsHistories.json"}, names)
+
+	f, err := zr.File[1].Open()
+	assert.NoError(t, err)
+	var loans []*model.Loan
+	assert.NoError(t, json.NewDecoder(f).Decode(&loans))
+	assert.Equal(t, "110101199003071234", loans[0].UserID)
+
+	// the export is recorded in the access log
+	assert.Len(t, accessLogs.logs, 3)
+	assert.Equal(t, piiResourceLoan, accessLogs.logs[0].Resource)
+}
+
+func Test_subjectHandler_Anonymize(t *testing.T) {
+	subjects := &fakeSubjectDao{data: newSubjectData(0)}
+	r := newSubjectRouter(&subjectHandler{iDao: subjects, accessLogs: &fakePiiAccessLogDao{}})
+	post := func(body string) {
+		req := httptest.NewRequest(http.MethodPost, "/subject/anonymize", strings.NewReader(body))
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// the loan is not paid off
+	post(`{"userID":"110101199003071234"}`)
+	assert.Empty(t, subjects.anonymized)
+
+	// no identifier
+	subjects.data = newSubjectData(1)
+	post(`{}`)
+	assert.Empty(t, subjects.anonymized)
+
+	subjects.err = errors.New("db error")
+	post(`{"userID":"110101199003071234"}`)
+	assert.Empty(t, subjects.anonymized)
+
+	subjects.err = nil
+	post(`{"userID":"110101199003071234"}`)
+	assert.Equal(t, subjectPseudonym("", "110101199003071234"), subjects.anonymized)
+}
+
+func Test_subjectPseudonym(t *testing.T) {
+	pseudonym := subjectPseudonym("13800000000", "")
+	assert.Len(t, pseudonym, 16)
+	assert.True(t, strings.HasPrefix(pseudonym, subjectPseudonymPrefix))
+	assert.Equal(t, pseudonym, subjectPseudonym("13800000000", ""))
+	assert.NotEqual(t, pseudonym, subjectPseudonym("13800000001", ""))
+}
@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"reflect"
+	"strings"
+)
+
+// updatedColumns the columns of the table given by the update request, i.e. the columns of the fields of the table
+// whose namesake pointer fields of the form are not nil, they are written even if their values are zero
+func updatedColumns(form interface{}, table interface{}) []string {
+	fv := reflect.Indirect(reflect.ValueOf(form))
+	tt := reflect.Indirect(reflect.ValueOf(table)).Type()
+	var columns []string
+	for i := 0; i < fv.NumField(); i++ {
+		field := fv.Type().Field(i)
+		if field.Type.Kind() != reflect.Ptr || fv.Field(i).IsNil() {
+			continue
+		}
+		tableField, ok := tt.FieldByName(field.Name)
+		if !ok {
+			continue
+		}
+		for _, setting := range strings.Split(tableField.Tag.Get("gorm"), ";") {
+			if column, ok := strings.CutPrefix(setting, "column:"); ok {
+				columns = append(columns, column)
+			}
+		}
+	}
+	return columns
+}
@@ -0,0 +1,18 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"lol/internal/model"
+	"lol/internal/types"
+)
+
+func Test_updatedColumns(t *testing.T) {
+	plate, status := "", 0
+	form := &types.UpdateLoanByIDRequest{ID: 1, CarPlate: &plate, Status: &status}
+	assert.Equal(t, []string{"car_plate", "status"}, updatedColumns(form, &model.Loan{}))
+
+	assert.Empty(t, updatedColumns(&types.UpdateSmsHistoryByIDRequest{ID: 1}, &model.SmsHistory{}))
+}
@@ -0,0 +1,20 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/go-dev-frame/sponge/pkg/gin/response"
+
+	"lol/internal/ecode"
+	"lol/internal/validation"
+)
+
+// responseInvalidParams respond the request that failed the binding, with the errors of its fields
+// if it failed the validation, such as {"errors": [{"field": "userID", "message": "is not a valid ID card number"}]}
+func responseInvalidParams(c *gin.Context, err error) {
+	if fieldErrs := validation.Errors(err); len(fieldErrs) > 0 {
+		response.Error(c, ecode.InvalidParams, gin.H{"errors": fieldErrs})
+		return
+	}
+	response.Error(c, ecode.InvalidParams)
+}
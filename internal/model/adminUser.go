@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+)
+
+type AdminUser struct {
+	ID                 uint64     `gorm:"column:id;type:int(11);primary_key;AUTO_INCREMENT" json:"id"`           // 序号
+	Username           string     `gorm:"column:username;type:varchar(50)" json:"username"`                      // 用户名
+	Password           string     `gorm:"column:password;type:varchar(100)" json:"-"`                            // bcrypt密码哈希
+	Role               string     `gorm:"column:role;type:varchar(20)" json:"role"`                              // 角色 viewer/operator/finance/admin
+	Status             int        `gorm:"column:status;type:tinyint(4)" json:"status"`                           // 状态 1:正常 2:禁用
+	MustChangePassword bool       `gorm:"column:must_change_password;type:tinyint(1)" json:"mustChangePassword"` // 是否需要先修改密码，修改前只能访问修改密码和退出登录
+	TotpSecret         string     `gorm:"column:totp_secret;type:varchar(64)" json:"-"`                          // 两步验证密钥，不参与json序列化
+	TotpEnabled        bool       `gorm:"column:totp_enabled;type:tinyint(1)" json:"totpEnabled"`                // 是否已开启两步验证
+	RecoveryCodes      string     `gorm:"column:recovery_codes;type:varchar(1000)" json:"-"`                     // 恢复码的sha256哈希，多个用逗号分隔
+	TotpCounter        int64      `gorm:"column:totp_counter;type:bigint(20)" json:"-"`                          // 最后一次通过验证的动态验证码的时间步，不能小于等于它
+	CreateAt           *time.Time `gorm:"column:create_at;type:datetime" json:"createAt"`                        // 创建时间
+	UpdateAt           *time.Time `gorm:"column:update_at;type:datetime" json:"updateAt"`                        // 更新时间
+}
+
+// TableName table name
+func (m *AdminUser) TableName() string {
+	return "admin_user"
+}
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+)
+
+// AuditLog an admin user created, updated or deleted a record
+type AuditLog struct {
+	ID          uint64     `gorm:"column:id;type:int(11);primary_key;AUTO_INCREMENT" json:"id"` // 序号
+	AdminUserID uint64     `gorm:"column:admin_user_id;type:int(11)" json:"adminUserID"`        // 操作员序号
+	Username    string     `gorm:"column:username;type:varchar(50)" json:"username"`            // 操作员用户名
+	Action      string     `gorm:"column:action;type:varchar(10)" json:"action"`                // 操作 create/update/delete
+	Table       string     `gorm:"column:table_name;type:varchar(50)" json:"table"`             // 表名
+	RecordID    uint64     `gorm:"column:record_id;type:int(11)" json:"recordID"`               // 记录序号
+	Changes     string     `gorm:"column:changes;type:text" json:"changes"`                     // 变更的字段，json {"字段":{"before":旧值,"after":新值}}
+	RequestID   string     `gorm:"column:request_id;type:varchar(64)" json:"requestID"`         // 请求ID
+	CreateAt    *time.Time `gorm:"column:create_at;type:datetime" json:"createAt"`              // 操作时间
+}
+
+// TableName table name
+func (m *AuditLog) TableName() string {
+	return "audit_log"
+}
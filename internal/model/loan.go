@@ -2,25 +2,39 @@ package model
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type Loan struct {
-	ID             uint64     `gorm:"column:id;type:int(11);primary_key;AUTO_INCREMENT" json:"id"`   // 序号
-	Name           string     `gorm:"column:name;type:varchar(10)" json:"name"`                      // 姓名
-	UserID         string     `gorm:"column:user_id;type:varchar(18)" json:"userID"`                 // 身份证号码
-	Mobile         string     `gorm:"column:mobile;type:varchar(11)" json:"mobile"`                  // 手机号码
-	CarModel       string     `gorm:"column:car_model;type:varchar(15)" json:"carModel"`             // 车型
-	CarPlate       string     `gorm:"column:car_plate;type:varchar(10)" json:"carPlate"`             // 车牌
-	LoanMoney      float64    `gorm:"column:loan_money;type:double" json:"loanMoney"`                // 借款金额
-	LoanPeriod     int        `gorm:"column:loan_period;type:int(11)" json:"loanPeriod"`             // 借款期数
-	LoanReturnDate string     `gorm:"column:loan_return_date;type:varchar(2)" json:"loanReturnDate"` // 还款日期
-	MonthlyPayment float64    `gorm:"column:monthly_payment;type:double" json:"monthlyPayment"`      // 每月应还
-	CreateAt       *time.Time `gorm:"column:create_at;type:datetime" json:"createAt"`                // 创建时间
-	Status         int        `gorm:"column:status;type:tinyint" json:"status"`                      // 状态
-	PaidCount      int        `gorm:"-" json:"paidCount"`                                            // 已还期数 数据库没有 但是 需要record赋值
-	OverDueDays    int        `gorm:"-" json:"overDueDays"`                                          // 逾期天数
-	LastPayDate    time.Time  `gorm:"-" json:"lastPayDate"`                                          // 上次还款日期
-	OverDueMoney   int        `gorm:"-" json:"overDueMoney"`                                         // 逾期金额
+	ID              uint64         `gorm:"column:id;type:int(11);primary_key;AUTO_INCREMENT" json:"id"`                    // 序号
+	Name            string         `gorm:"column:name;type:varchar(255);serializer:encrypt" json:"name" pii:"name"`        // 姓名，加密存储
+	UserID          string         `gorm:"column:user_id;type:varchar(255);serializer:encrypt" json:"userID" pii:"idcard"` // 身份证号码，加密存储
+	Mobile          string         `gorm:"column:mobile;type:varchar(255);serializer:encrypt" json:"mobile" pii:"mobile"`  // 手机号码，加密存储
+	NameIndex       string         `gorm:"column:name_index;type:varchar(64)" json:"-"`                                    // 姓名的盲索引
+	UserIDIndex     string         `gorm:"column:user_id_index;type:varchar(64)" json:"-"`                                 // 身份证号码的盲索引
+	UserCodeIndex   string         `gorm:"column:user_code_index;type:varchar(64)" json:"-"`                               // 身份证号码后6位的盲索引
+	MobileIndex     string         `gorm:"column:mobile_index;type:varchar(64)" json:"-"`                                  // 手机号码的盲索引
+	CarModel        string         `gorm:"column:car_model;type:varchar(15)" json:"carModel"`                              // 车型
+	CarPlate        string         `gorm:"column:car_plate;type:varchar(10)" json:"carPlate"`                              // 车牌
+	LoanMoney       float64        `gorm:"column:loan_money;type:double" json:"loanMoney"`                                 // 借款金额
+	LoanPeriod      int            `gorm:"column:loan_period;type:int(11)" json:"loanPeriod"`                              // 借款期数
+	LoanReturnDate  string         `gorm:"column:loan_return_date;type:varchar(2)" json:"loanReturnDate"`                  // 还款日期
+	MonthlyPayment  float64        `gorm:"column:monthly_payment;type:double" json:"monthlyPayment"`                       // 每月应还
+	CreateAt        *time.Time     `gorm:"column:create_at;type:datetime" json:"createAt"`                                 // 创建时间
+	DeletedAt       gorm.DeletedAt `gorm:"column:deleted_at;type:datetime" json:"-"`                                       // 删除时间，为空表示未删除，删除的记录在回收站中
+	Status          int            `gorm:"column:status;type:tinyint" json:"status"`                                       // 状态
+	ProductID       uint64         `gorm:"column:product_id;type:int(11)" json:"productID"`                                // 产品序号
+	AnnualRate      float64        `gorm:"column:annual_rate;type:double" json:"annualRate"`                               // 年利率，创建时取自产品
+	RepaymentMethod string         `gorm:"column:repayment_method;type:varchar(20)" json:"repaymentMethod"`                // 还款方式，创建时取自产品
+	BalloonRatio    float64        `gorm:"column:balloon_ratio;type:double" json:"balloonRatio"`                           // 尾款占本金比例
+	Version         uint64         `gorm:"column:version;type:int(11)" json:"version"`                                     // 版本号，每次更新加1，更新时须与读取时的版本一致
+	PaidCount       int            `gorm:"-" json:"paidCount"`                                                             // 已还期数 数据库没有 但是 需要record赋值
+	OverDueDays     int            `gorm:"-" json:"overDueDays"`                                                           // 逾期天数
+	LastPayDate     time.Time      `gorm:"-" json:"lastPayDate"`                                                           // 上次还款日期
+	OverDueMoney    int            `gorm:"-" json:"overDueMoney"`                                                          // 逾期金额
+	PaidMoney       float64        `gorm:"-" json:"paidMoney"`                                                             // 已还金额
+	RemainingMoney  float64        `gorm:"-" json:"remainingMoney"`                                                        // 剩余应还金额
 }
 
 // TableName table name
@@ -0,0 +1,14 @@
+package model
+
+import (
+	"time"
+)
+
+// LoanPaymentSummary 借款支付成功订单的汇总，由支付记录聚合得到，没有对应的数据表
+type LoanPaymentSummary struct {
+	LoanID      uint64     `json:"loanID"`      // 借款序号
+	PaidCount   int        `json:"paidCount"`   // 支付成功的订单数
+	LegacyCount int        `json:"legacyCount"` // 没有记录金额的旧订单数，每笔按一期月租计算
+	PaidAmount  float64    `json:"paidAmount"`  // 有记录金额的订单的金额合计
+	LastPaidAt  *time.Time `json:"lastPaidAt"`  // 最后一笔订单的创建时间
+}
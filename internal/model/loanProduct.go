@@ -0,0 +1,20 @@
+package model
+
+import (
+	"time"
+)
+
+type LoanProduct struct {
+	ID              uint64     `gorm:"column:id;type:int(11);primary_key;AUTO_INCREMENT" json:"id"`     // 序号
+	Name            string     `gorm:"column:name;type:varchar(50)" json:"name"`                        // 产品名称
+	Terms           string     `gorm:"column:terms;type:varchar(100)" json:"terms"`                     // 可选期数，多个用逗号分隔，如 12,24,36
+	AnnualRate      float64    `gorm:"column:annual_rate;type:double" json:"annualRate"`                // 年利率，如 0.24 表示 24%
+	RepaymentMethod string     `gorm:"column:repayment_method;type:varchar(20)" json:"repaymentMethod"` // 还款方式 flat/annuity/equal_principal/balloon
+	BalloonRatio    float64    `gorm:"column:balloon_ratio;type:double" json:"balloonRatio"`            // 尾款占本金比例，仅尾款方式使用
+	CreateAt        *time.Time `gorm:"column:create_at;type:datetime" json:"createAt"`                  // 创建时间
+}
+
+// TableName table name
+func (m *LoanProduct) TableName() string {
+	return "loan_product"
+}
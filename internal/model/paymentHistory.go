@@ -2,15 +2,23 @@ package model
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type PaymentHistory struct {
-	ID         uint64     `gorm:"column:id;type:int(11);primary_key;AUTO_INCREMENT" json:"id"` // 序号
-	UserPhone  string     `gorm:"column:user_phone;type:varchar(11)" json:"userPhone"`         // 用户手机号码
-	OutTradeNo string     `gorm:"column:out_trade_no;type:varchar(255)" json:"outTradeNo"`     // 支付订单号
-	Status     string     `gorm:"column:status;type:varchar(12)" json:"status"`                // 状态
-	Method     string     `gorm:"column:method;type:varchar(12)" json:"method"`                // 支付方式
-	CreateAt   *time.Time `gorm:"column:create_at;type:datetime" json:"createAt"`              // 创建时间
+	ID             uint64         `gorm:"column:id;type:int(11);primary_key;AUTO_INCREMENT" json:"id"`                          // 序号
+	UserPhone      string         `gorm:"column:user_phone;type:varchar(255);serializer:encrypt" json:"userPhone" pii:"mobile"` // 用户手机号码，加密存储
+	UserPhoneIndex string         `gorm:"column:user_phone_index;type:varchar(64)" json:"-"`                                    // 用户手机号码的盲索引
+	OutTradeNo     string         `gorm:"column:out_trade_no;type:varchar(255)" json:"outTradeNo"`                              // 支付订单号
+	Status         string         `gorm:"column:status;type:varchar(12)" json:"status"`                                         // 状态
+	Method         string         `gorm:"column:method;type:varchar(12)" json:"method"`                                         // 支付方式
+	LoanID         uint64         `gorm:"column:loan_id;type:int(11)" json:"loanID"`                                            // 借款序号
+	Installments   int            `gorm:"column:installments;type:int(11)" json:"installments"`                                 // 支付期数，0表示自定义金额
+	Amount         float64        `gorm:"column:amount;type:double" json:"amount"`                                              // 分配到分期的金额
+	TotalAmount    float64        `gorm:"column:total_amount;type:double" json:"totalAmount"`                                   // 订单金额，含逾期费用及手续费
+	CreateAt       *time.Time     `gorm:"column:create_at;type:datetime" json:"createAt"`                                       // 创建时间
+	DeletedAt      gorm.DeletedAt `gorm:"column:deleted_at;type:datetime" json:"-"`                                             // 删除时间，为空表示未删除，删除的记录在回收站中
 }
 
 // TableName table name
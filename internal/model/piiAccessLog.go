@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+)
+
+// PiiAccessLog an admin user viewed the unmasked personal information of a record
+type PiiAccessLog struct {
+	ID          uint64     `gorm:"column:id;type:int(11);primary_key;AUTO_INCREMENT" json:"id"` // 序号
+	AdminUserID uint64     `gorm:"column:admin_user_id;type:int(11)" json:"adminUserID"`        // 操作员序号
+	Username    string     `gorm:"column:username;type:varchar(50)" json:"username"`            // 操作员用户名
+	Resource    string     `gorm:"column:resource;type:varchar(30)" json:"resource"`            // 资源 loan/paymentHistory/smsHistory
+	ResourceID  uint64     `gorm:"column:resource_id;type:int(11)" json:"resourceID"`           // 记录序号
+	LoanID      uint64     `gorm:"column:loan_id;type:int(11)" json:"loanID"`                   // 关联借款序号，0为不关联借款
+	Fields      string     `gorm:"column:fields;type:varchar(100)" json:"fields"`               // 显示的字段，逗号分隔
+	Route       string     `gorm:"column:route;type:varchar(100)" json:"route"`                 // 请求的接口
+	RequestID   string     `gorm:"column:request_id;type:varchar(64)" json:"requestID"`         // 请求ID
+	IP          string     `gorm:"column:ip;type:varchar(64)" json:"ip"`                        // 客户端IP
+	CreateAt    *time.Time `gorm:"column:create_at;type:datetime" json:"createAt"`              // 访问时间
+}
+
+// TableName table name
+func (m *PiiAccessLog) TableName() string {
+	return "pii_access_log"
+}
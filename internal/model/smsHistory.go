@@ -2,13 +2,27 @@ package model
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type SmsHistory struct {
-	ID       uint64     `gorm:"column:id;type:int(11);primary_key;AUTO_INCREMENT" json:"id"` // 序号
-	UserName string     `gorm:"column:user_name;type:varchar(6)" json:"userName"`            // 收信人
-	Mobile   string     `gorm:"column:mobile;type:varchar(14)" json:"mobile"`                // 手机号
-	CreateAt *time.Time `gorm:"column:create_at;type:datetime" json:"createAt"`              // 创建时间
+	ID             uint64         `gorm:"column:id;type:int(11);primary_key;AUTO_INCREMENT" json:"id"`                   // 序号
+	UserName       string         `gorm:"column:user_name;type:varchar(6)" json:"userName" pii:"name"`                   // 收信人
+	Mobile         string         `gorm:"column:mobile;type:varchar(255);serializer:encrypt" json:"mobile" pii:"mobile"` // 手机号，加密存储
+	MobileIndex    string         `gorm:"column:mobile_index;type:varchar(64)" json:"-"`                                 // 手机号的盲索引
+	Content        string         `gorm:"column:content;type:varchar(500)" json:"content"`                               // 短信内容
+	Template       string         `gorm:"column:template;type:varchar(50)" json:"template"`                              // 模板编码
+	LoanID         uint64         `gorm:"column:loan_id;type:int(11)" json:"loanID"`                                     // 关联借款序号，0为不关联借款
+	Provider       string         `gorm:"column:provider;type:varchar(20)" json:"provider"`                              // 短信服务商 aliyun/tencent/log
+	MessageID      string         `gorm:"column:message_id;type:varchar(64)" json:"messageID"`                           // 服务商消息ID
+	Status         int            `gorm:"column:status;type:tinyint(4)" json:"status"`                                   // 发送状态 0:手工录入 1:发送中 2:已发送 3:发送失败
+	DeliveryStatus int            `gorm:"column:delivery_status;type:tinyint(4)" json:"deliveryStatus"`                  // 送达状态 0:未回执 1:已送达 2:送达失败
+	ErrorCode      string         `gorm:"column:error_code;type:varchar(64)" json:"errorCode"`                           // 发送失败或送达回执的错误码
+	ReportAt       *time.Time     `gorm:"column:report_at;type:datetime" json:"reportAt"`                                // 送达回执时间
+	BizKey         *string        `gorm:"column:biz_key;type:varchar(64)" json:"bizKey"`                                 // 业务去重键，唯一，为空表示不去重
+	CreateAt       *time.Time     `gorm:"column:create_at;type:datetime" json:"createAt"`                                // 创建时间
+	DeletedAt      gorm.DeletedAt `gorm:"column:deleted_at;type:datetime" json:"-"`                                      // 删除时间，为空表示未删除，删除的记录在回收站中
 }
 
 // TableName table name
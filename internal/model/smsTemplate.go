@@ -0,0 +1,20 @@
+package model
+
+import (
+	"time"
+)
+
+type SmsTemplate struct {
+	ID         uint64     `gorm:"column:id;type:int(11);primary_key;AUTO_INCREMENT" json:"id"` // 序号
+	Code       string     `gorm:"column:code;type:varchar(50)" json:"code"`                    // 模板编码，业务代码按编码发送，如 loginCode
+	TemplateID string     `gorm:"column:template_id;type:varchar(64)" json:"templateID"`       // 服务商审核通过的模板ID
+	Content    string     `gorm:"column:content;type:varchar(500)" json:"content"`             // 模板内容，变量写作 {name}
+	Enabled    bool       `gorm:"column:enabled;type:tinyint(1)" json:"enabled"`               // 是否启用
+	Sensitive  bool       `gorm:"column:sensitive;type:tinyint(1)" json:"sensitive"`           // 是否敏感，如验证码，发送记录中不保存变量的值
+	CreateAt   *time.Time `gorm:"column:create_at;type:datetime" json:"createAt"`              // 创建时间
+}
+
+// TableName table name
+func (m *SmsTemplate) TableName() string {
+	return "sms_template"
+}
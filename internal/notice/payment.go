@@ -0,0 +1,223 @@
+// Package notice notify borrowers of their successful payments, the notifications are sent asynchronously
+// so the payment callbacks are acknowledged without waiting for them.
+package notice
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-dev-frame/sponge/pkg/logger"
+
+	"lol/internal/cache"
+	"lol/internal/config"
+	"lol/internal/dao"
+	"lol/internal/database"
+	"lol/internal/model"
+	"lol/internal/sms"
+)
+
+// BizKeyPrefix the prefix of the sms_history biz_key of payment confirmations, followed by the trade no
+const BizKeyPrefix = "payment:"
+
+const (
+	defaultRetryInterval = 5 * time.Second
+	notifyTimeout        = 5 * time.Minute
+	maxConcurrency       = 16
+)
+
+type paymentSource interface {
+	GetPaymentByTradeNo(ctx context.Context, tradeNo string) (*model.PaymentHistory, error)
+	GetWithRepayment(ctx context.Context, id uint64) (*model.Loan, error)
+}
+
+type historySource interface {
+	ExistsByBizKey(ctx context.Context, bizKey string) (bool, error)
+}
+
+type smsSender interface {
+	Send(ctx context.Context, req *sms.Request) (*model.SmsHistory, error)
+}
+
+type wechatSender interface {
+	Send(ctx context.Context, openid string, params map[string]string) error
+}
+
+// PaymentNotifier send the confirmation of a successful payment by sms, and by a wechat subscription message
+// if the openid of the payer is known. The sms is recorded in sms_history with the biz_key of the trade no,
+// so a payment is confirmed at most once even if the callback is received again.
+type PaymentNotifier struct {
+	payments      paymentSource
+	history       historySource
+	sms           smsSender
+	wechat        wechatSender // if nil, no wechat message is sent
+	template      string
+	maxRetries    int
+	retryInterval time.Duration
+
+	sem      chan struct{}
+	inflight sync.Map // the trade nos being notified
+	wg       sync.WaitGroup
+}
+
+// NewPaymentNotifier create the notifier with the config, nil is returned if it is disabled
+func NewPaymentNotifier() *PaymentNotifier {
+	conf := config.Get().PaymentNotice
+	if !conf.Enable {
+		return nil
+	}
+
+	smsDao := dao.NewSmsHistoryDao(
+		database.GetDB(), // db driver is mysql
+		cache.NewSmsHistoryCache(database.GetCacheType()),
+	)
+	loanDao := dao.NewLoanDao(
+		database.GetDB(),
+		cache.NewLoanCache(database.GetCacheType()),
+	)
+	templateDao := dao.NewSmsTemplateDao(
+		database.GetDB(),
+		cache.NewSmsTemplateCache(database.GetCacheType()),
+	)
+
+	var wechat wechatSender
+	if conf.Wechat.Enable {
+		wechat = NewWechatSender(conf.Wechat)
+	}
+	return NewPaymentNotifierWithSource(conf, loanDao, smsDao, sms.NewService(smsDao, templateDao), wechat)
+}
+
+// NewPaymentNotifierWithSource create the notifier with the payments, the sms history and the senders
+func NewPaymentNotifierWithSource(conf config.PaymentNotice, payments paymentSource, history historySource,
+	sender smsSender, wechat wechatSender) *PaymentNotifier {
+	template := conf.SmsTemplate
+	if template == "" {
+		template = sms.TemplatePaymentSuccess
+	}
+	retryInterval := time.Duration(conf.RetryInterval) * time.Second
+	if retryInterval <= 0 {
+		retryInterval = defaultRetryInterval
+	}
+	return &PaymentNotifier{
+		payments:      payments,
+		history:       history,
+		sms:           sender,
+		wechat:        wechat,
+		template:      template,
+		maxRetries:    conf.MaxRetries,
+		retryInterval: retryInterval,
+		sem:           make(chan struct{}, maxConcurrency),
+	}
+}
+
+// Notify send the confirmation of the payment in the background, openid is the wechat payer and may be empty.
+// It returns immediately, a nil notifier does nothing.
+func (n *PaymentNotifier) Notify(tradeNo string, openid string) {
+	if n == nil {
+		return
+	}
+	// the callback and the order tracking may report the same payment at the same time
+	if _, loaded := n.inflight.LoadOrStore(tradeNo, struct{}{}); loaded {
+		return
+	}
+
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		defer n.inflight.Delete(tradeNo)
+		n.sem <- struct{}{}
+		defer func() { <-n.sem }()
+
+		ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+		defer cancel()
+		if err := n.notify(ctx, tradeNo, openid); err != nil {
+			logger.Error("send payment notice error", logger.Err(err), logger.String("tradeNo", tradeNo))
+		}
+	}()
+}
+
+// Wait for the notifications in progress
+func (n *PaymentNotifier) Wait() {
+	if n != nil {
+		n.wg.Wait()
+	}
+}
+
+// notify send the confirmation of the payment, payments that are not successful, not of a loan
+// or confirmed before are skipped.
+func (n *PaymentNotifier) notify(ctx context.Context, tradeNo string, openid string) error {
+	var (
+		payment *model.PaymentHistory
+		loan    *model.Loan
+		bizKey  = BizKeyPrefix + tradeNo
+		exists  bool
+	)
+	err := n.retry(ctx, isRetryableLoadError, func() error {
+		var err error
+		payment, err = n.payments.GetPaymentByTradeNo(ctx, tradeNo)
+		if err != nil {
+			return err
+		}
+		if payment.Status != "SUCCESS" || payment.LoanID == 0 {
+			return nil
+		}
+		exists, err = n.history.ExistsByBizKey(ctx, bizKey)
+		if err != nil || exists {
+			return err
+		}
+		loan, err = n.payments.GetWithRepayment(ctx, payment.LoanID)
+		return err
+	})
+	if err != nil || loan == nil || loan.Mobile == "" {
+		return err
+	}
+
+	params := sms.PaymentParams(loan, payment)
+	// temporary failures of the provider are retried by the sms service
+	_, smsErr := n.sms.Send(ctx, &sms.Request{
+		UserName: loan.Name,
+		Mobile:   loan.Mobile,
+		Template: n.template,
+		Params:   params,
+		LoanID:   loan.ID,
+		BizKey:   bizKey,
+	})
+	// confirmed by another callback or replica since the check above
+	if errors.Is(smsErr, dao.ErrBizKeyExists) {
+		return nil
+	}
+
+	var wechatErr error
+	if n.wechat != nil && openid != "" {
+		wechatErr = n.retry(ctx, sms.IsTemporary, func() error {
+			return n.wechat.Send(ctx, openid, params)
+		})
+	}
+	return errors.Join(smsErr, wechatErr)
+}
+
+// retry call fn until it succeeds, the error is not retryable or maxRetries is reached,
+// the interval is doubled after every retry.
+func (n *PaymentNotifier) retry(ctx context.Context, retryable func(error) bool, fn func() error) error {
+	interval := n.retryInterval
+	for i := 0; ; i++ {
+		err := fn()
+		if err == nil || !retryable(err) || i >= n.maxRetries {
+			return err
+		}
+		logger.Warn("payment notice failed, retrying", logger.Err(err), logger.Int("attempt", i+1))
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+		interval *= 2
+	}
+}
+
+// isRetryableLoadError a missing record is not retried, the payment or the loan does not exist
+func isRetryableLoadError(err error) bool {
+	return !errors.Is(err, database.ErrRecordNotFound)
+}
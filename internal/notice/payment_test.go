@@ -0,0 +1,204 @@
+package notice
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"lol/internal/config"
+	"lol/internal/dao"
+	"lol/internal/database"
+	"lol/internal/model"
+	"lol/internal/sms"
+)
+
+type fakePayments struct {
+	payment *model.PaymentHistory
+	loan    *model.Loan
+	errs    []error // returned by GetPaymentByTradeNo one by one before the payment
+}
+
+func (f *fakePayments) GetPaymentByTradeNo(ctx context.Context, tradeNo string) (*model.PaymentHistory, error) {
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		return nil, err
+	}
+	if f.payment == nil || f.payment.OutTradeNo != tradeNo {
+		return nil, database.ErrRecordNotFound
+	}
+	return f.payment, nil
+}
+
+func (f *fakePayments) GetWithRepayment(ctx context.Context, id uint64) (*model.Loan, error) {
+	if f.loan == nil || f.loan.ID != id {
+		return nil, database.ErrRecordNotFound
+	}
+	return f.loan, nil
+}
+
+// fakeSms the sent messages of sms_history
+type fakeSms struct {
+	mu   sync.Mutex
+	sent []*sms.Request
+	err  error
+}
+
+func (f *fakeSms) ExistsByBizKey(ctx context.Context, bizKey string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, v := range f.sent {
+		if v.BizKey == bizKey {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *fakeSms) Send(ctx context.Context, req *sms.Request) (*model.SmsHistory, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	// the biz_key is unique
+	for _, v := range f.sent {
+		if v.BizKey == req.BizKey {
+			return nil, dao.ErrBizKeyExists
+		}
+	}
+	f.sent = append(f.sent, req)
+	return &model.SmsHistory{}, f.err
+}
+
+type fakeWechat struct {
+	openids []string
+	errs    []error
+}
+
+func (f *fakeWechat) Send(ctx context.Context, openid string, params map[string]string) error {
+	f.openids = append(f.openids, openid)
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		return err
+	}
+	return nil
+}
+
+func newTestNotifier() (*PaymentNotifier, *fakePayments, *fakeSms, *fakeWechat) {
+	createAt := time.Date(2024, 1, 10, 0, 0, 0, 0, time.Local)
+	payments := &fakePayments{
+		payment: &model.PaymentHistory{OutTradeNo: "T001", Status: "SUCCESS", LoanID: 1, Amount: 110, TotalAmount: 110.66},
+		loan: &model.Loan{
+			ID:             1,
+			Name:           "张三",
+			Mobile:         "13800000000",
+			CarPlate:       "粤A12345",
+			LoanMoney:      1200,
+			LoanPeriod:     12,
+			LoanReturnDate: "15",
+			MonthlyPayment: 110,
+			CreateAt:       &createAt,
+			PaidMoney:      110,
+		},
+	}
+	smsFake := &fakeSms{}
+	wechat := &fakeWechat{}
+	n := NewPaymentNotifierWithSource(config.PaymentNotice{MaxRetries: 2}, payments, smsFake, smsFake, wechat)
+	n.retryInterval = time.Millisecond
+	return n, payments, smsFake, wechat
+}
+
+func TestPaymentNotifier_Notify(t *testing.T) {
+	n, _, smsFake, wechat := newTestNotifier()
+	n.Notify("T001", "openid-1")
+	n.Wait()
+
+	assert.Len(t, smsFake.sent, 1)
+	req := smsFake.sent[0]
+	assert.Equal(t, "13800000000", req.Mobile)
+	assert.Equal(t, sms.TemplatePaymentSuccess, req.Template)
+	assert.Equal(t, "payment:T001", req.BizKey)
+	assert.Equal(t, uint64(1), req.LoanID)
+	assert.Equal(t, map[string]string{
+		"name": "张三", "carPlate": "粤A12345", "amount": "110.66", "installment": "1",
+		"remaining": "1210.00", "nextDueDate": "2024-03-15",
+	}, req.Params)
+	assert.Equal(t, []string{"openid-1"}, wechat.openids)
+
+	// the callback is received again
+	n.Notify("T001", "openid-1")
+	n.Wait()
+	assert.Len(t, smsFake.sent, 1)
+	assert.Len(t, wechat.openids, 1)
+
+	// a nil notifier is disabled
+	var disabled *PaymentNotifier
+	disabled.Notify("T001", "")
+	disabled.Wait()
+}
+
+func TestPaymentNotifier_notify_skip(t *testing.T) {
+	n, payments, smsFake, wechat := newTestNotifier()
+
+	// no openid of alipay payments
+	assert.NoError(t, n.notify(context.Background(), "T001", ""))
+	assert.Len(t, smsFake.sent, 1)
+	assert.Empty(t, wechat.openids)
+
+	// unknown trade no
+	err := n.notify(context.Background(), "T002", "")
+	assert.ErrorIs(t, err, database.ErrRecordNotFound)
+
+	payments.payment = &model.PaymentHistory{OutTradeNo: "T003", Status: "FAILED", LoanID: 1, Amount: 110}
+	assert.NoError(t, n.notify(context.Background(), "T003", ""))
+	payments.payment = &model.PaymentHistory{OutTradeNo: "T004", Status: "SUCCESS", Amount: 110}
+	assert.NoError(t, n.notify(context.Background(), "T004", ""))
+	assert.Len(t, smsFake.sent, 1)
+}
+
+func TestPaymentNotifier_notify_retry(t *testing.T) {
+	n, payments, smsFake, wechat := newTestNotifier()
+	payments.errs = []error{errors.New("i/o timeout"), errors.New("i/o timeout")}
+	wechat.errs = []error{&sms.Error{Code: "-1", Temporary: true}}
+	assert.NoError(t, n.notify(context.Background(), "T001", "openid-1"))
+	assert.Len(t, smsFake.sent, 1)
+	assert.Len(t, wechat.openids, 2)
+
+	// retries are exhausted
+	n, payments, smsFake, _ = newTestNotifier()
+	payments.errs = []error{errors.New("i/o timeout"), errors.New("i/o timeout"), errors.New("i/o timeout")}
+	assert.Error(t, n.notify(context.Background(), "T001", ""))
+	assert.Empty(t, smsFake.sent)
+
+	// errors that are not temporary are not retried, the sms is still sent
+	n, _, smsFake, wechat = newTestNotifier()
+	wechat.errs = []error{&sms.Error{Code: "43101"}}
+	assert.Error(t, n.notify(context.Background(), "T001", "openid-1"))
+	assert.Len(t, smsFake.sent, 1)
+	assert.Len(t, wechat.openids, 1)
+
+	n, _, smsFake, _ = newTestNotifier()
+	smsFake.err = errors.New("send error")
+	assert.Error(t, n.notify(context.Background(), "T001", ""))
+}
+
+// staleHistory a replica checking the history before the confirmation of another replica is recorded
+type staleHistory struct{}
+
+func (staleHistory) ExistsByBizKey(ctx context.Context, bizKey string) (bool, error) {
+	return false, nil
+}
+
+func TestPaymentNotifier_notify_replicas(t *testing.T) {
+	n, _, smsFake, wechat := newTestNotifier()
+	assert.NoError(t, n.notify(context.Background(), "T001", "openid-1"))
+
+	// the insert of the other replica loses, neither the sms nor the wechat message is sent again
+	n.history = staleHistory{}
+	assert.NoError(t, n.notify(context.Background(), "T001", "openid-1"))
+	assert.Len(t, smsFake.sent, 1)
+	assert.Len(t, wechat.openids, 1)
+}
@@ -0,0 +1,170 @@
+package notice
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"lol/internal/config"
+	"lol/internal/sms"
+)
+
+const (
+	wechatEndpoint = "https://api.weixin.qq.com"
+	wechatTimeout  = 10 * time.Second
+	// the access token is refreshed a while before it expires
+	tokenExpiryMargin = 5 * time.Minute
+)
+
+// error codes of the wechat api
+const (
+	wechatSystemBusy   = -1
+	wechatInvalidToken = 40001
+	wechatTokenExpired = 42001
+)
+
+// WechatSender send subscription messages of the mini program through the wechat api
+type WechatSender struct {
+	conf     config.NoticeWechat
+	endpoint string
+	client   *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	expireAt    time.Time
+}
+
+// NewWechatSender create a wechat sender
+func NewWechatSender(conf config.NoticeWechat) *WechatSender {
+	endpoint := conf.Endpoint
+	if endpoint == "" {
+		endpoint = wechatEndpoint
+	}
+	return &WechatSender{
+		conf:     conf,
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: wechatTimeout},
+	}
+}
+
+type wechatReply struct {
+	ErrCode     int    `json:"errcode"`
+	ErrMsg      string `json:"errmsg"`
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"` // unit(second)
+}
+
+type wechatMessage struct {
+	ToUser     string                       `json:"touser"`
+	TemplateID string                       `json:"template_id"`
+	Page       string                       `json:"page,omitempty"`
+	Data       map[string]map[string]string `json:"data"`
+}
+
+// Send the subscription message to the openid, the data of the config is rendered with the params.
+// The message is rejected with 43101 if the user has not subscribed to the template, it is not retried.
+func (s *WechatSender) Send(ctx context.Context, openid string, params map[string]string) error {
+	data := make(map[string]map[string]string, len(s.conf.Data))
+	for key, content := range s.conf.Data {
+		value, _, err := sms.Render(content, params)
+		if err != nil {
+			return err
+		}
+		data[key] = map[string]string{"value": value}
+	}
+	payload, err := json.Marshal(&wechatMessage{
+		ToUser:     openid,
+		TemplateID: s.conf.TemplateID,
+		Page:       s.conf.Page,
+		Data:       data,
+	})
+	if err != nil {
+		return err
+	}
+
+	token, err := s.getAccessToken(ctx)
+	if err != nil {
+		return err
+	}
+	reply := &wechatReply{}
+	err = s.do(ctx, http.MethodPost, "/cgi-bin/message/subscribe/send?access_token="+url.QueryEscape(token), payload, reply)
+	if err != nil {
+		return err
+	}
+	if reply.ErrCode == wechatInvalidToken || reply.ErrCode == wechatTokenExpired {
+		// the token was refreshed by another server of the same app, get a new one on retry
+		s.resetAccessToken(token)
+	}
+	return replyError(reply)
+}
+
+// getAccessToken the cached access token, a new one is requested if it is about to expire
+func (s *WechatSender) getAccessToken(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.accessToken != "" && time.Now().Before(s.expireAt) {
+		return s.accessToken, nil
+	}
+
+	query := url.Values{}
+	query.Set("grant_type", "client_credential")
+	query.Set("appid", s.conf.AppID)
+	query.Set("secret", s.conf.AppSecret)
+	reply := &wechatReply{}
+	if err := s.do(ctx, http.MethodGet, "/cgi-bin/token?"+query.Encode(), nil, reply); err != nil {
+		return "", err
+	}
+	if err := replyError(reply); err != nil {
+		return "", err
+	}
+	s.accessToken = reply.AccessToken
+	s.expireAt = time.Now().Add(time.Duration(reply.ExpiresIn)*time.Second - tokenExpiryMargin)
+	return s.accessToken, nil
+}
+
+// resetAccessToken discard the token if it is still the cached one
+func (s *WechatSender) resetAccessToken(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.accessToken == token {
+		s.accessToken = ""
+	}
+}
+
+func (s *WechatSender) do(ctx context.Context, method string, path string, payload []byte, reply *wechatReply) error {
+	req, err := http.NewRequestWithContext(ctx, method, s.endpoint+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint
+
+	if err = json.NewDecoder(resp.Body).Decode(reply); err != nil {
+		return &sms.Error{Code: resp.Status, Message: err.Error(), Temporary: resp.StatusCode >= http.StatusInternalServerError}
+	}
+	return nil
+}
+
+// replyError the error of the reply, nil if errcode is 0
+func replyError(reply *wechatReply) error {
+	if reply.ErrCode == 0 {
+		return nil
+	}
+	switch reply.ErrCode {
+	case wechatSystemBusy, wechatInvalidToken, wechatTokenExpired:
+		return &sms.Error{Code: strconv.Itoa(reply.ErrCode), Message: reply.ErrMsg, Temporary: true}
+	}
+	return &sms.Error{Code: strconv.Itoa(reply.ErrCode), Message: reply.ErrMsg}
+}
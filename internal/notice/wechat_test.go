@@ -0,0 +1,74 @@
+package notice
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"lol/internal/config"
+	"lol/internal/sms"
+)
+
+func TestWechatSender_Send(t *testing.T) {
+	var (
+		tokens   int
+		messages []*wechatMessage
+		replies  = []string{`{"errcode":0,"errmsg":"ok"}`}
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cgi-bin/token":
+			assert.Equal(t, "wx123", r.URL.Query().Get("appid"))
+			assert.Equal(t, "secret", r.URL.Query().Get("secret"))
+			tokens++
+			_, _ = w.Write([]byte(`{"access_token":"token","expires_in":7200}`))
+		case "/cgi-bin/message/subscribe/send":
+			assert.Equal(t, "token", r.URL.Query().Get("access_token"))
+			msg := &wechatMessage{}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(msg))
+			messages = append(messages, msg)
+			_, _ = w.Write([]byte(replies[0]))
+			replies = replies[1:]
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	s := NewWechatSender(config.NoticeWechat{
+		AppID:      "wx123",
+		AppSecret:  "secret",
+		TemplateID: "tpl",
+		Page:       "pages/loan/index",
+		Data:       map[string]string{"amount1": "{amount}", "thing2": "第{installment}期"},
+		Endpoint:   server.URL,
+	})
+	params := map[string]string{"amount": "110.00", "installment": "3"}
+	assert.NoError(t, s.Send(context.Background(), "openid-1", params))
+	assert.Equal(t, &wechatMessage{
+		ToUser:     "openid-1",
+		TemplateID: "tpl",
+		Page:       "pages/loan/index",
+		Data: map[string]map[string]string{
+			"amount1": {"value": "110.00"},
+			"thing2":  {"value": "第3期"},
+		},
+	}, messages[0])
+
+	// the token is cached until it is rejected
+	replies = []string{`{"errcode":40001,"errmsg":"invalid credential"}`, `{"errcode":43101,"errmsg":"user refuse to accept the msg"}`}
+	err := s.Send(context.Background(), "openid-1", params)
+	assert.True(t, sms.IsTemporary(err))
+	assert.Equal(t, 1, tokens)
+	err = s.Send(context.Background(), "openid-1", params)
+	assert.Error(t, err)
+	assert.False(t, sms.IsTemporary(err))
+	assert.Equal(t, 2, tokens)
+
+	// a variable of the data is missing
+	assert.Error(t, s.Send(context.Background(), "openid-1", map[string]string{"amount": "110.00"}))
+}
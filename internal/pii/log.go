@@ -0,0 +1,164 @@
+package pii
+
+import (
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Any a log field of the value with the marked fields masked, use it instead of logger.Any for forms and models
+func Any(key string, v interface{}) zap.Field {
+	return zap.Any(key, Mask(v))
+}
+
+// String a log field of the sensitive value of the kind, masked
+func String(key string, kind string, value string) zap.Field {
+	return zap.String(key, MaskString(kind, value))
+}
+
+// secretNames the json names of the credentials in the request and response bodies, they are always redacted
+// in log lines, e.g. the passwords of the admin login and the tokens it issues
+var secretNames = []string{"password", "newPassword", "token", "challengeToken", "otp"}
+
+var (
+	registryMu sync.RWMutex
+	jsonKinds  = map[string]string{} // json name of the marked fields -> kind
+	jsonRegexp *regexp.Regexp
+)
+
+func init() {
+	Register()
+}
+
+// Register collect the json names of the marked fields of the types of the values, string log fields
+// written by a logger of WrapLogger have the values of these names redacted, e.g. the request body.
+// The values of the secret names, such as password and token, are redacted without registering.
+func Register(values ...interface{}) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	// the map is replaced instead of modified, RedactJSON reads it without the lock
+	kinds := make(map[string]string, len(jsonKinds))
+	for name, kind := range jsonKinds {
+		kinds[name] = kind
+	}
+	for _, v := range values {
+		t := reflect.TypeOf(v)
+		for t != nil && t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t == nil || t.Kind() != reflect.Struct {
+			continue
+		}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			kind, ok := field.Tag.Lookup(TagName)
+			if !ok {
+				continue
+			}
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if name == "" {
+				name = field.Name
+			}
+			if name != "-" {
+				kinds[name] = kind
+			}
+		}
+	}
+	// a marked field of the same name does not weaken the redaction of a secret
+	for _, name := range secretNames {
+		kinds[name] = KindSecret
+	}
+	jsonKinds = kinds
+
+	names := make([]string, 0, len(kinds))
+	for name := range kinds {
+		names = append(names, regexp.QuoteMeta(name))
+	}
+	if len(names) == 0 {
+		return
+	}
+	sort.Strings(names)
+	// "name":"value", the value may be truncated by the logging middleware so the closing quote is optional
+	jsonRegexp = regexp.MustCompile(`"(` + strings.Join(names, "|") + `)"(\s*:\s*)"((?:[^"\\]|\\.)*)`)
+}
+
+// registeredKind the kind of the registered json name
+func registeredKind(name string) (string, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	kind, ok := jsonKinds[name]
+	return kind, ok
+}
+
+// RedactJSON mask the values of the registered json names in the text, the text does not need to be valid json
+func RedactJSON(text string) string {
+	registryMu.RLock()
+	re, kinds := jsonRegexp, jsonKinds
+	registryMu.RUnlock()
+	if re == nil || !strings.Contains(text, `"`) {
+		return text
+	}
+	return re.ReplaceAllStringFunc(text, func(match string) string {
+		sub := re.FindStringSubmatch(match)
+		return `"` + sub[1] + `"` + sub[2] + `"` + MaskString(kinds[sub[1]], sub[3])
+	})
+}
+
+// WrapLogger returns a logger which redacts every field it writes: string fields named as a registered json name,
+// the values of registered json names in string fields and the marked fields of structs are masked.
+// A nil logger is returned as it is.
+func WrapLogger(l *zap.Logger) *zap.Logger {
+	if l == nil {
+		return nil
+	}
+	return l.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &redactCore{Core: core}
+	}))
+}
+
+type redactCore struct {
+	zapcore.Core
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(redactFields(fields))}
+}
+
+func (c *redactCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *redactCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(entry, redactFields(fields))
+}
+
+// redactFields returns the fields with the sensitive values masked, fields is not modified
+func redactFields(fields []zapcore.Field) []zapcore.Field {
+	redacted := make([]zapcore.Field, len(fields))
+	for i, f := range fields {
+		switch f.Type {
+		case zapcore.StringType:
+			if kind, ok := registeredKind(f.Key); ok {
+				f.String = MaskString(kind, f.String)
+			} else {
+				f.String = RedactJSON(f.String)
+			}
+		case zapcore.ByteStringType:
+			if b, ok := f.Interface.([]byte); ok {
+				f.Interface = []byte(RedactJSON(string(b)))
+			}
+		case zapcore.ReflectType:
+			f.Interface = Mask(f.Interface)
+		}
+		redacted[i] = f
+	}
+	return redacted
+}
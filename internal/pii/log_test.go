@@ -0,0 +1,49 @@
+package pii
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRedactJSON(t *testing.T) {
+	Register(person{}, &person{}, "not a struct")
+	assert.Equal(t, `{"name":"张*","userID":"4401**********1234","mobile" : "138****5678","age":30}`,
+		RedactJSON(`{"name":"张三","userID":"440101199001011234","mobile" : "13812345678","age":30}`))
+	// the body may be truncated by the logging middleware
+	assert.Equal(t, `{"mobile":"138**3456`, RedactJSON(`{"mobile":"138123456`))
+	assert.Equal(t, `{"product":"abc"}`, RedactJSON(`{"product":"abc"}`))
+
+	// the credentials are redacted without registering
+	assert.Equal(t, `{"username":"admin","password":"******","newPassword":"******"}`,
+		RedactJSON(`{"username":"admin","password":"secret123","newPassword":"secret456"}`))
+	assert.Equal(t, `{"data":{"token":"******","challengeToken":"******"}}`,
+		RedactJSON(`{"data":{"token":"eyJhbGciOi.x.y","challengeToken":"a1b2c3"}}`))
+	assert.Equal(t, `{"mobile":"138****5678","otp":"******"}`, RedactJSON(`{"mobile":"13812345678","otp":"123456"}`))
+}
+
+func TestWrapLogger(t *testing.T) {
+	Register(person{})
+	core, logs := observer.New(zap.InfoLevel)
+	l := WrapLogger(zap.New(core)).With(zap.String("mobile", "13812345678"), zap.String("token", "eyJhbGciOi.x.y"))
+	l.Info("request",
+		zap.String("body", `{"userID":"440101199001011234"}`),
+		zap.ByteString("response", []byte(`{"mobile":"13812345678"}`)),
+		zap.Any("form", &person{UserID: "440101199001011234"}),
+	)
+
+	entries := logs.All()
+	assert.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, "138****5678", fields["mobile"])
+	assert.Equal(t, "******", fields["token"])
+	assert.Equal(t, `{"userID":"4401**********1234"}`, fields["body"])
+	assert.Equal(t, `{"mobile":"138****5678"}`, fields["response"])
+	assert.Equal(t, "4401**********1234", fields["form"].(*person).UserID)
+
+	assert.Nil(t, WrapLogger(nil))
+	assert.Equal(t, "138****5678", Any("form", person{Mobile: "13812345678"}).Interface.(person).Mobile)
+	assert.Equal(t, "138****5678", String("mobile", KindMobile, "13812345678").String)
+}
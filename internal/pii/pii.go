@@ -0,0 +1,229 @@
+// Package pii mask personal information in api responses and logs.
+//
+// Sensitive string fields are marked with the struct tag `pii:"{kind}"`, Mask returns a copy of a value
+// with every marked field masked, e.g. the ID number 440101199001011234 is masked to 4401**********1234.
+// The json names of the marked fields of the registered types are also redacted in log lines, see WrapLogger.
+package pii
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// TagName the struct tag marking a sensitive field, its value is the kind of the field
+const TagName = "pii"
+
+// kinds of the sensitive fields
+const (
+	KindIDCard   = "idcard"   // ID number, the first 4 and the last 4 characters are kept
+	KindMobile   = "mobile"   // mobile number, the first 3 and the last 4 digits are kept
+	KindName     = "name"     // name, the first character is kept
+	KindCarPlate = "carplate" // car plate, the province and the city are kept
+	KindSecret   = "secret"   // password, token or code, replaced entirely without keeping the length
+)
+
+// secretMask the masked value of the secrets
+const secretMask = "******"
+
+// MaskString mask the value of the kind, unknown kinds are masked entirely
+func MaskString(kind string, value string) string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return ""
+	}
+	switch kind {
+	case KindIDCard:
+		return maskMiddle(value, 4, 4)
+	case KindMobile:
+		return maskMiddle(value, 3, 4)
+	case KindName:
+		return maskMiddle(value, 1, 0)
+	case KindCarPlate:
+		return maskMiddle(value, 2, 0)
+	case KindSecret:
+		return secretMask
+	}
+	return maskMiddle(value, 0, 0)
+}
+
+// maskMiddle keep the prefix and the suffix of the value and replace the other characters with *,
+// values too short to keep both are masked entirely
+func maskMiddle(value string, prefix int, suffix int) string {
+	runes := []rune(value)
+	if len(runes) <= prefix+suffix {
+		return strings.Repeat("*", len(runes))
+	}
+	return string(runes[:prefix]) + strings.Repeat("*", len(runes)-prefix-suffix) + string(runes[len(runes)-suffix:])
+}
+
+// Mask returns a copy of v with the marked fields of its structs masked, v itself is not modified.
+// Structs, pointers, slices, arrays, maps and interfaces are walked, values without marked fields are shared.
+func Mask[T any](v T) T {
+	masked, changed := maskValue(reflect.ValueOf(&v).Elem())
+	if !changed {
+		return v
+	}
+	return masked.Interface().(T)
+}
+
+// maskValue returns a masked copy of v and true, or v itself and false if it has nothing to mask
+func maskValue(v reflect.Value) (reflect.Value, bool) {
+	if !v.IsValid() || !mayHavePII(v.Type()) {
+		return v, false
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v, false
+		}
+		elem, changed := maskValue(v.Elem())
+		if !changed {
+			return v, false
+		}
+		p := reflect.New(elem.Type())
+		p.Elem().Set(elem)
+		return p, true
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return v, false
+		}
+		elem, changed := maskValue(v.Elem())
+		if !changed {
+			return v, false
+		}
+		i := reflect.New(v.Type()).Elem()
+		i.Set(elem)
+		return i, true
+
+	case reflect.Struct:
+		s := reflect.New(v.Type()).Elem()
+		s.Set(v)
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			kind, marked := field.Tag.Lookup(TagName)
+			if marked && field.Type.Kind() == reflect.String {
+				s.Field(i).SetString(MaskString(kind, s.Field(i).String()))
+				continue
+			}
+			// the optional fields of the update requests
+			if marked && field.Type == stringPtrType {
+				if !s.Field(i).IsNil() {
+					masked := MaskString(kind, s.Field(i).Elem().String())
+					s.Field(i).Set(reflect.ValueOf(&masked))
+				}
+				continue
+			}
+			if masked, changed := maskValue(s.Field(i)); changed {
+				s.Field(i).Set(masked)
+			}
+		}
+		return s, true
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return v, false
+		}
+		var c reflect.Value
+		if v.Kind() == reflect.Slice {
+			c = reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		} else {
+			c = reflect.New(v.Type()).Elem()
+		}
+		for i := 0; i < v.Len(); i++ {
+			masked, _ := maskValue(v.Index(i))
+			c.Index(i).Set(masked)
+		}
+		return c, true
+
+	case reflect.Map:
+		if v.IsNil() {
+			return v, false
+		}
+		m := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			masked, _ := maskValue(iter.Value())
+			m.SetMapIndex(iter.Key(), masked)
+		}
+		return m, true
+	}
+	return v, false
+}
+
+var piiTypes sync.Map // reflect.Type -> bool
+
+var stringPtrType = reflect.TypeOf((*string)(nil))
+
+// mayHavePII whether values of the type may contain marked fields, interfaces are checked by their dynamic values
+func mayHavePII(t reflect.Type) bool {
+	if v, ok := piiTypes.Load(t); ok {
+		return v.(bool)
+	}
+	has := hasPII(t, map[reflect.Type]bool{})
+	piiTypes.Store(t, has)
+	return has
+}
+
+// hasPII check the type, visiting holds the types being checked so recursive types terminate
+func hasPII(t reflect.Type, visiting map[reflect.Type]bool) bool {
+	if visiting[t] {
+		return false
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	switch t.Kind() {
+	case reflect.Interface:
+		return true
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return hasPII(t.Elem(), visiting)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			if _, ok := field.Tag.Lookup(TagName); ok || hasPII(field.Type, visiting) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Fields the json names of the marked fields of the struct that are not empty, i.e. the personal information
+// revealed by the struct if it is not masked
+func Fields(v interface{}) []string {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var fields []string
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if _, ok := field.Tag.Lookup(TagName); !ok || field.Type.Kind() != reflect.String || rv.Field(i).String() == "" {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			name = field.Name
+		}
+		fields = append(fields, name)
+	}
+	return fields
+}
@@ -0,0 +1,84 @@
+package pii
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type person struct {
+	Name   string `json:"name" pii:"name"`
+	UserID string `json:"userID" pii:"idcard"`
+	Mobile string `json:"mobile" pii:"mobile"`
+	Age    int    `json:"age"`
+}
+
+type family struct {
+	Owner    *person
+	Members  []*person
+	Extra    map[string]interface{}
+	Comment  string
+	internal *person
+}
+
+func TestMaskString(t *testing.T) {
+	assert.Equal(t, "4401**********1234", MaskString(KindIDCard, "440101199001011234"))
+	assert.Equal(t, "138****5678", MaskString(KindMobile, "13812345678"))
+	assert.Equal(t, "张**", MaskString(KindName, "张三丰"))
+	assert.Equal(t, "*", MaskString(KindName, "张"))
+	assert.Equal(t, "粤A*****", MaskString(KindCarPlate, "粤A12345"))
+	assert.Equal(t, "******", MaskString(KindIDCard, "011234"))
+	assert.Equal(t, "******", MaskString(KindSecret, "p@ssw0rd-long-enough"))
+	assert.Equal(t, "***", MaskString("other", "abc"))
+	assert.Equal(t, "", MaskString(KindMobile, " "))
+}
+
+func TestMask(t *testing.T) {
+	p := &person{Name: "张三", UserID: "440101199001011234", Mobile: "13812345678", Age: 30}
+	masked := Mask(p)
+	assert.Equal(t, &person{Name: "张*", UserID: "4401**********1234", Mobile: "138****5678", Age: 30}, masked)
+	assert.Equal(t, "440101199001011234", p.UserID)
+
+	f := family{
+		Owner:    p,
+		Members:  []*person{p, nil},
+		Extra:    map[string]interface{}{"person": *p, "count": 1},
+		Comment:  "13812345678",
+		internal: p,
+	}
+	mf := Mask(f)
+	assert.Equal(t, "138****5678", mf.Owner.Mobile)
+	assert.Equal(t, "138****5678", mf.Members[0].Mobile)
+	assert.Nil(t, mf.Members[1])
+	assert.Equal(t, "138****5678", mf.Extra["person"].(person).Mobile)
+	assert.Equal(t, 1, mf.Extra["count"])
+	assert.Equal(t, "13812345678", mf.Comment)
+	assert.Equal(t, "13812345678", f.Owner.Mobile)
+
+	var list []*person
+	assert.Nil(t, Mask(list))
+	assert.Equal(t, 3, Mask(3))
+	var v interface{} = []person{*p}
+	assert.Equal(t, "138****5678", Mask(v).([]person)[0].Mobile)
+}
+
+func TestMask_optional(t *testing.T) {
+	type update struct {
+		Mobile *string `json:"mobile" pii:"mobile"`
+		Name   *string `json:"name" pii:"name"`
+	}
+	mobile := "13812345678"
+	u := &update{Mobile: &mobile}
+	masked := Mask(u)
+	assert.Equal(t, "138****5678", *masked.Mobile)
+	assert.Nil(t, masked.Name)
+	assert.Equal(t, "13812345678", mobile)
+}
+
+func TestFields(t *testing.T) {
+	assert.Equal(t, []string{"userID", "mobile"}, Fields(&person{UserID: "440101199001011234", Mobile: "13812345678"}))
+	assert.Equal(t, []string{"name"}, Fields(person{Name: "张三"}))
+	assert.Nil(t, Fields(&person{Age: 30}))
+	assert.Nil(t, Fields((*person)(nil)))
+	assert.Nil(t, Fields("13812345678"))
+}
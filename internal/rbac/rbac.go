@@ -0,0 +1,75 @@
+// Package rbac defines the roles of admin users and the permissions granted to them.
+package rbac
+
+// roles of admin users
+const (
+	RoleViewer   = "viewer"   // 只读
+	RoleOperator = "operator" // 业务操作员，管理借款和短信
+	RoleFinance  = "finance"  // 财务，管理支付记录和产品
+	RoleAdmin    = "admin"    // 管理员，拥有所有权限
+)
+
+// Permission the permission required by a route
+type Permission string
+
+// permissions of admin routes
+const (
+	// Public the route is open to everyone, e.g. borrower routes and payment callbacks
+	Public Permission = "public"
+	// Authenticated the route is open to every logged in admin user whatever the role
+	Authenticated Permission = "authenticated"
+	// Onboarding the route is open to every logged in admin user, including the ones who must change the password
+	// before any other route is allowed
+	Onboarding Permission = "onboarding"
+
+	LoanRead     Permission = "loan:read"
+	LoanWrite    Permission = "loan:write"
+	ProductRead  Permission = "product:read"
+	ProductWrite Permission = "product:write"
+	PaymentRead  Permission = "payment:read"
+	PaymentWrite Permission = "payment:write"
+	SmsRead      Permission = "sms:read"
+	SmsWrite     Permission = "sms:write"
+	UserManage   Permission = "user:manage"
+	// PIIView view the full ID numbers, mobiles and names in responses, they are masked without it
+	PIIView Permission = "pii:view"
+	// AuditRead read the audit logs, such as who viewed the personal information and who changed the records
+	AuditRead Permission = "audit:read"
+	// SubjectManage export and anonymize the data of a borrower on the request of the data subject
+	SubjectManage Permission = "subject:manage"
+	// TrashPurge delete the records in the trash permanently, only admins can, the other roles can restore them
+	TrashPurge Permission = "trash:purge"
+)
+
+var readPermissions = []Permission{LoanRead, ProductRead, PaymentRead, SmsRead}
+
+var rolePermissions = map[string][]Permission{
+	RoleViewer:   readPermissions,
+	RoleOperator: append([]Permission{LoanWrite, SmsWrite}, readPermissions...),
+	RoleFinance:  append([]Permission{PaymentWrite, ProductWrite}, readPermissions...),
+	RoleAdmin: append([]Permission{LoanWrite, ProductWrite, PaymentWrite, SmsWrite, UserManage, PIIView, AuditRead,
+		SubjectManage, TrashPurge},
+		readPermissions...),
+}
+
+// IsRole whether the role exists
+func IsRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// HasPermission whether the role is granted the permission
+func HasPermission(role string, permission Permission) bool {
+	if permission == Public {
+		return true
+	}
+	if permission == Authenticated || permission == Onboarding {
+		return IsRole(role)
+	}
+	for _, v := range rolePermissions[role] {
+		if v == permission {
+			return true
+		}
+	}
+	return false
+}
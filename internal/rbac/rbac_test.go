@@ -0,0 +1,44 @@
+package rbac
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHasPermission(t *testing.T) {
+	assert.True(t, HasPermission(RoleViewer, LoanRead))
+	assert.False(t, HasPermission(RoleViewer, LoanWrite))
+
+	assert.True(t, HasPermission(RoleOperator, LoanWrite))
+	assert.False(t, HasPermission(RoleOperator, PaymentWrite))
+
+	assert.True(t, HasPermission(RoleFinance, PaymentWrite))
+	assert.False(t, HasPermission(RoleFinance, LoanWrite))
+
+	assert.True(t, HasPermission(RoleAdmin, UserManage))
+	assert.False(t, HasPermission(RoleOperator, UserManage))
+
+	assert.True(t, HasPermission(RoleAdmin, PIIView))
+	assert.False(t, HasPermission(RoleOperator, PIIView))
+	assert.False(t, HasPermission(RoleViewer, PIIView))
+	assert.True(t, HasPermission(RoleAdmin, AuditRead))
+	assert.False(t, HasPermission(RoleFinance, AuditRead))
+	assert.True(t, HasPermission(RoleAdmin, SubjectManage))
+	assert.False(t, HasPermission(RoleOperator, SubjectManage))
+	assert.True(t, HasPermission(RoleAdmin, TrashPurge))
+	assert.False(t, HasPermission(RoleFinance, TrashPurge))
+
+	// unknown roles have no permission except public
+	assert.False(t, HasPermission("guest", LoanRead))
+	assert.True(t, HasPermission("guest", Public))
+	assert.True(t, HasPermission(RoleViewer, Authenticated))
+	assert.False(t, HasPermission("guest", Authenticated))
+	assert.True(t, HasPermission(RoleViewer, Onboarding))
+	assert.False(t, HasPermission("guest", Onboarding))
+}
+
+func TestIsRole(t *testing.T) {
+	assert.True(t, IsRole(RoleFinance))
+	assert.False(t, IsRole("root"))
+}
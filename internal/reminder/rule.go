@@ -0,0 +1,98 @@
+// Package reminder send repayment reminder text messages of unpaid installments according to the configured rules.
+package reminder
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"lol/internal/config"
+	"lol/internal/repayment"
+)
+
+// BizKeyPrefix the prefix of the sms_history biz_key of reminders, used to count the reminders of a day
+const BizKeyPrefix = "reminder:"
+
+const maxNameLength = 24
+
+// Rule a reminder rule, an installment is reminded on its due date plus OffsetDays
+type Rule struct {
+	Name       string
+	OffsetDays int // 负数为到期前天数，0为到期当天，正数为逾期天数
+	Template   string
+	DailyCap   int // 每个借款人每天最多收到的提醒条数，0为不限制
+
+	quietStart int // 免打扰开始时间，当天的分钟数，-1为不设置
+	quietEnd   int
+}
+
+// NewRule parse the rule of the config
+func NewRule(conf config.ReminderRule) (*Rule, error) {
+	if conf.Name == "" || conf.Template == "" {
+		return nil, fmt.Errorf("reminder rule %q: name and template are required", conf.Name)
+	}
+	// the biz_key column is varchar(64)
+	if len(conf.Name) > maxNameLength {
+		return nil, fmt.Errorf("reminder rule %q: name is longer than %d", conf.Name, maxNameLength)
+	}
+	r := &Rule{
+		Name:       conf.Name,
+		OffsetDays: conf.OffsetDays,
+		Template:   conf.Template,
+		DailyCap:   conf.DailyCap,
+		quietStart: -1,
+		quietEnd:   -1,
+	}
+	if conf.QuietStart != "" || conf.QuietEnd != "" {
+		var err error
+		if r.quietStart, err = parseClock(conf.QuietStart); err != nil {
+			return nil, fmt.Errorf("reminder rule %q: quietStart %v", conf.Name, err)
+		}
+		if r.quietEnd, err = parseClock(conf.QuietEnd); err != nil {
+			return nil, fmt.Errorf("reminder rule %q: quietEnd %v", conf.Name, err)
+		}
+	}
+	return r, nil
+}
+
+// parseClock parse HH:MM to minutes of the day
+func parseClock(s string) (int, error) {
+	hour, minute, ok := strings.Cut(s, ":")
+	if !ok {
+		return 0, fmt.Errorf("%q is not HH:MM", s)
+	}
+	h, err1 := strconv.Atoi(hour)
+	m, err2 := strconv.Atoi(minute)
+	if err1 != nil || err2 != nil || h < 0 || h > 23 || m < 0 || m > 59 {
+		return 0, fmt.Errorf("%q is not HH:MM", s)
+	}
+	return h*60 + m, nil
+}
+
+// IsQuiet whether t is in the quiet hours of the rule, the quiet hours may wrap midnight, e.g. 21:00-08:00
+func (r *Rule) IsQuiet(t time.Time) bool {
+	if r.quietStart < 0 || r.quietStart == r.quietEnd {
+		return false
+	}
+	now := t.Hour()*60 + t.Minute()
+	if r.quietStart < r.quietEnd {
+		return now >= r.quietStart && now < r.quietEnd
+	}
+	return now >= r.quietStart || now < r.quietEnd
+}
+
+// Matches whether the installment should be reminded on the day of t
+func (r *Rule) Matches(installment *repayment.Installment, t time.Time) bool {
+	if installment.IsPaid() {
+		return false
+	}
+	y1, m1, d1 := installment.DueDate.AddDate(0, 0, r.OffsetDays).Date()
+	y2, m2, d2 := t.Date()
+	return y1 == y2 && m1 == m2 && d1 == d2
+}
+
+// BizKey the dedup key of the reminder of an installment, a reminder is sent at most once
+func (r *Rule) BizKey(loanID uint64, seq int) string {
+	return fmt.Sprintf("%s%s:%d:%d", BizKeyPrefix, r.Name, loanID, seq)
+}
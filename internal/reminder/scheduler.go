@@ -0,0 +1,229 @@
+package reminder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-dev-frame/sponge/pkg/app"
+	"github.com/go-dev-frame/sponge/pkg/logger"
+
+	"lol/internal/cache"
+	"lol/internal/config"
+	"lol/internal/dao"
+	"lol/internal/database"
+	"lol/internal/model"
+	"lol/internal/repayment"
+	"lol/internal/sms"
+)
+
+var _ app.IServer = (*Scheduler)(nil)
+
+const defaultInterval = 60 * time.Minute
+
+type loanSource interface {
+	GetUnsettled(ctx context.Context) ([]*model.Loan, error)
+}
+
+type historySource interface {
+	ExistsByBizKey(ctx context.Context, bizKey string) (bool, error)
+	CountByMobileSince(ctx context.Context, mobile string, bizKeyPrefix string, since time.Time) (int64, error)
+}
+
+type smsSender interface {
+	Send(ctx context.Context, req *sms.Request) (*model.SmsHistory, error)
+}
+
+// Scheduler scan the unpaid installments periodically and send the reminders of the rules,
+// every reminder is recorded in sms_history with its unique biz_key before sending, so neither a restart
+// nor another replica sends it twice.
+type Scheduler struct {
+	loans    loanSource
+	history  historySource
+	sender   smsSender
+	rules    []*Rule
+	interval time.Duration
+	now      func() time.Time
+
+	ctx    context.Context // canceled by Stop
+	cancel context.CancelFunc
+}
+
+// NewScheduler create the scheduler with the config, panic if a rule is invalid
+func NewScheduler() *Scheduler {
+	smsDao := dao.NewSmsHistoryDao(
+		database.GetDB(), // db driver is mysql
+		cache.NewSmsHistoryCache(database.GetCacheType()),
+	)
+	loanDao := dao.NewLoanDao(
+		database.GetDB(),
+		cache.NewLoanCache(database.GetCacheType()),
+	)
+
+	templateDao := dao.NewSmsTemplateDao(
+		database.GetDB(),
+		cache.NewSmsTemplateCache(database.GetCacheType()),
+	)
+
+	s, err := NewSchedulerWithSource(config.Get().Reminder, loanDao, smsDao, sms.NewService(smsDao, templateDao))
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// NewSchedulerWithSource create the scheduler with the loans, the sms history and the sender
+func NewSchedulerWithSource(conf config.Reminder, loans loanSource, history historySource, sender smsSender) (*Scheduler, error) {
+	names := map[string]bool{}
+	rules := make([]*Rule, 0, len(conf.Rules))
+	for _, v := range conf.Rules {
+		rule, err := NewRule(v)
+		if err != nil {
+			return nil, err
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("reminder rule %q is duplicated", rule.Name)
+		}
+		names[rule.Name] = true
+		rules = append(rules, rule)
+	}
+
+	interval := time.Duration(conf.Interval) * time.Minute
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		loans:    loans,
+		history:  history,
+		sender:   sender,
+		rules:    rules,
+		interval: interval,
+		now:      time.Now,
+		ctx:      ctx,
+		cancel:   cancel,
+	}, nil
+}
+
+// Start scan immediately and then every interval, until Stop is called
+func (s *Scheduler) Start() error {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		sent, err := s.Run(s.ctx)
+		if err != nil && s.ctx.Err() == nil {
+			logger.Error("scan repayment reminders error", logger.Err(err))
+		} else if sent > 0 {
+			logger.Info("repayment reminders sent", logger.Int("count", sent))
+		}
+
+		select {
+		case <-s.ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stop the scheduler, a scan in progress is canceled
+func (s *Scheduler) Stop() error {
+	s.cancel()
+	return nil
+}
+
+// String comment
+func (s *Scheduler) String() string {
+	return fmt.Sprintf("repayment reminder scheduler, %d rules, interval %s", len(s.rules), s.interval)
+}
+
+// Run scan the unpaid installments once and send the reminders due now, the number of messages sent is returned.
+// Rules in their quiet hours are skipped, their reminders are sent by the first scan after the quiet hours of the day.
+func (s *Scheduler) Run(ctx context.Context) (int, error) {
+	now := s.now()
+	var rules []*Rule
+	for _, rule := range s.rules {
+		if !rule.IsQuiet(now) {
+			rules = append(rules, rule)
+		}
+	}
+	if len(rules) == 0 {
+		return 0, nil
+	}
+
+	loans, err := s.loans.GetUnsettled(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for _, loan := range loans {
+		if loan.Mobile == "" {
+			continue
+		}
+		schedule := repayment.NewLoanSchedule(loan)
+		schedule.Allocate(loan.PaidMoney)
+		for _, installment := range schedule.Unpaid() {
+			for _, rule := range rules {
+				if !rule.Matches(installment, now) {
+					continue
+				}
+				ok, err := s.remind(ctx, rule, loan, installment, startOfDay)
+				if err != nil {
+					if ctx.Err() != nil {
+						return sent, ctx.Err()
+					}
+					logger.Error("send repayment reminder error", logger.Err(err), logger.String("rule", rule.Name),
+						logger.Uint64("loanID", loan.ID), logger.Int("seq", installment.Seq))
+					continue
+				}
+				if ok {
+					sent++
+				}
+			}
+		}
+	}
+	return sent, nil
+}
+
+// remind send the reminder of the installment, false is returned if it was sent before or the daily cap is reached
+func (s *Scheduler) remind(ctx context.Context, rule *Rule, loan *model.Loan, installment *repayment.Installment, startOfDay time.Time) (bool, error) {
+	bizKey := rule.BizKey(loan.ID, installment.Seq)
+	exists, err := s.history.ExistsByBizKey(ctx, bizKey)
+	if err != nil {
+		return false, err
+	}
+	if exists {
+		return false, nil
+	}
+
+	if rule.DailyCap > 0 {
+		count, err := s.history.CountByMobileSince(ctx, loan.Mobile, BizKeyPrefix, startOfDay)
+		if err != nil {
+			return false, err
+		}
+		if count >= int64(rule.DailyCap) {
+			logger.Info("repayment reminder skipped, daily cap reached", logger.String("rule", rule.Name),
+				logger.Uint64("loanID", loan.ID), logger.Int("seq", installment.Seq))
+			return false, nil
+		}
+	}
+
+	_, err = s.sender.Send(ctx, &sms.Request{
+		UserName: loan.Name,
+		Mobile:   loan.Mobile,
+		Template: rule.Template,
+		Params:   sms.LoanParams(loan, installment),
+		LoanID:   loan.ID,
+		BizKey:   bizKey,
+	})
+	if err != nil {
+		// sent by another replica since the check above
+		if errors.Is(err, dao.ErrBizKeyExists) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,276 @@
+package reminder
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"lol/internal/config"
+	"lol/internal/dao"
+	"lol/internal/model"
+	"lol/internal/repayment"
+	"lol/internal/sms"
+)
+
+type fakeLoans struct {
+	loans []*model.Loan
+	err   error
+}
+
+func (f *fakeLoans) GetUnsettled(ctx context.Context) ([]*model.Loan, error) {
+	return f.loans, f.err
+}
+
+// fakeHistory the sent messages of sms_history, shared with fakeSender
+type fakeHistory struct {
+	sent []*sms.Request
+}
+
+func (f *fakeHistory) ExistsByBizKey(ctx context.Context, bizKey string) (bool, error) {
+	for _, v := range f.sent {
+		if v.BizKey == bizKey {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *fakeHistory) CountByMobileSince(ctx context.Context, mobile string, bizKeyPrefix string, since time.Time) (int64, error) {
+	var count int64
+	for _, v := range f.sent {
+		if v.Mobile == mobile && strings.HasPrefix(v.BizKey, bizKeyPrefix) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+type fakeSender struct {
+	history *fakeHistory
+	err     error
+}
+
+func (f *fakeSender) Send(ctx context.Context, req *sms.Request) (*model.SmsHistory, error) {
+	// the biz_key is unique
+	for _, v := range f.history.sent {
+		if v.BizKey == req.BizKey {
+			return nil, dao.ErrBizKeyExists
+		}
+	}
+	// the history row is written before sending, also when sending fails
+	f.history.sent = append(f.history.sent, req)
+	return &model.SmsHistory{}, f.err
+}
+
+func newTestScheduler(t *testing.T, loans []*model.Loan, now time.Time) (*Scheduler, *fakeHistory, *fakeSender) {
+	history := &fakeHistory{}
+	sender := &fakeSender{history: history}
+	s, err := NewSchedulerWithSource(config.Reminder{
+		Rules: []config.ReminderRule{
+			{Name: "before3", OffsetDays: -3, Template: "repaymentReminder", QuietStart: "21:00", QuietEnd: "09:00", DailyCap: 1},
+			{Name: "overdue1", OffsetDays: 1, Template: "overdueReminder", DailyCap: 2},
+		},
+	}, &fakeLoans{loans: loans}, history, sender)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.now = func() time.Time { return now }
+	return s, history, sender
+}
+
+func TestScheduler_Run(t *testing.T) {
+	createAt := time.Date(2024, 1, 10, 0, 0, 0, 0, time.Local)
+	loan := &model.Loan{
+		ID:             1,
+		Name:           "张三",
+		Mobile:         "13800000000",
+		CarPlate:       "粤A12345",
+		LoanMoney:      1200,
+		LoanPeriod:     12,
+		LoanReturnDate: "15",
+		MonthlyPayment: 110,
+		CreateAt:       &createAt,
+	}
+	// the first installment is due on 2024-02-15
+	now := time.Date(2024, 2, 12, 10, 0, 0, 0, time.Local)
+	s, history, _ := newTestScheduler(t, []*model.Loan{loan}, now)
+
+	sent, err := s.Run(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, "reminder:before3:1:1", history.sent[0].BizKey)
+	assert.Equal(t, "repaymentReminder", history.sent[0].Template)
+	assert.Equal(t, uint64(1), history.sent[0].LoanID)
+	assert.Equal(t, map[string]string{
+		"name": "张三", "carPlate": "粤A12345", "amount": "110.00", "dueDate": "2024-02-15",
+	}, history.sent[0].Params)
+
+	// a restart never sends it twice
+	sent, err = s.Run(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, sent)
+
+	// quiet hours
+	s, history, _ = newTestScheduler(t, []*model.Loan{loan}, time.Date(2024, 2, 12, 22, 0, 0, 0, time.Local))
+	sent, err = s.Run(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, sent)
+	assert.Empty(t, history.sent)
+
+	// the partially paid installment is reminded of the outstanding amount
+	loan.PaidMoney = 50
+	s, history, _ = newTestScheduler(t, []*model.Loan{loan}, time.Date(2024, 2, 16, 10, 0, 0, 0, time.Local))
+	sent, err = s.Run(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, "reminder:overdue1:1:1", history.sent[0].BizKey)
+	assert.Equal(t, "60.00", history.sent[0].Params["amount"])
+
+	// paid installments are not reminded
+	loan.PaidMoney = 110
+	s, _, _ = newTestScheduler(t, []*model.Loan{loan}, time.Date(2024, 2, 16, 10, 0, 0, 0, time.Local))
+	sent, err = s.Run(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, sent)
+}
+
+func TestScheduler_Run_dailyCap(t *testing.T) {
+	createAt := time.Date(2024, 1, 10, 0, 0, 0, 0, time.Local)
+	var loans []*model.Loan
+	for i := 1; i <= 3; i++ {
+		loans = append(loans, &model.Loan{
+			ID:             uint64(i),
+			Mobile:         "13800000000",
+			LoanMoney:      1200,
+			LoanPeriod:     12,
+			LoanReturnDate: "15",
+			MonthlyPayment: 110,
+			CreateAt:       &createAt,
+		})
+	}
+
+	// three loans of the same borrower are overdue, the cap of the rule is 2
+	s, history, _ := newTestScheduler(t, loans, time.Date(2024, 2, 16, 10, 0, 0, 0, time.Local))
+	sent, err := s.Run(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, sent)
+	assert.Len(t, history.sent, 2)
+}
+
+// staleHistory a replica checking the history before the reminder of another replica is recorded
+type staleHistory struct {
+	*fakeHistory
+}
+
+func (f staleHistory) ExistsByBizKey(ctx context.Context, bizKey string) (bool, error) {
+	return false, nil
+}
+
+func TestScheduler_Run_replicas(t *testing.T) {
+	createAt := time.Date(2024, 1, 10, 0, 0, 0, 0, time.Local)
+	loan := &model.Loan{ID: 1, Mobile: "13800000000", LoanMoney: 1200, LoanPeriod: 12,
+		LoanReturnDate: "15", MonthlyPayment: 110, CreateAt: &createAt}
+	now := time.Date(2024, 2, 12, 10, 0, 0, 0, time.Local)
+
+	s, history, _ := newTestScheduler(t, []*model.Loan{loan}, now)
+	sent, err := s.Run(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+
+	// the insert of the other replica loses, the reminder is skipped without an error
+	other, _, _ := newTestScheduler(t, []*model.Loan{loan}, now)
+	other.history = staleHistory{history}
+	other.sender = &fakeSender{history: history}
+	sent, err = other.Run(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, sent)
+	assert.Len(t, history.sent, 1)
+}
+
+func TestScheduler_Run_error(t *testing.T) {
+	createAt := time.Date(2024, 1, 10, 0, 0, 0, 0, time.Local)
+	loan := &model.Loan{ID: 1, Mobile: "13800000000", LoanMoney: 1200, LoanPeriod: 12,
+		LoanReturnDate: "15", MonthlyPayment: 110, CreateAt: &createAt}
+
+	// a failed message is recorded and not sent again
+	s, history, sender := newTestScheduler(t, []*model.Loan{loan}, time.Date(2024, 2, 16, 10, 0, 0, 0, time.Local))
+	sender.err = errors.New("send error")
+	sent, err := s.Run(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, sent)
+	sender.err = nil
+	sent, err = s.Run(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, sent)
+	assert.Len(t, history.sent, 1)
+
+	s, _, _ = newTestScheduler(t, nil, time.Date(2024, 2, 16, 10, 0, 0, 0, time.Local))
+	s.loans = &fakeLoans{err: errors.New("db error")}
+	_, err = s.Run(context.Background())
+	assert.Error(t, err)
+}
+
+func TestScheduler_StartStop(t *testing.T) {
+	s, _, _ := newTestScheduler(t, nil, time.Now())
+	done := make(chan error)
+	go func() {
+		done <- s.Start()
+	}()
+	assert.NoError(t, s.Stop())
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("scheduler not stopped")
+	}
+	assert.Contains(t, s.String(), "2 rules")
+}
+
+func TestNewSchedulerWithSource(t *testing.T) {
+	_, err := NewSchedulerWithSource(config.Reminder{Rules: []config.ReminderRule{
+		{Name: "due", Template: "repaymentDue"},
+		{Name: "due", Template: "repaymentDue"},
+	}}, nil, nil, nil)
+	assert.Error(t, err)
+}
+
+func TestRule(t *testing.T) {
+	_, err := NewRule(config.ReminderRule{Name: "due"})
+	assert.Error(t, err)
+	_, err = NewRule(config.ReminderRule{Name: strings.Repeat("a", 25), Template: "repaymentDue"})
+	assert.Error(t, err)
+	_, err = NewRule(config.ReminderRule{Name: "due", Template: "repaymentDue", QuietStart: "25:00", QuietEnd: "08:00"})
+	assert.Error(t, err)
+	_, err = NewRule(config.ReminderRule{Name: "due", Template: "repaymentDue", QuietStart: "21:00"})
+	assert.Error(t, err)
+
+	// the quiet hours wrap midnight
+	r, err := NewRule(config.ReminderRule{Name: "due", Template: "repaymentDue", QuietStart: "21:00", QuietEnd: "08:30"})
+	assert.NoError(t, err)
+	day := time.Date(2024, 2, 15, 0, 0, 0, 0, time.Local)
+	assert.True(t, r.IsQuiet(day.Add(21*time.Hour)))
+	assert.True(t, r.IsQuiet(day.Add(2*time.Hour)))
+	assert.True(t, r.IsQuiet(day.Add(8*time.Hour+29*time.Minute)))
+	assert.False(t, r.IsQuiet(day.Add(8*time.Hour+30*time.Minute)))
+	assert.False(t, r.IsQuiet(day.Add(20*time.Hour+59*time.Minute)))
+
+	r, err = NewRule(config.ReminderRule{Name: "due", Template: "repaymentDue", QuietStart: "12:00", QuietEnd: "14:00"})
+	assert.NoError(t, err)
+	assert.True(t, r.IsQuiet(day.Add(13*time.Hour)))
+	assert.False(t, r.IsQuiet(day.Add(14*time.Hour)))
+
+	r, err = NewRule(config.ReminderRule{Name: "due", Template: "repaymentDue"})
+	assert.NoError(t, err)
+	assert.False(t, r.IsQuiet(day.Add(23*time.Hour)))
+
+	installment := &repayment.Installment{Seq: 2, DueDate: day, Amount: 100}
+	assert.True(t, r.Matches(installment, day.Add(10*time.Hour)))
+	assert.False(t, r.Matches(installment, day.AddDate(0, 0, 1)))
+	installment.PaidAmount = 100
+	assert.False(t, r.Matches(installment, day))
+	assert.Equal(t, "reminder:due:7:2", r.BizKey(7, 2))
+}
@@ -0,0 +1,398 @@
+// Package repayment builds the repayment schedule of a loan and allocates
+// the money a borrower has paid to its installments, oldest first.
+package repayment
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"lol/internal/model"
+)
+
+// repayment methods
+const (
+	// MethodFlat flat rate, the interest of every installment is charged on the original principal
+	MethodFlat = "flat"
+	// MethodAnnuity equal installments, principal and interest are paid in equal monthly amounts
+	MethodAnnuity = "annuity"
+	// MethodEqualPrincipal equal principal, the interest is charged on the outstanding principal
+	MethodEqualPrincipal = "equal_principal"
+	// MethodBalloon equal installments on the principal minus the balloon, which is paid with the last installment
+	MethodBalloon = "balloon"
+
+	// DefaultAnnualRate the flat rate of loans created without a product, 2% per month
+	DefaultAnnualRate = 0.24
+	// PaymentFeeRate the fee the payment channels charge on every payment, 0.6%
+	PaymentFeeRate = 0.006
+)
+
+var (
+	// ErrInvalidPlan the plan cannot produce a schedule
+	ErrInvalidPlan = errors.New("invalid repayment plan")
+	// ErrInvalidTerms the term options are not a comma separated list of positive numbers
+	ErrInvalidTerms = errors.New("invalid terms")
+	// ErrInvalidInstallments the number of installments is out of range
+	ErrInvalidInstallments = errors.New("installments out of range")
+	// ErrInvalidAmount the amount is not a positive value with at most two decimals
+	ErrInvalidAmount = errors.New("invalid amount")
+	// ErrAmountExceedsBalance the amount is greater than the outstanding balance
+	ErrAmountExceedsBalance = errors.New("amount exceeds outstanding balance")
+)
+
+// Plan the conditions a repayment schedule is built from
+type Plan struct {
+	Principal    float64   // 借款金额
+	Period       int       // 借款期数
+	AnnualRate   float64   // 年利率
+	Method       string    // 还款方式
+	BalloonRatio float64   // 尾款占本金比例，仅尾款方式使用
+	Start        time.Time // 起租日期，第一期在下个月还款
+	DueDay       int       // 每月还款日，超过当月天数按月末计算
+}
+
+// Installment a single period of the repayment schedule
+type Installment struct {
+	Seq        int       `json:"seq"`        // 期数，从1开始
+	DueDate    time.Time `json:"dueDate"`    // 应还日期
+	Amount     float64   `json:"amount"`     // 应还金额
+	Principal  float64   `json:"principal"`  // 应还本金
+	Interest   float64   `json:"interest"`   // 应还利息
+	Fee        float64   `json:"fee"`        // 支付手续费
+	PaidAmount float64   `json:"paidAmount"` // 已分配的还款金额
+}
+
+// IsPaid whether the installment is fully covered
+func (i *Installment) IsPaid() bool {
+	return toCents(i.PaidAmount) >= toCents(i.Amount)
+}
+
+// Outstanding the amount still owed for the installment
+func (i *Installment) Outstanding() float64 {
+	return fromCents(toCents(i.Amount) - toCents(i.PaidAmount))
+}
+
+// Schedule the repayment schedule of a loan
+type Schedule struct {
+	Installments []*Installment `json:"installments"`
+}
+
+// NewSchedule build the schedule of a plan by its repayment method
+func NewSchedule(p *Plan) (*Schedule, error) {
+	if p.Period < 1 || p.Principal <= 0 || p.AnnualRate < 0 || p.BalloonRatio < 0 || p.BalloonRatio >= 1 {
+		return nil, ErrInvalidPlan
+	}
+
+	var principals, interests []int64
+	switch p.Method {
+	case MethodFlat:
+		principals, interests = flatInstallments(p)
+	case MethodAnnuity:
+		principals, interests = annuityInstallments(p, 0)
+	case MethodEqualPrincipal:
+		principals, interests = equalPrincipalInstallments(p)
+	case MethodBalloon:
+		principals, interests = annuityInstallments(p, toCents(p.Principal*p.BalloonRatio))
+	default:
+		return nil, ErrInvalidPlan
+	}
+
+	dueDay := p.DueDay
+	if dueDay < 1 {
+		dueDay = p.Start.Day()
+	}
+	s := &Schedule{}
+	for i := 0; i < p.Period; i++ {
+		s.Installments = append(s.Installments, &Installment{
+			Seq:       i + 1,
+			DueDate:   dueDate(p.Start, i+1, dueDay),
+			Amount:    fromCents(principals[i] + interests[i]),
+			Principal: fromCents(principals[i]),
+			Interest:  fromCents(interests[i]),
+			Fee:       Fee(fromCents(principals[i] + interests[i])),
+		})
+	}
+	return s, nil
+}
+
+// flatInstallments the principal is split evenly, every installment pays interest on the original principal
+func flatInstallments(p *Plan) ([]int64, []int64) {
+	principal := toCents(p.Principal)
+	interest := toCents(p.Principal * p.AnnualRate / 12)
+	principals := splitEvenly(principal, p.Period)
+	interests := make([]int64, p.Period)
+	for i := range interests {
+		interests[i] = interest
+	}
+	return principals, interests
+}
+
+// annuityInstallments equal payments that amortize the principal down to the balloon,
+// the balloon is added to the principal of the last installment
+func annuityInstallments(p *Plan, balloon int64) ([]int64, []int64) {
+	rate := p.AnnualRate / 12
+	balance := toCents(p.Principal)
+	n := float64(p.Period)
+
+	var payment float64
+	if rate == 0 {
+		payment = float64(balance-balloon) / n
+	} else {
+		factor := math.Pow(1+rate, n)
+		payment = (float64(balance)*factor - float64(balloon)) * rate / (factor - 1)
+	}
+
+	principals := make([]int64, p.Period)
+	interests := make([]int64, p.Period)
+	for i := 0; i < p.Period; i++ {
+		interests[i] = int64(math.Round(float64(balance) * rate))
+		if i == p.Period-1 {
+			principals[i] = balance
+		} else {
+			principals[i] = int64(math.Round(payment)) - interests[i]
+		}
+		balance -= principals[i]
+	}
+	return principals, interests
+}
+
+// equalPrincipalInstallments the principal is split evenly, interest is charged on the outstanding principal
+func equalPrincipalInstallments(p *Plan) ([]int64, []int64) {
+	rate := p.AnnualRate / 12
+	balance := toCents(p.Principal)
+	principals := splitEvenly(balance, p.Period)
+	interests := make([]int64, p.Period)
+	for i := range interests {
+		interests[i] = int64(math.Round(float64(balance) * rate))
+		balance -= principals[i]
+	}
+	return principals, interests
+}
+
+// splitEvenly split cents into n parts, the last part absorbs the rounding difference
+func splitEvenly(total int64, n int) []int64 {
+	parts := make([]int64, n)
+	part := total / int64(n)
+	for i := range parts {
+		parts[i] = part
+	}
+	parts[n-1] = total - part*int64(n-1)
+	return parts
+}
+
+// NewLoanSchedule build the schedule of a loan from the terms it was created with,
+// loans created before repayment methods existed pay MonthlyPayment every installment,
+// those without a period have one installment of MonthlyPayment.
+func NewLoanSchedule(loan *model.Loan) *Schedule {
+	start := time.Now()
+	if loan.CreateAt != nil && !loan.CreateAt.IsZero() {
+		start = *loan.CreateAt
+	}
+	dueDay, err := strconv.Atoi(loan.LoanReturnDate)
+	if err != nil || dueDay < 1 {
+		dueDay = start.Day()
+	}
+
+	if loan.RepaymentMethod != "" {
+		s, err := NewSchedule(&Plan{
+			Principal:    loan.LoanMoney,
+			Period:       loan.LoanPeriod,
+			AnnualRate:   loan.AnnualRate,
+			Method:       loan.RepaymentMethod,
+			BalloonRatio: loan.BalloonRatio,
+			Start:        start,
+			DueDay:       dueDay,
+		})
+		if err == nil {
+			return s
+		}
+	}
+
+	period := loan.LoanPeriod
+	if period < 1 {
+		period = 1
+	}
+	s := &Schedule{}
+	amount := toCents(loan.MonthlyPayment)
+	principals := splitEvenly(toCents(loan.LoanMoney), period)
+	for seq := 1; seq <= period; seq++ {
+		s.Installments = append(s.Installments, &Installment{
+			Seq:       seq,
+			DueDate:   dueDate(start, seq, dueDay),
+			Amount:    fromCents(amount),
+			Principal: fromCents(principals[seq-1]),
+			Interest:  fromCents(amount - principals[seq-1]),
+			Fee:       Fee(fromCents(amount)),
+		})
+	}
+	return s
+}
+
+// ParseTerms parse the comma separated term options of a product, e.g. "12,24,36"
+func ParseTerms(terms string) ([]int, error) {
+	var periods []int
+	for _, v := range strings.Split(terms, ",") {
+		period, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil || period < 1 {
+			return nil, ErrInvalidTerms
+		}
+		periods = append(periods, period)
+	}
+	return periods, nil
+}
+
+// dueDate the date of the seq-th installment, days past the end of the month fall on its last day
+func dueDate(start time.Time, seq int, dueDay int) time.Time {
+	firstOfMonth := time.Date(start.Year(), start.Month()+time.Month(seq), 1, 0, 0, 0, 0, start.Location())
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	if dueDay > lastDay {
+		dueDay = lastDay
+	}
+	return firstOfMonth.AddDate(0, 0, dueDay-1)
+}
+
+// TotalInterest the sum of the interest of all installments
+func (s *Schedule) TotalInterest() float64 {
+	var total int64
+	for _, v := range s.Installments {
+		total += toCents(v.Interest)
+	}
+	return fromCents(total)
+}
+
+// TotalFees the sum of the payment fees of all installments
+func (s *Schedule) TotalFees() float64 {
+	var total int64
+	for _, v := range s.Installments {
+		total += toCents(v.Fee)
+	}
+	return fromCents(total)
+}
+
+// APR the annual percentage rate of the schedule including fees, which is the monthly
+// internal rate of return of the installments against the principal multiplied by 12.
+func (s *Schedule) APR(principal float64) float64 {
+	presentValue := func(rate float64) float64 {
+		pv := 0.0
+		for i, v := range s.Installments {
+			pv += (v.Amount + v.Fee) / math.Pow(1+rate, float64(i+1))
+		}
+		return pv
+	}
+	if principal <= 0 || presentValue(0) <= principal {
+		return 0
+	}
+
+	// the present value decreases as the rate grows, bisect until it equals the principal
+	low, high := 0.0, 1.0
+	for i := 0; i < 100; i++ {
+		mid := (low + high) / 2
+		if presentValue(mid) > principal {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	return math.Round(low*12*1e6) / 1e6
+}
+
+// Total the sum of all installments
+func (s *Schedule) Total() float64 {
+	var total int64
+	for _, v := range s.Installments {
+		total += toCents(v.Amount)
+	}
+	return fromCents(total)
+}
+
+// Allocate distribute the paid money to installments oldest first, previous allocations are reset
+func (s *Schedule) Allocate(paid float64) {
+	left := toCents(paid)
+	for _, v := range s.Installments {
+		amount := toCents(v.Amount)
+		if left >= amount {
+			v.PaidAmount = v.Amount
+			left -= amount
+			continue
+		}
+		v.PaidAmount = fromCents(left)
+		left = 0
+	}
+}
+
+// Paid the money allocated to installments
+func (s *Schedule) Paid() float64 {
+	var paid int64
+	for _, v := range s.Installments {
+		paid += toCents(v.PaidAmount)
+	}
+	return fromCents(paid)
+}
+
+// Outstanding the money still owed
+func (s *Schedule) Outstanding() float64 {
+	return fromCents(toCents(s.Total()) - toCents(s.Paid()))
+}
+
+// PaidCount the number of fully paid installments
+func (s *Schedule) PaidCount() int {
+	count := 0
+	for _, v := range s.Installments {
+		if v.IsPaid() {
+			count++
+		}
+	}
+	return count
+}
+
+// Unpaid the installments that are not fully paid, oldest first
+func (s *Schedule) Unpaid() []*Installment {
+	var unpaid []*Installment
+	for _, v := range s.Installments {
+		if !v.IsPaid() {
+			unpaid = append(unpaid, v)
+		}
+	}
+	return unpaid
+}
+
+// AmountForInstallments the money needed to settle the next n unpaid installments,
+// including the remainder of a partially paid one.
+func (s *Schedule) AmountForInstallments(n int) (float64, error) {
+	unpaid := s.Unpaid()
+	if n < 1 || n > len(unpaid) {
+		return 0, ErrInvalidInstallments
+	}
+	var amount int64
+	for _, v := range unpaid[:n] {
+		amount += toCents(v.Outstanding())
+	}
+	return fromCents(amount), nil
+}
+
+// CheckAmount check that a custom amount can be paid against the schedule
+func (s *Schedule) CheckAmount(amount float64) error {
+	cents := toCents(amount)
+	if cents <= 0 || math.Abs(amount*100-float64(cents)) > 1e-6 {
+		return ErrInvalidAmount
+	}
+	if cents > toCents(s.Outstanding()) {
+		return ErrAmountExceedsBalance
+	}
+	return nil
+}
+
+// Fee the payment fee charged on an amount
+func Fee(amount float64) float64 {
+	return fromCents(toCents(amount * PaymentFeeRate))
+}
+
+func toCents(v float64) int64 {
+	return int64(math.Round(v * 100))
+}
+
+func fromCents(v int64) float64 {
+	return float64(v) / 100
+}
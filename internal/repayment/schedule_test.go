@@ -0,0 +1,212 @@
+package repayment
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"lol/internal/model"
+)
+
+func newTestLoan() *model.Loan {
+	createAt := time.Date(2024, 1, 31, 10, 0, 0, 0, time.Local)
+	return &model.Loan{
+		ID:             1,
+		LoanPeriod:     3,
+		LoanReturnDate: "31",
+		MonthlyPayment: 1000.5,
+		CreateAt:       &createAt,
+	}
+}
+
+func TestNewLoanSchedule(t *testing.T) {
+	s := NewLoanSchedule(newTestLoan())
+	assert.Len(t, s.Installments, 3)
+	assert.Equal(t, 3001.5, s.Total())
+
+	// due day is clamped to the end of short months
+	assert.Equal(t, time.Date(2024, 2, 29, 0, 0, 0, 0, time.Local), s.Installments[0].DueDate)
+	assert.Equal(t, time.Date(2024, 3, 31, 0, 0, 0, 0, time.Local), s.Installments[1].DueDate)
+	assert.Equal(t, time.Date(2024, 4, 30, 0, 0, 0, 0, time.Local), s.Installments[2].DueDate)
+}
+
+func TestNewLoanSchedule_noPeriod(t *testing.T) {
+	loan := newTestLoan()
+	loan.LoanPeriod = 0
+
+	// one installment of the monthly payment, not an empty schedule which would look fully paid
+	s := NewLoanSchedule(loan)
+	assert.Len(t, s.Installments, 1)
+	assert.Equal(t, loan.MonthlyPayment, s.Installments[0].Amount)
+	assert.Equal(t, loan.MonthlyPayment, s.Outstanding())
+
+	amount, err := s.AmountForInstallments(1)
+	assert.NoError(t, err)
+	assert.Equal(t, loan.MonthlyPayment, amount)
+}
+
+func TestSchedule_Allocate(t *testing.T) {
+	s := NewLoanSchedule(newTestLoan())
+
+	s.Allocate(1500)
+	assert.Equal(t, 1, s.PaidCount())
+	assert.Equal(t, 1000.5, s.Installments[0].PaidAmount)
+	assert.Equal(t, 499.5, s.Installments[1].PaidAmount)
+	assert.Equal(t, 0.0, s.Installments[2].PaidAmount)
+	assert.Equal(t, 1501.5, s.Outstanding())
+
+	// allocation is recomputed from scratch
+	s.Allocate(5000)
+	assert.Equal(t, 3, s.PaidCount())
+	assert.Equal(t, 0.0, s.Outstanding())
+	assert.Empty(t, s.Unpaid())
+}
+
+func TestSchedule_AmountForInstallments(t *testing.T) {
+	s := NewLoanSchedule(newTestLoan())
+	s.Allocate(1500)
+
+	amount, err := s.AmountForInstallments(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 501.0, amount)
+
+	amount, err = s.AmountForInstallments(2)
+	assert.NoError(t, err)
+	assert.Equal(t, 1501.5, amount)
+
+	_, err = s.AmountForInstallments(0)
+	assert.ErrorIs(t, err, ErrInvalidInstallments)
+	_, err = s.AmountForInstallments(3)
+	assert.ErrorIs(t, err, ErrInvalidInstallments)
+}
+
+func TestSchedule_CheckAmount(t *testing.T) {
+	s := NewLoanSchedule(newTestLoan())
+	s.Allocate(1000.5)
+
+	assert.NoError(t, s.CheckAmount(0.01))
+	assert.NoError(t, s.CheckAmount(2001))
+	assert.ErrorIs(t, s.CheckAmount(0), ErrInvalidAmount)
+	assert.ErrorIs(t, s.CheckAmount(-1), ErrInvalidAmount)
+	assert.ErrorIs(t, s.CheckAmount(10.001), ErrInvalidAmount)
+	assert.ErrorIs(t, s.CheckAmount(2001.01), ErrAmountExceedsBalance)
+}
+
+func newTestPlan(method string) *Plan {
+	return &Plan{
+		Principal:  12000,
+		Period:     12,
+		AnnualRate: 0.12,
+		Method:     method,
+		Start:      time.Date(2024, 1, 15, 0, 0, 0, 0, time.Local),
+		DueDay:     10,
+	}
+}
+
+func assertPrincipalRepaid(t *testing.T, s *Schedule, principal float64) {
+	var cents int64
+	for _, v := range s.Installments {
+		cents += toCents(v.Principal)
+		assert.Equal(t, toCents(v.Amount), toCents(v.Principal)+toCents(v.Interest))
+	}
+	assert.Equal(t, toCents(principal), cents)
+}
+
+func TestNewSchedule(t *testing.T) {
+	// flat: interest on the original principal every month
+	s, err := NewSchedule(newTestPlan(MethodFlat))
+	assert.NoError(t, err)
+	assertPrincipalRepaid(t, s, 12000)
+	assert.Equal(t, 1120.0, s.Installments[0].Amount)
+	assert.Equal(t, 1440.0, s.TotalInterest())
+	assert.Equal(t, time.Date(2024, 2, 10, 0, 0, 0, 0, time.Local), s.Installments[0].DueDate)
+
+	// annuity: equal installments, decreasing interest
+	s, err = NewSchedule(newTestPlan(MethodAnnuity))
+	assert.NoError(t, err)
+	assertPrincipalRepaid(t, s, 12000)
+	assert.Equal(t, 1066.19, s.Installments[0].Amount)
+	assert.Equal(t, 1066.19, s.Installments[5].Amount)
+	assert.Equal(t, 120.0, s.Installments[0].Interest)
+	assert.InDelta(t, 1066.19, s.Installments[11].Amount, 0.05)
+
+	// equal principal: same principal, decreasing installments
+	s, err = NewSchedule(newTestPlan(MethodEqualPrincipal))
+	assert.NoError(t, err)
+	assertPrincipalRepaid(t, s, 12000)
+	assert.Equal(t, 1120.0, s.Installments[0].Amount)
+	assert.Equal(t, 1010.0, s.Installments[11].Amount)
+	assert.Equal(t, 780.0, s.TotalInterest())
+
+	// balloon: the last installment pays the balloon
+	plan := newTestPlan(MethodBalloon)
+	plan.BalloonRatio = 0.3
+	s, err = NewSchedule(plan)
+	assert.NoError(t, err)
+	assertPrincipalRepaid(t, s, 12000)
+	assert.Greater(t, s.Installments[11].Principal, 3600.0)
+	assert.Less(t, s.Installments[0].Amount, 1066.19)
+
+	// zero rate
+	plan = newTestPlan(MethodAnnuity)
+	plan.AnnualRate = 0
+	s, err = NewSchedule(plan)
+	assert.NoError(t, err)
+	assert.Equal(t, 1000.0, s.Installments[0].Amount)
+	assert.Equal(t, 0.0, s.TotalInterest())
+
+	// invalid plans
+	_, err = NewSchedule(newTestPlan("unknown"))
+	assert.ErrorIs(t, err, ErrInvalidPlan)
+	plan = newTestPlan(MethodFlat)
+	plan.Period = 0
+	_, err = NewSchedule(plan)
+	assert.ErrorIs(t, err, ErrInvalidPlan)
+}
+
+func TestNewLoanSchedule_Method(t *testing.T) {
+	loan := newTestLoan()
+	loan.LoanMoney = 3000
+	loan.AnnualRate = 0.24
+	loan.RepaymentMethod = MethodFlat
+
+	s := NewLoanSchedule(loan)
+	assert.Len(t, s.Installments, 3)
+	assert.Equal(t, 1060.0, s.Installments[0].Amount)
+	assert.Equal(t, 180.0, s.TotalInterest())
+}
+
+func TestParseTerms(t *testing.T) {
+	terms, err := ParseTerms("12, 24,36")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{12, 24, 36}, terms)
+
+	for _, v := range []string{"", "12,", "a", "0", "-12"} {
+		_, err = ParseTerms(v)
+		assert.ErrorIs(t, err, ErrInvalidTerms, v)
+	}
+}
+
+func TestSchedule_FeesAndAPR(t *testing.T) {
+	start := time.Date(2024, 1, 15, 0, 0, 0, 0, time.Local)
+	s, err := NewSchedule(&Plan{Principal: 12000, Period: 12, AnnualRate: 0.24, Method: MethodFlat, Start: start, DueDay: 15})
+	assert.NoError(t, err)
+	assert.Equal(t, 7.44, s.Installments[0].Fee)
+	assert.Equal(t, 89.28, s.TotalFees())
+	// a flat rate of 2% per month is much more expensive than its nominal rate
+	apr := s.APR(12000)
+	assert.Greater(t, apr, 0.42)
+	assert.Less(t, apr, 0.45)
+
+	// the APR of an annuity is its rate plus the fees
+	s, err = NewSchedule(&Plan{Principal: 12000, Period: 12, AnnualRate: 0.12, Method: MethodAnnuity, Start: start, DueDay: 15})
+	assert.NoError(t, err)
+	apr = s.APR(12000)
+	assert.Greater(t, apr, 0.12)
+	assert.Less(t, apr, 0.135)
+
+	assert.Equal(t, 0.0, s.APR(0))
+	assert.Equal(t, 0.0, s.APR(100000))
+	assert.Equal(t, 0.6, Fee(100))
+}
@@ -0,0 +1,35 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"lol/internal/handler"
+)
+
+func init() {
+	apiV1RouterFns = append(apiV1RouterFns, func(group *gin.RouterGroup) {
+		adminUserRouter(group, handler.NewAdminUserHandler())
+	})
+}
+
+func adminUserRouter(group *gin.RouterGroup, h handler.AdminUserHandler) {
+	g := group.Group("/adminUser")
+
+	// the permission of every route is checked by the admin auth middleware in registerRouters, see apiV1Permissions
+
+	g.POST("/", h.Create)          // [post] /api/v1/adminUser
+	g.DELETE("/:id", h.DeleteByID) // [delete] /api/v1/adminUser/:id
+	g.PUT("/:id", h.UpdateByID)    // [put] /api/v1/adminUser/:id
+	g.GET("/:id", h.GetByID)       // [get] /api/v1/adminUser/:id
+	g.POST("/list", h.List)        // [post] /api/v1/adminUser/list
+	g.POST("/login", h.Login)      // [post] /api/v1/adminUser/login
+
+	g.PUT("/password", h.ChangePassword) // [put] /api/v1/adminUser/password
+
+	g.POST("/2fa/challenge/setup", h.SetupChallenge)   // [post] /api/v1/adminUser/2fa/challenge/setup
+	g.POST("/2fa/challenge/verify", h.VerifyChallenge) // [post] /api/v1/adminUser/2fa/challenge/verify
+	g.POST("/2fa/setup", h.SetupTwoFactor)             // [post] /api/v1/adminUser/2fa/setup
+	g.POST("/2fa/enable", h.EnableTwoFactor)           // [post] /api/v1/adminUser/2fa/enable
+	g.POST("/2fa/disable", h.DisableTwoFactor)         // [post] /api/v1/adminUser/2fa/disable
+	g.POST("/:id/2fa/reset", h.ResetTwoFactor)         // [post] /api/v1/adminUser/:id/2fa/reset
+}
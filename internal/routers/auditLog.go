@@ -0,0 +1,21 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"lol/internal/handler"
+)
+
+func init() {
+	apiV1RouterFns = append(apiV1RouterFns, func(group *gin.RouterGroup) {
+		auditLogRouter(group, handler.NewAuditLogHandler())
+	})
+}
+
+func auditLogRouter(group *gin.RouterGroup, h handler.AuditLogHandler) {
+	g := group.Group("/auditLog")
+
+	// the permission of every route is checked by the admin auth middleware in registerRouters, see apiV1Permissions
+
+	g.POST("/list", h.List) // [post] /api/v1/auditLog/list
+}
@@ -0,0 +1,27 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/go-dev-frame/sponge/pkg/gin/middleware"
+
+	"lol/internal/handler"
+)
+
+func init() {
+	apiV1RouterFns = append(apiV1RouterFns, func(group *gin.RouterGroup) {
+		borrowerRouter(group, handler.NewBorrowerHandler())
+	})
+}
+
+func borrowerRouter(group *gin.RouterGroup, h handler.BorrowerHandler) {
+	g := group.Group("/borrower")
+
+	// login routes are public, the issued token authorizes the borrower routes of loan
+	g.POST("/otp", h.SendOtp)     // [post] /api/v1/borrower/otp
+	g.POST("/login", h.Login)     // [post] /api/v1/borrower/login
+	g.POST("/captcha", h.Captcha) // [post] /api/v1/borrower/captcha
+
+	borrowerAuth := middleware.Auth(middleware.WithVerify(handler.NewBorrowerAuth().Verify))
+	g.POST("/logout", borrowerAuth, h.Logout) // [post] /api/v1/borrower/logout
+}
@@ -3,6 +3,8 @@ package routers
 import (
 	"github.com/gin-gonic/gin"
 
+	"github.com/go-dev-frame/sponge/pkg/gin/middleware"
+
 	"lol/internal/handler"
 )
 
@@ -15,18 +17,23 @@ func init() {
 func loanRouter(group *gin.RouterGroup, h handler.LoanHandler) {
 	g := group.Group("/loan")
 
-	// All the following routes use jwt authentication, you also can use middleware.Auth(middleware.WithVerify(fn))
-	//g.Use(middleware.Auth())
+	// the permission of every route is checked by the admin auth middleware in registerRouters, see apiV1Permissions
+
+	g.POST("/", h.Create)                // [post] /api/v1/loan
+	g.DELETE("/:id", h.DeleteByID)       // [delete] /api/v1/loan/:id
+	g.POST("/trash/list", h.ListDeleted) // [post] /api/v1/loan/trash/list
+	g.PUT("/:id/restore", h.RestoreByID) // [put] /api/v1/loan/:id/restore
+	g.DELETE("/:id/purge", h.PurgeByID)  // [delete] /api/v1/loan/:id/purge
+	g.PUT("/:id", h.UpdateByID)          // [put] /api/v1/loan/:id
+	g.GET("/:id", h.GetByID)             // [get] /api/v1/loan/:id
+	g.POST("/list", h.List)              // [post] /api/v1/loan/list
+	g.POST("/quote", h.Quote)            // [post] /api/v1/loan/quote
 
-	// If jwt authentication is not required for all routes, authentication middleware can be added
-	// separately for only certain routes. In this case, g.Use(middleware.Auth()) above should not be used.
+	// borrower routes, authorized by the token issued at borrower login
+	borrowerAuth := middleware.Auth(middleware.WithVerify(handler.NewBorrowerAuth().Verify))
+	g.POST("/detail", borrowerAuth, h.GetDetail)
+	g.POST("/pay", borrowerAuth, h.Pay)
+	g.GET("/payment/:tradeNo", borrowerAuth, h.GetPayment)
 
-	g.POST("/", h.Create)          // [post] /api/v1/loan
-	g.DELETE("/:id", h.DeleteByID) // [delete] /api/v1/loan/:id
-	g.PUT("/:id", h.UpdateByID)    // [put] /api/v1/loan/:id
-	g.GET("/:id", h.GetByID)       // [get] /api/v1/loan/:id
-	g.POST("/list", h.List)        // [post] /api/v1/loan/list
-	g.POST("/detail", h.GetDetail)
-	g.POST("/pay", h.Pay)
 	g.POST("/:bandName/notify", h.Notify)
 }
@@ -0,0 +1,25 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"lol/internal/handler"
+)
+
+func init() {
+	apiV1RouterFns = append(apiV1RouterFns, func(group *gin.RouterGroup) {
+		loanProductRouter(group, handler.NewLoanProductHandler())
+	})
+}
+
+func loanProductRouter(group *gin.RouterGroup, h handler.LoanProductHandler) {
+	g := group.Group("/loanProduct")
+
+	// the permission of every route is checked by the admin auth middleware in registerRouters, see apiV1Permissions
+
+	g.POST("/", h.Create)          // [post] /api/v1/loanProduct
+	g.DELETE("/:id", h.DeleteByID) // [delete] /api/v1/loanProduct/:id
+	g.PUT("/:id", h.UpdateByID)    // [put] /api/v1/loanProduct/:id
+	g.GET("/:id", h.GetByID)       // [get] /api/v1/loanProduct/:id
+	g.POST("/list", h.List)        // [post] /api/v1/loanProduct/list
+}
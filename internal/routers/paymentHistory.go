@@ -15,15 +15,14 @@ func init() {
 func paymentHistoryRouter(group *gin.RouterGroup, h handler.PaymentHistoryHandler) {
 	g := group.Group("/paymentHistory")
 
-	// All the following routes use jwt authentication, you also can use middleware.Auth(middleware.WithVerify(fn))
-	//g.Use(middleware.Auth())
+	// the permission of every route is checked by the admin auth middleware in registerRouters, see apiV1Permissions
 
-	// If jwt authentication is not required for all routes, authentication middleware can be added
-	// separately for only certain routes. In this case, g.Use(middleware.Auth()) above should not be used.
-
-	g.POST("/", h.Create)          // [post] /api/v1/paymentHistory
-	g.DELETE("/:id", h.DeleteByID) // [delete] /api/v1/paymentHistory/:id
-	g.PUT("/:id", h.UpdateByID)    // [put] /api/v1/paymentHistory/:id
-	g.GET("/:id", h.GetByID)       // [get] /api/v1/paymentHistory/:id
-	g.POST("/list", h.List)        // [post] /api/v1/paymentHistory/list
+	g.POST("/", h.Create)                // [post] /api/v1/paymentHistory
+	g.DELETE("/:id", h.DeleteByID)       // [delete] /api/v1/paymentHistory/:id
+	g.POST("/trash/list", h.ListDeleted) // [post] /api/v1/paymentHistory/trash/list
+	g.PUT("/:id/restore", h.RestoreByID) // [put] /api/v1/paymentHistory/:id/restore
+	g.DELETE("/:id/purge", h.PurgeByID)  // [delete] /api/v1/paymentHistory/:id/purge
+	g.PUT("/:id", h.UpdateByID)          // [put] /api/v1/paymentHistory/:id
+	g.GET("/:id", h.GetByID)             // [get] /api/v1/paymentHistory/:id
+	g.POST("/list", h.List)              // [post] /api/v1/paymentHistory/list
 }
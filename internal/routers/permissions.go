@@ -0,0 +1,109 @@
+package routers
+
+import (
+	"lol/internal/rbac"
+)
+
+// apiV1Permissions the permission required by every api v1 route, the key is "METHOD fullPath".
+// borrower routes authorize by their own token and payment callbacks must stay open, so they are public,
+// routes missing here are denied, add new routes here when registering them.
+var apiV1Permissions = map[string]rbac.Permission{
+	// loan
+	"POST /api/v1/loan/":                 rbac.LoanWrite,
+	"DELETE /api/v1/loan/:id":            rbac.LoanWrite,
+	"POST /api/v1/loan/trash/list":       rbac.LoanWrite,
+	"PUT /api/v1/loan/:id/restore":       rbac.LoanWrite,
+	"DELETE /api/v1/loan/:id/purge":      rbac.TrashPurge,
+	"PUT /api/v1/loan/:id":               rbac.LoanWrite,
+	"GET /api/v1/loan/:id":               rbac.LoanRead,
+	"POST /api/v1/loan/list":             rbac.LoanRead,
+	"POST /api/v1/loan/quote":            rbac.ProductRead,
+	"POST /api/v1/loan/detail":           rbac.Public,
+	"POST /api/v1/loan/pay":              rbac.Public,
+	"GET /api/v1/loan/payment/:tradeNo":  rbac.Public,
+	"POST /api/v1/loan/:bandName/notify": rbac.Public,
+	"POST /api/v1/borrower/otp":          rbac.Public,
+	"POST /api/v1/borrower/login":        rbac.Public,
+	"POST /api/v1/borrower/captcha":      rbac.Public,
+	"POST /api/v1/borrower/logout":       rbac.Public,
+
+	// loan product
+	"POST /api/v1/loanProduct/":      rbac.ProductWrite,
+	"DELETE /api/v1/loanProduct/:id": rbac.ProductWrite,
+	"PUT /api/v1/loanProduct/:id":    rbac.ProductWrite,
+	"GET /api/v1/loanProduct/:id":    rbac.ProductRead,
+	"POST /api/v1/loanProduct/list":  rbac.ProductRead,
+
+	// payment history and payment callback results
+	"POST /api/v1/paymentHistory/":            rbac.PaymentWrite,
+	"DELETE /api/v1/paymentHistory/:id":       rbac.PaymentWrite,
+	"POST /api/v1/paymentHistory/trash/list":  rbac.PaymentWrite,
+	"PUT /api/v1/paymentHistory/:id/restore":  rbac.PaymentWrite,
+	"DELETE /api/v1/paymentHistory/:id/purge": rbac.TrashPurge,
+	"PUT /api/v1/paymentHistory/:id":          rbac.PaymentWrite,
+	"GET /api/v1/paymentHistory/:id":          rbac.PaymentRead,
+	"POST /api/v1/paymentHistory/list":        rbac.PaymentRead,
+	"POST /api/v1/result/":                    rbac.PaymentWrite,
+	"DELETE /api/v1/result/:id":               rbac.PaymentWrite,
+	"POST /api/v1/result/trash/list":          rbac.PaymentWrite,
+	"PUT /api/v1/result/:id/restore":          rbac.PaymentWrite,
+	"DELETE /api/v1/result/:id/purge":         rbac.TrashPurge,
+	"PUT /api/v1/result/:id":                  rbac.PaymentWrite,
+	"GET /api/v1/result/:id":                  rbac.PaymentRead,
+	"POST /api/v1/result/list":                rbac.PaymentRead,
+
+	// sms history
+	"POST /api/v1/smsHistory/":            rbac.SmsWrite,
+	"DELETE /api/v1/smsHistory/:id":       rbac.SmsWrite,
+	"POST /api/v1/smsHistory/trash/list":  rbac.SmsWrite,
+	"PUT /api/v1/smsHistory/:id/restore":  rbac.SmsWrite,
+	"DELETE /api/v1/smsHistory/:id/purge": rbac.TrashPurge,
+	"PUT /api/v1/smsHistory/:id":          rbac.SmsWrite,
+	"GET /api/v1/smsHistory/:id":          rbac.SmsRead,
+	"POST /api/v1/smsHistory/list":        rbac.SmsRead,
+	// delivery reports of the provider are checked by the report token
+	"POST /api/v1/smsHistory/report/:provider": rbac.Public,
+
+	// sms template, the preview renders the data of a loan
+	"POST /api/v1/smsTemplate/":            rbac.SmsWrite,
+	"DELETE /api/v1/smsTemplate/:id":       rbac.SmsWrite,
+	"PUT /api/v1/smsTemplate/:id":          rbac.SmsWrite,
+	"GET /api/v1/smsTemplate/:id":          rbac.SmsRead,
+	"POST /api/v1/smsTemplate/list":        rbac.SmsRead,
+	"POST /api/v1/smsTemplate/:id/preview": rbac.SmsWrite,
+
+	// admin user
+	"POST /api/v1/adminUser/":      rbac.UserManage,
+	"DELETE /api/v1/adminUser/:id": rbac.UserManage,
+	"PUT /api/v1/adminUser/:id":    rbac.UserManage,
+	"GET /api/v1/adminUser/:id":    rbac.UserManage,
+	"POST /api/v1/adminUser/list":  rbac.UserManage,
+	"POST /api/v1/adminUser/login": rbac.Public,
+	// the admin users created with a bootstrap password can only change it and logout
+	"PUT /api/v1/adminUser/password": rbac.Onboarding,
+
+	// admin two-factor authentication, the challenge routes are checked by the challenge token of login
+	"POST /api/v1/adminUser/2fa/challenge/setup":  rbac.Public,
+	"POST /api/v1/adminUser/2fa/challenge/verify": rbac.Public,
+	"POST /api/v1/adminUser/2fa/setup":            rbac.Authenticated,
+	"POST /api/v1/adminUser/2fa/enable":           rbac.Authenticated,
+	"POST /api/v1/adminUser/2fa/disable":          rbac.Authenticated,
+	"POST /api/v1/adminUser/:id/2fa/reset":        rbac.UserManage,
+
+	// pii access log
+	"POST /api/v1/piiAccessLog/list": rbac.AuditRead,
+
+	// audit log of the changes made by the admin users
+	"POST /api/v1/auditLog/list": rbac.AuditRead,
+
+	// data subject requests, export and anonymize the data of a borrower
+	"POST /api/v1/subject/export":    rbac.SubjectManage,
+	"POST /api/v1/subject/anonymize": rbac.SubjectManage,
+
+	// session
+	"POST /api/v1/session/list":   rbac.UserManage,
+	"POST /api/v1/session/all":    rbac.UserManage,
+	"POST /api/v1/session/revoke": rbac.UserManage,
+	"DELETE /api/v1/session/:id":  rbac.UserManage,
+	"POST /api/v1/session/logout": rbac.Onboarding,
+}
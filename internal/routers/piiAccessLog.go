@@ -0,0 +1,21 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"lol/internal/handler"
+)
+
+func init() {
+	apiV1RouterFns = append(apiV1RouterFns, func(group *gin.RouterGroup) {
+		piiAccessLogRouter(group, handler.NewPiiAccessLogHandler())
+	})
+}
+
+func piiAccessLogRouter(group *gin.RouterGroup, h handler.PiiAccessLogHandler) {
+	g := group.Group("/piiAccessLog")
+
+	// the permission of every route is checked by the admin auth middleware in registerRouters, see apiV1Permissions
+
+	g.POST("/list", h.List) // [post] /api/v1/piiAccessLog/list
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
+	govalidator "github.com/go-playground/validator/v10"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 
@@ -22,6 +23,11 @@ import (
 
 	"lol/docs"
 	"lol/internal/config"
+	"lol/internal/handler"
+	"lol/internal/model"
+	"lol/internal/pii"
+	"lol/internal/types"
+	"lol/internal/validation"
 )
 
 var (
@@ -46,19 +52,41 @@ func NewRouter() *gin.Engine {
 	// request id middleware
 	r.Use(middleware.RequestID())
 
-	// logger middleware, to print simple messages, replace middleware.Logging with middleware.SimpleLog
+	// logger middleware, to print simple messages, replace middleware.Logging with middleware.SimpleLog,
+	// the personal information of the models and the credentials such as passwords and tokens in request and
+	// response bodies are masked,
+	// the token of the sms report callback url is removed before the request is logged,
+	// the two-factor routes are not logged, their responses have the secrets and the recovery codes
+	pii.Register(model.Loan{}, model.PaymentHistory{}, model.SmsHistory{},
+		// the marked fields of the requests, e.g. the code of the borrower otp request is the tail of the ID number
+		types.SendBorrowerOtpRequest{}, types.BorrowerLoginRequest{},
+		types.CreateLoanRequest{}, types.UpdateLoanByIDRequest{},
+		types.CreatePaymentHistoryRequest{}, types.UpdatePaymentHistoryByIDRequest{},
+		types.CreateSmsHistoryRequest{}, types.UpdateSmsHistoryByIDRequest{}, types.ListSmsHistorysRequest{},
+		types.ExportSubjectRequest{}, types.AnonymizeSubjectRequest{}, types.ListPiiAccessLogsRequest{},
+	)
+	r.Use(handler.HideReportToken())
 	r.Use(middleware.Logging(
-		middleware.WithLog(logger.Get()),
+		middleware.WithLog(pii.WrapLogger(logger.Get())),
 		middleware.WithRequestIDFromContext(),
-		middleware.WithIgnoreRoutes("/metrics"), // ignore path
+		middleware.WithIgnoreRoutes("/metrics", // ignore path
+			"/api/v1/adminUser/2fa/challenge/setup",
+			"/api/v1/adminUser/2fa/challenge/verify",
+			"/api/v1/adminUser/2fa/setup",
+			"/api/v1/adminUser/2fa/enable",
+			"/api/v1/adminUser/2fa/disable",
+		),
 	))
 
 	// init jwt middleware, you can replace it with your own jwt middleware
-	jwt.Init(
-	//jwt.WithExpire(time.Hour*24),
-	//jwt.WithSigningKey("123456"),
-	//jwt.WithSigningMethod(jwt.HS384),
-	)
+	jwtOpts := []jwt.Option{}
+	if config.Get().Jwt.SigningKey != "" {
+		jwtOpts = append(jwtOpts, jwt.WithSigningKey(config.Get().Jwt.SigningKey))
+	}
+	if config.Get().Jwt.Expire > 0 {
+		jwtOpts = append(jwtOpts, jwt.WithExpire(time.Hour*time.Duration(config.Get().Jwt.Expire)))
+	}
+	jwt.Init(jwtOpts...)
 
 	// metrics middleware
 	if config.Get().App.EnableMetrics {
@@ -89,7 +117,12 @@ func NewRouter() *gin.Engine {
 	}
 
 	// validator
-	binding.Validator = validator.Init()
+	v := validator.Init()
+	// the domain validators of the binding tags, such as cn_idcard and cn_mobile
+	if err := validation.Register(v.Engine().(*govalidator.Validate)); err != nil {
+		panic(err)
+	}
+	binding.Validator = v
 
 	r.GET("/health", handlerfunc.CheckHealth)
 	r.GET("/ping", handlerfunc.Ping)
@@ -103,8 +136,8 @@ func NewRouter() *gin.Engine {
 		r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	}
 
-	// register routers, middleware support
-	registerRouters(r, "/api/v1", apiV1RouterFns)
+	// register routers, middleware support, every api v1 route is checked against its permission
+	registerRouters(r, "/api/v1", apiV1RouterFns, handler.NewAdminAuth().Middleware(apiV1Permissions))
 	// if you have other group routes you can add them here
 	// example:
 	//    registerRouters(r, "/api/v2", apiV2RouteFns, middleware.Auth())
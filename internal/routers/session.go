@@ -0,0 +1,25 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"lol/internal/handler"
+)
+
+func init() {
+	apiV1RouterFns = append(apiV1RouterFns, func(group *gin.RouterGroup) {
+		sessionRouter(group, handler.NewSessionHandler())
+	})
+}
+
+func sessionRouter(group *gin.RouterGroup, h handler.SessionHandler) {
+	g := group.Group("/session")
+
+	// the permission of every route is checked by the admin auth middleware in registerRouters, see apiV1Permissions
+
+	g.POST("/list", h.List)        // [post] /api/v1/session/list
+	g.POST("/all", h.ListAll)      // [post] /api/v1/session/all
+	g.POST("/revoke", h.Revoke)    // [post] /api/v1/session/revoke
+	g.DELETE("/:id", h.DeleteByID) // [delete] /api/v1/session/:id
+	g.POST("/logout", h.Logout)    // [post] /api/v1/session/logout
+}
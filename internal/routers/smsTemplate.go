@@ -0,0 +1,27 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"lol/internal/handler"
+)
+
+func init() {
+	apiV1RouterFns = append(apiV1RouterFns, func(group *gin.RouterGroup) {
+		smsTemplateRouter(group, handler.NewSmsTemplateHandler())
+	})
+}
+
+func smsTemplateRouter(group *gin.RouterGroup, h handler.SmsTemplateHandler) {
+	g := group.Group("/smsTemplate")
+
+	// the permission of every route is checked by the admin auth middleware in registerRouters, see apiV1Permissions
+
+	g.POST("/", h.Create)          // [post] /api/v1/smsTemplate
+	g.DELETE("/:id", h.DeleteByID) // [delete] /api/v1/smsTemplate/:id
+	g.PUT("/:id", h.UpdateByID)    // [put] /api/v1/smsTemplate/:id
+	g.GET("/:id", h.GetByID)       // [get] /api/v1/smsTemplate/:id
+	g.POST("/list", h.List)        // [post] /api/v1/smsTemplate/list
+
+	g.POST("/:id/preview", h.Preview) // [post] /api/v1/smsTemplate/:id/preview
+}
@@ -0,0 +1,22 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"lol/internal/handler"
+)
+
+func init() {
+	apiV1RouterFns = append(apiV1RouterFns, func(group *gin.RouterGroup) {
+		subjectRouter(group, handler.NewSubjectHandler())
+	})
+}
+
+func subjectRouter(group *gin.RouterGroup, h handler.SubjectHandler) {
+	g := group.Group("/subject")
+
+	// the permission of every route is checked by the admin auth middleware in registerRouters, see apiV1Permissions
+
+	g.POST("/export", h.Export)       // [post] /api/v1/subject/export
+	g.POST("/anonymize", h.Anonymize) // [post] /api/v1/subject/anonymize
+}
@@ -0,0 +1,148 @@
+package sms
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"lol/internal/config"
+)
+
+const aliyunEndpoint = "https://dysmsapi.aliyuncs.com"
+
+// aliyun error codes which can be retried, the others are caused by the request or the account
+var aliyunTemporaryCodes = map[string]bool{
+	"isp.SYSTEM_ERROR":   true,
+	"ServiceUnavailable": true,
+	"Throttling":         true,
+	"Throttling.User":    true,
+	"InternalError":      true,
+}
+
+// AliyunSender send text messages through the SendSms api of Aliyun SMS
+type AliyunSender struct {
+	conf     config.SmsAliyun
+	endpoint string
+	client   *http.Client
+}
+
+// NewAliyunSender create an aliyun sender
+func NewAliyunSender(conf config.SmsAliyun) *AliyunSender {
+	endpoint := conf.Endpoint
+	if endpoint == "" {
+		endpoint = aliyunEndpoint
+	}
+	return &AliyunSender{
+		conf:     conf,
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: defaultTimeout},
+	}
+}
+
+// Name the provider name
+func (s *AliyunSender) Name() string {
+	return ProviderAliyun
+}
+
+type aliyunReply struct {
+	Code      string `json:"Code"`
+	Message   string `json:"Message"`
+	BizID     string `json:"BizId"`
+	RequestID string `json:"RequestId"`
+}
+
+// Send the message, the BizId of aliyun is returned as the message id
+func (s *AliyunSender) Send(ctx context.Context, msg *Message) (string, error) {
+	templateParam := map[string]string{}
+	for _, p := range msg.Params {
+		templateParam[p.Name] = p.Value
+	}
+	paramJSON, err := json.Marshal(templateParam)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, 16)
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("AccessKeyId", s.conf.AccessKeyID)
+	params.Set("Action", "SendSms")
+	params.Set("Format", "JSON")
+	params.Set("PhoneNumbers", msg.Mobile)
+	params.Set("RegionId", "cn-hangzhou")
+	params.Set("SignName", msg.SignName)
+	params.Set("SignatureMethod", "HMAC-SHA1")
+	params.Set("SignatureNonce", hex.EncodeToString(nonce))
+	params.Set("SignatureVersion", "1.0")
+	params.Set("TemplateCode", msg.TemplateID)
+	params.Set("TemplateParam", string(paramJSON))
+	params.Set("Timestamp", time.Now().UTC().Format("2006-01-02T15:04:05Z"))
+	params.Set("Version", "2017-05-25")
+	query := aliyunCanonicalQuery(params)
+	signature := aliyunSign(http.MethodGet, query, s.conf.AccessKeySecret)
+	reqURL := s.endpoint + "/?Signature=" + aliyunEncode(signature) + "&" + query
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close() //nolint
+
+	reply := &aliyunReply{}
+	err = json.NewDecoder(resp.Body).Decode(reply)
+	if err != nil {
+		return "", &Error{Code: resp.Status, Message: err.Error(), Temporary: resp.StatusCode >= http.StatusInternalServerError}
+	}
+	if reply.Code != "OK" {
+		return "", &Error{Code: reply.Code, Message: reply.Message, Temporary: aliyunTemporaryCodes[reply.Code]}
+	}
+	return reply.BizID, nil
+}
+
+// aliyunCanonicalQuery the query sorted by key and encoded by aliyunEncode
+func aliyunCanonicalQuery(params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, aliyunEncode(k)+"="+aliyunEncode(params.Get(k)))
+	}
+	return strings.Join(pairs, "&")
+}
+
+// aliyunSign the signature of the rpc api, base64(hmac-sha1(secret&, METHOD&%2F&encode(query)))
+func aliyunSign(method string, canonicalQuery string, secret string) string {
+	stringToSign := method + "&" + aliyunEncode("/") + "&" + aliyunEncode(canonicalQuery)
+	mac := hmac.New(sha1.New, []byte(secret+"&"))
+	mac.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// aliyunEncode percent encoding of RFC 3986 required by aliyun
+func aliyunEncode(s string) string {
+	s = url.QueryEscape(s)
+	s = strings.ReplaceAll(s, "+", "%20")
+	s = strings.ReplaceAll(s, "*", "%2A")
+	s = strings.ReplaceAll(s, "%7E", "~")
+	return s
+}
@@ -0,0 +1,72 @@
+package sms
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-dev-frame/sponge/pkg/logger"
+
+	"lol/internal/pii"
+)
+
+// LogSender a sender for development, the messages are written to the log, or appended to a file as json lines
+type LogSender struct {
+	file string
+	mu   sync.Mutex
+}
+
+// NewLogSender create a log sender, messages are only logged if file is empty
+func NewLogSender(file string) *LogSender {
+	return &LogSender{file: file}
+}
+
+// Name the provider name
+func (s *LogSender) Name() string {
+	return ProviderLog
+}
+
+// Send write the message, a random message id is returned
+func (s *LogSender) Send(ctx context.Context, msg *Message) (string, error) {
+	b := make([]byte, 8)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	messageID := "log-" + hex.EncodeToString(b)
+
+	if s.file == "" {
+		logger.Info("sms sent to log", logger.String("messageID", messageID), pii.String("mobile", pii.KindMobile, msg.Mobile),
+			logger.String("templateID", msg.TemplateID), logger.String("content", msg.Content))
+		return messageID, nil
+	}
+
+	line, err := json.Marshal(map[string]interface{}{
+		"messageID":  messageID,
+		"mobile":     msg.Mobile,
+		"signName":   msg.SignName,
+		"templateID": msg.TemplateID,
+		"content":    msg.Content,
+		"sentAt":     time.Now(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.file, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close() //nolint
+	_, err = f.Write(append(line, '\n'))
+	if err != nil {
+		return "", err
+	}
+	return messageID, nil
+}
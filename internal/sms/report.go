@@ -0,0 +1,135 @@
+package sms
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// delivery status of sms_history, updated by the delivery reports of the provider
+const (
+	DeliveryPending   = 0
+	DeliveryDelivered = 1
+	DeliveryFailed    = 2
+)
+
+// Report the delivery report of a message pushed by the provider
+type Report struct {
+	MessageID    string    // the message id returned when sending
+	Mobile       string    // 手机号
+	Delivered    bool      // 是否送达
+	ErrorCode    string    // 运营商状态码，送达时也可能有值，如 DELIVRD
+	ErrorMessage string    // 状态描述
+	ReportAt     time.Time // 用户接收时间，服务商没有提供时为收到回执的时间
+}
+
+// aliyun pushes the reports as a json array, see the SmsReport message of the aliyun sms document
+type aliyunReport struct {
+	PhoneNumber string `json:"phone_number"`
+	Success     bool   `json:"success"`
+	BizID       string `json:"biz_id"`
+	ReportTime  string `json:"report_time"`
+	ErrCode     string `json:"err_code"`
+	ErrMsg      string `json:"err_msg"`
+}
+
+// tencent pushes the reports as a json array, see the status callback of the tencent sms document
+type tencentReport struct {
+	Mobile          string `json:"mobile"`
+	UserReceiveTime string `json:"user_receive_time"`
+	ReportStatus    string `json:"report_status"`
+	ErrMsg          string `json:"errmsg"`
+	Description     string `json:"description"`
+	Sid             string `json:"sid"`
+}
+
+// logReport the reports of the log provider, used to test the callback in development
+type logReport struct {
+	MessageID string `json:"messageID"`
+	Mobile    string `json:"mobile"`
+	Delivered bool   `json:"delivered"`
+	ErrorCode string `json:"errorCode"`
+}
+
+// ParseReports parse the delivery reports pushed by the provider
+func ParseReports(provider string, body []byte) ([]*Report, error) {
+	now := time.Now()
+	var reports []*Report
+	switch provider {
+	case ProviderAliyun:
+		var values []aliyunReport
+		if err := json.Unmarshal(body, &values); err != nil {
+			return nil, err
+		}
+		for _, v := range values {
+			reports = append(reports, &Report{
+				MessageID:    v.BizID,
+				Mobile:       v.PhoneNumber,
+				Delivered:    v.Success,
+				ErrorCode:    v.ErrCode,
+				ErrorMessage: v.ErrMsg,
+				ReportAt:     parseReportTime(v.ReportTime, now),
+			})
+		}
+
+	case ProviderTencent:
+		var values []tencentReport
+		if err := json.Unmarshal(body, &values); err != nil {
+			return nil, err
+		}
+		for _, v := range values {
+			reports = append(reports, &Report{
+				MessageID:    v.Sid,
+				Mobile:       v.Mobile,
+				Delivered:    v.ReportStatus == "SUCCESS",
+				ErrorCode:    v.ErrMsg,
+				ErrorMessage: v.Description,
+				ReportAt:     parseReportTime(v.UserReceiveTime, now),
+			})
+		}
+
+	case ProviderLog:
+		var values []logReport
+		if err := json.Unmarshal(body, &values); err != nil {
+			return nil, err
+		}
+		for _, v := range values {
+			reports = append(reports, &Report{
+				MessageID: v.MessageID,
+				Mobile:    v.Mobile,
+				Delivered: v.Delivered,
+				ErrorCode: v.ErrorCode,
+				ReportAt:  now,
+			})
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown sms provider %q", provider)
+	}
+	return reports, nil
+}
+
+// ReportAck the reply the provider expects after the reports are received, otherwise they are pushed again
+func ReportAck(provider string) interface{} {
+	if provider == ProviderTencent {
+		return map[string]interface{}{"result": 0, "errmsg": "OK"}
+	}
+	return map[string]interface{}{"code": 0, "msg": "成功"}
+}
+
+// DeliveryStatus the delivery status of the report
+func (r *Report) DeliveryStatus() int {
+	if r.Delivered {
+		return DeliveryDelivered
+	}
+	return DeliveryFailed
+}
+
+// parseReportTime the time of the reports is the local time of China, e.g. 2024-01-15 10:00:00
+func parseReportTime(s string, defaultTime time.Time) time.Time {
+	t, err := time.ParseInLocation(time.DateTime, s, time.Local)
+	if err != nil {
+		return defaultTime
+	}
+	return t
+}
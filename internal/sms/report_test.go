@@ -0,0 +1,50 @@
+package sms
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseReports(t *testing.T) {
+	body := `[{"phone_number":"13800000000","send_time":"2024-01-15 10:00:00","report_time":"2024-01-15 10:00:05",
+		"success":true,"err_code":"DELIVERED","err_msg":"用户接收成功","sms_size":"1","biz_id":"biz1","out_id":""}]`
+	reports, err := ParseReports(ProviderAliyun, []byte(body))
+	assert.NoError(t, err)
+	assert.Len(t, reports, 1)
+	assert.Equal(t, "biz1", reports[0].MessageID)
+	assert.Equal(t, DeliveryDelivered, reports[0].DeliveryStatus())
+	assert.Equal(t, "DELIVERED", reports[0].ErrorCode)
+	assert.Equal(t, time.Date(2024, 1, 15, 10, 0, 5, 0, time.Local), reports[0].ReportAt)
+
+	body = `[{"user_receive_time":"2024-01-15 10:00:05","nationcode":"86","mobile":"13800000000",
+		"report_status":"FAIL","errmsg":"MK:0001","description":"用户关机","sid":"serial1"}]`
+	reports, err = ParseReports(ProviderTencent, []byte(body))
+	assert.NoError(t, err)
+	assert.Len(t, reports, 1)
+	assert.Equal(t, "serial1", reports[0].MessageID)
+	assert.Equal(t, DeliveryFailed, reports[0].DeliveryStatus())
+	assert.Equal(t, "MK:0001", reports[0].ErrorCode)
+
+	reports, err = ParseReports(ProviderLog, []byte(`[{"messageID":"log-1","delivered":true}]`))
+	assert.NoError(t, err)
+	assert.Equal(t, "log-1", reports[0].MessageID)
+	assert.False(t, reports[0].ReportAt.IsZero())
+
+	_, err = ParseReports(ProviderAliyun, []byte(`{}`))
+	assert.Error(t, err)
+	_, err = ParseReports("unknown", []byte(`[]`))
+	assert.Error(t, err)
+}
+
+func TestReportAck(t *testing.T) {
+	assert.Equal(t, map[string]interface{}{"result": 0, "errmsg": "OK"}, ReportAck(ProviderTencent))
+	assert.Equal(t, map[string]interface{}{"code": 0, "msg": "成功"}, ReportAck(ProviderAliyun))
+}
+
+func Test_errorCode(t *testing.T) {
+	assert.Equal(t, "isv.MOBILE_NUMBER_ILLEGAL", errorCode(&Error{Code: "isv.MOBILE_NUMBER_ILLEGAL"}))
+	assert.Equal(t, "NETWORK_ERROR", errorCode(&timeoutError{}))
+	assert.Equal(t, "SEND_ERROR", errorCode(assert.AnError))
+}
@@ -0,0 +1,79 @@
+// Package sms sends text messages through the configured provider and records them in sms_history.
+package sms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"lol/internal/config"
+)
+
+// provider names in the config
+const (
+	ProviderAliyun  = "aliyun"
+	ProviderTencent = "tencent"
+	ProviderLog     = "log"
+)
+
+// default timeout of the provider http requests
+const defaultTimeout = 10 * time.Second
+
+// Param a template variable, the order matters for providers which only accept positional params
+type Param struct {
+	Name  string
+	Value string
+}
+
+// Message a text message to send
+type Message struct {
+	Mobile     string  // 手机号
+	SignName   string  // 短信签名
+	TemplateID string  // 服务商模板ID
+	Params     []Param // 模板变量，按模板中出现的顺序
+	Content    string  // 渲染后的短信内容
+}
+
+// SmsSender send a text message through a provider
+type SmsSender interface {
+	// Name the provider name
+	Name() string
+	// Send the message and return the message id of the provider
+	Send(ctx context.Context, msg *Message) (string, error)
+}
+
+// Error an error returned by the provider
+type Error struct {
+	Code      string // 服务商错误码
+	Message   string // 服务商错误信息
+	Temporary bool   // 是否可以重试
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("sms provider error, code=%s, message=%s", e.Code, e.Message)
+}
+
+// IsTemporary whether the failed send can be retried, network errors and provider errors marked temporary are
+func IsTemporary(err error) bool {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Temporary
+	}
+	var ne net.Error
+	return errors.As(err, &ne)
+}
+
+// NewSender create the sender of the provider in the config
+func NewSender(conf config.Sms) (SmsSender, error) {
+	switch conf.Provider {
+	case ProviderAliyun:
+		return NewAliyunSender(conf.Aliyun), nil
+	case ProviderTencent:
+		return NewTencentSender(conf.Tencent), nil
+	case ProviderLog, "":
+		return NewLogSender(conf.LogFile), nil
+	}
+	return nil, fmt.Errorf("unknown sms provider %q", conf.Provider)
+}
@@ -0,0 +1,145 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"lol/internal/config"
+)
+
+func TestAliyunSign(t *testing.T) {
+	// the example of the aliyun sms signature document
+	params := url.Values{}
+	params.Set("AccessKeyId", "testId")
+	params.Set("Action", "SendSms")
+	params.Set("Format", "XML")
+	params.Set("OutId", "123")
+	params.Set("PhoneNumbers", "15300000001")
+	params.Set("RegionId", "cn-hangzhou")
+	params.Set("SignName", "阿里云短信测试专用")
+	params.Set("SignatureMethod", "HMAC-SHA1")
+	params.Set("SignatureNonce", "45e25e9b-0a6f-4070-8c85-2956eda1b466")
+	params.Set("SignatureVersion", "1.0")
+	params.Set("TemplateCode", "SMS_71390007")
+	params.Set("TemplateParam", `{"customer":"test"}`)
+	params.Set("Timestamp", "2017-07-12T02:42:19Z")
+	params.Set("Version", "2017-05-25")
+
+	signature := aliyunSign(http.MethodGet, aliyunCanonicalQuery(params), "testSecret")
+	assert.Equal(t, "zJDF+Lrzhj/ThnlvIToysFRq6t4=", signature)
+	assert.Equal(t, "a%20b%2Ac~", aliyunEncode("a b*c~"))
+}
+
+func TestAliyunSender_Send(t *testing.T) {
+	code := "OK"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "SendSms", r.URL.Query().Get("Action"))
+		assert.Equal(t, `{"code":"1234"}`, r.URL.Query().Get("TemplateParam"))
+		assert.NotEmpty(t, r.URL.Query().Get("Signature"))
+		_ = json.NewEncoder(w).Encode(map[string]string{"Code": code, "Message": "msg", "BizId": "biz1"})
+	}))
+	defer server.Close()
+
+	s := NewAliyunSender(config.SmsAliyun{AccessKeyID: "id", AccessKeySecret: "secret", Endpoint: server.URL})
+	msg := &Message{Mobile: "13800000000", SignName: "lol", TemplateID: "SMS_1", Params: []Param{{Name: "code", Value: "1234"}}}
+	messageID, err := s.Send(context.Background(), msg)
+	assert.NoError(t, err)
+	assert.Equal(t, "biz1", messageID)
+
+	code = "Throttling"
+	_, err = s.Send(context.Background(), msg)
+	assert.Error(t, err)
+	assert.True(t, IsTemporary(err))
+
+	code = "isv.MOBILE_NUMBER_ILLEGAL"
+	_, err = s.Send(context.Background(), msg)
+	assert.Error(t, err)
+	assert.False(t, IsTemporary(err))
+}
+
+func TestTencentSender_Send(t *testing.T) {
+	reply := `{"Response":{"SendStatusSet":[{"SerialNo":"serial1","Code":"Ok","Message":"send success"}],"RequestId":"r1"}}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "SendSms", r.Header.Get("X-TC-Action"))
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "TC3-HMAC-SHA256 Credential=id/"))
+		req := &tencentRequest{}
+		_ = json.NewDecoder(r.Body).Decode(req)
+		assert.Equal(t, []string{"+8613800000000"}, req.PhoneNumberSet)
+		assert.Equal(t, []string{"1234", "5"}, req.TemplateParamSet)
+		_, _ = w.Write([]byte(reply))
+	}))
+	defer server.Close()
+
+	s := NewTencentSender(config.SmsTencent{SecretID: "id", SecretKey: "key", SdkAppID: "app", Endpoint: server.URL})
+	msg := &Message{Mobile: "13800000000", TemplateID: "1", Params: []Param{{Name: "code", Value: "1234"}, {Name: "expire", Value: "5"}}}
+	messageID, err := s.Send(context.Background(), msg)
+	assert.NoError(t, err)
+	assert.Equal(t, "serial1", messageID)
+
+	reply = `{"Response":{"Error":{"Code":"InternalError.Timeout","Message":"timeout"},"RequestId":"r2"}}`
+	_, err = s.Send(context.Background(), msg)
+	assert.Error(t, err)
+	assert.True(t, IsTemporary(err))
+
+	reply = `{"Response":{"SendStatusSet":[{"Code":"LimitExceeded.PhoneNumberDailyLimit","Message":"limit"}],"RequestId":"r3"}}`
+	_, err = s.Send(context.Background(), msg)
+	assert.Error(t, err)
+	assert.False(t, IsTemporary(err))
+}
+
+func Test_tencentAuthorization(t *testing.T) {
+	a1 := tencentAuthorization("id", "key", "sms.tencentcloudapi.com", []byte("{}"), 1700000000)
+	a2 := tencentAuthorization("id", "key", "sms.tencentcloudapi.com", []byte("{}"), 1700000000)
+	a3 := tencentAuthorization("id", "key", "sms.tencentcloudapi.com", []byte("{ }"), 1700000000)
+	assert.Equal(t, a1, a2)
+	assert.NotEqual(t, a1, a3)
+	assert.True(t, strings.HasPrefix(a1, "TC3-HMAC-SHA256 Credential=id/2023-11-14/sms/tc3_request, SignedHeaders=content-type;host, Signature="))
+}
+
+func TestLogSender_Send(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "sms.log")
+	s := NewLogSender(file)
+	messageID, err := s.Send(context.Background(), &Message{Mobile: "13800000000", Content: "hello"})
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(messageID, "log-"))
+
+	data, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "hello")
+
+	_, err = NewLogSender("").Send(context.Background(), &Message{Mobile: "13800000000", Content: "hello"})
+	assert.NoError(t, err)
+}
+
+func TestNewSender(t *testing.T) {
+	for _, provider := range []string{ProviderAliyun, ProviderTencent, ProviderLog, ""} {
+		s, err := NewSender(config.Sms{Provider: provider})
+		assert.NoError(t, err)
+		assert.NotNil(t, s)
+	}
+	_, err := NewSender(config.Sms{Provider: "unknown"})
+	assert.Error(t, err)
+}
+
+func TestIsTemporary(t *testing.T) {
+	assert.True(t, IsTemporary(&Error{Temporary: true}))
+	assert.False(t, IsTemporary(&Error{}))
+	assert.False(t, IsTemporary(errors.New("error")))
+	assert.True(t, IsTemporary(&url.Error{Op: "Get", URL: "http://localhost", Err: &timeoutError{}}))
+}
+
+type timeoutError struct{}
+
+func (e *timeoutError) Error() string   { return "timeout" }
+func (e *timeoutError) Timeout() bool   { return true }
+func (e *timeoutError) Temporary() bool { return true }
@@ -0,0 +1,265 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-dev-frame/sponge/pkg/logger"
+
+	"lol/internal/config"
+	"lol/internal/dao"
+	"lol/internal/database"
+	"lol/internal/model"
+	"lol/internal/pii"
+)
+
+// TemplateLoginCode the template of borrower login codes, variables: code, expire,
+// it is always sensitive whatever the template says
+const TemplateLoginCode = "loginCode"
+
+// redactedValue the value of the variables of sensitive templates recorded in sms_history
+const redactedValue = "******"
+
+// TemplatePaymentSuccess the default template of payment confirmations,
+// variables: name, carPlate, amount, installment, remaining, nextDueDate
+const TemplatePaymentSuccess = "paymentSuccess"
+
+// send status of sms_history, 0 is the rows created by hand
+const (
+	StatusSending = 1
+	StatusSent    = 2
+	StatusFailed  = 3
+)
+
+// ErrTemplateDisabled the template of sms_template is disabled
+var ErrTemplateDisabled = errors.New("sms template is disabled")
+
+// Request a text message to send with a template
+type Request struct {
+	UserName string            // 收信人
+	Mobile   string            // 手机号
+	Template string            // 模板编码
+	Params   map[string]string // 模板变量
+	LoanID   uint64            // 关联借款序号，可以为0
+	BizKey   string            // 业务去重键，同一个键只发送一次，为空表示不去重
+}
+
+// Service render the template, send the message through the provider and record it in sms_history,
+// templates are looked up in sms_template by code first, and then in the config.
+type Service struct {
+	sender        SmsSender
+	smsDao        dao.SmsHistoryDao
+	templateDao   dao.SmsTemplateDao // if nil, only the templates of the config are used
+	signName      string
+	templates     map[string]config.SmsTemplate
+	maxRetries    int
+	retryInterval time.Duration
+}
+
+// NewService create the send service with the provider in the config, panic if the provider is unknown
+func NewService(smsDao dao.SmsHistoryDao, templateDao dao.SmsTemplateDao) *Service {
+	conf := config.Get().Sms
+	sender, err := NewSender(conf)
+	if err != nil {
+		panic(err)
+	}
+	return NewServiceWithSender(conf, sender, smsDao, templateDao)
+}
+
+// NewServiceWithSender create the send service with the sender
+func NewServiceWithSender(conf config.Sms, sender SmsSender, smsDao dao.SmsHistoryDao, templateDao dao.SmsTemplateDao) *Service {
+	retryInterval := time.Duration(conf.RetryInterval) * time.Second
+	if retryInterval <= 0 {
+		retryInterval = time.Second
+	}
+	return &Service{
+		sender:        sender,
+		smsDao:        smsDao,
+		templateDao:   templateDao,
+		signName:      conf.SignName,
+		templates:     conf.Templates,
+		maxRetries:    conf.MaxRetries,
+		retryInterval: retryInterval,
+	}
+}
+
+// Send the message, the sms_history row is written before sending and updated with the result,
+// so a message is recorded even if the process exits while sending. The biz_key of sms_history is unique,
+// if the business key of the request is recorded already, e.g. by another replica, the message is not sent
+// and dao.ErrBizKeyExists is returned.
+func (s *Service) Send(ctx context.Context, req *Request) (*model.SmsHistory, error) {
+	tpl, err := s.getTemplate(ctx, req.Template)
+	if err != nil {
+		return nil, err
+	}
+	content, params, err := Render(tpl.Content, req.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	// 敏感模板如验证码，发送记录中的变量值替换为******，有短信查看权限的用户也无法获取
+	stored := content
+	if tpl.Sensitive || req.Template == TemplateLoginCode {
+		stored = Redact(tpl.Content)
+	}
+
+	now := time.Now()
+	history := &model.SmsHistory{
+		UserName: req.UserName,
+		Mobile:   req.Mobile,
+		Content:  stored,
+		Template: req.Template,
+		LoanID:   req.LoanID,
+		Provider: s.sender.Name(),
+		Status:   StatusSending,
+		CreateAt: &now,
+	}
+	if req.BizKey != "" {
+		history.BizKey = &req.BizKey
+	}
+	err = s.smsDao.Create(ctx, history)
+	if err != nil {
+		return nil, err
+	}
+
+	messageID, err := s.send(ctx, &Message{
+		Mobile:     req.Mobile,
+		SignName:   s.signName,
+		TemplateID: tpl.TemplateID,
+		Params:     params,
+		Content:    content,
+	})
+	history.MessageID = messageID
+	history.Status = StatusSent
+	if err != nil {
+		history.Status = StatusFailed
+		history.ErrorCode = errorCode(err)
+	}
+	updateErr := s.smsDao.UpdateByID(ctx, &model.SmsHistory{
+		ID:        history.ID,
+		MessageID: messageID,
+		Status:    history.Status,
+		ErrorCode: history.ErrorCode,
+	})
+	if updateErr != nil {
+		logger.Error("update sms history error", logger.Err(updateErr), logger.Uint64("id", history.ID))
+	}
+	return history, err
+}
+
+// getTemplate get the template of the code from sms_template, or from the config if it is not in the table
+func (s *Service) getTemplate(ctx context.Context, code string) (config.SmsTemplate, error) {
+	if s.templateDao != nil {
+		tpl, err := s.templateDao.GetByCode(ctx, code)
+		if err == nil {
+			if !tpl.Enabled {
+				return config.SmsTemplate{}, fmt.Errorf("%w: %s", ErrTemplateDisabled, code)
+			}
+			return config.SmsTemplate{TemplateID: tpl.TemplateID, Content: tpl.Content, Sensitive: tpl.Sensitive}, nil
+		}
+		if !errors.Is(err, database.ErrRecordNotFound) {
+			return config.SmsTemplate{}, err
+		}
+	}
+
+	tpl, ok := s.templates[code]
+	if !ok {
+		return config.SmsTemplate{}, fmt.Errorf("sms template %q is not configured", code)
+	}
+	return tpl, nil
+}
+
+// send the message, transient failures are retried with an exponential interval
+func (s *Service) send(ctx context.Context, msg *Message) (string, error) {
+	interval := s.retryInterval
+	for i := 0; ; i++ {
+		messageID, err := s.sender.Send(ctx, msg)
+		if err == nil || !IsTemporary(err) || i >= s.maxRetries {
+			return messageID, err
+		}
+		logger.Warn("send sms failed, retrying", logger.Err(err), logger.String("provider", s.sender.Name()),
+			pii.String("mobile", pii.KindMobile, msg.Mobile), logger.Int("attempt", i+1))
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(interval):
+		}
+		interval *= 2
+	}
+}
+
+// errorCode the error code recorded in sms_history, the code of the provider if there is one
+func errorCode(err error) string {
+	code := "SEND_ERROR"
+	var e *Error
+	switch {
+	case errors.As(err, &e) && e.Code != "":
+		code = e.Code
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		code = "CANCELED"
+	case IsTemporary(err):
+		code = "NETWORK_ERROR"
+	}
+	if len(code) > 64 {
+		code = code[:64]
+	}
+	return code
+}
+
+// Render replace the {name} variables of the content, the variables are returned in the order they appear,
+// every variable must be provided.
+func Render(content string, values map[string]string) (string, []Param, error) {
+	var (
+		b      strings.Builder
+		params []Param
+		seen   = map[string]bool{}
+	)
+	for {
+		start := strings.Index(content, "{")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(content[start:], "}")
+		if end < 0 {
+			break
+		}
+		name := content[start+1 : start+end]
+		value, ok := values[name]
+		if !ok {
+			return "", nil, fmt.Errorf("sms template variable %q is missing", name)
+		}
+		b.WriteString(content[:start])
+		b.WriteString(value)
+		if !seen[name] {
+			seen[name] = true
+			params = append(params, Param{Name: name, Value: value})
+		}
+		content = content[start+end+1:]
+	}
+	b.WriteString(content)
+	return b.String(), params, nil
+}
+
+// Redact replace every {name} variable of the content with ******
+func Redact(content string) string {
+	var b strings.Builder
+	for {
+		start := strings.Index(content, "{")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(content[start:], "}")
+		if end < 0 {
+			break
+		}
+		b.WriteString(content[:start])
+		b.WriteString(redactedValue)
+		content = content[start+end+1:]
+	}
+	b.WriteString(content)
+	return b.String()
+}
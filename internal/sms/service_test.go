@@ -0,0 +1,176 @@
+package sms
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm/schema"
+
+	"github.com/go-dev-frame/sponge/pkg/gotest"
+
+	"lol/internal/config"
+	"lol/internal/dao"
+	"lol/internal/model"
+)
+
+type fakeSender struct {
+	errs  []error
+	calls int
+}
+
+func (s *fakeSender) Name() string { return "fake" }
+
+func (s *fakeSender) Send(ctx context.Context, msg *Message) (string, error) {
+	s.calls++
+	if len(s.errs) > 0 {
+		err := s.errs[0]
+		s.errs = s.errs[1:]
+		return "", err
+	}
+	return "id1", nil
+}
+
+func newTestService(sender SmsSender) (*Service, *gotest.Dao) {
+	d := gotest.NewDao(nil, &model.SmsHistory{})
+	conf := config.Sms{
+		MaxRetries: 2,
+		Templates: map[string]config.SmsTemplate{
+			TemplateLoginCode: {TemplateID: "SMS_1", Content: "code {code}, expire {expire} minutes"},
+		},
+	}
+	s := NewServiceWithSender(conf, sender, dao.NewSmsHistoryDao(d.DB, nil), nil)
+	s.retryInterval = 0
+	return s, d
+}
+
+func expectHistory(d *gotest.Dao) {
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("INSERT INTO .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	d.SQLMock.ExpectCommit()
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	d.SQLMock.ExpectCommit()
+}
+
+func TestService_Send(t *testing.T) {
+	sender := &fakeSender{errs: []error{&Error{Code: "Throttling", Temporary: true}}}
+	s, d := newTestService(sender)
+	defer d.Close()
+	req := &Request{
+		UserName: "张三",
+		Mobile:   "13800000000",
+		Template: TemplateLoginCode,
+		Params:   map[string]string{"code": "1234", "expire": "5"},
+	}
+
+	// the transient failure is retried
+	expectHistory(d)
+	history, err := s.Send(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, sender.calls)
+	assert.Equal(t, StatusSent, history.Status)
+	assert.Equal(t, "id1", history.MessageID)
+	assert.Equal(t, "code ******, expire ****** minutes", history.Content)
+
+	// the permanent failure is not retried
+	sender.calls = 0
+	sender.errs = []error{&Error{Code: "isv.MOBILE_NUMBER_ILLEGAL"}}
+	expectHistory(d)
+	history, err = s.Send(context.Background(), req)
+	assert.Error(t, err)
+	assert.Equal(t, 1, sender.calls)
+	assert.Equal(t, StatusFailed, history.Status)
+
+	// unknown template
+	_, err = s.Send(context.Background(), &Request{Template: "unknown"})
+	assert.Error(t, err)
+}
+
+// noCode match the values written to the database which don't contain the login code
+type noCode string
+
+func (code noCode) Match(v driver.Value) bool {
+	s, ok := v.(string)
+	return !ok || !strings.Contains(s, string(code))
+}
+
+func TestService_Send_loginCode(t *testing.T) {
+	sender := &fakeSender{}
+	s, d := newTestService(sender)
+	defer d.Close()
+
+	// the code is sent to the provider, but never written to sms_history
+	table, err := schema.Parse(&model.SmsHistory{}, &sync.Map{}, schema.NamingStrategy{})
+	assert.NoError(t, err)
+	args := make([]driver.Value, len(table.DBNames)-1) // all the columns except id
+	for i := range args {
+		args[i] = noCode("73920")
+	}
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("INSERT INTO .*").WithArgs(args...).WillReturnResult(sqlmock.NewResult(1, 1))
+	d.SQLMock.ExpectCommit()
+	d.SQLMock.ExpectBegin()
+	d.SQLMock.ExpectExec("UPDATE .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	d.SQLMock.ExpectCommit()
+
+	history, err := s.Send(context.Background(), &Request{
+		Mobile:   "13800000000",
+		Template: TemplateLoginCode,
+		Params:   map[string]string{"code": "73920", "expire": "5"},
+	})
+	assert.NoError(t, err)
+	assert.NotContains(t, history.Content, "73920")
+	assert.NoError(t, d.SQLMock.ExpectationsWereMet())
+}
+
+func TestRedact(t *testing.T) {
+	assert.Equal(t, "验证码：******，******分钟内有效", Redact("验证码：{code}，{expire}分钟内有效"))
+	assert.Equal(t, "no variables", Redact("no variables"))
+}
+
+func TestRender(t *testing.T) {
+	content, params, err := Render("{name}您好，{amount}元将于{dueDate}到期，{name}请按时还款", map[string]string{
+		"name": "张三", "amount": "100.00", "dueDate": "2024-01-15",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "张三您好，100.00元将于2024-01-15到期，张三请按时还款", content)
+	assert.Equal(t, []Param{{"name", "张三"}, {"amount", "100.00"}, {"dueDate", "2024-01-15"}}, params)
+
+	_, _, err = Render("{code}", nil)
+	assert.Error(t, err)
+
+	content, params, err = Render("no variables", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "no variables", content)
+	assert.Empty(t, params)
+}
+
+func TestService_getTemplate(t *testing.T) {
+	s, d := newTestService(&fakeSender{})
+	defer d.Close()
+	s.templateDao = dao.NewSmsTemplateDao(d.DB, nil)
+
+	// the template of the table takes precedence
+	d.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id", "code", "template_id", "content", "enabled"}).
+		AddRow(1, TemplateLoginCode, "SMS_2", "code {code}", true))
+	tpl, err := s.getTemplate(context.Background(), TemplateLoginCode)
+	assert.NoError(t, err)
+	assert.Equal(t, "SMS_2", tpl.TemplateID)
+
+	// disabled
+	d.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id", "code", "template_id", "content", "enabled"}).
+		AddRow(1, TemplateLoginCode, "SMS_2", "code {code}", false))
+	_, err = s.getTemplate(context.Background(), TemplateLoginCode)
+	assert.ErrorIs(t, err, ErrTemplateDisabled)
+
+	// not in the table, the config is used
+	d.SQLMock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	tpl, err = s.getTemplate(context.Background(), TemplateLoginCode)
+	assert.NoError(t, err)
+	assert.Equal(t, "SMS_1", tpl.TemplateID)
+}
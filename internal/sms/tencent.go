@@ -0,0 +1,174 @@
+package sms
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"lol/internal/config"
+)
+
+const (
+	tencentEndpoint = "https://sms.tencentcloudapi.com"
+	tencentService  = "sms"
+	tencentVersion  = "2021-01-11"
+	tencentRegion   = "ap-guangzhou"
+)
+
+// TencentSender send text messages through the SendSms api 3.0 of Tencent Cloud SMS
+type TencentSender struct {
+	conf     config.SmsTencent
+	endpoint string
+	client   *http.Client
+}
+
+// NewTencentSender create a tencent sender
+func NewTencentSender(conf config.SmsTencent) *TencentSender {
+	endpoint := conf.Endpoint
+	if endpoint == "" {
+		endpoint = tencentEndpoint
+	}
+	return &TencentSender{
+		conf:     conf,
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: defaultTimeout},
+	}
+}
+
+// Name the provider name
+func (s *TencentSender) Name() string {
+	return ProviderTencent
+}
+
+type tencentRequest struct {
+	PhoneNumberSet   []string `json:"PhoneNumberSet"`
+	SmsSdkAppID      string   `json:"SmsSdkAppId"`
+	SignName         string   `json:"SignName"`
+	TemplateID       string   `json:"TemplateId"`
+	TemplateParamSet []string `json:"TemplateParamSet"`
+}
+
+type tencentReply struct {
+	Response struct {
+		Error *struct {
+			Code    string `json:"Code"`
+			Message string `json:"Message"`
+		} `json:"Error"`
+		SendStatusSet []struct {
+			SerialNo string `json:"SerialNo"`
+			Code     string `json:"Code"`
+			Message  string `json:"Message"`
+		} `json:"SendStatusSet"`
+		RequestID string `json:"RequestId"`
+	} `json:"Response"`
+}
+
+// Send the message, the SerialNo of tencent is returned as the message id,
+// tencent only accepts positional template params, they are sent in the order of msg.Params.
+func (s *TencentSender) Send(ctx context.Context, msg *Message) (string, error) {
+	mobile := msg.Mobile
+	if !strings.HasPrefix(mobile, "+") {
+		mobile = "+86" + mobile
+	}
+	params := make([]string, 0, len(msg.Params))
+	for _, p := range msg.Params {
+		params = append(params, p.Value)
+	}
+	payload, err := json.Marshal(&tencentRequest{
+		PhoneNumberSet:   []string{mobile},
+		SmsSdkAppID:      s.conf.SdkAppID,
+		SignName:         msg.SignName,
+		TemplateID:       msg.TemplateID,
+		TemplateParamSet: params,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(s.endpoint)
+	if err != nil {
+		return "", err
+	}
+	region := s.conf.Region
+	if region == "" {
+		region = tencentRegion
+	}
+	timestamp := time.Now().Unix()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Host", u.Host)
+	req.Header.Set("X-TC-Action", "SendSms")
+	req.Header.Set("X-TC-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-TC-Version", tencentVersion)
+	req.Header.Set("X-TC-Region", region)
+	req.Header.Set("Authorization", tencentAuthorization(s.conf.SecretID, s.conf.SecretKey, u.Host, payload, timestamp))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close() //nolint
+
+	reply := &tencentReply{}
+	err = json.NewDecoder(resp.Body).Decode(reply)
+	if err != nil {
+		return "", &Error{Code: resp.Status, Message: err.Error(), Temporary: resp.StatusCode >= http.StatusInternalServerError}
+	}
+	if e := reply.Response.Error; e != nil {
+		return "", &Error{Code: e.Code, Message: e.Message, Temporary: tencentTemporary(e.Code)}
+	}
+	if len(reply.Response.SendStatusSet) == 0 {
+		return "", &Error{Code: "EmptySendStatus", Message: "no send status, request id " + reply.Response.RequestID}
+	}
+	status := reply.Response.SendStatusSet[0]
+	if status.Code != "Ok" {
+		return "", &Error{Code: status.Code, Message: status.Message, Temporary: tencentTemporary(status.Code)}
+	}
+	return status.SerialNo, nil
+}
+
+// tencentTemporary internal errors and the rate limit of the api can be retried,
+// the frequency limits of a mobile (LimitExceeded.PhoneNumber*) can not.
+func tencentTemporary(code string) bool {
+	return strings.HasPrefix(code, "InternalError") || code == "RequestLimitExceeded"
+}
+
+// tencentAuthorization the TC3-HMAC-SHA256 signature of api 3.0, only content-type and host are signed
+func tencentAuthorization(secretID string, secretKey string, host string, payload []byte, timestamp int64) string {
+	date := time.Unix(timestamp, 0).UTC().Format(time.DateOnly)
+	canonicalRequest := "POST\n/\n\n" +
+		"content-type:application/json; charset=utf-8\nhost:" + host + "\n\n" +
+		"content-type;host\n" + sha256Hex(payload)
+	credentialScope := date + "/" + tencentService + "/tc3_request"
+	stringToSign := "TC3-HMAC-SHA256\n" + strconv.FormatInt(timestamp, 10) + "\n" + credentialScope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	secretDate := hmacSHA256([]byte("TC3"+secretKey), date)
+	secretService := hmacSHA256(secretDate, tencentService)
+	secretSigning := hmacSHA256(secretService, "tc3_request")
+	signature := hex.EncodeToString(hmacSHA256(secretSigning, stringToSign))
+
+	return "TC3-HMAC-SHA256 Credential=" + secretID + "/" + credentialScope +
+		", SignedHeaders=content-type;host, Signature=" + signature
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, s string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(s))
+	return mac.Sum(nil)
+}
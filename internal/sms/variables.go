@@ -0,0 +1,180 @@
+package sms
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"lol/internal/model"
+	"lol/internal/repayment"
+)
+
+// LoanVariables the variables of the messages about a loan, such as repayment reminders
+var LoanVariables = []string{"name", "carPlate", "amount", "dueDate"}
+
+// PaymentVariables the variables of payment confirmations
+var PaymentVariables = []string{"name", "carPlate", "amount", "installment", "remaining", "nextDueDate"}
+
+// templateVariables the variables provided by the business code for the templates,
+// templates of other codes are sent with LoanVariables.
+var templateVariables = map[string][]string{
+	TemplateLoginCode:      {"code", "expire"},
+	TemplatePaymentSuccess: PaymentVariables,
+}
+
+// sampleParams the values of the variables that do not come from a loan, used to preview templates
+var sampleParams = map[string]string{
+	"code":        "123456",
+	"expire":      "5",
+	"installment": "1",
+}
+
+// Variables the variables available to the template of the code
+func Variables(code string) []string {
+	if v, ok := templateVariables[code]; ok {
+		return v
+	}
+	return LoanVariables
+}
+
+// Placeholders the {name} variables of the content in the order they appear, duplicates are removed
+func Placeholders(content string) ([]string, error) {
+	var (
+		names []string
+		seen  = map[string]bool{}
+	)
+	for {
+		start := strings.IndexAny(content, "{}")
+		if start < 0 {
+			return names, nil
+		}
+		if content[start] == '}' {
+			return nil, fmt.Errorf("unexpected '}' in sms template")
+		}
+		end := strings.IndexAny(content[start+1:], "{}")
+		if end < 0 || content[start+1+end] == '{' {
+			return nil, fmt.Errorf("unclosed '{' in sms template")
+		}
+		name := content[start+1 : start+1+end]
+		if name == "" {
+			return nil, fmt.Errorf("empty variable in sms template")
+		}
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+		content = content[start+end+2:]
+	}
+}
+
+// Validate check that every variable of the content is available to the template of the code
+func Validate(code string, content string) error {
+	names, err := Placeholders(content)
+	if err != nil {
+		return err
+	}
+	available := map[string]bool{}
+	for _, v := range Variables(code) {
+		available[v] = true
+	}
+	for _, name := range names {
+		if !available[name] {
+			return fmt.Errorf("variable {%s} is not available, the variables of %q are %s",
+				name, code, strings.Join(Variables(code), ", "))
+		}
+	}
+	return nil
+}
+
+// LoanParams the values of LoanVariables, amount and dueDate are of the installment,
+// they are empty if installment is nil.
+func LoanParams(loan *model.Loan, installment *repayment.Installment) map[string]string {
+	params := map[string]string{
+		"name":     loan.Name,
+		"carPlate": loan.CarPlate,
+		"amount":   "",
+		"dueDate":  "",
+	}
+	if installment != nil {
+		params["amount"] = strconv.FormatFloat(installment.Outstanding(), 'f', 2, 64)
+		params["dueDate"] = installment.DueDate.Format(time.DateOnly)
+	}
+	return params
+}
+
+// PreviewParams the values to preview a template with the loan, the next unpaid installment is used,
+// variables that do not come from a loan are filled with sample values.
+func PreviewParams(loan *model.Loan) map[string]string {
+	schedule := repayment.NewLoanSchedule(loan)
+	schedule.Allocate(loan.PaidMoney)
+	var installment *repayment.Installment
+	if unpaid := schedule.Unpaid(); len(unpaid) > 0 {
+		installment = unpaid[0]
+	}
+
+	params := LoanParams(loan, installment)
+	params["remaining"] = strconv.FormatFloat(schedule.Outstanding(), 'f', 2, 64)
+	params["nextDueDate"] = nextDueDate(schedule)
+	for k, v := range sampleParams {
+		params[k] = v
+	}
+	return params
+}
+
+// PaymentParams the values of PaymentVariables for the payment, the loan must be loaded with its repayment
+// including the payment. installment is the range of installments the payment was allocated to, e.g. 3 or 3-4.
+func PaymentParams(loan *model.Loan, payment *model.PaymentHistory) map[string]string {
+	schedule := repayment.NewLoanSchedule(loan)
+	paid := loan.PaidMoney
+	if loan.Status == 1 {
+		// the repayment of settled loans is not loaded
+		paid = schedule.Total()
+	}
+
+	schedule.Allocate(paid - payment.Amount)
+	before := make([]float64, len(schedule.Installments))
+	for i, v := range schedule.Installments {
+		before[i] = v.PaidAmount
+	}
+	schedule.Allocate(paid)
+	first, last := 0, 0
+	for i, v := range schedule.Installments {
+		if v.PaidAmount > before[i] {
+			if first == 0 {
+				first = v.Seq
+			}
+			last = v.Seq
+		}
+	}
+	installment := "-"
+	switch {
+	case first == 0:
+	case first == last:
+		installment = strconv.Itoa(first)
+	default:
+		installment = fmt.Sprintf("%d-%d", first, last)
+	}
+
+	// the amount the borrower paid, including the overdue fee and the payment fee
+	amount := payment.TotalAmount
+	if amount <= 0 {
+		amount = payment.Amount
+	}
+	return map[string]string{
+		"name":        loan.Name,
+		"carPlate":    loan.CarPlate,
+		"amount":      strconv.FormatFloat(amount, 'f', 2, 64),
+		"installment": installment,
+		"remaining":   strconv.FormatFloat(schedule.Outstanding(), 'f', 2, 64),
+		"nextDueDate": nextDueDate(schedule),
+	}
+}
+
+// nextDueDate the due date of the first unpaid installment, - if the loan is paid off
+func nextDueDate(schedule *repayment.Schedule) string {
+	if unpaid := schedule.Unpaid(); len(unpaid) > 0 {
+		return unpaid[0].DueDate.Format(time.DateOnly)
+	}
+	return "-"
+}
@@ -0,0 +1,100 @@
+package sms
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"lol/internal/model"
+)
+
+func TestPlaceholders(t *testing.T) {
+	names, err := Placeholders("{name}您好，{amount}元将于{dueDate}到期，{name}请按时还款")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"name", "amount", "dueDate"}, names)
+
+	names, err = Placeholders("no variables")
+	assert.NoError(t, err)
+	assert.Empty(t, names)
+
+	for _, content := range []string{"{name", "name}", "{}", "{na{me}"} {
+		_, err = Placeholders(content)
+		assert.Error(t, err, content)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate("repaymentReminder", "{name}您好，{carPlate}应还{amount}元，还款日{dueDate}"))
+	assert.NoError(t, Validate(TemplateLoginCode, "验证码{code}，{expire}分钟内有效"))
+	assert.Error(t, Validate(TemplateLoginCode, "{name}您好，验证码{code}"))
+	assert.Error(t, Validate("repaymentReminder", "验证码{code}"))
+	assert.Error(t, Validate("repaymentReminder", "{name"))
+	assert.Equal(t, LoanVariables, Variables("overdueReminder"))
+}
+
+func TestPreviewParams(t *testing.T) {
+	createAt := time.Date(2024, 1, 10, 0, 0, 0, 0, time.Local)
+	loan := &model.Loan{
+		Name:           "张三",
+		CarPlate:       "粤A12345",
+		LoanMoney:      1200,
+		LoanPeriod:     12,
+		LoanReturnDate: "15",
+		MonthlyPayment: 110,
+		CreateAt:       &createAt,
+		PaidMoney:      150,
+	}
+	params := PreviewParams(loan)
+	assert.Equal(t, "张三", params["name"])
+	assert.Equal(t, "粤A12345", params["carPlate"])
+	assert.Equal(t, "70.00", params["amount"])
+	assert.Equal(t, "2024-03-15", params["dueDate"])
+	assert.Equal(t, "2024-03-15", params["nextDueDate"])
+	assert.Equal(t, "1170.00", params["remaining"])
+	assert.NotEmpty(t, params["code"])
+	assert.NoError(t, Validate(TemplatePaymentSuccess, "{name}您好，您已成功还款{amount}元（第{installment}期），剩余应还{remaining}元"))
+
+	// the loan is paid off
+	loan.PaidMoney = 1320
+	params = PreviewParams(loan)
+	assert.Equal(t, "", params["amount"])
+	assert.Equal(t, "", params["dueDate"])
+}
+
+func TestPaymentParams(t *testing.T) {
+	createAt := time.Date(2024, 1, 10, 0, 0, 0, 0, time.Local)
+	loan := &model.Loan{
+		Name:           "张三",
+		CarPlate:       "粤A12345",
+		LoanMoney:      1200,
+		LoanPeriod:     12,
+		LoanReturnDate: "15",
+		MonthlyPayment: 110,
+		CreateAt:       &createAt,
+		PaidMoney:      280,
+	}
+
+	// the first installment was paid before, the payment settles the second one and a part of the third one
+	params := PaymentParams(loan, &model.PaymentHistory{Amount: 170, TotalAmount: 171.02})
+	assert.Equal(t, map[string]string{
+		"name":        "张三",
+		"carPlate":    "粤A12345",
+		"amount":      "171.02",
+		"installment": "2-3",
+		"remaining":   "1040.00",
+		"nextDueDate": "2024-04-15",
+	}, params)
+
+	params = PaymentParams(loan, &model.PaymentHistory{Amount: 60})
+	assert.Equal(t, "60.00", params["amount"])
+	assert.Equal(t, "3", params["installment"])
+
+	// the payment settles the loan
+	loan.Status = 1
+	loan.PaidMoney = 0
+	params = PaymentParams(loan, &model.PaymentHistory{Amount: 110})
+	assert.Equal(t, "12", params["installment"])
+	assert.Equal(t, "0.00", params["remaining"])
+	assert.Equal(t, "-", params["nextDueDate"])
+}
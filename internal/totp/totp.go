@@ -0,0 +1,98 @@
+// Package totp implements time-based one-time passwords (RFC 6238) compatible with authenticator apps,
+// the codes have 6 digits and change every 30 seconds.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1" //nolint
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Period the seconds a code is valid for
+	Period = 30
+	// Digits the number of digits of a code
+	Digits = 6
+	// Skew the number of periods before and after the current one which are also accepted
+	Skew = 1
+
+	secretSize = 20
+)
+
+var b32 = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret generate a random base32 secret
+func GenerateSecret() (string, error) {
+	secret := make([]byte, secretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return b32.EncodeToString(secret), nil
+}
+
+// Code compute the code of the secret at time t
+func Code(secret string, t time.Time) (string, error) {
+	key, err := b32.DecodeString(strings.ToUpper(strings.TrimSpace(secret)))
+	if err != nil {
+		return "", err
+	}
+	return hotp(key, uint64(t.Unix()/Period)), nil
+}
+
+// Validate check the code of the secret at time t, allowing a clock skew of one period
+func Validate(secret string, code string, t time.Time) bool {
+	_, ok := ValidateCounter(secret, code, t)
+	return ok
+}
+
+// ValidateCounter check the code like Validate and return the counter (the time step) it matched,
+// a code is accepted for several periods, so the counter is saved to reject the same code again
+func ValidateCounter(secret string, code string, t time.Time) (int64, bool) {
+	key, err := b32.DecodeString(strings.ToUpper(strings.TrimSpace(secret)))
+	if err != nil || len(code) != Digits {
+		return 0, false
+	}
+	counter := t.Unix() / Period
+	for i := -Skew; i <= Skew; i++ {
+		if subtle.ConstantTimeCompare([]byte(hotp(key, uint64(counter+int64(i)))), []byte(code)) == 1 {
+			return counter + int64(i), true
+		}
+	}
+	return 0, false
+}
+
+// URI the otpauth provisioning uri, authenticator apps scan it as a QR code
+func URI(secret string, issuer string, account string) string {
+	v := url.Values{}
+	v.Set("secret", secret)
+	v.Set("issuer", issuer)
+	v.Set("algorithm", "SHA1")
+	v.Set("digits", fmt.Sprint(Digits))
+	v.Set("period", fmt.Sprint(Period))
+	label := url.PathEscape(issuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + v.Encode()
+}
+
+// hotp the HMAC-based one-time password of RFC 4226
+func hotp(key []byte, counter uint64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < Digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", Digits, value%mod)
+}
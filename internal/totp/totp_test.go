@@ -0,0 +1,73 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// the sha1 test vectors of RFC 6238, truncated to 6 digits
+func TestCode(t *testing.T) {
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+	}
+	for _, tt := range tests {
+		got, err := Code(secret, time.Unix(tt.unix, 0))
+		assert.NoError(t, err)
+		assert.Equal(t, tt.want, got)
+	}
+
+	_, err := Code("not base32!", time.Now())
+	assert.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	assert.NoError(t, err)
+	now := time.Now()
+	code, err := Code(secret, now)
+	assert.NoError(t, err)
+
+	assert.True(t, Validate(secret, code, now))
+	assert.True(t, Validate(strings.ToLower(secret), code, now.Add(Period*time.Second)))
+	assert.False(t, Validate(secret, code, now.Add(3*Period*time.Second)))
+	assert.False(t, Validate(secret, "12345", now))
+	assert.False(t, Validate("not base32!", code, now))
+}
+
+func TestValidateCounter(t *testing.T) {
+	secret, err := GenerateSecret()
+	assert.NoError(t, err)
+	now := time.Unix(1700000000, 0)
+	code, err := Code(secret, now)
+	assert.NoError(t, err)
+
+	counter, ok := ValidateCounter(secret, code, now)
+	assert.True(t, ok)
+	assert.Equal(t, now.Unix()/Period, counter)
+	// the same counter is matched in the next period
+	counter, ok = ValidateCounter(secret, code, now.Add(Period*time.Second))
+	assert.True(t, ok)
+	assert.Equal(t, now.Unix()/Period, counter)
+
+	_, ok = ValidateCounter(secret, "12345", now)
+	assert.False(t, ok)
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("JBSWY3DPEHPK3PXP", "lol", "admin")
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/lol:admin?"))
+	assert.Contains(t, uri, "secret=JBSWY3DPEHPK3PXP")
+	assert.Contains(t, uri, "issuer=lol")
+}
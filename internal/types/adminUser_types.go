@@ -0,0 +1,150 @@
+package types
+
+import (
+	"time"
+
+	"github.com/go-dev-frame/sponge/pkg/sgorm/query"
+)
+
+var _ time.Time
+
+// Tip: suggested filling in the binding rules https://github.com/go-playground/validator in request struct fields tag.
+
+// CreateAdminUserRequest request params
+type CreateAdminUserRequest struct {
+	Username string `json:"username" binding:"required,max=50"`                          // 用户名
+	Password string `json:"password" binding:"required,min=8,max=72"`                    // 密码
+	Role     string `json:"role" binding:"required,oneof=viewer operator finance admin"` // 角色
+}
+
+// UpdateAdminUserByIDRequest request params
+type UpdateAdminUserByIDRequest struct {
+	ID uint64 `json:"id" binding:""` // uint64 id
+	// 序号
+	Password string `json:"password" binding:"omitempty,min=8,max=72"`                    // 密码，为空时不修改
+	Role     string `json:"role" binding:"omitempty,oneof=viewer operator finance admin"` // 角色
+	Status   int    `json:"status" binding:"omitempty,oneof=1 2"`                         // 状态 1:正常 2:禁用
+}
+
+// AdminUserObjDetail detail
+type AdminUserObjDetail struct {
+	ID uint64 `json:"id"` // convert to uint64 id
+	// 序号
+	Username           string     `json:"username"`           // 用户名
+	Role               string     `json:"role"`               // 角色
+	Status             int        `json:"status"`             // 状态 1:正常 2:禁用
+	TotpEnabled        bool       `json:"totpEnabled"`        // 是否已开启两步验证
+	MustChangePassword bool       `json:"mustChangePassword"` // 是否需要先修改密码
+	CreateAt           *time.Time `json:"createAt"`           // 创建时间
+	UpdateAt           *time.Time `json:"updateAt"`           // 更新时间
+}
+
+// AdminLoginRequest request params
+type AdminLoginRequest struct {
+	Username string `json:"username" binding:"required"` // 用户名
+	Password string `json:"password" binding:"required"` // 密码
+}
+
+// ChangeAdminPasswordRequest request params
+type ChangeAdminPasswordRequest struct {
+	Password    string `json:"password" binding:"required"`                 // 当前密码
+	NewPassword string `json:"newPassword" binding:"required,min=8,max=72"` // 新密码
+}
+
+// AdminChallengeRequest request params
+type AdminChallengeRequest struct {
+	ChallengeToken string `json:"challengeToken" binding:"required"` // 登录返回的两步验证令牌
+}
+
+// VerifyAdminChallengeRequest request params
+type VerifyAdminChallengeRequest struct {
+	ChallengeToken string `json:"challengeToken" binding:"required"` // 登录返回的两步验证令牌
+	Code           string `json:"code" binding:"required"`           // 动态验证码或恢复码
+}
+
+// TwoFactorCodeRequest request params
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"` // 动态验证码
+}
+
+// CreateAdminUserReply only for api docs
+type CreateAdminUserReply struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		ID uint64 `json:"id"` // id
+	} `json:"data"` // return data
+}
+
+// DeleteAdminUserByIDReply only for api docs
+type DeleteAdminUserByIDReply struct {
+	Result
+}
+
+// UpdateAdminUserByIDReply only for api docs
+type UpdateAdminUserByIDReply struct {
+	Result
+}
+
+// GetAdminUserByIDReply only for api docs
+type GetAdminUserByIDReply struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		AdminUser AdminUserObjDetail `json:"adminUser"`
+	} `json:"data"` // return data
+}
+
+// ListAdminUsersRequest request params
+type ListAdminUsersRequest struct {
+	query.Params
+}
+
+// ListAdminUsersReply only for api docs
+type ListAdminUsersReply struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		AdminUsers []AdminUserObjDetail `json:"adminUsers"`
+	} `json:"data"` // return data
+}
+
+// AdminLoginReply only for api docs
+type AdminLoginReply struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		Token          string `json:"token"`          // token，需要两步验证时为空
+		ChallengeToken string `json:"challengeToken"` // 两步验证令牌，5分钟内有效
+		EnrollRequired bool   `json:"enrollRequired"` // 角色要求两步验证但尚未开启，需先设置
+	} `json:"data"` // return data
+}
+
+// TwoFactorSetupReply only for api docs
+type TwoFactorSetupReply struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		Secret string `json:"secret"` // 两步验证密钥
+		URI    string `json:"uri"`    // otpauth地址，生成二维码供验证器扫描
+	} `json:"data"` // return data
+}
+
+// TwoFactorEnableReply only for api docs
+type TwoFactorEnableReply struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		RecoveryCodes []string `json:"recoveryCodes"` // 一次性恢复码，只返回一次
+	} `json:"data"` // return data
+}
+
+// VerifyAdminChallengeReply only for api docs
+type VerifyAdminChallengeReply struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		Token         string   `json:"token"`         // token
+		RecoveryCodes []string `json:"recoveryCodes"` // 登录时开启两步验证才返回的一次性恢复码
+	} `json:"data"` // return data
+}
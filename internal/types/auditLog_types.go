@@ -0,0 +1,45 @@
+package types
+
+import (
+	"time"
+
+	"github.com/go-dev-frame/sponge/pkg/sgorm/query"
+)
+
+// ListAuditLogsRequest request params
+type ListAuditLogsRequest struct {
+	query.Params
+
+	// the filters are combined with the columns of params by and, empty filters are ignored,
+	// the logs of the last 90 days are listed if neither startTime nor endTime is set
+	AdminUserID uint64     `json:"adminUserID" binding:""`                                // 操作员序号
+	Action      string     `json:"action" binding:"omitempty,oneof=create update delete"` // 操作
+	Table       string     `json:"table" binding:""`                                      // 表名，如loan、payment_history
+	RecordID    uint64     `json:"recordID" binding:""`                                   // 记录序号
+	RequestID   string     `json:"requestID" binding:""`                                  // 请求ID
+	StartTime   *time.Time `json:"startTime" binding:""`                                  // 操作时间起，包含
+	EndTime     *time.Time `json:"endTime" binding:""`                                    // 操作时间止，不包含
+}
+
+// AuditLogObjDetail detail
+type AuditLogObjDetail struct {
+	ID          uint64     `json:"id"`          // 序号
+	AdminUserID uint64     `json:"adminUserID"` // 操作员序号
+	Username    string     `json:"username"`    // 操作员用户名
+	Action      string     `json:"action"`      // 操作 create/update/delete
+	Table       string     `json:"table"`       // 表名
+	RecordID    uint64     `json:"recordID"`    // 记录序号
+	Changes     string     `json:"changes"`     // 变更的字段，json {"字段":{"before":旧值,"after":新值}}
+	RequestID   string     `json:"requestID"`   // 请求ID
+	CreateAt    *time.Time `json:"createAt"`    // 操作时间
+}
+
+// ListAuditLogsReply only for api docs
+type ListAuditLogsReply struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		AuditLogs []AuditLogObjDetail `json:"auditLogs"`
+		Total     int64               `json:"total"`
+	} `json:"data"` // return data
+}
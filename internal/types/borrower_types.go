@@ -0,0 +1,46 @@
+package types
+
+// SendBorrowerOtpRequest request params
+type SendBorrowerOtpRequest struct {
+	Mobile string `json:"mobile" binding:"required" pii:"mobile"` // 手机号码
+	Code   string `json:"code" binding:"required" pii:"idcard"`   // 身份证号码后六位
+
+	// 图形验证码，多次验证失败后需要，获取验证码的接口为 /api/v1/borrower/captcha
+	CaptchaID string `json:"captchaID"`
+	Captcha   string `json:"captcha"`
+}
+
+// BorrowerLoginRequest request params
+type BorrowerLoginRequest struct {
+	Mobile string `json:"mobile" binding:"required" pii:"mobile"` // 手机号码
+	Otp    string `json:"otp" binding:"required"`                 // 短信验证码
+}
+
+// SendBorrowerOtpReply only for api docs
+type SendBorrowerOtpReply struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		Expire int `json:"expire"` // 验证码有效期，单位秒
+	} `json:"data"` // return data
+}
+
+// BorrowerLoginReply only for api docs
+type BorrowerLoginReply struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		Token string `json:"token"` // 借款人token
+	} `json:"data"` // return data
+}
+
+// BorrowerCaptchaReply only for api docs
+type BorrowerCaptchaReply struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		CaptchaID string `json:"captchaID"` // 验证码ID
+		Image     string `json:"image"`     // base64 编码的 png 图片，data URL 格式
+		Expire    int    `json:"expire"`    // 验证码有效期，单位秒
+	} `json:"data"` // return data
+}
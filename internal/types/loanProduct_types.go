@@ -0,0 +1,85 @@
+package types
+
+import (
+	"time"
+
+	"github.com/go-dev-frame/sponge/pkg/sgorm/query"
+)
+
+var _ time.Time
+
+// Tip: suggested filling in the binding rules https://github.com/go-playground/validator in request struct fields tag.
+
+// CreateLoanProductRequest request params
+type CreateLoanProductRequest struct {
+	Name            string  `json:"name" binding:"required"`                                                       // 产品名称
+	Terms           string  `json:"terms" binding:"required"`                                                      // 可选期数，多个用逗号分隔，如 12,24,36
+	AnnualRate      float64 `json:"annualRate" binding:"gte=0,lt=1"`                                               // 年利率，如 0.24 表示 24%
+	RepaymentMethod string  `json:"repaymentMethod" binding:"required,oneof=flat annuity equal_principal balloon"` // 还款方式
+	BalloonRatio    float64 `json:"balloonRatio" binding:"gte=0,lt=1"`                                             // 尾款占本金比例，仅尾款方式使用
+}
+
+// UpdateLoanProductByIDRequest request params
+type UpdateLoanProductByIDRequest struct {
+	ID uint64 `json:"id" binding:""` // uint64 id
+	// 序号
+	Name            string  `json:"name" binding:""`                                                                // 产品名称
+	Terms           string  `json:"terms" binding:""`                                                               // 可选期数，多个用逗号分隔
+	AnnualRate      float64 `json:"annualRate" binding:"gte=0,lt=1"`                                                // 年利率
+	RepaymentMethod string  `json:"repaymentMethod" binding:"omitempty,oneof=flat annuity equal_principal balloon"` // 还款方式
+	BalloonRatio    float64 `json:"balloonRatio" binding:"gte=0,lt=1"`                                              // 尾款占本金比例
+}
+
+// LoanProductObjDetail detail
+type LoanProductObjDetail struct {
+	ID uint64 `json:"id"` // convert to uint64 id
+	// 序号
+	Name            string     `json:"name"`            // 产品名称
+	Terms           string     `json:"terms"`           // 可选期数
+	AnnualRate      float64    `json:"annualRate"`      // 年利率
+	RepaymentMethod string     `json:"repaymentMethod"` // 还款方式
+	BalloonRatio    float64    `json:"balloonRatio"`    // 尾款占本金比例
+	CreateAt        *time.Time `json:"createAt"`        // 创建时间
+}
+
+// CreateLoanProductReply only for api docs
+type CreateLoanProductReply struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		ID uint64 `json:"id"` // id
+	} `json:"data"` // return data
+}
+
+// DeleteLoanProductByIDReply only for api docs
+type DeleteLoanProductByIDReply struct {
+	Result
+}
+
+// UpdateLoanProductByIDReply only for api docs
+type UpdateLoanProductByIDReply struct {
+	Result
+}
+
+// GetLoanProductByIDReply only for api docs
+type GetLoanProductByIDReply struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		LoanProduct LoanProductObjDetail `json:"loanProduct"`
+	} `json:"data"` // return data
+}
+
+// ListLoanProductsRequest request params
+type ListLoanProductsRequest struct {
+	query.Params
+}
+
+// ListLoanProductsReply only for api docs
+type ListLoanProductsReply struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		LoanProducts []LoanProductObjDetail `json:"loanProducts"`
+	} `json:"data"` // return data
+}
@@ -12,59 +12,73 @@ var _ time.Time
 
 // CreateLoanRequest request params
 type CreateLoanRequest struct {
-	Name           string `json:"name" binding:""`           // 姓名
-	UserID         string `json:"userID" binding:""`         // 身份证号码
-	Mobile         string `json:"mobile" binding:""`         // 手机号码
-	CarModel       string `json:"carModel" binding:""`       // 车型
-	CarPlate       string `json:"carPlate" binding:""`       // 车牌
-	LoanMoney      int    `json:"loanMoney" binding:""`      // 借款金额
-	LoanPeriod     int    `json:"loanPeriod" binding:""`     // 借款期数
-	LoanReturnDate string `json:"loanReturnDate" binding:""` // 还款日期
+	Name           string `json:"name" binding:"required" pii:"name"`               // 姓名
+	UserID         string `json:"userID" binding:"required,cn_idcard" pii:"idcard"` // 身份证号码
+	Mobile         string `json:"mobile" binding:"required,cn_mobile" pii:"mobile"` // 手机号码
+	CarModel       string `json:"carModel" binding:""`                              // 车型
+	CarPlate       string `json:"carPlate" binding:"cn_plate"`                      // 车牌，可以是新能源车牌
+	LoanMoney      int    `json:"loanMoney" binding:"loan_amount"`                  // 借款金额
+	LoanPeriod     int    `json:"loanPeriod" binding:"loan_period"`                 // 借款期数，单位月
+	LoanReturnDate string `json:"loanReturnDate" binding:"due_day"`                 // 还款日，1-28，31表示每月月末，为空时取起租日
+	ProductID      uint64 `json:"productID" binding:""`                             // 产品序号，为空时按平息法月息2%计算
 }
 
-type GetDetailRequest struct {
-	Mobile string `json:"mobile" binding:""` // mobile id
-	Code   string `json:"code" binding:""`   // code
+// QuoteLoanRequest request params
+type QuoteLoanRequest struct {
+	LoanMoney      int    `json:"loanMoney" binding:"loan_amount"`  // 借款金额
+	LoanPeriod     int    `json:"loanPeriod" binding:"loan_period"` // 借款期数，单位月
+	LoanReturnDate string `json:"loanReturnDate" binding:"due_day"` // 还款日，1-28，31表示每月月末，为空时取起租日
+	ProductID      uint64 `json:"productID" binding:""`             // 产品序号，为空时按平息法月息2%计算
+	StartDate      string `json:"startDate" binding:""`             // 起租日期，格式2006-01-02，为空时取当天
 }
 
 type PayRequest struct {
-	Mobile string `json:"mobile" binding:""` // mobile id
-	Code   string `json:"code" binding:""`   // code
-	Method string `json:"method" binding:""` // method
+	LoanID       uint64  `json:"loanID" binding:""`                      // loan id, can be empty if the borrower has only one active loan
+	Method       string  `json:"method" binding:""`                      // method
+	Installments int     `json:"installments" binding:"omitempty,min=1"` // number of installments to pay, default 1
+	Amount       float64 `json:"amount" binding:"omitempty,gt=0"`        // custom amount, cannot be used with installments
 }
 
-// UpdateLoanByIDRequest request params
+// UpdateLoanByIDRequest request params, the fields given are updated even if they are zero values,
+// the fields left out are kept
 type UpdateLoanByIDRequest struct {
 	ID uint64 `json:"id" binding:""` // uint64 id
 	// 序号
-	Name           string     `json:"name" binding:""`           // 姓名
-	UserID         string     `json:"userID" binding:""`         // 身份证号码
-	Mobile         string     `json:"mobile" binding:""`         // 手机号码
-	CarModel       string     `json:"carModel" binding:""`       // 车型
-	CarPlate       string     `json:"carPlate" binding:""`       // 车牌
-	LoanMoney      float64    `json:"loanMoney" binding:""`      // 借款金额
-	LoanPeriod     int        `json:"loanPeriod" binding:""`     // 借款期数
-	LoanReturnDate string     `json:"loanReturnDate" binding:""` // 还款日期
-	MonthlyPayment int        `json:"monthlyPayment" binding:""` // 每月应还
-	CreateAt       *time.Time `json:"createAt" binding:""`       // 创建时间
-	Status         string     `json:"status" binding:""`         // 状态
+	Name           *string    `json:"name" binding:"" pii:"name"`                        // 姓名
+	UserID         *string    `json:"userID" binding:"omitempty,cn_idcard" pii:"idcard"` // 身份证号码
+	Mobile         *string    `json:"mobile" binding:"omitempty,cn_mobile" pii:"mobile"` // 手机号码
+	CarModel       *string    `json:"carModel" binding:""`                               // 车型
+	CarPlate       *string    `json:"carPlate" binding:"omitempty,cn_plate"`             // 车牌
+	LoanMoney      *float64   `json:"loanMoney" binding:"omitempty,min=0,max=5000000"`   // 借款金额，修改时可以为0
+	LoanPeriod     *int       `json:"loanPeriod" binding:"omitempty,loan_period"`        // 借款期数
+	LoanReturnDate *string    `json:"loanReturnDate" binding:"omitempty,due_day"`        // 还款日，1-28，31表示每月月末
+	MonthlyPayment *float64   `json:"monthlyPayment" binding:""`                         // 每月应还
+	CreateAt       *time.Time `json:"createAt" binding:""`                               // 创建时间
+	Status         *int       `json:"status" binding:""`                                 // 状态，1表示已还完
+	Version        *uint64    `json:"version" binding:"" copier:"-"`                     // 版本号，取自详情，请求头If-Match为空时必填
 }
 
 // LoanObjDetail detail
 type LoanObjDetail struct {
 	ID uint64 `json:"id"` // convert to uint64 id
 	// 序号
-	Name           string     `json:"name"`           // 姓名
-	UserID         string     `json:"userID"`         // 身份证号码
-	Mobile         string     `json:"mobile"`         // 手机号码
-	CarModel       string     `json:"carModel"`       // 车型
-	CarPlate       string     `json:"carPlate"`       // 车牌
-	LoanMoney      float64    `json:"loanMoney"`      // 借款金额
-	LoanPeriod     int        `json:"loanPeriod"`     // 借款期数
-	LoanReturnDate string     `json:"loanReturnDate"` // 还款日期
-	MonthlyPayment int        `json:"monthlyPayment"` // 每月应还
-	CreateAt       *time.Time `json:"createAt"`       // 创建时间
-	Status         string     `json:"status"`         // 状态
+	Name            string     `json:"name" pii:"name"`                // 姓名
+	UserID          string     `json:"userID" pii:"idcard"`            // 身份证号码
+	Mobile          string     `json:"mobile" pii:"mobile"`            // 手机号码
+	CarModel        string     `json:"carModel"`                       // 车型
+	CarPlate        string     `json:"carPlate"`                       // 车牌
+	LoanMoney       float64    `json:"loanMoney"`                      // 借款金额
+	LoanPeriod      int        `json:"loanPeriod"`                     // 借款期数
+	LoanReturnDate  string     `json:"loanReturnDate"`                 // 还款日期
+	MonthlyPayment  int        `json:"monthlyPayment"`                 // 每月应还
+	CreateAt        *time.Time `json:"createAt"`                       // 创建时间
+	DeletedAt       *time.Time `json:"deletedAt,omitempty" copier:"-"` // 删除时间，只有回收站中的记录有
+	Status          string     `json:"status"`                         // 状态
+	ProductID       uint64     `json:"productID"`                      // 产品序号
+	AnnualRate      float64    `json:"annualRate"`                     // 年利率
+	RepaymentMethod string     `json:"repaymentMethod"`                // 还款方式
+	BalloonRatio    float64    `json:"balloonRatio"`                   // 尾款占本金比例
+	Version         uint64     `json:"version"`                        // 版本号，更新时带上
 }
 
 // CreateLoanReply only for api docs
@@ -76,11 +90,52 @@ type CreateLoanReply struct {
 	} `json:"data"` // return data
 }
 
+// LoanQuoteInstallment installment of a loan quote
+type LoanQuoteInstallment struct {
+	Seq       int     `json:"seq"`       // 期数
+	DueDate   string  `json:"dueDate"`   // 应还日期
+	Amount    float64 `json:"amount"`    // 应还金额
+	Principal float64 `json:"principal"` // 应还本金
+	Interest  float64 `json:"interest"`  // 应还利息
+	Fee       float64 `json:"fee"`       // 支付手续费
+}
+
+// LoanQuote loan quote
+type LoanQuote struct {
+	AnnualRate      float64                 `json:"annualRate"`      // 年利率
+	RepaymentMethod string                  `json:"repaymentMethod"` // 还款方式
+	MonthlyPayment  float64                 `json:"monthlyPayment"`  // 首期月供
+	TotalAmount     float64                 `json:"totalAmount"`     // 应还总额，不含手续费
+	TotalInterest   float64                 `json:"totalInterest"`   // 利息总额
+	TotalFees       float64                 `json:"totalFees"`       // 手续费总额
+	APR             float64                 `json:"apr"`             // 含手续费的年化利率
+	Installments    []*LoanQuoteInstallment `json:"installments"`    // 还款计划
+}
+
+// QuoteLoanReply only for api docs
+type QuoteLoanReply struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		Quote LoanQuote `json:"quote"`
+	} `json:"data"` // return data
+}
+
 // DeleteLoanByIDReply only for api docs
 type DeleteLoanByIDReply struct {
 	Result
 }
 
+// RestoreLoanByIDReply only for api docs
+type RestoreLoanByIDReply struct {
+	Result
+}
+
+// PurgeLoanByIDReply only for api docs
+type PurgeLoanByIDReply struct {
+	Result
+}
+
 // UpdateLoanByIDReply only for api docs
 type UpdateLoanByIDReply struct {
 	Result
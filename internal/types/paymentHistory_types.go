@@ -12,30 +12,45 @@ var _ time.Time
 
 // CreatePaymentHistoryRequest request params
 type CreatePaymentHistoryRequest struct {
-	UserPhone  string     `json:"userPhone" binding:""`  // 用户手机号码
-	OutTradeNo string     `json:"outTradeNo" binding:""` // 支付订单号
-	Status     string     `json:"status" binding:""`     // 状态
-	CreateAt   *time.Time `json:"createAt" binding:""`   // 创建时间
+	UserPhone    string     `json:"userPhone" binding:"" pii:"mobile"` // 用户手机号码
+	OutTradeNo   string     `json:"outTradeNo" binding:""`             // 支付订单号
+	Status       string     `json:"status" binding:""`                 // 状态
+	LoanID       uint64     `json:"loanID" binding:""`                 // 借款序号
+	Installments int        `json:"installments" binding:""`           // 支付期数，0表示自定义金额
+	Amount       float64    `json:"amount" binding:""`                 // 分配到分期的金额
+	TotalAmount  float64    `json:"totalAmount" binding:""`            // 订单金额
+	CreateAt     *time.Time `json:"createAt" binding:""`               // 创建时间
 }
 
-// UpdatePaymentHistoryByIDRequest request params
+// UpdatePaymentHistoryByIDRequest request params, the fields given are updated even if they are zero values,
+// the fields left out are kept
 type UpdatePaymentHistoryByIDRequest struct {
 	ID uint64 `json:"id" binding:""` // uint64 id
 	// 序号
-	UserPhone  string     `json:"userPhone" binding:""`  // 用户手机号码
-	OutTradeNo string     `json:"outTradeNo" binding:""` // 支付订单号
-	Status     string     `json:"status" binding:""`     // 状态
-	CreateAt   *time.Time `json:"createAt" binding:""`   // 创建时间
+	UserPhone    *string    `json:"userPhone" binding:"" pii:"mobile"` // 用户手机号码
+	OutTradeNo   *string    `json:"outTradeNo" binding:""`             // 支付订单号
+	Status       *string    `json:"status" binding:""`                 // 状态
+	LoanID       *uint64    `json:"loanID" binding:""`                 // 借款序号
+	Installments *int       `json:"installments" binding:""`           // 支付期数，0表示自定义金额
+	Amount       *float64   `json:"amount" binding:""`                 // 分配到分期的金额
+	TotalAmount  *float64   `json:"totalAmount" binding:""`            // 订单金额
+	CreateAt     *time.Time `json:"createAt" binding:""`               // 创建时间
 }
 
 // PaymentHistoryObjDetail detail
 type PaymentHistoryObjDetail struct {
 	ID uint64 `json:"id"` // convert to uint64 id
 	// 序号
-	UserPhone  string     `json:"userPhone"`  // 用户手机号码
-	OutTradeNo string     `json:"outTradeNo"` // 支付订单号
-	Status     string     `json:"status"`     // 状态
-	CreateAt   *time.Time `json:"createAt"`   // 创建时间
+	UserPhone    string     `json:"userPhone" pii:"mobile"`         // 用户手机号码
+	OutTradeNo   string     `json:"outTradeNo"`                     // 支付订单号
+	Status       string     `json:"status"`                         // 状态
+	Method       string     `json:"method"`                         // 支付方式
+	LoanID       uint64     `json:"loanID"`                         // 借款序号
+	Installments int        `json:"installments"`                   // 支付期数，0表示自定义金额
+	Amount       float64    `json:"amount"`                         // 分配到分期的金额
+	TotalAmount  float64    `json:"totalAmount"`                    // 订单金额
+	CreateAt     *time.Time `json:"createAt"`                       // 创建时间
+	DeletedAt    *time.Time `json:"deletedAt,omitempty" copier:"-"` // 删除时间，只有回收站中的记录有
 }
 
 // CreatePaymentHistoryReply only for api docs
@@ -52,6 +67,16 @@ type DeletePaymentHistoryByIDReply struct {
 	Result
 }
 
+// RestorePaymentHistoryByIDReply only for api docs
+type RestorePaymentHistoryByIDReply struct {
+	Result
+}
+
+// PurgePaymentHistoryByIDReply only for api docs
+type PurgePaymentHistoryByIDReply struct {
+	Result
+}
+
 // UpdatePaymentHistoryByIDReply only for api docs
 type UpdatePaymentHistoryByIDReply struct {
 	Result
@@ -0,0 +1,46 @@
+package types
+
+import (
+	"time"
+
+	"github.com/go-dev-frame/sponge/pkg/sgorm/query"
+)
+
+// ListPiiAccessLogsRequest request params
+type ListPiiAccessLogsRequest struct {
+	query.Params
+
+	// the filters are combined with the columns of params by and, empty filters are ignored,
+	// the logs of the last 90 days are listed if neither startTime nor endTime is set
+	LoanID      uint64     `json:"loanID" binding:""`                                                 // 借款序号
+	Mobile      string     `json:"mobile" binding:"" pii:"mobile"`                                    // 借款人手机号，查询其所有借款
+	AdminUserID uint64     `json:"adminUserID" binding:""`                                            // 操作员序号
+	Resource    string     `json:"resource" binding:"omitempty,oneof=loan paymentHistory smsHistory"` // 资源
+	StartTime   *time.Time `json:"startTime" binding:""`                                              // 访问时间起，包含
+	EndTime     *time.Time `json:"endTime" binding:""`                                                // 访问时间止，不包含
+}
+
+// PiiAccessLogObjDetail detail
+type PiiAccessLogObjDetail struct {
+	ID          uint64     `json:"id"`          // 序号
+	AdminUserID uint64     `json:"adminUserID"` // 操作员序号
+	Username    string     `json:"username"`    // 操作员用户名
+	Resource    string     `json:"resource"`    // 资源
+	ResourceID  uint64     `json:"resourceID"`  // 记录序号
+	LoanID      uint64     `json:"loanID"`      // 关联借款序号
+	Fields      string     `json:"fields"`      // 显示的字段，逗号分隔
+	Route       string     `json:"route"`       // 请求的接口
+	RequestID   string     `json:"requestID"`   // 请求ID
+	IP          string     `json:"ip"`          // 客户端IP
+	CreateAt    *time.Time `json:"createAt"`    // 访问时间
+}
+
+// ListPiiAccessLogsReply only for api docs
+type ListPiiAccessLogsReply struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		PiiAccessLogs []PiiAccessLogObjDetail `json:"piiAccessLogs"`
+		Total         int64                   `json:"total"`
+	} `json:"data"` // return data
+}
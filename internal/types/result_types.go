@@ -28,23 +28,24 @@ type CreateResultRequest struct {
 	CreateAt       *time.Time `json:"createAt" binding:""`    // 创建时间
 }
 
-// UpdateResultByIDRequest request params
+// UpdateResultByIDRequest request params, the fields given are updated even if they are zero values,
+// the fields left out are kept
 type UpdateResultByIDRequest struct {
 	ID uint64 `json:"id" binding:""` // uint64 id
 	// 序号
-	EventType      string     `json:"eventType" binding:""`      // 事件类型
-	ResourceAppid  string     `json:"resourceAppid" binding:""`  // 来源ID
-	ResourceMchid  string     `json:"resourceMchid" binding:""`  // 来源商户ID
-	OutTradeNo     string     `json:"outTradeNo" binding:""`     // 订单号
-	TransactionID  string     `json:"transactionID" binding:""`  // 交易ID
-	TradeType      string     `json:"tradeType" binding:""`      // 交易类型
-	TradeState     string     `json:"tradeState" binding:""`     // 交易状态
-	TradeStateDesc string     `json:"tradeStateDesc" binding:""` // 交易状态描述
-	BankType       string     `json:"bankType" binding:""`       // 银行类型
-	Attach         string     `json:"attach" binding:""`
-	SuccessTime    string     `json:"successTime" binding:""` // 成功时间
-	Payer          string     `json:"payer" binding:""`       // 支付人
-	AmountTotal    float64    `json:"amountTotal" binding:""` // 合计
+	EventType      *string    `json:"eventType" binding:""`      // 事件类型
+	ResourceAppid  *string    `json:"resourceAppid" binding:""`  // 来源ID
+	ResourceMchid  *string    `json:"resourceMchid" binding:""`  // 来源商户ID
+	OutTradeNo     *string    `json:"outTradeNo" binding:""`     // 订单号
+	TransactionID  *string    `json:"transactionID" binding:""`  // 交易ID
+	TradeType      *string    `json:"tradeType" binding:""`      // 交易类型
+	TradeState     *string    `json:"tradeState" binding:""`     // 交易状态
+	TradeStateDesc *string    `json:"tradeStateDesc" binding:""` // 交易状态描述
+	BankType       *string    `json:"bankType" binding:""`       // 银行类型
+	Attach         *string    `json:"attach" binding:""`
+	SuccessTime    *string    `json:"successTime" binding:""` // 成功时间
+	Payer          *string    `json:"payer" binding:""`       // 支付人
+	AmountTotal    *float64   `json:"amountTotal" binding:""` // 合计
 	CreateAt       *time.Time `json:"createAt" binding:""`    // 创建时间
 }
 
@@ -62,10 +63,11 @@ type ResultObjDetail struct {
 	TradeStateDesc string     `json:"tradeStateDesc"` // 交易状态描述
 	BankType       string     `json:"bankType"`       // 银行类型
 	Attach         string     `json:"attach"`
-	SuccessTime    string     `json:"successTime"` // 成功时间
-	Payer          string     `json:"payer"`       // 支付人
-	AmountTotal    float64    `json:"amountTotal"` // 合计
-	CreateAt       *time.Time `json:"createAt"`    // 创建时间
+	SuccessTime    string     `json:"successTime"`                    // 成功时间
+	Payer          string     `json:"payer"`                          // 支付人
+	AmountTotal    float64    `json:"amountTotal"`                    // 合计
+	CreateAt       *time.Time `json:"createAt"`                       // 创建时间
+	DeletedAt      *time.Time `json:"deletedAt,omitempty" copier:"-"` // 删除时间，只有回收站中的记录有
 }
 
 // CreateResultReply only for api docs
@@ -82,6 +84,16 @@ type DeleteResultByIDReply struct {
 	Result
 }
 
+// RestoreResultByIDReply only for api docs
+type RestoreResultByIDReply struct {
+	Result
+}
+
+// PurgeResultByIDReply only for api docs
+type PurgeResultByIDReply struct {
+	Result
+}
+
 // UpdateResultByIDReply only for api docs
 type UpdateResultByIDReply struct {
 	Result
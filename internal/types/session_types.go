@@ -0,0 +1,67 @@
+package types
+
+import (
+	"time"
+)
+
+// SessionUserRequest request params
+type SessionUserRequest struct {
+	UserType string `json:"userType" binding:"oneof=admin borrower"` // 用户类型 admin/borrower
+	UID      string `json:"uid" binding:"required"`                  // 管理员序号或借款人手机号
+}
+
+// ListAllSessionsRequest request params
+type ListAllSessionsRequest struct {
+	UserType string `json:"userType" binding:"omitempty,oneof=admin borrower"` // 用户类型 admin/borrower，为空表示所有用户
+	Page     int    `json:"page" binding:"gte=0"`                              // 页码，从0开始
+	Limit    int    `json:"limit" binding:"gte=1,lte=100"`                     // 每页数量
+}
+
+// SessionObjDetail detail
+type SessionObjDetail struct {
+	ID        string    `json:"id"`        // 会话ID
+	UserType  string    `json:"userType"`  // 用户类型 admin/borrower
+	UID       string    `json:"uid"`       // 管理员序号或借款人手机号
+	IP        string    `json:"ip"`        // 登录IP
+	UserAgent string    `json:"userAgent"` // 登录设备
+	CreatedAt time.Time `json:"createdAt"` // 登录时间
+	ExpireAt  time.Time `json:"expireAt"`  // 过期时间
+}
+
+// ListSessionsReply only for api docs
+type ListSessionsReply struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		Sessions []SessionObjDetail `json:"sessions"`
+	} `json:"data"` // return data
+}
+
+// ListAllSessionsReply only for api docs
+type ListAllSessionsReply struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		Sessions []SessionObjDetail `json:"sessions"`
+		Total    int                `json:"total"`
+	} `json:"data"` // return data
+}
+
+// RevokeSessionsReply only for api docs
+type RevokeSessionsReply struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		Count int `json:"count"` // 吊销的会话数量
+	} `json:"data"` // return data
+}
+
+// DeleteSessionByIDReply only for api docs
+type DeleteSessionByIDReply struct {
+	Result
+}
+
+// LogoutReply only for api docs
+type LogoutReply struct {
+	Result
+}
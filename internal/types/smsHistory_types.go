@@ -12,27 +12,39 @@ var _ time.Time
 
 // CreateSmsHistoryRequest request params
 type CreateSmsHistoryRequest struct {
-	UserName string     `json:"userName" binding:""` // 收信人
-	Mobile   string     `json:"mobile" binding:""`   // 手机号
-	CreateAt *time.Time `json:"createAt" binding:""` // 创建时间
+	UserName string     `json:"userName" binding:"" pii:"name"` // 收信人
+	Mobile   string     `json:"mobile" binding:"" pii:"mobile"` // 手机号
+	CreateAt *time.Time `json:"createAt" binding:""`            // 创建时间
 }
 
-// UpdateSmsHistoryByIDRequest request params
+// UpdateSmsHistoryByIDRequest request params, the fields given are updated even if they are zero values,
+// the fields left out are kept
 type UpdateSmsHistoryByIDRequest struct {
 	ID uint64 `json:"id" binding:""` // uint64 id
 	// 序号
-	UserName string     `json:"userName" binding:""` // 收信人
-	Mobile   string     `json:"mobile" binding:""`   // 手机号
-	CreateAt *time.Time `json:"createAt" binding:""` // 创建时间
+	UserName *string    `json:"userName" binding:"" pii:"name"` // 收信人
+	Mobile   *string    `json:"mobile" binding:"" pii:"mobile"` // 手机号
+	CreateAt *time.Time `json:"createAt" binding:""`            // 创建时间
 }
 
 // SmsHistoryObjDetail detail
 type SmsHistoryObjDetail struct {
 	ID uint64 `json:"id"` // convert to uint64 id
 	// 序号
-	UserName string     `json:"userName"` // 收信人
-	Mobile   string     `json:"mobile"`   // 手机号
-	CreateAt *time.Time `json:"createAt"` // 创建时间
+	UserName       string     `json:"userName" pii:"name"`            // 收信人
+	Mobile         string     `json:"mobile" pii:"mobile"`            // 手机号
+	Content        string     `json:"content"`                        // 短信内容
+	Template       string     `json:"template"`                       // 模板编码
+	LoanID         uint64     `json:"loanID"`                         // 关联借款序号
+	Provider       string     `json:"provider"`                       // 短信服务商
+	MessageID      string     `json:"messageID"`                      // 服务商消息ID
+	Status         int        `json:"status"`                         // 发送状态 0:手工录入 1:发送中 2:已发送 3:发送失败
+	DeliveryStatus int        `json:"deliveryStatus"`                 // 送达状态 0:未回执 1:已送达 2:送达失败
+	ErrorCode      string     `json:"errorCode"`                      // 错误码
+	ReportAt       *time.Time `json:"reportAt"`                       // 送达回执时间
+	BizKey         string     `json:"bizKey"`                         // 业务去重键
+	CreateAt       *time.Time `json:"createAt"`                       // 创建时间
+	DeletedAt      *time.Time `json:"deletedAt,omitempty" copier:"-"` // 删除时间，只有回收站中的记录有
 }
 
 // CreateSmsHistoryReply only for api docs
@@ -49,6 +61,16 @@ type DeleteSmsHistoryByIDReply struct {
 	Result
 }
 
+// RestoreSmsHistoryByIDReply only for api docs
+type RestoreSmsHistoryByIDReply struct {
+	Result
+}
+
+// PurgeSmsHistoryByIDReply only for api docs
+type PurgeSmsHistoryByIDReply struct {
+	Result
+}
+
 // UpdateSmsHistoryByIDReply only for api docs
 type UpdateSmsHistoryByIDReply struct {
 	Result
@@ -66,6 +88,16 @@ type GetSmsHistoryByIDReply struct {
 // ListSmsHistorysRequest request params
 type ListSmsHistorysRequest struct {
 	query.Params
+
+	// the filters are combined with the columns of params by and, empty filters are ignored
+	Mobile         string     `json:"mobile" binding:"" pii:"mobile"`                 // 手机号
+	Template       string     `json:"template" binding:""`                            // 模板编码
+	LoanID         uint64     `json:"loanID" binding:""`                              // 关联借款序号
+	Status         *int       `json:"status" binding:"omitempty,oneof=0 1 2 3"`       // 发送状态
+	DeliveryStatus *int       `json:"deliveryStatus" binding:"omitempty,oneof=0 1 2"` // 送达状态
+	ErrorCode      string     `json:"errorCode" binding:""`                           // 错误码
+	StartTime      *time.Time `json:"startTime" binding:""`                           // 创建时间起，包含
+	EndTime        *time.Time `json:"endTime" binding:""`                             // 创建时间止，不包含
 }
 
 // ListSmsHistorysReply only for api docs
@@ -0,0 +1,102 @@
+package types
+
+import (
+	"time"
+
+	"github.com/go-dev-frame/sponge/pkg/sgorm/query"
+)
+
+var _ time.Time
+
+// Tip: suggested filling in the binding rules https://github.com/go-playground/validator in request struct fields tag.
+
+// CreateSmsTemplateRequest request params
+type CreateSmsTemplateRequest struct {
+	Code       string `json:"code" binding:"required,max=50"`     // 模板编码，如 loginCode、repaymentReminder
+	TemplateID string `json:"templateID" binding:"max=64"`        // 服务商审核通过的模板ID
+	Content    string `json:"content" binding:"required,max=500"` // 模板内容，变量写作 {name}
+	Enabled    *bool  `json:"enabled" binding:""`                 // 是否启用，默认启用
+	Sensitive  *bool  `json:"sensitive" binding:""`               // 是否敏感，如验证码，发送记录中不保存变量的值，默认否
+}
+
+// UpdateSmsTemplateByIDRequest request params
+type UpdateSmsTemplateByIDRequest struct {
+	ID uint64 `json:"id" binding:""` // uint64 id
+	// 序号
+	Code       string `json:"code" binding:"max=50"`       // 模板编码
+	TemplateID string `json:"templateID" binding:"max=64"` // 服务商模板ID
+	Content    string `json:"content" binding:"max=500"`   // 模板内容
+	Enabled    *bool  `json:"enabled" binding:""`          // 是否启用，不传则不修改
+	Sensitive  *bool  `json:"sensitive" binding:""`        // 是否敏感，不传则不修改
+}
+
+// SmsTemplateObjDetail detail
+type SmsTemplateObjDetail struct {
+	ID uint64 `json:"id"` // convert to uint64 id
+	// 序号
+	Code       string     `json:"code"`       // 模板编码
+	TemplateID string     `json:"templateID"` // 服务商模板ID
+	Content    string     `json:"content"`    // 模板内容
+	Enabled    bool       `json:"enabled"`    // 是否启用
+	Sensitive  bool       `json:"sensitive"`  // 是否敏感，发送记录中不保存变量的值
+	Variables  []string   `json:"variables"`  // 模板可用的变量
+	CreateAt   *time.Time `json:"createAt"`   // 创建时间
+}
+
+// CreateSmsTemplateReply only for api docs
+type CreateSmsTemplateReply struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		ID uint64 `json:"id"` // id
+	} `json:"data"` // return data
+}
+
+// DeleteSmsTemplateByIDReply only for api docs
+type DeleteSmsTemplateByIDReply struct {
+	Result
+}
+
+// UpdateSmsTemplateByIDReply only for api docs
+type UpdateSmsTemplateByIDReply struct {
+	Result
+}
+
+// GetSmsTemplateByIDReply only for api docs
+type GetSmsTemplateByIDReply struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		SmsTemplate SmsTemplateObjDetail `json:"smsTemplate"`
+	} `json:"data"` // return data
+}
+
+// ListSmsTemplatesRequest request params
+type ListSmsTemplatesRequest struct {
+	query.Params
+}
+
+// ListSmsTemplatesReply only for api docs
+type ListSmsTemplatesReply struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		SmsTemplates []SmsTemplateObjDetail `json:"smsTemplates"`
+	} `json:"data"` // return data
+}
+
+// PreviewSmsTemplateRequest request params
+type PreviewSmsTemplateRequest struct {
+	LoanID  uint64 `json:"loanID" binding:"required"` // 用于渲染变量的借款序号
+	Content string `json:"content" binding:"max=500"` // 预览未保存的模板内容，不传则使用已保存的内容
+}
+
+// PreviewSmsTemplateReply only for api docs
+type PreviewSmsTemplateReply struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		Content string            `json:"content"` // 渲染后的短信内容
+		Params  map[string]string `json:"params"`  // 变量的值
+	} `json:"data"` // return data
+}
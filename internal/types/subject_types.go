@@ -0,0 +1,55 @@
+package types
+
+import (
+	"time"
+)
+
+// ExportSubjectRequest request params, the data subject is identified by mobile or ID number
+type ExportSubjectRequest struct {
+	Mobile string `json:"mobile" binding:"required_without=UserID" pii:"mobile"` // 手机号码
+	UserID string `json:"userID" binding:"required_without=Mobile" pii:"idcard"` // 身份证号码
+	Format string `json:"format" binding:"omitempty,oneof=json zip"`             // 导出格式 json/zip，默认json
+}
+
+// AnonymizeSubjectRequest request params, the data subject is identified by mobile or ID number
+type AnonymizeSubjectRequest struct {
+	Mobile string `json:"mobile" binding:"required_without=UserID" pii:"mobile"` // 手机号码
+	UserID string `json:"userID" binding:"required_without=Mobile" pii:"idcard"` // 身份证号码
+}
+
+// SubjectCounts the number of records of each kind
+type SubjectCounts struct {
+	Loans            int `json:"loans"`            // 借款
+	PaymentHistories int `json:"paymentHistories"` // 支付记录
+	Results          int `json:"results"`          // 支付结果
+	SmsHistories     int `json:"smsHistories"`     // 短信
+}
+
+// SubjectManifest the manifest of an export
+type SubjectManifest struct {
+	ExportedAt time.Time     `json:"exportedAt"` // 导出时间
+	Counts     SubjectCounts `json:"counts"`     // 记录数量
+}
+
+// ExportSubjectReply only for api docs, the zip format returns a zip file of manifest.json and a json file per table
+type ExportSubjectReply struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		Manifest         SubjectManifest           `json:"manifest"`
+		Loans            []LoanObjDetail           `json:"loans"`
+		PaymentHistories []PaymentHistoryObjDetail `json:"paymentHistories"`
+		Results          []ResultObjDetail         `json:"results"`
+		SmsHistories     []SmsHistoryObjDetail     `json:"smsHistories"`
+	} `json:"data"` // return data
+}
+
+// AnonymizeSubjectReply only for api docs
+type AnonymizeSubjectReply struct {
+	Code int    `json:"code"` // return code
+	Msg  string `json:"msg"`  // return information description
+	Data struct {
+		// the loans, the payments and the payment results are anonymized, the messages are deleted
+		Counts SubjectCounts `json:"counts"`
+	} `json:"data"` // return data
+}
@@ -0,0 +1,205 @@
+// Package validation the validators of the domain values of the requests, such as Chinese ID card numbers,
+// mobile numbers and vehicle plates, used in the binding tags of the request structs.
+//
+// Register adds them to the validator of the gin binding, and names the fields of the validation errors
+// by their json names, so Errors can return the errors of a request field by field.
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// tags of the validators
+const (
+	TagIDCard     = "cn_idcard"
+	TagMobile     = "cn_mobile"
+	TagPlate      = "cn_plate"
+	TagLoanAmount = "loan_amount"
+	TagLoanPeriod = "loan_period"
+	TagDueDay     = "due_day"
+)
+
+// bounds of the loans
+const (
+	MaxLoanAmount = 5000000 // 借款金额上限
+	MinLoanPeriod = 1       // 借款期数下限，单位月
+	MaxLoanPeriod = 60      // 借款期数上限，单位月
+	MaxDueDay     = 28      // 每月还款日上限，每个月都有的日子
+)
+
+// EndOfMonth the due day of the loans repaid at the end of every month, the repayment schedule takes
+// the last day of the months shorter than it
+const EndOfMonth = "31"
+
+var (
+	mobileRegexp = regexp.MustCompile(`^1[3-9]\d{9}$`)
+
+	// the province, the letter of the city, then 5 characters, or 6 for new-energy vehicles,
+	// letters I and O are not used
+	plateProvince = "[京津沪渝冀豫云辽黑湘皖鲁新苏浙赣鄂桂甘晋蒙陕吉闽贵粤青藏川宁琼]"
+	plateRegexp   = regexp.MustCompile(`^` + plateProvince + `[A-HJ-NP-Z][A-HJ-NP-Z0-9]{4}[A-HJ-NP-Z0-9挂学警港澳]$`)
+	// new-energy vehicles, D or F at the start for small ones and at the end for large ones
+	newEnergyPlateRegexp = regexp.MustCompile(`^` + plateProvince + `[A-HJ-NP-Z](?:[DF][A-HJ-NP-Z0-9]\d{4}|\d{5}[DF])$`)
+
+	// GB 11643 weights of the first 17 digits and check characters by the weighted sum modulo 11
+	idCardWeights = []int{7, 9, 10, 5, 8, 4, 2, 1, 6, 3, 7, 9, 10, 5, 8, 4, 2}
+	idCardChecks  = "10X98765432"
+)
+
+// Register register the validators and name the fields of the validation errors by their json names
+func Register(v *validator.Validate) error {
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			return field.Name
+		}
+		return name
+	})
+
+	validators := map[string]validator.Func{
+		TagIDCard:     stringValidator(IsIDCard),
+		TagMobile:     stringValidator(IsMobile),
+		TagPlate:      stringValidator(IsPlate),
+		TagLoanAmount: numberValidator(func(v float64) bool { return v > 0 && v <= MaxLoanAmount }),
+		TagLoanPeriod: numberValidator(func(v float64) bool { return v >= MinLoanPeriod && v <= MaxLoanPeriod && v == float64(int(v)) }),
+		TagDueDay:     stringValidator(IsDueDay),
+	}
+	for tag, fn := range validators {
+		if err := v.RegisterValidation(tag, fn); err != nil {
+			return fmt.Errorf("register validator %s error: %v", tag, err)
+		}
+	}
+	return nil
+}
+
+// stringValidator the empty strings are valid, they are checked by required, so the optional fields of
+// the update requests can be cleared
+func stringValidator(fn func(string) bool) validator.Func {
+	return func(fl validator.FieldLevel) bool {
+		field := fl.Field()
+		return field.Kind() == reflect.String && (field.String() == "" || fn(field.String()))
+	}
+}
+
+func numberValidator(fn func(float64) bool) validator.Func {
+	return func(fl validator.FieldLevel) bool {
+		field := fl.Field()
+		switch field.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return fn(float64(field.Int()))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return fn(float64(field.Uint()))
+		case reflect.Float32, reflect.Float64:
+			return fn(field.Float())
+		}
+		return false
+	}
+}
+
+// IsIDCard whether s is an 18 characters ID card number of GB 11643 with a valid birth date and check character
+func IsIDCard(s string) bool {
+	_, err := BirthDate(s)
+	return err == nil
+}
+
+// BirthDate the birth date of an ID card number of GB 11643, an error is returned if the number is not valid
+func BirthDate(idCard string) (time.Time, error) {
+	if len(idCard) != 18 {
+		return time.Time{}, errors.New("the ID card number must be 18 characters")
+	}
+	sum := 0
+	for i, weight := range idCardWeights {
+		c := idCard[i]
+		if c < '0' || c > '9' {
+			return time.Time{}, errors.New("the first 17 characters of the ID card number must be digits")
+		}
+		sum += int(c-'0') * weight
+	}
+	if check := idCardChecks[sum%11]; strings.ToUpper(idCard[17:]) != string(check) {
+		return time.Time{}, errors.New("the check character of the ID card number is wrong")
+	}
+
+	birth, err := time.ParseInLocation("20060102", idCard[6:14], time.Local)
+	if err != nil {
+		return time.Time{}, errors.New("the birth date of the ID card number is not a date")
+	}
+	if birth.Year() < 1900 || birth.After(time.Now()) {
+		return time.Time{}, errors.New("the birth date of the ID card number is out of range")
+	}
+	return birth, nil
+}
+
+// IsMobile whether s is a mainland China mobile number of 11 digits
+func IsMobile(s string) bool {
+	return mobileRegexp.MatchString(s)
+}
+
+// IsPlate whether s is a mainland China vehicle plate number, including the plates of new-energy vehicles
+func IsPlate(s string) bool {
+	return plateRegexp.MatchString(s) || newEnergyPlateRegexp.MatchString(s)
+}
+
+// IsDueDay whether s is a monthly due day, 1 to 28 or EndOfMonth
+func IsDueDay(s string) bool {
+	if s == EndOfMonth {
+		return true
+	}
+	day, err := strconv.Atoi(s)
+	return err == nil && day >= 1 && day <= MaxDueDay && strconv.Itoa(day) == s
+}
+
+// FieldError the error of a field of a request
+type FieldError struct {
+	Field   string `json:"field"`   // json name of the field
+	Message string `json:"message"` // why the value is not valid
+}
+
+// Errors the errors of the fields of a request failing the binding, nil if err is not about the fields
+func Errors(err error) []FieldError {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fieldErrs := make([]FieldError, 0, len(validationErrs))
+		for _, e := range validationErrs {
+			fieldErrs = append(fieldErrs, FieldError{Field: e.Field(), Message: message(e)})
+		}
+		return fieldErrs
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return []FieldError{{Field: typeErr.Field, Message: "must be of type " + typeErr.Type.String()}}
+	}
+	return nil
+}
+
+func message(e validator.FieldError) string {
+	switch e.Tag() {
+	case "required":
+		return "is required"
+	case TagIDCard:
+		return "is not a valid ID card number"
+	case TagMobile:
+		return "is not a valid mobile number"
+	case TagPlate:
+		return "is not a valid vehicle plate number"
+	case TagLoanAmount:
+		return fmt.Sprintf("must be greater than 0 and at most %d", MaxLoanAmount)
+	case TagLoanPeriod:
+		return fmt.Sprintf("must be %d to %d months", MinLoanPeriod, MaxLoanPeriod)
+	case TagDueDay:
+		return fmt.Sprintf("must be a day from 1 to %d, or %s for the end of the month", MaxDueDay, EndOfMonth)
+	}
+	if e.Param() != "" {
+		return fmt.Sprintf("must satisfy %s=%s", e.Tag(), e.Param())
+	}
+	return "must satisfy " + e.Tag()
+}
@@ -0,0 +1,145 @@
+package validation
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+
+	"lol/internal/types"
+)
+
+func TestBirthDate(t *testing.T) {
+	birth, err := BirthDate("11010519491231002X")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(1949, 12, 31, 0, 0, 0, 0, time.Local), birth)
+	_, err = BirthDate("11010519491231002x")
+	assert.NoError(t, err)
+
+	_, err = BirthDate("110105194912310021") // check character
+	assert.Error(t, err)
+	_, err = BirthDate("110105194913310025") // month 13
+	assert.Error(t, err)
+	_, err = BirthDate("11010519491231002")
+	assert.Error(t, err)
+	_, err = BirthDate("1101051949123100AX")
+	assert.Error(t, err)
+}
+
+func TestIsMobile(t *testing.T) {
+	assert.True(t, IsMobile("13812345678"))
+	assert.True(t, IsMobile("19912345678"))
+	assert.False(t, IsMobile("12812345678"))
+	assert.False(t, IsMobile("1381234567"))
+	assert.False(t, IsMobile("+8613812345678"))
+}
+
+func TestIsPlate(t *testing.T) {
+	assert.True(t, IsPlate("粤B12345"))
+	assert.True(t, IsPlate("京AF0236"))
+	assert.True(t, IsPlate("沪AD12345"))
+	assert.True(t, IsPlate("苏E12345D"))
+	assert.True(t, IsPlate("鲁B1234学"))
+	assert.False(t, IsPlate("粤BO1234")) // letter O is not used
+	assert.False(t, IsPlate("粤B1234"))
+	assert.False(t, IsPlate("AB12345"))
+	assert.False(t, IsPlate("粤B123456")) // 6 characters are new-energy only
+}
+
+func TestIsDueDay(t *testing.T) {
+	assert.True(t, IsDueDay("1"))
+	assert.True(t, IsDueDay("28"))
+	assert.True(t, IsDueDay(EndOfMonth))
+	assert.False(t, IsDueDay("0"))
+	assert.False(t, IsDueDay("29"))
+	assert.False(t, IsDueDay("05"))
+	assert.False(t, IsDueDay(""))
+}
+
+type loanRequest struct {
+	UserID         string  `json:"userID" binding:"required,cn_idcard"`
+	Mobile         string  `json:"mobile" binding:"required,cn_mobile"`
+	CarPlate       string  `json:"carPlate" binding:"cn_plate"`
+	LoanMoney      int     `json:"loanMoney" binding:"loan_amount"`
+	LoanPeriod     int     `json:"loanPeriod" binding:"loan_period"`
+	LoanReturnDate string  `json:"loanReturnDate" binding:"due_day"`
+	Phone          *string `json:"phone" binding:"omitempty,cn_mobile"`
+}
+
+func newValidate(t *testing.T) *validator.Validate {
+	v := validator.New()
+	v.SetTagName("binding")
+	assert.NoError(t, Register(v))
+	return v
+}
+
+func TestRegister(t *testing.T) {
+	v := newValidate(t)
+
+	err := v.Struct(&loanRequest{
+		UserID:         "11010519491231002X",
+		Mobile:         "13812345678",
+		CarPlate:       "粤B12345",
+		LoanMoney:      100000,
+		LoanPeriod:     36,
+		LoanReturnDate: EndOfMonth,
+	})
+	assert.NoError(t, err)
+
+	// the empty values are checked by required only
+	err = v.Struct(&loanRequest{UserID: "11010519491231002X", Mobile: "13812345678", LoanMoney: 1, LoanPeriod: 1})
+	assert.NoError(t, err)
+
+	mobile := "123"
+	err = v.Struct(&loanRequest{
+		UserID:         "110105194912310021",
+		CarPlate:       "B12345",
+		LoanMoney:      MaxLoanAmount + 1,
+		LoanPeriod:     0,
+		LoanReturnDate: "30",
+		Phone:          &mobile,
+	})
+	assert.Equal(t, []FieldError{
+		{Field: "userID", Message: "is not a valid ID card number"},
+		{Field: "mobile", Message: "is required"},
+		{Field: "carPlate", Message: "is not a valid vehicle plate number"},
+		{Field: "loanMoney", Message: "must be greater than 0 and at most 5000000"},
+		{Field: "loanPeriod", Message: "must be 1 to 60 months"},
+		{Field: "loanReturnDate", Message: "must be a day from 1 to 28, or 31 for the end of the month"},
+		{Field: "phone", Message: "is not a valid mobile number"},
+	}, Errors(err))
+}
+
+func TestRegister_updateLoan(t *testing.T) {
+	v := newValidate(t)
+
+	// the fields left out are not validated
+	assert.NoError(t, v.Struct(&types.UpdateLoanByIDRequest{}))
+
+	money, period := 100000.0, 36
+	assert.NoError(t, v.Struct(&types.UpdateLoanByIDRequest{LoanMoney: &money, LoanPeriod: &period}))
+
+	// an admin can set the amount to 0
+	money = 0
+	assert.NoError(t, v.Struct(&types.UpdateLoanByIDRequest{LoanMoney: &money}))
+
+	money, period = -1, MaxLoanPeriod+1
+	err := v.Struct(&types.UpdateLoanByIDRequest{LoanMoney: &money, LoanPeriod: &period})
+	assert.Equal(t, []FieldError{
+		{Field: "loanMoney", Message: "must satisfy min=0"},
+		{Field: "loanPeriod", Message: "must be 1 to 60 months"},
+	}, Errors(err))
+
+	money = MaxLoanAmount + 1
+	err = v.Struct(&types.UpdateLoanByIDRequest{LoanMoney: &money})
+	assert.Equal(t, []FieldError{{Field: "loanMoney", Message: "must satisfy max=5000000"}}, Errors(err))
+}
+
+func TestErrors(t *testing.T) {
+	err := json.Unmarshal([]byte(`{"loanMoney":"many"}`), &loanRequest{})
+	assert.Equal(t, []FieldError{{Field: "loanMoney", Message: "must be of type int"}}, Errors(err))
+
+	assert.Nil(t, Errors(json.Unmarshal([]byte(`{`), &loanRequest{})))
+}